@@ -0,0 +1,171 @@
+// Package analysis provides tools for analyzing the points evaluated during
+// optimization runs.
+package analysis
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Basin is a group of points believed to lie in the same basin of
+// attraction.
+type Basin struct {
+	// Best is the best point in the basin.
+	Best   *optim.Point
+	Points []*optim.Point
+}
+
+// DistBasins clusters points into basins using a distance threshold.
+// Points are processed from best to worst and each joins the basin of the
+// nearest already-processed point if it is within dist, otherwise it starts
+// a new basin.  Points with infinite or NaN values are ignored.  Basins are
+// returned ordered from best to worst.
+func DistBasins(pts []*optim.Point, dist float64) []*Basin {
+	sorted := sortedFinite(pts)
+	label := make([]int, len(sorted))
+	nbasin := 0
+	near, dists := nearestBefore(sorted)
+	for i := range sorted {
+		if j, d := near[i], dists[i]; j >= 0 && d <= dist {
+			label[i] = label[j]
+		} else {
+			label[i] = nbasin
+			nbasin++
+		}
+	}
+	return group(sorted, label, nbasin)
+}
+
+// NearestBetter clusters points into basins using nearest-better
+// clustering as described in:
+//
+//	Preuss, Mike. "Improved topological niching for real-valued global
+//	optimization." European Conference on the Applications of Evolutionary
+//	Computation. Springer, 2012.
+//
+// Every point is linked to its nearest better point.  Links longer than
+// phi times the mean link length are cut and each resulting tree is a
+// basin.  A phi of 2 is typical.  Unlike DistBasins, no problem-specific
+// distance scale is required.  Points with infinite or NaN values are
+// ignored.  Basins are returned ordered from best to worst.
+func NearestBetter(pts []*optim.Point, phi float64) []*Basin {
+	sorted := sortedFinite(pts)
+	if len(sorted) == 0 {
+		return nil
+	}
+
+	parent, dists := nearestBefore(sorted)
+	mean := 0.0
+	for i := 1; i < len(sorted); i++ {
+		mean += dists[i] / float64(len(sorted)-1)
+	}
+
+	label := make([]int, len(sorted))
+	nbasin := 1
+	for i := 1; i < len(sorted); i++ {
+		if dists[i] > phi*mean {
+			label[i] = nbasin
+			nbasin++
+		} else {
+			label[i] = label[parent[i]]
+		}
+	}
+	return group(sorted, label, nbasin)
+}
+
+func sortedFinite(pts []*optim.Point) []*optim.Point {
+	sorted := make([]*optim.Point, 0, len(pts))
+	for _, p := range pts {
+		if !math.IsInf(p.Val, 0) && !math.IsNaN(p.Val) {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Stable(byval(sorted))
+	return sorted
+}
+
+// nearestBefore returns, for each point, the index of and distance to the
+// nearest point preceding it in pts.  Ties go to the earliest point.  The
+// first point's index is -1.
+func nearestBefore(pts []*optim.Point) (index []int, dist []float64) {
+	index = make([]int, len(pts))
+	dist = make([]float64, len(pts))
+	h := &optim.History{}
+	seen := map[*optim.Point]int{}
+	for i, p := range pts {
+		index[i], dist[i] = -1, math.Inf(1)
+		if nn := h.Nearest(p.Pos, 1); len(nn) > 0 {
+			index[i], dist[i] = seen[nn[0]], optim.L2Dist(p, nn[0])
+		}
+		if _, ok := seen[p]; !ok {
+			seen[p] = i
+		}
+		h.Add(p)
+	}
+	return index, dist
+}
+
+// group collects sorted points into basins by label.  Because points are
+// sorted and labels are assigned in order, basin i's first point is its
+// best and basins are ordered by their best points.
+func group(sorted []*optim.Point, label []int, nbasin int) []*Basin {
+	basins := make([]*Basin, nbasin)
+	for i, p := range sorted {
+		b := basins[label[i]]
+		if b == nil {
+			b = &Basin{Best: p}
+			basins[label[i]] = b
+		}
+		b.Points = append(b.Points, p)
+	}
+	return basins
+}
+
+// Tracker is an Evaler that records every point evaluated by the wrapped
+// Evaler so the run's global best and distinct optima can be analyzed
+// afterward.
+type Tracker struct {
+	optim.Evaler
+	Points []*optim.Point
+	mu     sync.Mutex
+}
+
+func (t *Tracker) Eval(obj optim.Objectiver, points ...*optim.Point) (results []*optim.Point, n int, err error) {
+	results, n, err = t.Evaler.Eval(obj, points...)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, p := range results {
+		t.Points = append(t.Points, p.Clone())
+	}
+	return results, n, err
+}
+
+// Best returns the best point evaluated so far or nil if no points have
+// been evaluated.
+func (t *Tracker) Best() *optim.Point {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var best *optim.Point
+	for _, p := range t.Points {
+		if best == nil || p.Val < best.Val {
+			best = p
+		}
+	}
+	return best
+}
+
+// Basins clusters all recorded points into basins using NearestBetter.
+func (t *Tracker) Basins(phi float64) []*Basin {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return NearestBetter(t.Points, phi)
+}
+
+type byval []*optim.Point
+
+func (b byval) Less(i, j int) bool { return b[i].Val < b[j].Val }
+func (b byval) Len() int           { return len(b) }
+func (b byval) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
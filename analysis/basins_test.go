@@ -0,0 +1,64 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// twoWells has minima at x=-2 (val -1) and x=3 (val -0.5).
+var twoWells = optim.Func(func(v []float64) float64 {
+	a := (v[0] + 2) * (v[0] + 2)
+	b := (v[0] - 3) * (v[0] - 3)
+	return math.Min(a-1, b-0.5)
+})
+
+func samples() []*optim.Point {
+	var pts []*optim.Point
+	for x := -4.0; x <= 5; x += 0.25 {
+		v, _ := twoWells.Objective([]float64{x})
+		pts = append(pts, &optim.Point{Pos: []float64{x}, Val: v})
+	}
+	return append(pts, &optim.Point{Pos: []float64{0}, Val: math.Inf(1)})
+}
+
+func checkBasins(t *testing.T, basins []*Basin) {
+	if len(basins) != 2 {
+		for _, b := range basins {
+			t.Logf("basin best %v (%v points)", b.Best, len(b.Points))
+		}
+		t.Fatalf("want 2 basins, got %v", len(basins))
+	}
+	if got := basins[0].Best.Pos[0]; got != -2 {
+		t.Errorf("best basin: want x=-2, got %v", got)
+	}
+	if got := basins[1].Best.Pos[0]; got != 3 {
+		t.Errorf("second basin: want x=3, got %v", got)
+	}
+	if n := len(basins[0].Points) + len(basins[1].Points); n != len(samples())-1 {
+		t.Errorf("want %v clustered points, got %v", len(samples())-1, n)
+	}
+}
+
+func TestNearestBetter(t *testing.T) {
+	checkBasins(t, NearestBetter(samples(), 2))
+}
+
+func TestDistBasins(t *testing.T) {
+	// samples are on a 0.25 grid so each well's points chain together
+	// outward from its minimum
+	checkBasins(t, DistBasins(samples(), 0.3))
+}
+
+func TestTracker(t *testing.T) {
+	tr := &Tracker{Evaler: optim.SerialEvaler{}}
+	pts := samples()
+	if _, _, err := tr.Eval(twoWells, pts[:len(pts)-1]...); err != nil {
+		t.Fatal(err)
+	}
+	if tr.Best().Pos[0] != -2 {
+		t.Errorf("want best at x=-2, got %v", tr.Best())
+	}
+	checkBasins(t, tr.Basins(2))
+}
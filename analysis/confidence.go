@@ -0,0 +1,324 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// ResidualFunc computes the residuals (model predictions minus
+// observations) of a calibration model with parameters v.  The calibration
+// objective is the sum of squared residuals.
+type ResidualFunc func(v []float64) ([]float64, error)
+
+// Interval is a confidence interval for a single parameter.
+type Interval struct {
+	Low, Up float64
+}
+
+// Confidence holds uncertainty estimates for calibrated parameters.
+type Confidence struct {
+	Pos []float64
+	// Level is the confidence level of the intervals (e.g. 0.95).
+	Level     float64
+	Intervals []Interval
+	// Std holds the standard error of each parameter.
+	Std []float64
+	// Cov is the parameter covariance matrix.
+	Cov [][]float64
+	// Sigma2 is the estimated residual variance RSS/(m-p) for m residuals
+	// and p parameters.
+	Sigma2 float64
+	// Replicates holds the refit parameters of each bootstrap replicate.
+	// It is nil for linearized estimates.
+	Replicates [][]float64
+}
+
+// LinearConfidence estimates parameter confidence intervals at the
+// calibrated optimum pos using the linearized (Gauss-Newton approximate
+// Hessian) covariance s^2 (J'J)^-1 where J is the residual Jacobian
+// estimated by central differences with relative step h.  Intervals use
+// the normal approximation and are accurate when the model is close to
+// linear in its parameters near pos.
+func LinearConfidence(res ResidualFunc, pos []float64, level, h float64) (*Confidence, error) {
+	r, err := res(pos)
+	if err != nil {
+		return nil, err
+	}
+	m, p := len(r), len(pos)
+	if m <= p {
+		return nil, fmt.Errorf("analysis: need more residuals (%v) than parameters (%v)", m, p)
+	}
+	jac, err := jacobian(res, pos, h)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := invert(jtj(jac))
+	if err != nil {
+		return nil, err
+	}
+	c := &Confidence{Pos: append([]float64{}, pos...), Level: level, Sigma2: sumsq(r) / float64(m-p)}
+	z := math.Sqrt2 * math.Erfinv(level)
+	c.Cov = inv
+	for i := range inv {
+		for j := range inv[i] {
+			c.Cov[i][j] *= c.Sigma2
+		}
+		std := math.Sqrt(c.Cov[i][i])
+		c.Std = append(c.Std, std)
+		c.Intervals = append(c.Intervals, Interval{pos[i] - z*std, pos[i] + z*std})
+	}
+	return c, nil
+}
+
+// BootstrapConfidence estimates parameter confidence intervals at the
+// calibrated optimum pos by residual bootstrap.  For each of nboot
+// replicates, the residuals at pos are resampled with replacement to form
+// synthetic observations and the parameters are refit starting from pos
+// with a damped Gauss-Newton solver.  Intervals are the percentiles of the
+// refit parameters.  Replicates whose refit fails are skipped.  Random
+// numbers are drawn from optim.Rand.
+func BootstrapConfidence(res ResidualFunc, pos []float64, level float64, nboot int) (*Confidence, error) {
+	r0, err := res(pos)
+	if err != nil {
+		return nil, err
+	}
+	m, p := len(r0), len(pos)
+	if m <= p {
+		return nil, fmt.Errorf("analysis: need more residuals (%v) than parameters (%v)", m, p)
+	}
+
+	c := &Confidence{Pos: append([]float64{}, pos...), Level: level, Sigma2: sumsq(r0) / float64(m-p)}
+	for b := 0; b < nboot; b++ {
+		resampled := make([]float64, m)
+		for i := range resampled {
+			resampled[i] = r0[optim.Rand.Intn(m)]
+		}
+		// model(v) - (model(pos) - resampled residual)
+		replicate := func(v []float64) ([]float64, error) {
+			r, err := res(v)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]float64, m)
+			for i := range r {
+				out[i] = r[i] - r0[i] + resampled[i]
+			}
+			return out, nil
+		}
+		fit, err := GaussNewton(replicate, pos, 50)
+		if err != nil {
+			continue
+		}
+		c.Replicates = append(c.Replicates, fit)
+	}
+	if len(c.Replicates) < 2 {
+		return nil, errors.New("analysis: too few successful bootstrap replicates")
+	}
+
+	n := float64(len(c.Replicates))
+	mean := make([]float64, p)
+	for _, fit := range c.Replicates {
+		for i := range fit {
+			mean[i] += fit[i] / n
+		}
+	}
+	c.Cov = make([][]float64, p)
+	for i := range c.Cov {
+		c.Cov[i] = make([]float64, p)
+		for j := range c.Cov[i] {
+			for _, fit := range c.Replicates {
+				c.Cov[i][j] += (fit[i] - mean[i]) * (fit[j] - mean[j]) / (n - 1)
+			}
+		}
+		c.Std = append(c.Std, math.Sqrt(c.Cov[i][i]))
+
+		vals := make([]float64, len(c.Replicates))
+		for k, fit := range c.Replicates {
+			vals[k] = fit[i]
+		}
+		sort.Float64s(vals)
+		alpha := (1 - level) / 2
+		c.Intervals = append(c.Intervals, Interval{percentile(vals, alpha), percentile(vals, 1-alpha)})
+	}
+	return c, nil
+}
+
+// GaussNewton minimizes the sum of squared residuals starting from start
+// using Levenberg-Marquardt damped Gauss-Newton steps with a central
+// difference Jacobian.  It stops after maxiter iterations or when steps no
+// longer reduce the sum of squares.
+func GaussNewton(res ResidualFunc, start []float64, maxiter int) ([]float64, error) {
+	x := append([]float64{}, start...)
+	r, err := res(x)
+	if err != nil {
+		return nil, err
+	}
+	cost := sumsq(r)
+	lambda := 1e-3
+	for iter := 0; iter < maxiter && lambda < 1e10; iter++ {
+		jac, err := jacobian(res, x, 1e-6)
+		if err != nil {
+			return nil, err
+		}
+		a := jtj(jac)
+		g := make([]float64, len(x))
+		for i := range r {
+			for j := range g {
+				g[j] += jac[i][j] * r[i]
+			}
+		}
+
+		for {
+			damped := make([][]float64, len(a))
+			for i := range a {
+				damped[i] = append([]float64{}, a[i]...)
+				damped[i][i] += lambda * math.Max(a[i][i], 1e-12)
+			}
+			inv, err := invert(damped)
+			if err != nil {
+				lambda *= 10
+				if lambda > 1e10 {
+					break
+				}
+				continue
+			}
+			trial := make([]float64, len(x))
+			for i := range x {
+				trial[i] = x[i]
+				for j := range g {
+					trial[i] -= inv[i][j] * g[j]
+				}
+			}
+			rt, err := res(trial)
+			if err == nil && sumsq(rt) < cost {
+				improved := cost - sumsq(rt)
+				x, r, cost = trial, rt, sumsq(rt)
+				lambda = math.Max(lambda/10, 1e-12)
+				if improved <= 1e-15*(1+cost) {
+					return x, nil
+				}
+				break
+			}
+			lambda *= 10
+			if lambda > 1e10 {
+				break
+			}
+		}
+	}
+	return x, nil
+}
+
+func jacobian(res ResidualFunc, pos []float64, h float64) ([][]float64, error) {
+	x := append([]float64{}, pos...)
+	var jac [][]float64
+	for j := range pos {
+		step := h * math.Max(1, math.Abs(pos[j]))
+		x[j] = pos[j] + step
+		rp, err := res(x)
+		if err != nil {
+			return nil, err
+		}
+		x[j] = pos[j] - step
+		rm, err := res(x)
+		if err != nil {
+			return nil, err
+		}
+		x[j] = pos[j]
+		if jac == nil {
+			jac = make([][]float64, len(rp))
+			for i := range jac {
+				jac[i] = make([]float64, len(pos))
+			}
+		}
+		for i := range rp {
+			jac[i][j] = (rp[i] - rm[i]) / (2 * step)
+		}
+	}
+	return jac, nil
+}
+
+// jtj returns J'J.
+func jtj(jac [][]float64) [][]float64 {
+	p := len(jac[0])
+	a := make([][]float64, p)
+	for i := range a {
+		a[i] = make([]float64, p)
+		for j := range a[i] {
+			for k := range jac {
+				a[i][j] += jac[k][i] * jac[k][j]
+			}
+		}
+	}
+	return a
+}
+
+// invert inverts a using Gauss-Jordan elimination with partial pivoting.
+// Pivots tiny relative to a's largest diagonal element are treated as
+// singular.
+func invert(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	scale := 0.0
+	for i := range a {
+		scale = math.Max(scale, math.Abs(a[i][i]))
+	}
+	m := make([][]float64, n)
+	for i := range a {
+		m[i] = make([]float64, 2*n)
+		copy(m[i], a[i])
+		m[i][n+i] = 1
+	}
+	for col := 0; col < n; col++ {
+		piv := col
+		for i := col + 1; i < n; i++ {
+			if math.Abs(m[i][col]) > math.Abs(m[piv][col]) {
+				piv = i
+			}
+		}
+		if math.Abs(m[piv][col]) <= 1e-12*scale {
+			return nil, errors.New("analysis: singular matrix (parameters may be unidentifiable)")
+		}
+		m[col], m[piv] = m[piv], m[col]
+		d := m[col][col]
+		for j := range m[col] {
+			m[col][j] /= d
+		}
+		for i := 0; i < n; i++ {
+			if i == col || m[i][col] == 0 {
+				continue
+			}
+			f := m[i][col]
+			for j := range m[i] {
+				m[i][j] -= f * m[col][j]
+			}
+		}
+	}
+	inv := make([][]float64, n)
+	for i := range m {
+		inv[i] = m[i][n:]
+	}
+	return inv, nil
+}
+
+func sumsq(r []float64) float64 {
+	tot := 0.0
+	for _, v := range r {
+		tot += v * v
+	}
+	return tot
+}
+
+// percentile returns the linearly interpolated q'th quantile of sorted.
+func percentile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	i := int(pos)
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	frac := pos - float64(i)
+	return sorted[i] + frac*(sorted[i+1]-sorted[i])
+}
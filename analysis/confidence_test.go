@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// lineData returns residuals for fitting y = a*x + b to noisy observations
+// generated with a=2, b=1.
+func lineData() (ResidualFunc, []float64) {
+	r := rand.New(rand.NewSource(3))
+	xs := make([]float64, 40)
+	ys := make([]float64, 40)
+	for i := range xs {
+		xs[i] = float64(i) / 4
+		ys[i] = 2*xs[i] + 1 + 0.5*r.NormFloat64()
+	}
+	res := func(v []float64) ([]float64, error) {
+		out := make([]float64, len(xs))
+		for i := range xs {
+			out[i] = v[0]*xs[i] + v[1] - ys[i]
+		}
+		return out, nil
+	}
+	return res, xs
+}
+
+func TestConfidence(t *testing.T) {
+	res, xs := lineData()
+	fit, err := GaussNewton(res, []float64{0, 0}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lin, err := LinearConfidence(res, fit, 0.95, 1e-6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// compare with the closed form ordinary least squares standard errors
+	n := float64(len(xs))
+	mean, sxx := 0.0, 0.0
+	for _, x := range xs {
+		mean += x / n
+	}
+	for _, x := range xs {
+		sxx += (x - mean) * (x - mean)
+	}
+	wantStd := []float64{math.Sqrt(lin.Sigma2 / sxx), math.Sqrt(lin.Sigma2 * (1/n + mean*mean/sxx))}
+	for i := range wantStd {
+		if math.Abs(lin.Std[i]-wantStd[i]) > 1e-4*wantStd[i] {
+			t.Errorf("param %v: want std %v, got %v", i, wantStd[i], lin.Std[i])
+		}
+	}
+	for i, want := range []float64{2, 1} {
+		if iv := lin.Intervals[i]; iv.Low > want || iv.Up < want {
+			t.Errorf("param %v: true value %v outside linear interval %v", i, want, iv)
+		}
+	}
+
+	optim.Rand = rand.New(rand.NewSource(1))
+	boot, err := BootstrapConfidence(res, fit, 0.95, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boot.Replicates) != 200 {
+		t.Errorf("want 200 replicates, got %v", len(boot.Replicates))
+	}
+	for i := range wantStd {
+		if math.Abs(boot.Std[i]-wantStd[i]) > 0.25*wantStd[i] {
+			t.Errorf("param %v: bootstrap std %v far from %v", i, boot.Std[i], wantStd[i])
+		}
+	}
+}
+
+func TestConfidenceUnidentifiable(t *testing.T) {
+	// only the sum of the parameters affects the residuals
+	res := func(v []float64) ([]float64, error) {
+		return []float64{v[0] + v[1] - 1, v[0] + v[1] - 1.1, v[0] + v[1] - 0.9}, nil
+	}
+	if _, err := LinearConfidence(res, []float64{0.5, 0.5}, 0.95, 1e-6); err == nil {
+		t.Errorf("want error for unidentifiable parameters")
+	}
+}
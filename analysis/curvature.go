@@ -0,0 +1,189 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Curvature describes the local shape of an objective around a point -
+// typically the best point found by a run.  It helps judge how sensitive
+// the optimum is to each parameter combination and whether further local
+// refinement is worthwhile.
+type Curvature struct {
+	Pos      []float64
+	Val      float64
+	Gradient []float64
+	Hessian  [][]float64
+	// Eigenvalues of the Hessian in ascending order with corresponding unit
+	// Eigenvectors.  Small eigenvalues mark directions along which the
+	// objective is nearly flat (poorly determined parameter combinations).
+	Eigenvalues  []float64
+	Eigenvectors [][]float64
+	// Cond is the Hessian's condition number - the ratio of the largest to
+	// smallest eigenvalue magnitude.
+	Cond float64
+	// Gain is the objective decrease predicted by taking a single Newton
+	// step from Pos.  It is NaN if the Hessian is not positive definite.
+	Gain float64
+	// Neval is the number of objective evaluations used.
+	Neval int
+}
+
+// Minimum returns true if the Hessian is positive definite, i.e. Pos is a
+// strict local minimum (apart from the gradient being nonzero).
+func (c *Curvature) Minimum() bool { return len(c.Eigenvalues) > 0 && c.Eigenvalues[0] > 0 }
+
+func (c *Curvature) String() string {
+	s := fmt.Sprintf("curvature at %v (val %v, %v evals):\n", c.Pos, c.Val, c.Neval)
+	s += fmt.Sprintf("    gradient:     %v\n", c.Gradient)
+	s += fmt.Sprintf("    eigenvalues:  %v\n", c.Eigenvalues)
+	s += fmt.Sprintf("    condition:    %.4g\n", c.Cond)
+	s += fmt.Sprintf("    local min:    %v\n", c.Minimum())
+	s += fmt.Sprintf("    newton gain:  %.4g\n", c.Gain)
+	return s
+}
+
+// LocalCurvature estimates the gradient and Hessian of obj at pos using
+// central finite differences with step h scaled by the magnitude of each
+// coordinate (or h if the coordinate is smaller than one).  A step size
+// around 1e-4 suits most smooth objectives.
+func LocalCurvature(obj optim.Objectiver, pos []float64, h float64) (*Curvature, error) {
+	n := len(pos)
+	c := &Curvature{Pos: append([]float64{}, pos...)}
+	x := append([]float64{}, pos...)
+	f := func() (float64, error) {
+		c.Neval++
+		return obj.Objective(x)
+	}
+	steps := make([]float64, n)
+	for i := range steps {
+		steps[i] = h * math.Max(1, math.Abs(pos[i]))
+	}
+
+	f0, err := f()
+	if err != nil {
+		return nil, err
+	}
+	c.Val = f0
+
+	c.Gradient = make([]float64, n)
+	c.Hessian = make([][]float64, n)
+	for i := range c.Hessian {
+		c.Hessian[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		x[i] = pos[i] + steps[i]
+		fp, err := f()
+		if err != nil {
+			return nil, err
+		}
+		x[i] = pos[i] - steps[i]
+		fm, err := f()
+		if err != nil {
+			return nil, err
+		}
+		x[i] = pos[i]
+		c.Gradient[i] = (fp - fm) / (2 * steps[i])
+		c.Hessian[i][i] = (fp - 2*f0 + fm) / (steps[i] * steps[i])
+
+		for j := 0; j < i; j++ {
+			var corners [4]float64
+			for k, sign := range [4][2]float64{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}} {
+				x[i], x[j] = pos[i]+sign[0]*steps[i], pos[j]+sign[1]*steps[j]
+				if corners[k], err = f(); err != nil {
+					return nil, err
+				}
+			}
+			x[i], x[j] = pos[i], pos[j]
+			hij := (corners[0] - corners[1] - corners[2] + corners[3]) / (4 * steps[i] * steps[j])
+			c.Hessian[i][j], c.Hessian[j][i] = hij, hij
+		}
+	}
+
+	c.Eigenvalues, c.Eigenvectors = symEigen(c.Hessian)
+	lo, hi := math.Inf(1), 0.0
+	for _, e := range c.Eigenvalues {
+		lo, hi = math.Min(lo, math.Abs(e)), math.Max(hi, math.Abs(e))
+	}
+	c.Cond = hi / lo
+
+	c.Gain = math.NaN()
+	if c.Minimum() {
+		c.Gain = 0
+		for k, vec := range c.Eigenvectors {
+			proj := 0.0
+			for i := range vec {
+				proj += vec[i] * c.Gradient[i]
+			}
+			c.Gain += proj * proj / (2 * c.Eigenvalues[k])
+		}
+	}
+	return c, nil
+}
+
+// symEigen computes the eigenvalues (ascending) and unit eigenvectors of
+// the symmetric matrix a using cyclic Jacobi rotations.
+func symEigen(a [][]float64) (vals []float64, vecs [][]float64) {
+	n := len(a)
+	m := make([][]float64, n)
+	v := make([][]float64, n)
+	for i := range m {
+		m[i] = append([]float64{}, a[i]...)
+		v[i] = make([]float64, n)
+		v[i][i] = 1
+	}
+
+	for sweep := 0; sweep < 100; sweep++ {
+		off := 0.0
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				off += m[i][j] * m[i][j]
+			}
+		}
+		if off < 1e-30 {
+			break
+		}
+
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if m[p][q] == 0 {
+					continue
+				}
+				theta := (m[q][q] - m[p][p]) / (2 * m[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				cs := 1 / math.Sqrt(t*t+1)
+				sn := t * cs
+				for k := 0; k < n; k++ {
+					mkp, mkq := m[k][p], m[k][q]
+					m[k][p], m[k][q] = cs*mkp-sn*mkq, sn*mkp+cs*mkq
+				}
+				for k := 0; k < n; k++ {
+					mpk, mqk := m[p][k], m[q][k]
+					m[p][k], m[q][k] = cs*mpk-sn*mqk, sn*mpk+cs*mqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p], v[k][q] = cs*vkp-sn*vkq, sn*vkp+cs*vkq
+				}
+			}
+		}
+	}
+
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool { return m[idx[a]][idx[a]] < m[idx[b]][idx[b]] })
+	for _, k := range idx {
+		vals = append(vals, m[k][k])
+		vec := make([]float64, n)
+		for i := range vec {
+			vec[i] = v[i][k]
+		}
+		vecs = append(vecs, vec)
+	}
+	return vals, vecs
+}
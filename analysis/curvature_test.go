@@ -0,0 +1,50 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+func TestLocalCurvature(t *testing.T) {
+	// f = 1*(x+y)^2/2 + 100*(x-y)^2/2 has hessian eigenvalues 2 and 200
+	// with eigenvectors along (1,1) and (1,-1).
+	obj := optim.Func(func(v []float64) float64 {
+		s, d := v[0]+v[1], v[0]-v[1]
+		return s*s/2 + 100*d*d/2
+	})
+
+	c, err := LocalCurvature(obj, []float64{0, 0}, 1e-4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(c)
+
+	want := []float64{2, 200}
+	for i := range want {
+		if math.Abs(c.Eigenvalues[i]-want[i]) > 1e-3*want[i] {
+			t.Errorf("eigenvalue %v: want %v, got %v", i, want[i], c.Eigenvalues[i])
+		}
+	}
+	if math.Abs(math.Abs(c.Eigenvectors[0][0])-math.Sqrt(0.5)) > 1e-6 || c.Eigenvectors[0][0]*c.Eigenvectors[0][1] < 0 {
+		t.Errorf("flat direction: want along (1,1), got %v", c.Eigenvectors[0])
+	}
+	if math.Abs(c.Cond-100) > 0.1 || !c.Minimum() {
+		t.Errorf("want condition 100 at a minimum, got %v (min=%v)", c.Cond, c.Minimum())
+	}
+	if c.Neval != 1+2*2+4 {
+		t.Errorf("want 9 evals, got %v", c.Neval)
+	}
+
+	// away from the minimum a newton step recovers the full decrease
+	c, _ = LocalCurvature(obj, []float64{1, 0}, 1e-4)
+	if math.Abs(c.Gain-c.Val) > 1e-4 {
+		t.Errorf("newton gain: want %v, got %v", c.Val, c.Gain)
+	}
+
+	saddle := optim.Func(func(v []float64) float64 { return v[0]*v[0] - v[1]*v[1] })
+	if c, _ := LocalCurvature(saddle, []float64{0, 0}, 1e-4); c.Minimum() || !math.IsNaN(c.Gain) {
+		t.Errorf("saddle reported as minimum: %v", c)
+	}
+}
@@ -0,0 +1,217 @@
+package analysis
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Importance describes how strongly the objective depends on a single
+// decision variable.
+type Importance struct {
+	// Var is the variable's index.
+	Var int
+	// Score is the variable's share of the total partial dependence
+	// variance - scores of all variables sum to 1.
+	Score float64
+	// Grid and Partial describe the partial dependence of the objective on
+	// the variable: Partial[i] is the surrogate's prediction averaged over
+	// the history with the variable fixed at Grid[i].
+	Grid, Partial []float64
+}
+
+// maxPartialPts is the maximum number of history points averaged over when
+// computing partial dependence.
+const maxPartialPts = 200
+
+// VarImportance fits a quadratic response surface (including interactions)
+// to the evaluated points pts - e.g. a run's history read with
+// optim.LoadPoints from the optim.TblEvals table or collected by a Tracker -
+// and uses it to estimate each variable's influence on the objective.
+// Variables are scored by the variance of their partial dependence over
+// ngrid evenly spaced values spanning the range they were sampled over.  If
+// there are too few points for a quadratic surface, a linear one is used.
+// The importances are returned in order of decreasing score along with the
+// surrogate's coefficient of determination (R^2) which indicates how much
+// to trust them.  Points with non-finite values are ignored.
+func VarImportance(pts []*optim.Point, ngrid int) (imps []Importance, r2 float64, err error) {
+	pts = sortedFinite(pts)
+	if len(pts) < 2 {
+		return nil, 0, errors.New("analysis: too few points to estimate variable importance")
+	}
+	if ngrid < 2 {
+		ngrid = 2
+	}
+	ndim := pts[0].Len()
+
+	low, up := make([]float64, ndim), make([]float64, ndim)
+	for i := range low {
+		low[i], up[i] = math.Inf(1), math.Inf(-1)
+	}
+	for _, p := range pts {
+		for i, x := range p.Pos {
+			low[i], up[i] = math.Min(low[i], x), math.Max(up[i], x)
+		}
+	}
+	s, r2, err := fitSurrogate(pts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// average partial dependence over a subsample of the history
+	stride := (len(pts) + maxPartialPts - 1) / maxPartialPts
+	var sub [][]float64
+	for i := 0; i < len(pts); i += stride {
+		sub = append(sub, append([]float64{}, pts[i].Pos...))
+	}
+
+	total := 0.0
+	imps = make([]Importance, ndim)
+	for i := range imps {
+		imp := Importance{Var: i, Grid: make([]float64, ngrid), Partial: make([]float64, ngrid)}
+		for g := range imp.Grid {
+			imp.Grid[g] = low[i] + (up[i]-low[i])*float64(g)/float64(ngrid-1)
+			for _, x := range sub {
+				orig := x[i]
+				x[i] = imp.Grid[g]
+				imp.Partial[g] += s.predict(x) / float64(len(sub))
+				x[i] = orig
+			}
+		}
+		imp.Score = variance(imp.Partial)
+		total += imp.Score
+		imps[i] = imp
+	}
+	for i := range imps {
+		if total > 0 {
+			imps[i].Score /= total
+		}
+	}
+	sort.SliceStable(imps, func(i, j int) bool { return imps[i].Score > imps[j].Score })
+	return imps, r2, nil
+}
+
+// surrogate is a linear or quadratic response surface in standardized
+// variables.
+type surrogate struct {
+	mean, std []float64
+	quad      bool
+	coeffs    []float64
+}
+
+// features returns the surrogate's basis functions evaluated at x.
+func (s *surrogate) features(x []float64) []float64 {
+	z := make([]float64, len(x))
+	for i := range x {
+		if s.std[i] > 0 {
+			z[i] = (x[i] - s.mean[i]) / s.std[i]
+		}
+	}
+	f := append([]float64{1}, z...)
+	if s.quad {
+		for i := range z {
+			for j := i; j < len(z); j++ {
+				f = append(f, z[i]*z[j])
+			}
+		}
+	}
+	return f
+}
+
+func (s *surrogate) predict(x []float64) float64 {
+	y := 0.0
+	for i, f := range s.features(x) {
+		y += s.coeffs[i] * f
+	}
+	return y
+}
+
+// fitSurrogate fits a quadratic surrogate to pts - or a linear one if there
+// are too few points - and returns it with the fit's coefficient of
+// determination.
+func fitSurrogate(pts []*optim.Point) (s *surrogate, r2 float64, err error) {
+	ndim := pts[0].Len()
+	s = &surrogate{mean: make([]float64, ndim), std: make([]float64, ndim)}
+	for _, p := range pts {
+		for i, x := range p.Pos {
+			s.mean[i] += x / float64(len(pts))
+		}
+	}
+	for _, p := range pts {
+		for i, x := range p.Pos {
+			s.std[i] += (x - s.mean[i]) * (x - s.mean[i]) / float64(len(pts))
+		}
+	}
+	for i := range s.std {
+		s.std[i] = math.Sqrt(s.std[i])
+	}
+	s.quad = len(pts) > 2*(ndim+1)*(ndim+2)/2
+	r2, err = s.fit(pts)
+	return s, r2, err
+}
+
+// fit computes the least squares coefficients for pts and returns the fit's
+// coefficient of determination.  A tiny ridge term keeps the normal
+// equations solvable when variables are constant or collinear.
+func (s *surrogate) fit(pts []*optim.Point) (r2 float64, err error) {
+	var xtx [][]float64
+	var xty []float64
+	for _, p := range pts {
+		f := s.features(p.Pos)
+		if xtx == nil {
+			xtx = make([][]float64, len(f))
+			for i := range xtx {
+				xtx[i] = make([]float64, len(f))
+			}
+			xty = make([]float64, len(f))
+		}
+		for i := range f {
+			xty[i] += f[i] * p.Val
+			for j := range f {
+				xtx[i][j] += f[i] * f[j]
+			}
+		}
+	}
+	for i := range xtx {
+		xtx[i][i] += 1e-8 * float64(len(pts))
+	}
+	inv, err := invert(xtx)
+	if err != nil {
+		return 0, err
+	}
+	s.coeffs = make([]float64, len(xty))
+	for i := range inv {
+		for j := range inv[i] {
+			s.coeffs[i] += inv[i][j] * xty[j]
+		}
+	}
+
+	ymean := 0.0
+	for _, p := range pts {
+		ymean += p.Val / float64(len(pts))
+	}
+	ssres, sstot := 0.0, 0.0
+	for _, p := range pts {
+		d := p.Val - s.predict(p.Pos)
+		ssres += d * d
+		sstot += (p.Val - ymean) * (p.Val - ymean)
+	}
+	if sstot == 0 {
+		return 1, nil
+	}
+	return 1 - ssres/sstot, nil
+}
+
+func variance(v []float64) float64 {
+	mean := 0.0
+	for _, x := range v {
+		mean += x / float64(len(v))
+	}
+	tot := 0.0
+	for _, x := range v {
+		tot += (x - mean) * (x - mean)
+	}
+	return tot / float64(len(v))
+}
@@ -0,0 +1,42 @@
+package analysis
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+func TestVarImportance(t *testing.T) {
+	// x0 matters most through its curvature, x1 only linearly, and x2 not
+	// at all.
+	rng := rand.New(rand.NewSource(1))
+	var pts []*optim.Point
+	for i := 0; i < 500; i++ {
+		x := []float64{2*rng.Float64() - 1, 2*rng.Float64() - 1, 2*rng.Float64() - 1}
+		pts = append(pts, &optim.Point{Pos: x, Val: 10*x[0]*x[0] + x[1]})
+	}
+
+	imps, r2, err := VarImportance(pts, 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r2 < .999 {
+		t.Errorf("want a near perfect fit, got R^2 = %v", r2)
+	}
+	for i, want := range []int{0, 1, 2} {
+		if imps[i].Var != want {
+			t.Fatalf("importance rank %v: want var %v, got %+v", i, want, imps)
+		}
+	}
+	if imps[2].Score > 1e-6 {
+		t.Errorf("irrelevant variable scored %v", imps[2].Score)
+	}
+
+	// partial dependence on x0 should be a parabola centered near zero
+	pd := imps[0]
+	mid := len(pd.Grid) / 2
+	if pd.Partial[mid] > pd.Partial[0]-5 || pd.Partial[mid] > pd.Partial[len(pd.Grid)-1]-5 {
+		t.Errorf("partial dependence isn't convex: grid=%v partial=%v", pd.Grid, pd.Partial)
+	}
+}
@@ -0,0 +1,191 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Landscape summarizes features of an objective sampled over box bounds -
+// an exploratory landscape analysis.  The features hint at which kind of
+// solver suits the problem: smooth, convex-like landscapes with high
+// fitness-distance correlation favor local methods while rugged,
+// multimodal landscapes favor global (population based) methods.
+type Landscape struct {
+	// Samples holds the uniformly sampled points.
+	Samples []*optim.Point
+	// Neval is the total number of objective evaluations performed.
+	Neval int
+	// Best is the best point sampled (including random walk points).
+	Best *optim.Point
+	// FDC is the fitness-distance correlation of the samples - the
+	// correlation between each sample's value and its distance from the
+	// best sample.  Values near 1 indicate a single global funnel; values
+	// near zero or negative indicate deceptive or multimodal landscapes.
+	FDC float64
+	// Autocorr is the lag-1 autocorrelation of objective values along a
+	// random walk with steps a tenth of the bounds' widths.  CorrLength is
+	// the corresponding correlation length -1/ln(|Autocorr|).  Low values
+	// indicate a rugged landscape.
+	Autocorr   float64
+	CorrLength float64
+	// Convexity is the fraction of random sample pairs for which the
+	// objective at the pair's midpoint is no greater than the mean of the
+	// pair's values.  It is 1 for convex objectives.
+	Convexity float64
+	// QuadR2 is the coefficient of determination of a quadratic (or for few
+	// samples linear) response surface fit to the samples.  Values near 1
+	// indicate a globally smooth, simple structure.
+	QuadR2 float64
+	// Basins is the number of samples better than each of their
+	// basinNeighbors nearest neighbors - a rough estimate of modality.
+	Basins int
+}
+
+// basinNeighbors is the number of nearest neighbors a sample must be better
+// than to count as a basin.  Even on a unimodal landscape each neighbor of
+// a random sample is worse with probability of about one half, so this
+// keeps spurious basins rare.
+const basinNeighbors = 10
+
+func (l *Landscape) String() string {
+	s := fmt.Sprintf("landscape of %v samples (%v evals, best %v):\n", len(l.Samples), l.Neval, l.Best)
+	s += fmt.Sprintf("    fdc:          %.4g\n", l.FDC)
+	s += fmt.Sprintf("    autocorr:     %.4g (length %.4g)\n", l.Autocorr, l.CorrLength)
+	s += fmt.Sprintf("    convexity:    %.4g\n", l.Convexity)
+	s += fmt.Sprintf("    quad r2:      %.4g\n", l.QuadR2)
+	s += fmt.Sprintf("    basins:       %v\n", l.Basins)
+	return s
+}
+
+// Probe samples obj within the box bounds low and up and computes
+// landscape features.  n points are sampled uniformly, a random walk of n
+// steps is taken, and n/4 sample pair midpoints are evaluated for a total
+// of about 2.25*n evaluations.  Random numbers are drawn from optim.Rand.
+// Something like 50 times the number of dimensions is a reasonable n.
+func Probe(obj optim.Objectiver, low, up []float64, n int) (*Landscape, error) {
+	if n < 4 {
+		return nil, errors.New("analysis: landscape probe needs at least 4 samples")
+	}
+	l := &Landscape{Best: &optim.Point{Val: math.Inf(1)}}
+	eval := func(pos []float64) (*optim.Point, error) {
+		l.Neval++
+		val, err := obj.Objective(pos)
+		p := &optim.Point{Pos: pos, Val: val}
+		if err == nil && val < l.Best.Val {
+			l.Best = p
+		}
+		return p, err
+	}
+	bounds := optim.Bounds{Low: low, Up: up}
+
+	for i := 0; i < n; i++ {
+		p, err := eval(bounds.Sample())
+		if err != nil {
+			return nil, err
+		}
+		l.Samples = append(l.Samples, p)
+	}
+	best := l.Best
+	finite := sortedFinite(l.Samples)
+	var vals, dists []float64
+	for _, p := range finite {
+		vals = append(vals, p.Val)
+		dists = append(dists, optim.L2Dist(p, best))
+	}
+	l.FDC = correlation(vals, dists)
+	l.Basins = localMinima(finite, basinNeighbors)
+	if len(finite) > 0 {
+		if _, r2, err := fitSurrogate(finite); err == nil {
+			l.QuadR2 = r2
+		}
+	}
+
+	// random walk reflecting off the bounds
+	pos := bounds.Sample()
+	widths := bounds.Widths()
+	walk := make([]float64, n)
+	for i := range walk {
+		p, err := eval(append([]float64{}, pos...))
+		if err != nil {
+			return nil, err
+		}
+		walk[i] = p.Val
+		for j, w := range widths {
+			pos[j] += (2*optim.RandFloat() - 1) * w / 10
+		}
+		pos = bounds.Reflect(pos)
+	}
+	l.Autocorr = correlation(walk[:n-1], walk[1:])
+	l.CorrLength = -1 / math.Log(math.Abs(l.Autocorr))
+
+	npair, nconvex := n/4, 0
+	for k := 0; k < npair; k++ {
+		a, b := l.Samples[optim.Rand.Intn(n)], l.Samples[optim.Rand.Intn(n)]
+		mid := make([]float64, len(low))
+		for i := range mid {
+			mid[i] = (a.Pos[i] + b.Pos[i]) / 2
+		}
+		p, err := eval(mid)
+		if err != nil {
+			return nil, err
+		}
+		if p.Val <= (a.Val+b.Val)/2 {
+			nconvex++
+		}
+	}
+	l.Convexity = float64(nconvex) / float64(npair)
+	return l, nil
+}
+
+// localMinima returns the number of points in pts better than each of their
+// k nearest neighbors.
+func localMinima(pts []*optim.Point, k int) int {
+	n := 0
+	dist := make([]float64, len(pts))
+	for i, p := range pts {
+		for j, q := range pts {
+			dist[j] = optim.L2Dist(p, q)
+		}
+		// p is better than each of its k nearest neighbors if at least k
+		// other points are closer than p's nearest better point.
+		nearer := math.Inf(1)
+		for j, q := range pts {
+			if q.Val < p.Val && dist[j] < nearer {
+				nearer = dist[j]
+			}
+		}
+		closer := 0
+		for j := range pts {
+			if j != i && dist[j] < nearer {
+				closer++
+			}
+		}
+		if closer >= k {
+			n++
+		}
+	}
+	return n
+}
+
+// correlation returns the Pearson correlation coefficient of x and y or
+// zero if either has no variance.
+func correlation(x, y []float64) float64 {
+	mx, my := 0.0, 0.0
+	for i := range x {
+		mx += x[i] / float64(len(x))
+		my += y[i] / float64(len(y))
+	}
+	sxy, sxx, syy := 0.0, 0.0, 0.0
+	for i := range x {
+		sxy += (x[i] - mx) * (y[i] - my)
+		sxx += (x[i] - mx) * (x[i] - mx)
+		syy += (y[i] - my) * (y[i] - my)
+	}
+	if sxx == 0 || syy == 0 {
+		return 0
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
@@ -0,0 +1,57 @@
+package analysis
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+func TestProbe(t *testing.T) {
+	optim.Rand = &optim.LockedRng{Rng: rand.New(rand.NewSource(1))}
+	low, up := []float64{-5, -5}, []float64{5, 5}
+
+	sphere := optim.Func(func(v []float64) float64 { return v[0]*v[0] + v[1]*v[1] })
+	smooth, err := Probe(sphere, low, up, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(smooth)
+	if smooth.Neval != 200+200+50 {
+		t.Errorf("want 450 evals, got %v", smooth.Neval)
+	}
+	if smooth.Convexity != 1 || smooth.QuadR2 < .999 || smooth.FDC < .9 || smooth.Basins > 3 {
+		t.Errorf("sphere should look convex and funnel shaped")
+	}
+
+	rastrigin := optim.Func(func(v []float64) float64 {
+		tot := 20.0
+		for _, x := range v {
+			tot += x*x - 10*math.Cos(2*math.Pi*x)
+		}
+		return tot
+	})
+	rugged, err := Probe(rastrigin, low, up, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(rugged)
+	if rugged.Autocorr >= smooth.Autocorr || rugged.Convexity >= smooth.Convexity || rugged.QuadR2 >= smooth.QuadR2 {
+		t.Errorf("rastrigin should look more rugged than the sphere")
+	}
+	if rugged.Basins <= smooth.Basins {
+		t.Errorf("rastrigin should have more basins: got %v vs %v", rugged.Basins, smooth.Basins)
+	}
+}
+
+func TestProbeInfeasible(t *testing.T) {
+	inf := optim.Func(func(v []float64) float64 { return math.Inf(1) })
+	l, err := Probe(inf, []float64{-5, -5}, []float64{5, 5}, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.QuadR2 != 0 || l.Basins != 0 {
+		t.Errorf("want no fit or basins without finite samples, got r2 %v and %v basins", l.QuadR2, l.Basins)
+	}
+}
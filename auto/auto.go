@@ -0,0 +1,227 @@
+// Package auto chooses and configures a solver from a problem's
+// characteristics - its dimension, bounds, integer dimensions, evaluation
+// budget and optionally a landscape probe (see analysis.Probe).  The rules
+// are simple heuristics, so Race is provided to hedge against a poor choice
+// by racing every candidate and keeping the best.
+//
+// All candidate solvers operate on the problem's bounds mapped onto the
+// unit hypercube - use the returned transform.Space to map their points
+// back to problem space.
+package auto
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/analysis"
+	"github.com/rwcarlsen/optim/crs"
+	"github.com/rwcarlsen/optim/pswarm"
+	"github.com/rwcarlsen/optim/sce"
+	"github.com/rwcarlsen/optim/transform"
+)
+
+// Problem describes a bounded minimization problem.
+type Problem struct {
+	Obj     optim.Objectiver
+	Low, Up []float64
+	// Int lists the indices of integer-valued dimensions.  Their bounds
+	// should be integers.
+	Int []int
+	// MaxEval is the total evaluation budget including any probe.  If zero,
+	// 50000 is used.
+	MaxEval int
+	// Probe is the number of uniform samples used for a landscape probe
+	// before choosing a solver.  If zero, no probe is performed.
+	Probe int
+	// Landscape holds the probe's results.  It is set by Candidates if
+	// nil and Probe is nonzero.
+	Landscape *analysis.Landscape
+	// Evaler is used by solvers that can evaluate points in batches.  If
+	// nil, optim.SerialEvaler is used.
+	Evaler optim.Evaler
+}
+
+// Candidate is a solver configuration suited to a problem.
+type Candidate struct {
+	Name string
+	// Reason explains why the candidate was chosen.
+	Reason string
+	// New returns a fresh solver limited to maxeval evaluations.
+	New func(maxeval int) *optim.Solver
+}
+
+// Solver returns the recommended solver for p and the space mapping its
+// points to problem space.
+func Solver(p *Problem) (*optim.Solver, transform.Space, *Candidate, error) {
+	cands, space, err := Candidates(p)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return cands[0].New(budget(p)), space, &cands[0], nil
+}
+
+// Candidates returns the solver configurations considered for p ordered
+// from most to least recommended along with the space mapping the solvers'
+// points to problem space.  If p.Probe is nonzero and p.Landscape is nil, a
+// landscape probe is performed first.
+func Candidates(p *Problem) ([]Candidate, transform.Space, error) {
+	ndim := len(p.Low)
+	if ndim == 0 || len(p.Up) != ndim {
+		return nil, nil, errors.New("auto: bounds must be nonempty and of equal length")
+	}
+	space := unitSpace(p)
+	obj := &transform.Objective{Obj: p.Obj, Space: space}
+	if p.Probe > 0 && p.Landscape == nil {
+		zeros, ones := unitBounds(ndim)
+		l, err := analysis.Probe(obj, zeros, ones, p.Probe)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.Landscape = l
+	}
+
+	ev := p.Evaler
+	if ev == nil {
+		ev = optim.SerialEvaler{}
+	}
+	swarmCand := Candidate{Name: "pswarm", New: func(maxeval int) *optim.Solver {
+		zeros, ones := unitBounds(ndim)
+		opts := []pswarm.Option{pswarm.MaxEval(maxeval), pswarm.WithEvaler(ev)}
+		if len(p.Int) > 0 {
+			// rounding makes the objective piecewise constant, so poll
+			// failures quickly shrink the mesh without convergence -
+			// rely on the swarm and stall limit instead.
+			opts = append(opts, pswarm.MinStep(0))
+		}
+		s, _ := pswarm.NewSolver(obj, zeros, ones, opts...)
+		return s
+	}}
+	crsCand := Candidate{Name: "crs", New: func(maxeval int) *optim.Solver {
+		zeros, ones := unitBounds(ndim)
+		return &optim.Solver{
+			Method:       crs.NewRand(zeros, ones, crs.Evaler(ev)),
+			Obj:          obj,
+			Mesh:         &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: zeros, Upper: ones},
+			MaxEval:      maxeval,
+			MaxNoImprove: 100 * (ndim + 1),
+		}
+	}}
+	sceCand := Candidate{Name: "sce", New: func(maxeval int) *optim.Solver {
+		zeros, ones := unitBounds(ndim)
+		return &optim.Solver{
+			Method:       sce.New(4, zeros, ones, sce.Evaler(ev)),
+			Obj:          obj,
+			Mesh:         &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: zeros, Upper: ones},
+			MaxEval:      maxeval,
+			MaxNoImprove: 20,
+		}
+	}}
+
+	l := p.Landscape
+	perdim := budget(p) / ndim
+	switch {
+	case len(p.Int) > 0:
+		swarmCand.Reason = "integer dimensions need a mesh-based method"
+		return []Candidate{swarmCand, sceCand, crsCand}, space, nil
+	case l != nil && l.QuadR2 >= .9 && l.Convexity >= .9 && ndim <= 20:
+		crsCand.Reason = fmt.Sprintf("landscape looks smooth and convex (quad r2 %.2f, convexity %.2f)", l.QuadR2, l.Convexity)
+		return []Candidate{crsCand, swarmCand, sceCand}, space, nil
+	case l != nil && l.Basins > 1 && ndim <= 10:
+		sceCand.Reason = fmt.Sprintf("landscape looks multimodal (%v basins) in few dimensions", l.Basins)
+		return []Candidate{sceCand, swarmCand, crsCand}, space, nil
+	case l == nil && ndim <= 10 && perdim < 500:
+		crsCand.Reason = fmt.Sprintf("few dimensions and a small budget (%v evals per dimension)", perdim)
+		return []Candidate{crsCand, swarmCand, sceCand}, space, nil
+	default:
+		swarmCand.Reason = "robust default for general and high dimensional problems"
+		return []Candidate{swarmCand, sceCand, crsCand}, space, nil
+	}
+}
+
+// Race runs every candidate solver for p with successive halving: each
+// round divides an equal share of the budget among the surviving candidates
+// and then drops the worse half of them until one remains.  Candidates that
+// finish early (e.g. converge) keep their best point but use no more
+// budget.  The winning solver, which can be run further, is returned with
+// its candidate and the space mapping its points to problem space.
+func Race(p *Problem) (*optim.Solver, transform.Space, *Candidate, error) {
+	cands, space, err := Candidates(p)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	total := budget(p)
+	nround := int(math.Ceil(math.Log2(float64(len(cands))))) + 1
+
+	racers := make([]*racer, len(cands))
+	for i := range cands {
+		racers[i] = &racer{cand: &cands[i], s: cands[i].New(total)}
+	}
+	used := 0
+	for round := 0; round < nround && len(racers) > 0; round++ {
+		share := (total - used) / ((nround - round) * len(racers))
+		for _, r := range racers {
+			start := r.s.Neval()
+			for !r.done && r.s.Neval()-start < share {
+				r.done = !r.s.Next()
+			}
+			used += r.s.Neval() - start
+			if err := r.s.Err(); err != nil {
+				return r.s, space, r.cand, err
+			}
+		}
+		sort.SliceStable(racers, func(i, j int) bool { return racers[i].val() < racers[j].val() })
+		if len(racers) > 1 {
+			racers = racers[:(len(racers)+1)/2]
+		}
+	}
+	return racers[0].s, space, racers[0].cand, nil
+}
+
+type racer struct {
+	cand *Candidate
+	s    *optim.Solver
+	done bool
+}
+
+func (r *racer) val() float64 {
+	if r.s.Best() == nil {
+		return math.Inf(1)
+	}
+	return r.s.Best().Val
+}
+
+// budget returns p's evaluation budget remaining after any probe.
+func budget(p *Problem) int {
+	n := p.MaxEval
+	if n == 0 {
+		n = 50000
+	}
+	if p.Landscape != nil {
+		n -= p.Landscape.Neval
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+func unitBounds(ndim int) (zeros, ones []float64) {
+	zeros, ones = make([]float64, ndim), make([]float64, ndim)
+	for i := range ones {
+		ones[i] = 1
+	}
+	return zeros, ones
+}
+
+// unitSpace maps the unit hypercube onto p's bounds rounding integer
+// dimensions (see transform.IntUnit).
+func unitSpace(p *Problem) transform.Space {
+	space := transform.UnitSpace(p.Low, p.Up)
+	for _, i := range p.Int {
+		space[i] = transform.IntUnit(p.Low[i], p.Up[i])
+	}
+	return space
+}
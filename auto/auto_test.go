@@ -0,0 +1,87 @@
+package auto
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+var sphere = optim.Func(func(v []float64) float64 {
+	tot := 0.0
+	for _, x := range v {
+		tot += (x - 1) * (x - 1)
+	}
+	return tot
+})
+
+var rastrigin = optim.Func(func(v []float64) float64 {
+	tot := 10 * float64(len(v))
+	for _, x := range v {
+		tot += x*x - 10*math.Cos(2*math.Pi*x)
+	}
+	return tot
+})
+
+func TestCandidates(t *testing.T) {
+	optim.Rand = &optim.LockedRng{Rng: rand.New(rand.NewSource(1))}
+	low, up := []float64{-5, -5, -5}, []float64{5, 5, 5}
+	tests := []struct {
+		p    *Problem
+		want string
+	}{
+		{&Problem{Obj: sphere, Low: low, Up: up, Int: []int{1}}, "pswarm"},
+		{&Problem{Obj: sphere, Low: low, Up: up, Probe: 150}, "crs"},
+		{&Problem{Obj: rastrigin, Low: low, Up: up, Probe: 150}, "sce"},
+		{&Problem{Obj: sphere, Low: low, Up: up, MaxEval: 1000}, "crs"},
+		{&Problem{Obj: sphere, Low: fill(30, -5), Up: fill(30, 5)}, "pswarm"},
+	}
+	for i, test := range tests {
+		cands, _, err := Candidates(test.p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cands[0].Name; got != test.want {
+			t.Errorf("case %v: want %v, got %v (%v)", i, test.want, got, cands[0].Reason)
+		}
+	}
+}
+
+func TestSolverInt(t *testing.T) {
+	optim.Rand = &optim.LockedRng{Rng: rand.New(rand.NewSource(1))}
+	p := &Problem{Obj: sphere, Low: []float64{-5, -5}, Up: []float64{5, 5}, Int: []int{0}, MaxEval: 5000}
+	s, space, cand, err := Solver(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for s.Next() {
+	}
+	best := space.Point(s.Best())
+	t.Logf("%v: %v", cand.Name, best)
+	if best.Pos[0] != 1 || math.Abs(best.Pos[1]-1) > 1e-3 {
+		t.Errorf("want [1 1], got %v", best.Pos)
+	}
+}
+
+func TestRace(t *testing.T) {
+	optim.Rand = &optim.LockedRng{Rng: rand.New(rand.NewSource(1))}
+	p := &Problem{Obj: rastrigin, Low: []float64{-5, -5, -5}, Up: []float64{5, 5, 5}, MaxEval: 20000}
+	s, space, cand, err := Race(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	best := space.Point(s.Best())
+	t.Logf("%v won: %v", cand.Name, best)
+	if best.Val > 1e-3 {
+		t.Errorf("want global min 0, got %v", best.Val)
+	}
+}
+
+func fill(n int, v float64) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = v
+	}
+	return x
+}
@@ -0,0 +1,148 @@
+// Package basinhop provides a basin hopping meta-method as described in:
+//
+//	Wales, David J., and Jonathan PK Doye. "Global optimization by
+//	basin-hopping and the lowest energy structures of Lennard-Jones clusters
+//	containing up to 110 atoms." The Journal of Physical Chemistry A 101.28
+//	(1997): 5111-5116.
+//
+// Each iteration randomly perturbs the current local minimum, refines the
+// perturbed point with a local solver, and accepts the resulting local
+// minimum as the new current point according to the Metropolis criterion.
+package basinhop
+
+import (
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Local returns a solver configured to locally refine the given start
+// point.  If the returned solver's Obj is nil, it is set to the objective
+// being optimized.  The solver should have its own mesh and termination
+// criteria (e.g. MaxEval or MinStep).
+type Local func(start *optim.Point) *optim.Solver
+
+type Option func(*Method)
+
+// JumpFrac sets the perturbation size in each dimension to frac times the
+// width of the bounds low and up.
+func JumpFrac(frac float64, low, up []float64) Option {
+	return func(m *Method) {
+		m.Jump = optim.Bounds{Low: low, Up: up}.Widths()
+		for i := range m.Jump {
+			m.Jump[i] *= frac
+		}
+	}
+}
+
+// JumpStep sets the perturbation size to mult times the step size of the
+// mesh passed to Iterate.  This is the default (with mult of 10) if the mesh
+// has a non-zero step size.
+func JumpStep(mult float64) Option { return func(m *Method) { m.Jump, m.JumpMult = nil, mult } }
+
+// Temp sets the temperature for the Metropolis acceptance criterion.
+// Higher temperatures more readily accept worse local minima.
+func Temp(t float64) Option { return func(m *Method) { m.Temp = t } }
+
+type Method struct {
+	// Curr is the current (accepted) local minimum.
+	Curr  *optim.Point
+	Local Local
+	// Jump holds the maximum perturbation in each dimension.  If nil,
+	// JumpMult times the mesh step (or 1 for continuous meshes) is used in
+	// every dimension.
+	Jump     []float64
+	JumpMult float64
+	Temp     float64
+	// Naccept is the number of accepted hops.
+	Naccept int
+	best    *optim.Point
+	init    bool
+}
+
+func New(start *optim.Point, local Local, opts ...Option) *Method {
+	m := &Method{
+		Curr:     start.Clone(),
+		Local:    local,
+		JumpMult: 10,
+		Temp:     1,
+		best:     start.Clone(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *Method) AddPoint(p *optim.Point) {
+	if p.Val < m.best.Val {
+		m.best = p
+	}
+}
+
+func (m *Method) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Point, n int, err error) {
+	if !m.init {
+		// refine the starting point before hopping from it
+		m.init = true
+		m.Curr, n, err = m.refine(obj, m.Curr)
+		if m.Curr.Val < m.best.Val {
+			m.best = m.Curr.Clone()
+		}
+		return m.best, n, err
+	}
+
+	pos := make([]float64, m.Curr.Len())
+	for i := range pos {
+		pos[i] = m.Curr.Pos[i] + (2*optim.RandFloat()-1)*m.jump(i, mesh)
+	}
+	if mesh != nil {
+		pos = mesh.Nearest(pos)
+	}
+
+	min, n, err := m.refine(obj, &optim.Point{Pos: pos, Val: math.Inf(1)})
+	if err != nil {
+		return m.best, n, err
+	}
+
+	if min.Val < m.best.Val {
+		m.best = min.Clone()
+	}
+	if min.Val <= m.Curr.Val || optim.RandFloat() < math.Exp(-(min.Val-m.Curr.Val)/m.Temp) {
+		m.Curr = min
+		m.Naccept++
+	}
+	return m.best, n, nil
+}
+
+func (m *Method) jump(i int, mesh optim.Mesh) float64 {
+	if m.Jump != nil {
+		return m.Jump[i]
+	} else if mesh != nil && mesh.Step() > 0 {
+		return m.JumpMult * mesh.Step()
+	}
+	return m.JumpMult
+}
+
+// refine runs a local solver from start to completion and returns the
+// local minimum found.
+func (m *Method) refine(obj optim.Objectiver, start *optim.Point) (*optim.Point, int, error) {
+	s := m.Local(start)
+	if s.Obj == nil {
+		s.Obj = obj
+	}
+	for s.Next() {
+	}
+
+	min := s.Best()
+	if min == nil || min.Val > start.Val {
+		// local solvers that don't evaluate their start point may not
+		// improve on it
+		min = start
+	}
+	if math.IsInf(min.Val, 1) {
+		// the start was never evaluated
+		v, err := obj.Objective(start.Pos)
+		return &optim.Point{Pos: start.Pos, Val: v}, s.Neval() + 1, err
+	}
+	return min.Clone(), s.Neval(), s.Err()
+}
@@ -0,0 +1,63 @@
+package basinhop
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+	"github.com/rwcarlsen/optim/pattern"
+)
+
+func patternLocal(start *optim.Point) *optim.Solver {
+	mesh := &optim.InfMesh{StepSize: 0.25}
+	mesh.SetOrigin(start.Pos)
+	return &optim.Solver{
+		Method:  pattern.New(start),
+		Mesh:    mesh,
+		MinStep: 1e-4,
+		MaxEval: 1000,
+	}
+}
+
+func TestRastrigin(t *testing.T) {
+	fn := bench.Rastrigin{NDim: 2}
+	low, up := fn.Bounds()
+	start := &optim.Point{Pos: []float64{3.9, -4.1}, Val: math.Inf(1)}
+
+	m := New(start, patternLocal, JumpFrac(0.2, low, up), Temp(2))
+	solv := &optim.Solver{
+		Method:  m,
+		Obj:     optim.Func(fn.Eval),
+		Mesh:    &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up},
+		MaxIter: 100,
+	}
+	for solv.Next() {
+		if solv.Best().Val < 1e-6 {
+			break
+		}
+	}
+	if err := solv.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("%v hops (%v accepted), %v evals: best %v", solv.Niter(), m.Naccept, solv.Neval(), solv.Best())
+	if solv.Best().Val > 1e-6 {
+		t.Errorf("failed to find global minimum: got %v", solv.Best())
+	}
+}
+
+func TestJump(t *testing.T) {
+	m := New(&optim.Point{Pos: []float64{0, 0}}, patternLocal)
+	if got := m.jump(0, nil); got != 10 {
+		t.Errorf("continuous default: want 10, got %v", got)
+	}
+	if got := m.jump(0, &optim.InfMesh{StepSize: 0.5}); got != 5 {
+		t.Errorf("mesh step: want 5, got %v", got)
+	}
+
+	m = New(&optim.Point{Pos: []float64{0, 0}}, patternLocal, JumpFrac(0.1, []float64{0, -5}, []float64{10, 5}))
+	if got := m.jump(1, &optim.InfMesh{StepSize: 0.5}); got != 1 {
+		t.Errorf("bounds fraction: want 1, got %v", got)
+	}
+}
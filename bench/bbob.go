@@ -0,0 +1,615 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// BBOB is one of the 24 noiseless functions of the BBOB (black-box
+// optimization benchmarking) suite used by the COCO platform:
+//
+//	Hansen, Nikolaus, et al. "Real-parameter black-box optimization
+//	benchmarking 2009: Noiseless functions definitions." INRIA Research
+//	Report RR-6829 (2009).
+//
+// Instances - the optimum location and value and the rotations - are
+// generated with the same pseudo-random scheme as COCO's bbob2009 code so
+// that results are comparable with published ones.  Use NewBBOB to create
+// functions; every function is defined on [-5, 5]^NDim.
+type BBOB struct {
+	// Fn is the function number from 1 to 24.
+	Fn       int
+	NDim     int
+	Instance int
+	// Precision is how far above the optimal value a point must be to
+	// count as solved.  NewBBOB sets it to COCO's final target of 1e-8.
+	Precision float64
+
+	xopt []float64
+	fopt float64
+	// r and q are the instance's two random rotations and m is a combined
+	// linear transformation used by some functions.
+	r, q, m [][]float64
+
+	// Gallagher peak data for f21 and f22.
+	peakvals []float64
+	scales   [][]float64
+	local    [][]float64
+}
+
+// BBOBSuite returns the 24 BBOB functions in ndim dimensions for the given
+// instance.
+func BBOBSuite(ndim, instance int) []Func {
+	fns := make([]Func, 24)
+	for i := range fns {
+		fns[i] = NewBBOB(i+1, ndim, instance)
+	}
+	return fns
+}
+
+// NewBBOB creates instance number instance of BBOB function fn (1 to 24) in
+// ndim dimensions.  ndim must be at least 2.
+func NewBBOB(fn, ndim, instance int) *BBOB {
+	if fn < 1 || fn > 24 {
+		panic(fmt.Sprintf("bench: invalid BBOB function f%v", fn))
+	} else if ndim < 2 {
+		panic("bench: BBOB functions need at least 2 dimensions")
+	}
+	b := &BBOB{Fn: fn, NDim: ndim, Instance: instance, Precision: 1e-8}
+
+	rseed := int64(fn)
+	switch fn {
+	case 4:
+		rseed = 3
+	case 18:
+		rseed = 17
+	}
+	rseed += 10000 * int64(instance)
+	b.fopt = bbobFopt(rseed)
+	b.xopt = bbobXopt(rseed, ndim)
+
+	d := ndim
+	switch fn {
+	case 4:
+		for i := 0; i < d; i += 2 {
+			b.xopt[i] = math.Abs(b.xopt[i])
+		}
+	case 5:
+		for i, x := range b.xopt {
+			b.xopt[i] = math.Copysign(5, x)
+		}
+	case 6, 13, 23:
+		b.r, b.q = bbobRotation(rseed+1000000, d), bbobRotation(rseed, d)
+		cond := map[int]float64{6: 10, 13: 10, 23: 100}[fn]
+		b.m = mulDiag(b.q, cond, b.r)
+	case 7:
+		b.r, b.q = bbobRotation(rseed+1000000, d), bbobRotation(rseed, d)
+	case 8:
+		for i := range b.xopt {
+			b.xopt[i] *= .75
+		}
+	case 9, 19:
+		b.r = bbobRotation(rseed, d)
+		scale := rosenScale(d)
+		for i := range b.xopt {
+			b.xopt[i] = 0
+			for j := range b.r {
+				b.xopt[i] += b.r[j][i] * .5 / scale
+			}
+		}
+	case 10, 11, 12, 14:
+		b.r = bbobRotation(rseed+1000000, d)
+	case 15, 16:
+		b.r, b.q = bbobRotation(rseed+1000000, d), bbobRotation(rseed, d)
+		cond := 10.0
+		if fn == 16 {
+			cond = 1.0 / 100
+		}
+		b.m = mulDiag(b.r, cond, b.q)
+	case 17, 18:
+		b.r, b.q = bbobRotation(rseed+1000000, d), bbobRotation(rseed, d)
+		cond := 10.0
+		if fn == 18 {
+			cond = 1000
+		}
+		b.m = mulDiag(nil, cond, b.q)
+	case 20:
+		u := bbobUnif(d, rseed)
+		for i := range b.xopt {
+			b.xopt[i] = .5 * 4.2096874637
+			if u[i] < .5 {
+				b.xopt[i] = -b.xopt[i]
+			}
+		}
+	case 21, 22:
+		b.initGallagher(rseed)
+	case 24:
+		b.r, b.q = bbobRotation(rseed+1000000, d), bbobRotation(rseed, d)
+		b.m = mulDiag(b.q, 100, b.r)
+		g := bbobGauss(d, rseed)
+		for i := range b.xopt {
+			b.xopt[i] = .5 * lunacekMu0
+			if g[i] < 0 {
+				b.xopt[i] = -b.xopt[i]
+			}
+		}
+	}
+	return b
+}
+
+func (fn *BBOB) Name() string {
+	return fmt.Sprintf("BBOB_f%v_%vD_i%v", fn.Fn, fn.NDim, fn.Instance)
+}
+
+func (fn *BBOB) Tol() float64 { return fn.fopt + fn.Precision }
+
+func (fn *BBOB) Bounds() (low, up []float64) {
+	low, up = make([]float64, fn.NDim), make([]float64, fn.NDim)
+	for i := range low {
+		low[i], up[i] = -5, 5
+	}
+	return low, up
+}
+
+func (fn *BBOB) Optima() []*optim.Point {
+	return []*optim.Point{{Pos: append([]float64{}, fn.xopt...), Val: fn.fopt}}
+}
+
+func (fn *BBOB) Eval(x []float64) float64 {
+	d := fn.NDim
+	fd := float64(d)
+	exp := func(i int) float64 { return float64(i) / float64(d-1) }
+	shifted := func() []float64 {
+		z := make([]float64, d)
+		for i := range z {
+			z[i] = x[i] - fn.xopt[i]
+		}
+		return z
+	}
+
+	f := 0.0
+	switch fn.Fn {
+	case 1:
+		for _, z := range shifted() {
+			f += z * z
+		}
+	case 2, 10:
+		z := shifted()
+		if fn.Fn == 10 {
+			z = matVec(fn.r, z)
+		}
+		for i, zi := range tosz(z) {
+			f += math.Pow(10, 6*exp(i)) * zi * zi
+		}
+	case 3:
+		z := tasy(tosz(shifted()), .2)
+		for i := range z {
+			z[i] *= math.Pow(10, .5*exp(i))
+		}
+		f = rastrigin(z)
+	case 4:
+		z := tosz(shifted())
+		for i := range z {
+			z[i] *= math.Pow(10, .5*exp(i))
+			if i%2 == 0 && z[i] > 0 {
+				z[i] *= 10
+			}
+		}
+		f = rastrigin(z) + 100*penalty(x)
+	case 5:
+		for i := range x {
+			s := math.Copysign(math.Pow(10, exp(i)), fn.xopt[i])
+			z := x[i]
+			if fn.xopt[i]*x[i] >= 25 {
+				z = fn.xopt[i]
+			}
+			f += 5*math.Abs(s) - s*z
+		}
+	case 6:
+		for i, zi := range matVec(fn.m, shifted()) {
+			if zi*fn.xopt[i] > 0 {
+				zi *= 100
+			}
+			f += zi * zi
+		}
+		f = math.Pow(tosz([]float64{f})[0], .9)
+	case 7:
+		zhat := matVec(fn.r, shifted())
+		for i := range zhat {
+			zhat[i] *= math.Pow(10, .5*exp(i))
+		}
+		ztil := make([]float64, d)
+		for i, zh := range zhat {
+			if math.Abs(zh) > .5 {
+				ztil[i] = math.Floor(.5 + zh)
+			} else {
+				ztil[i] = math.Floor(.5+10*zh) / 10
+			}
+		}
+		for i, zi := range matVec(fn.q, ztil) {
+			f += math.Pow(10, 2*exp(i)) * zi * zi
+		}
+		f = .1*math.Max(math.Abs(zhat[0])/1e4, f) + penalty(x)
+	case 8:
+		z := shifted()
+		for i := range z {
+			z[i] = rosenScale(d)*z[i] + 1
+		}
+		f = rosen(z)
+	case 9, 19:
+		z := matVec(fn.r, x)
+		for i := range z {
+			z[i] = rosenScale(d)*z[i] + .5
+		}
+		if fn.Fn == 9 {
+			f = rosen(z)
+			break
+		}
+		for i := 0; i < d-1; i++ {
+			s := 100*(z[i]*z[i]-z[i+1])*(z[i]*z[i]-z[i+1]) + (z[i]-1)*(z[i]-1)
+			f += s/4000 - math.Cos(s)
+		}
+		f = 10*f/(fd-1) + 10
+	case 11:
+		for i, zi := range tosz(matVec(fn.r, shifted())) {
+			if i == 0 {
+				zi *= 1e3
+			}
+			f += zi * zi
+		}
+	case 12:
+		z := matVec(fn.r, tasy(matVec(fn.r, shifted()), .5))
+		for i, zi := range z {
+			if i > 0 {
+				zi *= 1e3
+			}
+			f += zi * zi
+		}
+	case 13:
+		z := matVec(fn.m, shifted())
+		for _, zi := range z[1:] {
+			f += zi * zi
+		}
+		f = z[0]*z[0] + 100*math.Sqrt(f)
+	case 14:
+		for i, zi := range matVec(fn.r, shifted()) {
+			f += math.Pow(math.Abs(zi), 2+4*exp(i))
+		}
+		f = math.Sqrt(f)
+	case 15:
+		z := matVec(fn.m, tasy(tosz(matVec(fn.r, shifted())), .2))
+		f = rastrigin(z)
+	case 16:
+		z := matVec(fn.m, tosz(matVec(fn.r, shifted())))
+		f0 := 0.0
+		for k := 0; k < 12; k++ {
+			f0 += math.Pow(.5, float64(k)) * math.Cos(math.Pi*math.Pow(3, float64(k)))
+		}
+		for _, zi := range z {
+			for k := 0; k < 12; k++ {
+				f += math.Pow(.5, float64(k)) * math.Cos(2*math.Pi*math.Pow(3, float64(k))*(zi+.5))
+			}
+		}
+		f = 10*math.Pow(f/fd-f0, 3) + 10/fd*penalty(x)
+	case 17, 18:
+		z := matVec(fn.m, tasy(matVec(fn.r, shifted()), .5))
+		for i := 0; i < d-1; i++ {
+			s := math.Sqrt(z[i]*z[i] + z[i+1]*z[i+1])
+			sin := math.Sin(50 * math.Pow(s, .2))
+			f += math.Sqrt(s) + math.Sqrt(s)*sin*sin
+		}
+		f = f/(fd-1)*f/(fd-1) + 10*penalty(x)
+	case 20:
+		xhat := make([]float64, d)
+		for i := range xhat {
+			xhat[i] = math.Copysign(2, fn.xopt[i]) * x[i]
+		}
+		z := make([]float64, d)
+		for i := range z {
+			zhat := xhat[i]
+			if i > 0 {
+				zhat += .25 * (xhat[i-1] - 2*math.Abs(fn.xopt[i-1]))
+			}
+			a := 2 * math.Abs(fn.xopt[i])
+			z[i] = 100 * ((zhat-a)*math.Pow(10, .5*exp(i)) + a)
+		}
+		sum, pen := 0.0, 0.0
+		for _, zi := range z {
+			sum += zi * math.Sin(math.Sqrt(math.Abs(zi)))
+			if over := math.Abs(zi) - 500; over > 0 {
+				pen += over * over
+			}
+		}
+		f = .01 * (pen + 418.9828872724339 - sum/fd)
+	case 21, 22:
+		z := matVec(fn.r, x)
+		best := 0.0
+		for k, peak := range fn.peakvals {
+			dist := 0.0
+			for i := range z {
+				dz := z[i] - fn.local[k][i]
+				dist += fn.scales[k][i] * dz * dz
+			}
+			best = math.Max(best, peak*math.Exp(-dist/(2*fd)))
+		}
+		t := tosz([]float64{10 - best})[0]
+		f = t*t + penalty(x)
+	case 23:
+		z := matVec(fn.m, shifted())
+		f = 1
+		for i, zi := range z {
+			sum := 0.0
+			for j := 1; j <= 32; j++ {
+				p := math.Pow(2, float64(j))
+				sum += math.Abs(p*zi-math.Floor(p*zi+.5)) / p
+			}
+			f *= math.Pow(1+float64(i+1)*sum, 10/math.Pow(fd, 1.2))
+		}
+		f = 10/(fd*fd)*f - 10/(fd*fd) + penalty(x)
+	case 24:
+		s := 1 - 1/(2*math.Sqrt(fd+20)-8.2)
+		mu1 := -math.Sqrt((lunacekMu0*lunacekMu0 - 1) / s)
+		xhat := make([]float64, d)
+		shift := make([]float64, d)
+		sum0, sum1 := 0.0, 0.0
+		for i := range xhat {
+			xhat[i] = math.Copysign(2, fn.xopt[i]) * x[i]
+			shift[i] = xhat[i] - lunacekMu0
+			sum0 += shift[i] * shift[i]
+			sum1 += (xhat[i] - mu1) * (xhat[i] - mu1)
+		}
+		cos := 0.0
+		for _, zi := range matVec(fn.m, shift) {
+			cos += math.Cos(2 * math.Pi * zi)
+		}
+		f = math.Min(sum0, fd+s*sum1) + 10*(fd-cos) + 1e4*penalty(x)
+	}
+	return f + fn.fopt
+}
+
+const lunacekMu0 = 2.5
+
+// initGallagher generates the peaks of the Gallagher functions (f21 with
+// 101 peaks and f22 with 21 peaks).
+func (fn *BBOB) initGallagher(rseed int64) {
+	d := fn.NDim
+	npeak, b, c, cond0 := 101, 10.0, 5.0, math.Sqrt(1000)
+	if fn.Fn == 22 {
+		npeak, b, c, cond0 = 21, 9.8, 4.9, 1000
+	}
+	fn.r = bbobRotation(rseed, d)
+
+	perm := argsort(bbobUnif(npeak-1, rseed))
+	conds := make([]float64, npeak)
+	fn.peakvals = make([]float64, npeak)
+	conds[0], fn.peakvals[0] = cond0, 10
+	for i := 1; i < npeak; i++ {
+		conds[i] = math.Pow(1000, float64(perm[i-1])/float64(npeak-2))
+		fn.peakvals[i] = 1.1 + 8*float64(i-1)/float64(npeak-2)
+	}
+
+	fn.scales = make([][]float64, npeak)
+	for k := range fn.scales {
+		perm := argsort(bbobUnif(d, rseed+1000*int64(k)))
+		fn.scales[k] = make([]float64, d)
+		for i := range fn.scales[k] {
+			fn.scales[k][i] = math.Pow(conds[k], float64(perm[i])/float64(d-1)-.5)
+		}
+	}
+
+	u := bbobUnif(d*npeak, rseed)
+	fn.local = make([][]float64, npeak)
+	for k := range fn.local {
+		y := make([]float64, d)
+		for i := range y {
+			y[i] = b*u[k*d+i] - c
+		}
+		fn.local[k] = matVec(fn.r, y)
+		if k == 0 {
+			for i := range y {
+				fn.local[k][i] *= .8
+				fn.xopt[i] = .8 * y[i]
+			}
+		}
+	}
+}
+
+// bbobUnif returns n uniform random numbers in (0, 1] generated from seed
+// exactly as by COCO's bbob2009_unif.
+func bbobUnif(n int, seed int64) []float64 {
+	if seed < 0 {
+		seed = -seed
+	}
+	if seed < 1 {
+		seed = 1
+	}
+	next := func() {
+		tmp := seed / 127773
+		seed = 16807*(seed-tmp*127773) - 2836*tmp
+		if seed < 0 {
+			seed += 2147483647
+		}
+	}
+	var table [32]int64
+	for i := 39; i >= 0; i-- {
+		next()
+		if i < 32 {
+			table[i] = seed
+		}
+	}
+	r := make([]float64, n)
+	rand := table[0]
+	for i := range r {
+		next()
+		j := rand / 67108865
+		rand = table[j]
+		table[j] = seed
+		r[i] = float64(rand) / 2.147483647e9
+		if r[i] == 0 {
+			r[i] = 1e-99
+		}
+	}
+	return r
+}
+
+// bbobGauss returns n normally distributed random numbers generated from
+// seed exactly as by COCO's bbob2009_gauss.
+func bbobGauss(n int, seed int64) []float64 {
+	u := bbobUnif(2*n, seed)
+	g := make([]float64, n)
+	for i := range g {
+		g[i] = math.Sqrt(-2*math.Log(u[i])) * math.Cos(2*math.Pi*u[n+i])
+		if g[i] == 0 {
+			g[i] = 1e-99
+		}
+	}
+	return g
+}
+
+// bbobRotation returns a random d by d orthogonal matrix generated by
+// Gram-Schmidt orthonormalization of the columns of a gaussian matrix.
+func bbobRotation(seed int64, d int) [][]float64 {
+	g := bbobGauss(d*d, seed)
+	b := make([][]float64, d)
+	for i := range b {
+		b[i] = make([]float64, d)
+		for j := range b[i] {
+			b[i][j] = g[j*d+i]
+		}
+	}
+	for i := 0; i < d; i++ {
+		for j := 0; j < i; j++ {
+			prod := 0.0
+			for k := 0; k < d; k++ {
+				prod += b[k][i] * b[k][j]
+			}
+			for k := 0; k < d; k++ {
+				b[k][i] -= prod * b[k][j]
+			}
+		}
+		norm := 0.0
+		for k := 0; k < d; k++ {
+			norm += b[k][i] * b[k][i]
+		}
+		for k := 0; k < d; k++ {
+			b[k][i] /= math.Sqrt(norm)
+		}
+	}
+	return b
+}
+
+func bbobXopt(seed int64, d int) []float64 {
+	xopt := bbobUnif(d, seed)
+	for i, u := range xopt {
+		xopt[i] = 8*math.Floor(1e4*u)/1e4 - 4
+		if xopt[i] == 0 {
+			xopt[i] = -1e-5
+		}
+	}
+	return xopt
+}
+
+func bbobFopt(seed int64) float64 {
+	g1, g2 := bbobGauss(1, seed)[0], bbobGauss(1, seed+1)[0]
+	return math.Min(1000, math.Max(-1000, math.Floor(100*100*g1/g2+.5)/100))
+}
+
+// mulDiag returns a*diag(l)*b where l[i] = sqrt(cond)^(i/(d-1)) - the
+// BBOB "Lambda" conditioning matrix.  A nil a is treated as the identity.
+func mulDiag(a [][]float64, cond float64, b [][]float64) [][]float64 {
+	d := len(b)
+	m := make([][]float64, d)
+	for i := range m {
+		m[i] = make([]float64, d)
+		for j := range m[i] {
+			if a == nil {
+				m[i][j] = math.Pow(math.Sqrt(cond), float64(i)/float64(d-1)) * b[i][j]
+				continue
+			}
+			for k := 0; k < d; k++ {
+				m[i][j] += a[i][k] * math.Pow(math.Sqrt(cond), float64(k)/float64(d-1)) * b[k][j]
+			}
+		}
+	}
+	return m
+}
+
+func matVec(m [][]float64, x []float64) []float64 {
+	y := make([]float64, len(m))
+	for i := range m {
+		for j, xj := range x {
+			y[i] += m[i][j] * xj
+		}
+	}
+	return y
+}
+
+// tosz is the BBOB oscillation transformation T_osz.
+func tosz(x []float64) []float64 {
+	y := make([]float64, len(x))
+	for i, xi := range x {
+		if xi == 0 {
+			continue
+		}
+		xhat := math.Log(math.Abs(xi))
+		c1, c2 := 5.5, 3.1
+		if xi > 0 {
+			c1, c2 = 10, 7.9
+		}
+		y[i] = math.Copysign(math.Exp(xhat+.049*(math.Sin(c1*xhat)+math.Sin(c2*xhat))), xi)
+	}
+	return y
+}
+
+// tasy is the BBOB asymmetry transformation T_asy^beta.
+func tasy(x []float64, beta float64) []float64 {
+	y := make([]float64, len(x))
+	for i, xi := range x {
+		y[i] = xi
+		if xi > 0 {
+			y[i] = math.Pow(xi, 1+beta*float64(i)/float64(len(x)-1)*math.Sqrt(xi))
+		}
+	}
+	return y
+}
+
+// penalty is the BBOB boundary penalty f_pen for leaving [-5, 5]^d.
+func penalty(x []float64) float64 {
+	pen := 0.0
+	for _, xi := range x {
+		if over := math.Abs(xi) - 5; over > 0 {
+			pen += over * over
+		}
+	}
+	return pen
+}
+
+func rastrigin(z []float64) float64 {
+	cos, sq := 0.0, 0.0
+	for _, zi := range z {
+		cos += math.Cos(2 * math.Pi * zi)
+		sq += zi * zi
+	}
+	return 10*(float64(len(z))-cos) + sq
+}
+
+func rosen(z []float64) float64 {
+	f := 0.0
+	for i := 0; i < len(z)-1; i++ {
+		a, b := z[i]*z[i]-z[i+1], z[i]-1
+		f += 100*a*a + b*b
+	}
+	return f
+}
+
+func rosenScale(d int) float64 { return math.Max(1, math.Sqrt(float64(d))/8) }
+
+// argsort returns the indices that sort v in ascending order.
+func argsort(v []float64) []int {
+	idx := seq(len(v))
+	sort.SliceStable(idx, func(i, j int) bool { return v[idx[i]] < v[idx[j]] })
+	return idx
+}
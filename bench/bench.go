@@ -224,6 +224,42 @@ func (fn Styblinski) Optima() []*optim.Point {
 	}
 }
 
+type Sphere struct {
+	NDim int
+}
+
+func (fn Sphere) Name() string { return fmt.Sprintf("Sphere_%vD", fn.NDim) }
+
+func (fn Sphere) Tol() float64 { return .01 }
+
+func (fn Sphere) Eval(x []float64) float64 {
+	if !InsideBounds(x, fn) {
+		return math.Inf(1)
+	}
+
+	tot := 0.0
+	for _, v := range x {
+		tot += v * v
+	}
+	return tot
+}
+
+func (fn Sphere) Bounds() (low, up []float64) {
+	low = make([]float64, fn.NDim)
+	up = make([]float64, fn.NDim)
+	for i := range low {
+		low[i] = -5.12
+		up[i] = 5.12
+	}
+	return low, up
+}
+
+func (fn Sphere) Optima() []*optim.Point {
+	return []*optim.Point{
+		&optim.Point{make([]float64, fn.NDim), 0},
+	}
+}
+
 type Rastrigin struct {
 	NDim int
 }
@@ -348,8 +384,9 @@ var BenchSeed int64 = 7
 // Benchmark performs several optimization runs using sfn to generate
 // set up problems for each run.  It uses fn as the objective and performs
 // tests confirming that at least some successfrac of runs achieved better
-// than fn's tolerance for optimum in less than avgeval evaluations. Results
-// are logged to t.
+// than fn's tolerance for optimum in less than avgeval evaluations. Results,
+// including the expected running time (ERT) to reach the tolerance over all
+// runs, are logged to t.
 func Benchmark(t *testing.T, fn Func, sfn func() *optim.Solver, successfrac, avgeval float64) {
 	optim.Rand = rand.New(rand.NewSource(BenchSeed))
 	nrun := 44
@@ -378,6 +415,16 @@ func Benchmark(t *testing.T, fn Func, sfn func() *optim.Solver, successfrac, avg
 
 	sort.Sort(byevals(solvs))
 
+	// ERT is computed over every run before dropping outliers
+	allevals, allsuccess := 0, 0
+	for _, s := range solvs {
+		allevals += s.Neval()
+		if s.Best().Val < fn.Tol() {
+			allsuccess++
+		}
+	}
+	ert := float64(allevals) / float64(allsuccess)
+
 	for _, s := range solvs[ndrop : len(solvs)-ndrop] {
 		neval += s.Neval()
 		niter += s.Niter()
@@ -390,7 +437,7 @@ func Benchmark(t *testing.T, fn Func, sfn func() *optim.Solver, successfrac, avg
 	frac := float64(nsuccess) / float64(nkeep)
 	gotavg := float64(neval) / float64(nkeep)
 
-	t.Logf("[%v] %v/%v runs, %v iters, %v evals, want < %.3f, averaged %.3f", fn.Name(), nsuccess, nkeep, niter/nkeep, neval/nkeep, fn.Tol(), sum/float64(nkeep))
+	t.Logf("[%v] %v/%v runs, %v iters, %v evals, ERT %.1f, want < %.3f, averaged %.3f", fn.Name(), nsuccess, nkeep, niter/nkeep, neval/nkeep, ert, fn.Tol(), sum/float64(nkeep))
 
 	if frac < successfrac {
 		t.Errorf("    FAIL: only %v/%v runs succeeded, want %v/%v", nsuccess, nkeep, math.Ceil(successfrac*float64(nkeep)), nkeep)
@@ -411,10 +458,5 @@ var ErrMax = errors.New("hit max eval or iter limit")
 
 func InsideBounds(p []float64, fn Func) bool {
 	low, up := fn.Bounds()
-	for i := range p {
-		if p[i] < low[i] || p[i] > up[i] {
-			return false
-		}
-	}
-	return true
+	return optim.Bounds{Low: low, Up: up}.Contains(p)
 }
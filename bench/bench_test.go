@@ -1,6 +1,7 @@
 package bench_test
 
 import (
+	"bytes"
 	"database/sql"
 	"math"
 	"math/rand"
@@ -8,9 +9,13 @@ import (
 
 	_ "github.com/rwcarlsen/go-sqlite/sqlite3"
 	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/auto"
 	"github.com/rwcarlsen/optim/bench"
+	"github.com/rwcarlsen/optim/multi"
 	"github.com/rwcarlsen/optim/pattern"
+	"github.com/rwcarlsen/optim/pswarm"
 	"github.com/rwcarlsen/optim/swarm"
+	"github.com/rwcarlsen/optim/swarmx"
 )
 
 const (
@@ -194,6 +199,63 @@ func TestOverviewPSwarm(t *testing.T) {
 	}
 }
 
+func TestOverviewGreyWolf(t *testing.T) {
+	maxeval := 50000
+	avgeval := 7500.00
+	successfrac := 1.00
+
+	for _, fn := range bench.Basic {
+		sfn := func() *optim.Solver {
+			low, up := fn.Bounds()
+			return &optim.Solver{
+				Method:  swarmx.NewGreyWolf(optim.RandPop(30, low, up), swarmx.MaxIter(maxeval/30)),
+				Obj:     optim.Func(fn.Eval),
+				Mesh:    &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up},
+				MaxEval: maxeval,
+			}
+		}
+		bench.Benchmark(t, fn, sfn, successfrac, avgeval)
+	}
+}
+
+func TestOverviewWhale(t *testing.T) {
+	maxeval := 50000
+	avgeval := 7500.00
+	successfrac := 1.00
+
+	for _, fn := range bench.Basic {
+		sfn := func() *optim.Solver {
+			low, up := fn.Bounds()
+			return &optim.Solver{
+				Method:  swarmx.NewWhale(optim.RandPop(30, low, up), swarmx.MaxIter(maxeval/30)),
+				Obj:     optim.Func(fn.Eval),
+				Mesh:    &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up},
+				MaxEval: maxeval,
+			}
+		}
+		bench.Benchmark(t, fn, sfn, successfrac, avgeval)
+	}
+}
+
+func TestOverviewFirefly(t *testing.T) {
+	maxeval := 20000
+	avgeval := 20000.00
+	successfrac := .25
+
+	for _, fn := range bench.Basic {
+		sfn := func() *optim.Solver {
+			low, up := fn.Bounds()
+			return &optim.Solver{
+				Method:  swarmx.NewFirefly(optim.RandPop(30, low, up), low, up),
+				Obj:     optim.Func(fn.Eval),
+				Mesh:    &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up},
+				MaxEval: maxeval,
+			}
+		}
+		bench.Benchmark(t, fn, sfn, successfrac, avgeval)
+	}
+}
+
 func patternsolver(fn bench.Func, db *sql.DB) (*pattern.Method, optim.Mesh) {
 	low, up := fn.Bounds()
 	max, min := up[0], low[0]
@@ -203,6 +265,80 @@ func patternsolver(fn bench.Func, db *sql.DB) (*pattern.Method, optim.Mesh) {
 	return pattern.New(p, pattern.DB(db)), mesh
 }
 
+func TestBenchMixed(t *testing.T) {
+	maxeval := 5000
+	// the larger mixed problems are only logged as a baseline for
+	// discrete-capable solvers.
+	wantsuccess := map[string]float64{
+		"IntRosenbrock_2D":      0.95,
+		"IntRosenbrock_5D":      0.5,
+		"MixedRastrigin_4D_2I":  0.6,
+		"MixedRastrigin_10D_5I": 0,
+		"Knapsack_15D":          0,
+	}
+	for _, fn := range bench.Mixed {
+		low, up := fn.Bounds()
+		sfn := func() *optim.Solver {
+			s, _, _, _ := auto.Solver(&auto.Problem{Obj: optim.Func(fn.Eval), Low: low, Up: up, Int: fn.Int(), MaxEval: maxeval})
+			return s
+		}
+		bench.Benchmark(t, fn, sfn, wantsuccess[fn.Name()], float64(maxeval)+100)
+	}
+}
+
+func TestBenchScaling(t *testing.T) {
+	dims := bench.ScaleDims[:3]
+	fn := func(ndim int) bench.Func { return bench.Rastrigin{NDim: ndim} }
+	sfn := func(fn bench.Func) *optim.Solver {
+		low, up := fn.Bounds()
+		s, _ := pswarm.NewSolver(optim.Func(fn.Eval), low, up, pswarm.MaxEval(1000*len(low)))
+		return s
+	}
+	results, err := bench.Scaling(fn, dims, sfn, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := bench.WriteScaling(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%s", buf.String())
+	for _, r := range results {
+		if r.Successes == 0 {
+			t.Errorf("[%v] no runs reached the tolerance", r.Name)
+		}
+	}
+}
+
+func TestBenchFrontZDT1(t *testing.T) {
+	fn := bench.ZDT1{NDim: 5}
+	low, up := fn.Bounds()
+	run := func() ([]multi.Solution, error) {
+		e := &multi.EpsConstraint{
+			Obj:     fn,
+			NObj:    2,
+			NLevels: 10,
+			NewSolver: func(obj optim.Objectiver) *optim.Solver {
+				s, _ := pswarm.NewSolver(obj, low, up, pswarm.MaxEval(3000))
+				return s
+			},
+		}
+		return e.Run()
+	}
+	bench.BenchmarkFront(t, fn, run, 0.95, 0.03)
+}
+
+func TestBenchFrontZDT1SMPSO(t *testing.T) {
+	fn := bench.ZDT1{NDim: 10}
+	low, up := fn.Bounds()
+	run := func() ([]multi.Solution, error) {
+		s := &multi.SMPSO{Obj: fn, Low: low, Up: up, MaxEval: 20000}
+		return s.Run()
+	}
+	bench.BenchmarkFront(t, fn, run, 0.98, 0.01)
+}
+
 func swarmsolver(fn bench.Func, db *sql.DB, n int) optim.Method {
 	low, up := fn.Bounds()
 
@@ -242,3 +378,207 @@ func initialpoint(fn bench.Func) *optim.Point {
 	}
 	return &optim.Point{pos, math.Inf(1)}
 }
+
+func TestBBOB(t *testing.T) {
+	// optimal values of the first instance as published by COCO
+	fopts := map[int]float64{1: 79.48, 2: -209.88, 3: -462.09, 4: -462.09, 15: 1000, 22: -1000, 24: 102.61}
+
+	rng := rand.New(rand.NewSource(1))
+	for _, ndim := range []int{2, 5, 10} {
+		for _, f := range bench.BBOBSuite(ndim, 1) {
+			fn := f.(*bench.BBOB)
+			opt := fn.Optima()[0]
+			if want, ok := fopts[fn.Fn]; ok && opt.Val != want {
+				t.Errorf("%v: optimal value %v, want %v", fn.Name(), opt.Val, want)
+			}
+			if got := fn.Eval(opt.Pos); math.Abs(got-opt.Val) > 1e-6 {
+				t.Errorf("%v: value at optimum %v, want %v", fn.Name(), got, opt.Val)
+			}
+			for i := 0; i < 100; i++ {
+				x := make([]float64, ndim)
+				for j := range x {
+					x[j] = rng.Float64()*10 - 5
+				}
+				if v := fn.Eval(x); v < opt.Val {
+					t.Errorf("%v: value %v at %v is below the optimum %v", fn.Name(), v, x, opt.Val)
+				}
+			}
+		}
+	}
+}
+
+func TestMonteCarlo(t *testing.T) {
+	optim.Rand = &optim.LockedRng{Rng: rand.New(rand.NewSource(1))}
+	for _, sim := range bench.Simulators {
+		opt := sim.Optima()[0]
+		if got := sim.Eval(opt.Pos); math.Abs(got-opt.Val) > 1e-9 {
+			t.Errorf("%v: expected value at optimum %v, want %v", sim.Name(), got, opt.Val)
+		}
+
+		mc := &bench.MonteCarlo{Sim: sim, Levels: []int{1, 16}}
+		const n = 400
+		var means, vars [2]float64
+		for level := range means {
+			vals := make([]float64, n)
+			for i := range vals {
+				vals[i], _ = mc.FidelityObjective(opt.Pos, level)
+				means[level] += vals[i] / n
+			}
+			for _, v := range vals {
+				vars[level] += (v - means[level]) * (v - means[level]) / (n - 1)
+			}
+		}
+		if mc.Samples() != n*17 {
+			t.Errorf("%v: counted %v samples, want %v", sim.Name(), mc.Samples(), n*17)
+		}
+		if ratio := vars[0] / vars[1]; ratio < 10 || ratio > 25 {
+			t.Errorf("%v: 16 replications reduced variance by a factor of %v, want about 16", sim.Name(), ratio)
+		}
+		if se := math.Sqrt(vars[1] / n); math.Abs(means[1]-opt.Val) > 4*se {
+			t.Errorf("%v: estimated mean %v, want %v +/- %v", sim.Name(), means[1], opt.Val, 4*se)
+		}
+
+		a, _ := mc.SeededObjective(opt.Pos, 42)
+		b, _ := mc.SeededObjective(opt.Pos, 42)
+		if a != b {
+			t.Errorf("%v: seeded evaluations differ: %v != %v", sim.Name(), a, b)
+		}
+	}
+}
+
+func TestGradients(t *testing.T) {
+	optim.Rand = &optim.LockedRng{Rng: rand.New(rand.NewSource(1))}
+	for _, fn := range bench.Differentiable {
+		low, up := fn.Bounds()
+		for i := range low {
+			low[i], up[i] = .9*low[i], .9*up[i]
+		}
+		checks, err := optim.CheckGradient(fn, low, up, 20, 1e-6)
+		if err != nil {
+			t.Fatalf("%v: %v", fn.Name(), err)
+		}
+		for _, c := range checks {
+			if _, e := c.Worst(); e > 1e-5 {
+				t.Errorf("%v: %v", fn.Name(), c)
+			}
+		}
+		grad, _ := fn.Gradient(fn.Optima()[0].Pos)
+		for _, g := range grad {
+			if math.Abs(g) > 1e-9 {
+				t.Errorf("%v: nonzero gradient %v at optimum", fn.Name(), grad)
+				break
+			}
+		}
+	}
+}
+
+func TestBenchNonSmooth(t *testing.T) {
+	maxeval := 5000
+	// AbsRidge and the 20D problems are only logged as a baseline.
+	wantsuccess := map[string]float64{
+		"Step_5D":      0.95,
+		"Piecewise_5D": 0.95,
+	}
+	for _, fn := range bench.NonSmooth {
+		low, up := fn.Bounds()
+		sfn := func() *optim.Solver {
+			s, _ := pswarm.NewSolver(optim.Func(fn.Eval), low, up, pswarm.MaxEval(maxeval), pswarm.WithEvaler(optim.SerialEvaler{}))
+			return s
+		}
+		bench.Benchmark(t, fn, sfn, wantsuccess[fn.Name()], float64(maxeval)+100)
+	}
+}
+
+func TestBenchPlateau(t *testing.T) {
+	maxeval := 5000
+	// single runs stall on the flat part of Needle_5D - it is only logged
+	// as a baseline for restart strategies.
+	wantsuccess := map[string]float64{
+		"Easom":     0.9,
+		"Easom_4D":  0.95,
+		"Easom_10D": 0.95,
+		"Needle_2D": 0.95,
+	}
+	for _, fn := range bench.Plateau {
+		low, up := fn.Bounds()
+		sfn := func() *optim.Solver {
+			s, _ := pswarm.NewSolver(optim.Func(fn.Eval), low, up, pswarm.MaxEval(maxeval), pswarm.WithEvaler(optim.SerialEvaler{}))
+			return s
+		}
+		bench.Benchmark(t, fn, sfn, wantsuccess[fn.Name()], float64(maxeval)+100)
+	}
+}
+
+func regressSample(name string, neval, nsuccess int) *bench.Sample {
+	s := &bench.Sample{Name: name}
+	for i := 0; i < 30; i++ {
+		r := bench.Run{Neval: neval + 10*i, Best: 0, Success: i < nsuccess}
+		if !r.Success {
+			r.Best = 1
+		}
+		s.Runs = append(s.Runs, r)
+	}
+	return s
+}
+
+func TestRegression(t *testing.T) {
+	base := regressSample("fn", 1000, 28)
+	tests := []struct {
+		cur     *bench.Sample
+		regress bool
+	}{
+		{regressSample("fn", 1000, 28), false},
+		{regressSample("fn", 1020, 27), false},
+		{regressSample("fn", 900, 30), false},
+		{regressSample("fn", 2000, 28), true},
+		{regressSample("fn", 1000, 8), true},
+	}
+	for i, test := range tests {
+		r := bench.Compare(base, test.cur, bench.DefaultThresholds)
+		if got := r != nil; got != test.regress {
+			t.Errorf("case %v: got regression %v, want %v (%v)", i, got, test.regress, r)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := bench.WriteBaseline(&buf, base, regressSample("other", 50, 30)); err != nil {
+		t.Fatal(err)
+	}
+	samples, err := bench.ReadBaseline(&buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(samples) != 2 || samples[0].Name != "fn" || len(samples[0].Runs) != 30 {
+		t.Fatalf("read back wrong baseline: %v samples", len(samples))
+	} else if samples[0].Runs[29] != base.Runs[29] {
+		t.Errorf("read back run %v, want %v", samples[0].Runs[29], base.Runs[29])
+	}
+	if err := bench.CompareAll(samples, []*bench.Sample{regressSample("fn", 2000, 28), regressSample("new", 1, 0)}, bench.DefaultThresholds); err == nil {
+		t.Errorf("CompareAll missed regression")
+	}
+}
+
+func TestCollect(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 2}
+	sfn := func() *optim.Solver {
+		m, mesh := patternsolver(fn, nil)
+		return &optim.Solver{
+			Method:  m,
+			Obj:     optim.Func(fn.Eval),
+			Mesh:    mesh,
+			MaxEval: 5000,
+		}
+	}
+	a, err := bench.Collect(fn, sfn, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := bench.Collect(fn, sfn, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Runs) != 10 {
+		t.Fatalf("got %v runs, want 10", len(a.Runs))
+	} else if r := bench.Compare(a, b, bench.DefaultThresholds); r != nil {
+		t.Errorf("identical reseeded samples flagged: %v", r)
+	}
+}
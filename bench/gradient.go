@@ -0,0 +1,73 @@
+package bench
+
+import (
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// GradFunc is a differentiable benchmark function.  Its Objective method
+// evaluates the function like Eval and Gradient returns the analytic
+// gradient, so it can be passed directly to gradient-based and hybrid
+// solvers.  Gradients are computed from the functions' formulas and so are
+// also defined outside the bounds, where Eval returns +Inf.
+type GradFunc interface {
+	Func
+	optim.Gradienter
+}
+
+// Differentiable is a set of benchmark functions with analytic gradients.
+var Differentiable = []GradFunc{
+	Sphere{NDim: 10},
+	Ackley{},
+	Rosenbrock{NDim: 2},
+	Rosenbrock{NDim: 10},
+	Rastrigin{NDim: 2},
+	Rastrigin{NDim: 10},
+}
+
+func (fn Sphere) Objective(v []float64) (float64, error) { return fn.Eval(v), nil }
+
+func (fn Sphere) Gradient(x []float64) ([]float64, error) {
+	grad := make([]float64, len(x))
+	for i, v := range x {
+		grad[i] = 2 * v
+	}
+	return grad, nil
+}
+
+func (fn Ackley) Objective(v []float64) (float64, error) { return fn.Eval(v), nil }
+
+func (fn Ackley) Gradient(v []float64) ([]float64, error) {
+	x, y := v[0], v[1]
+	r := math.Sqrt(0.5 * (x*x + y*y))
+	e := math.Exp(0.5 * (math.Cos(2*math.Pi*x) + math.Cos(2*math.Pi*y)))
+	grad := []float64{math.Pi * math.Sin(2*math.Pi*x) * e, math.Pi * math.Sin(2*math.Pi*y) * e}
+	if r > 0 {
+		grad[0] += 2 * x * math.Exp(-0.2*r) / r
+		grad[1] += 2 * y * math.Exp(-0.2*r) / r
+	}
+	return grad, nil
+}
+
+func (fn Rastrigin) Objective(v []float64) (float64, error) { return fn.Eval(v), nil }
+
+func (fn Rastrigin) Gradient(x []float64) ([]float64, error) {
+	grad := make([]float64, len(x))
+	for i, v := range x {
+		grad[i] = 2*v + 20*math.Pi*math.Sin(2*math.Pi*v)
+	}
+	return grad, nil
+}
+
+func (fn Rosenbrock) Objective(v []float64) (float64, error) { return fn.Eval(v), nil }
+
+func (fn Rosenbrock) Gradient(x []float64) ([]float64, error) {
+	grad := make([]float64, len(x))
+	for i := 0; i < len(x)-1; i++ {
+		diff := x[i+1] - x[i]*x[i]
+		grad[i] += -400*x[i]*diff + 2*(x[i]-1)
+		grad[i+1] += 200 * diff
+	}
+	return grad, nil
+}
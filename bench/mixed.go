@@ -0,0 +1,154 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// IntFunc is a Func with integer-valued dimensions.  Eval returns positive
+// infinity for positions with non-integer values in those dimensions, so
+// solvers must search on an integer mesh (e.g. optim.IntMesh) or otherwise
+// round their points.
+type IntFunc interface {
+	Func
+	// Int returns the indices of the integer-valued dimensions.
+	Int() []int
+}
+
+// Mixed is a set of integer and mixed-integer benchmark problems.
+var Mixed = []IntFunc{
+	IntRosenbrock{NDim: 2},
+	IntRosenbrock{NDim: 5},
+	MixedRastrigin{NDim: 4, NInt: 2},
+	MixedRastrigin{NDim: 10, NInt: 5},
+	Knapsack{},
+}
+
+// IntRosenbrock is the Rosenbrock function restricted to integer positions.
+type IntRosenbrock struct {
+	NDim int
+}
+
+func (fn IntRosenbrock) Name() string { return fmt.Sprintf("IntRosenbrock_%vD", fn.NDim) }
+
+// Tol is below the smallest non-optimal value at integer positions.
+func (fn IntRosenbrock) Tol() float64 { return .5 }
+
+func (fn IntRosenbrock) Eval(x []float64) float64 {
+	if !integral(x, fn.Int()) {
+		return math.Inf(1)
+	}
+	return Rosenbrock{fn.NDim}.Eval(x)
+}
+
+func (fn IntRosenbrock) Bounds() (low, up []float64) { return Rosenbrock{fn.NDim}.Bounds() }
+func (fn IntRosenbrock) Optima() []*optim.Point      { return Rosenbrock{fn.NDim}.Optima() }
+func (fn IntRosenbrock) Int() []int                  { return seq(fn.NDim) }
+
+// MixedRastrigin is the Rastrigin function with its first NInt dimensions
+// restricted to integer values.
+type MixedRastrigin struct {
+	NDim int
+	NInt int
+}
+
+func (fn MixedRastrigin) Name() string {
+	return fmt.Sprintf("MixedRastrigin_%vD_%vI", fn.NDim, fn.NInt)
+}
+
+// Tol is below the value of every local minimum but the global one.
+func (fn MixedRastrigin) Tol() float64 { return .5 }
+
+func (fn MixedRastrigin) Eval(x []float64) float64 {
+	if !integral(x, fn.Int()) {
+		return math.Inf(1)
+	}
+	return Rastrigin{fn.NDim}.Eval(x)
+}
+
+func (fn MixedRastrigin) Bounds() (low, up []float64) {
+	low, up = Rastrigin{fn.NDim}.Bounds()
+	for i := 0; i < fn.NInt; i++ {
+		low[i], up[i] = -5, 5
+	}
+	return low, up
+}
+
+func (fn MixedRastrigin) Optima() []*optim.Point { return Rastrigin{fn.NDim}.Optima() }
+func (fn MixedRastrigin) Int() []int             { return seq(fn.NInt) }
+
+// Knapsack is a toy 0-1 knapsack problem: each dimension is 1 if an item is
+// packed and 0 otherwise.  The objective is the negated total value of the
+// packed items plus a penalty proportional to the weight exceeding the
+// capacity.
+type Knapsack struct{}
+
+var (
+	knapWeights = []float64{23, 31, 29, 44, 53, 38, 63, 85, 89, 82, 12, 17, 41, 27, 35}
+	knapValues  = []float64{92, 57, 49, 68, 60, 43, 67, 84, 87, 72, 22, 31, 51, 40, 44}
+	knapCap     = 250.0
+
+	knapOnce sync.Once
+	knapBest *optim.Point
+)
+
+func (fn Knapsack) Name() string { return fmt.Sprintf("Knapsack_%vD", len(knapWeights)) }
+
+// Tol is below the value of every suboptimal packing.
+func (fn Knapsack) Tol() float64 { return fn.Optima()[0].Val + .5 }
+
+func (fn Knapsack) Eval(x []float64) float64 {
+	if !InsideBounds(x, fn) || !integral(x, fn.Int()) {
+		return math.Inf(1)
+	}
+	value, weight := 0.0, 0.0
+	for i, take := range x {
+		value += take * knapValues[i]
+		weight += take * knapWeights[i]
+	}
+	return -value + 10*math.Max(0, weight-knapCap)
+}
+
+func (fn Knapsack) Bounds() (low, up []float64) {
+	return make([]float64, len(knapWeights)), ones(len(knapWeights))
+}
+
+// Optima returns the optimal packing found (once) by exhaustive search.
+func (fn Knapsack) Optima() []*optim.Point {
+	knapOnce.Do(func() {
+		knapBest = &optim.Point{Val: math.Inf(1)}
+		x := make([]float64, len(knapWeights))
+		for set := 0; set < 1<<uint(len(x)); set++ {
+			for i := range x {
+				x[i] = float64(set >> uint(i) & 1)
+			}
+			if v := fn.Eval(x); v < knapBest.Val {
+				knapBest = &optim.Point{Pos: append([]float64{}, x...), Val: v}
+			}
+		}
+	})
+	return []*optim.Point{knapBest.Clone()}
+}
+
+func (fn Knapsack) Int() []int { return seq(len(knapWeights)) }
+
+// integral reports whether x has integer values in dimensions dims.
+func integral(x []float64, dims []int) bool {
+	for _, i := range dims {
+		if x[i] != math.Floor(x[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func seq(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i
+	}
+	return s
+}
@@ -0,0 +1,165 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/multi"
+)
+
+// MultiFunc is a multi-objective benchmark problem with a known Pareto
+// front.
+type MultiFunc interface {
+	multi.Objectiver
+	Name() string
+	Bounds() (low, up []float64)
+	// Front returns n points sampling the true Pareto front.
+	Front(n int) [][]float64
+	// Ref returns the reference point used for hypervolume computations.
+	Ref() []float64
+}
+
+// ZDT1 is the convex two-objective problem from:
+//
+//	Zitzler, Eckart, Kalyanmoy Deb, and Lothar Thiele. "Comparison of
+//	multiobjective evolutionary algorithms: Empirical results."
+//	Evolutionary Computation 8.2 (2000): 173-195.
+type ZDT1 struct {
+	NDim int
+}
+
+func (fn ZDT1) Name() string { return fmt.Sprintf("ZDT1_%vD", fn.NDim) }
+
+func (fn ZDT1) Objectives(x []float64) ([]float64, error) {
+	g := 0.0
+	for _, v := range x[1:] {
+		g += v
+	}
+	g = 1 + 9*g/float64(len(x)-1)
+	return []float64{x[0], g * (1 - sqrt(x[0]/g))}, nil
+}
+
+func (fn ZDT1) Bounds() (low, up []float64) {
+	return make([]float64, fn.NDim), ones(fn.NDim)
+}
+
+func (fn ZDT1) Front(n int) [][]float64 {
+	front := make([][]float64, n)
+	for i := range front {
+		f1 := float64(i) / float64(n-1)
+		front[i] = []float64{f1, 1 - sqrt(f1)}
+	}
+	return front
+}
+
+func (fn ZDT1) Ref() []float64 { return []float64{1.1, 1.1} }
+
+// DTLZ2 is the scalable spherical-front problem from:
+//
+//	Deb, Kalyanmoy, et al. "Scalable test problems for evolutionary
+//	multiobjective optimization." Evolutionary Multiobjective Optimization.
+//	Springer London, 2005. 105-145.
+type DTLZ2 struct {
+	NObj int
+	NDim int
+}
+
+func (fn DTLZ2) Name() string { return fmt.Sprintf("DTLZ2_%vM_%vD", fn.NObj, fn.NDim) }
+
+func (fn DTLZ2) Objectives(x []float64) ([]float64, error) {
+	g := 0.0
+	for _, v := range x[fn.NObj-1:] {
+		g += (v - .5) * (v - .5)
+	}
+	f := make([]float64, fn.NObj)
+	for i := range f {
+		f[i] = 1 + g
+		for _, v := range x[:fn.NObj-1-i] {
+			f[i] *= cos(v * math.Pi / 2)
+		}
+		if i > 0 {
+			f[i] *= sin(x[fn.NObj-1-i] * math.Pi / 2)
+		}
+	}
+	return f, nil
+}
+
+func (fn DTLZ2) Bounds() (low, up []float64) {
+	return make([]float64, fn.NDim), ones(fn.NDim)
+}
+
+// Front returns points sampled on the unit sphere's positive orthant from
+// a regular grid of angles - len(points) may be somewhat less than n.
+func (fn DTLZ2) Front(n int) [][]float64 {
+	per := int(math.Pow(float64(n), 1/float64(fn.NObj-1)))
+	if per < 2 {
+		per = 2
+	}
+	x := make([]float64, fn.NDim)
+	for i := range x {
+		x[i] = .5
+	}
+	var front [][]float64
+	var grid func(d int)
+	grid = func(d int) {
+		if d == fn.NObj-1 {
+			f, _ := fn.Objectives(x)
+			front = append(front, f)
+			return
+		}
+		for i := 0; i < per; i++ {
+			x[d] = float64(i) / float64(per-1)
+			grid(d + 1)
+		}
+	}
+	grid(0)
+	return front
+}
+
+func (fn DTLZ2) Ref() []float64 {
+	ref := make([]float64, fn.NObj)
+	for i := range ref {
+		ref[i] = 1.1
+	}
+	return ref
+}
+
+func ones(n int) []float64 {
+	v := make([]float64, n)
+	for i := range v {
+		v[i] = 1
+	}
+	return v
+}
+
+// BenchmarkFront performs a multi-objective optimization run using run and
+// tests that the resulting front's hypervolume (relative to that of fn's
+// true front) is at least minhv and its inverted generational distance
+// from fn's true front is at most maxigd.  optim.Rand is seeded with
+// BenchSeed before the run.  Results are logged to t.
+func BenchmarkFront(t *testing.T, fn MultiFunc, run func() ([]multi.Solution, error), minhv, maxigd float64) {
+	optim.Rand = &optim.LockedRng{Rng: rand.New(rand.NewSource(BenchSeed))}
+	sols, err := run()
+	if err != nil {
+		t.Errorf("[%v:ERROR] %v", fn.Name(), err)
+	}
+	front := make([][]float64, len(sols))
+	for i, s := range sols {
+		front[i] = s.Vals
+	}
+
+	truefront := fn.Front(200)
+	hv := multi.Hypervolume(front, fn.Ref()) / multi.Hypervolume(truefront, fn.Ref())
+	igd := multi.IGD(front, truefront)
+
+	t.Logf("[%v] %v points, relative hypervolume %.4f, IGD %.4f", fn.Name(), len(front), hv, igd)
+	if hv < minhv {
+		t.Errorf("    FAIL: relative hypervolume: want >= %v, got %.4f", minhv, hv)
+	}
+	if igd > maxigd {
+		t.Errorf("    FAIL: IGD: want <= %v, got %.4f", maxigd, igd)
+	}
+}
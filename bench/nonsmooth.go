@@ -0,0 +1,163 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// NonSmooth is a set of discontinuous and non-differentiable benchmark
+// functions for testing the robustness of direct search methods to
+// plateaus, kinks and jumps.
+var NonSmooth = []Func{
+	Step{NDim: 5},
+	Step{NDim: 20},
+	AbsRidge{NDim: 5},
+	AbsRidge{NDim: 20},
+	Piecewise{NDim: 5},
+	Piecewise{NDim: 20},
+}
+
+// Step is De Jong's step function: the sum of squares of the rounded
+// variables.  It consists of flat plateaus separated by jumps, so it
+// provides no local gradient information at all.  Every point in
+// [-0.5, 0.5)^NDim is optimal.
+type Step struct {
+	NDim int
+}
+
+func (fn Step) Name() string { return fmt.Sprintf("Step_%vD", fn.NDim) }
+
+// Tol returns a value below which only the optimal plateau lies.
+func (fn Step) Tol() float64 { return .5 }
+
+func (fn Step) Eval(x []float64) float64 {
+	if !InsideBounds(x, fn) {
+		return math.Inf(1)
+	}
+
+	tot := 0.0
+	for _, v := range x {
+		r := math.Floor(v + .5)
+		tot += r * r
+	}
+	return tot
+}
+
+func (fn Step) Bounds() (low, up []float64) {
+	low = make([]float64, fn.NDim)
+	up = make([]float64, fn.NDim)
+	for i := range low {
+		low[i] = -5.12
+		up[i] = 5.12
+	}
+	return low, up
+}
+
+func (fn Step) Optima() []*optim.Point {
+	return []*optim.Point{
+		&optim.Point{make([]float64, fn.NDim), 0},
+	}
+}
+
+// AbsRidge is a sharp V-shaped valley along the diagonal:
+//
+//	f(x) = |x_1| + 10 * sum_{i>1} |x_i - x_{i-1}|
+//
+// The function is non-differentiable along the valley floor, which is
+// not aligned with the coordinate axes, so coordinate-wise moves stall on
+// the kink while progress requires moving all variables together.  The
+// optimum is at the origin.
+type AbsRidge struct {
+	NDim int
+}
+
+func (fn AbsRidge) Name() string { return fmt.Sprintf("AbsRidge_%vD", fn.NDim) }
+
+func (fn AbsRidge) Tol() float64 { return .01 }
+
+func (fn AbsRidge) Eval(x []float64) float64 {
+	if !InsideBounds(x, fn) {
+		return math.Inf(1)
+	}
+
+	tot := math.Abs(x[0])
+	for i := 1; i < len(x); i++ {
+		tot += 10 * math.Abs(x[i]-x[i-1])
+	}
+	return tot
+}
+
+func (fn AbsRidge) Bounds() (low, up []float64) {
+	low = make([]float64, fn.NDim)
+	up = make([]float64, fn.NDim)
+	for i := range low {
+		low[i] = -5
+		up[i] = 5
+	}
+	return low, up
+}
+
+func (fn AbsRidge) Optima() []*optim.Point {
+	return []*optim.Point{
+		&optim.Point{make([]float64, fn.NDim), 0},
+	}
+}
+
+// Piecewise is a separable function whose terms are defined piecewise with
+// jump discontinuities between the pieces:
+//
+//	g(t) = (t+3)^2 + 2         t < -1
+//	       |t - 1|             -1 <= t < 2
+//	       0.5 + 0.1(t - 2)^2  t >= 2
+//
+// Each term has a kinked global minimum of 0 at t = 1, a deceptive plateau
+// just above t = 2 where the term jumps down to 0.5, and a smooth local
+// minimum of 2 at t = -3.
+type Piecewise struct {
+	NDim int
+}
+
+func (fn Piecewise) Name() string { return fmt.Sprintf("Piecewise_%vD", fn.NDim) }
+
+func (fn Piecewise) Tol() float64 { return .01 }
+
+func (fn Piecewise) Eval(x []float64) float64 {
+	if !InsideBounds(x, fn) {
+		return math.Inf(1)
+	}
+
+	tot := 0.0
+	for _, t := range x {
+		switch {
+		case t < -1:
+			tot += (t+3)*(t+3) + 2
+		case t < 2:
+			tot += math.Abs(t - 1)
+		default:
+			tot += .5 + .1*(t-2)*(t-2)
+		}
+	}
+	return tot
+}
+
+func (fn Piecewise) Bounds() (low, up []float64) {
+	low = make([]float64, fn.NDim)
+	up = make([]float64, fn.NDim)
+	for i := range low {
+		low[i] = -5
+		up[i] = 5
+	}
+	return low, up
+}
+
+func (fn Piecewise) Optima() []*optim.Point {
+	pos := make([]float64, fn.NDim)
+	for i := range pos {
+		pos[i] = 1
+	}
+	return []*optim.Point{
+		&optim.Point{pos, 0},
+	}
+}
@@ -0,0 +1,141 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Plateau is a set of benchmark functions that are flat over most of their
+// domain with a narrow optimal basin.  Solvers see no improvement for long
+// stretches, which exercises stall detection and restart strategies.
+var Plateau = []Func{
+	Easom{},
+	EasomND{NDim: 4},
+	EasomND{NDim: 10},
+	Needle{NDim: 2},
+	Needle{NDim: 5},
+}
+
+// Easom is the Easom function.  It is almost exactly zero everywhere except
+// for a small basin around the global minimum of -1 at (pi, pi).
+type Easom struct{}
+
+func (fn Easom) Name() string { return "Easom" }
+
+func (fn Easom) Tol() float64 { return -.99 }
+
+func (fn Easom) Eval(v []float64) float64 {
+	if !InsideBounds(v, fn) {
+		return math.Inf(1)
+	}
+
+	x, y := v[0], v[1]
+	return -math.Cos(x) * math.Cos(y) * math.Exp(-((x-math.Pi)*(x-math.Pi) + (y-math.Pi)*(y-math.Pi)))
+}
+
+func (fn Easom) Bounds() (low, up []float64) {
+	return []float64{-100, -100}, []float64{100, 100}
+}
+
+func (fn Easom) Optima() []*optim.Point {
+	return []*optim.Point{
+		&optim.Point{[]float64{math.Pi, math.Pi}, -1},
+	}
+}
+
+// EasomND is an n-dimensional variant of the Easom function:
+//
+//	f(x) = -prod_i cos^2(x_i) * exp(-sum_i (x_i - pi)^2)
+//
+// The basin's relative volume shrinks rapidly with the dimension, so the
+// optimum becomes a needle in a haystack.  The global minimum is -1 at
+// (pi, ..., pi).
+type EasomND struct {
+	NDim int
+}
+
+func (fn EasomND) Name() string { return fmt.Sprintf("Easom_%vD", fn.NDim) }
+
+func (fn EasomND) Tol() float64 { return -.99 }
+
+func (fn EasomND) Eval(x []float64) float64 {
+	if !InsideBounds(x, fn) {
+		return math.Inf(1)
+	}
+
+	prod, sum := 1.0, 0.0
+	for _, v := range x {
+		prod *= math.Cos(v) * math.Cos(v)
+		sum += (v - math.Pi) * (v - math.Pi)
+	}
+	return -prod * math.Exp(-sum)
+}
+
+func (fn EasomND) Bounds() (low, up []float64) {
+	low = make([]float64, fn.NDim)
+	up = make([]float64, fn.NDim)
+	for i := range low {
+		low[i] = -2 * math.Pi
+		up[i] = 2 * math.Pi
+	}
+	return low, up
+}
+
+func (fn EasomND) Optima() []*optim.Point {
+	pos := make([]float64, fn.NDim)
+	for i := range pos {
+		pos[i] = math.Pi
+	}
+	return []*optim.Point{
+		&optim.Point{pos, -1},
+	}
+}
+
+// Needle is exactly flat (zero) everywhere except inside a cone of radius
+// NeedleRadius around (2.5, ..., 2.5), where it decreases linearly to the
+// global minimum of -1.  Unlike the Easom functions there is no gradient
+// information at all outside the cone.
+type Needle struct {
+	NDim int
+}
+
+// NeedleRadius is the radius of the Needle function's optimal cone.
+const NeedleRadius = 1.0
+
+func (fn Needle) Name() string { return fmt.Sprintf("Needle_%vD", fn.NDim) }
+
+func (fn Needle) Tol() float64 { return -.99 }
+
+func (fn Needle) Eval(x []float64) float64 {
+	if !InsideBounds(x, fn) {
+		return math.Inf(1)
+	}
+
+	dist := 0.0
+	for _, v := range x {
+		dist += (v - 2.5) * (v - 2.5)
+	}
+	return math.Min(0, math.Sqrt(dist)/NeedleRadius-1)
+}
+
+func (fn Needle) Bounds() (low, up []float64) {
+	low = make([]float64, fn.NDim)
+	up = make([]float64, fn.NDim)
+	for i := range low {
+		low[i] = -5
+		up[i] = 5
+	}
+	return low, up
+}
+
+func (fn Needle) Optima() []*optim.Point {
+	pos := make([]float64, fn.NDim)
+	for i := range pos {
+		pos[i] = 2.5
+	}
+	return []*optim.Point{
+		&optim.Point{pos, -1},
+	}
+}
@@ -0,0 +1,352 @@
+package bench
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Run is the outcome of a single benchmark optimization run.
+type Run struct {
+	Neval int
+	Best  float64
+	// Success is true if the run reached the function's tolerance.
+	Success bool
+}
+
+// Sample is a set of repeated runs of a solver on a single function.
+// Samples stored in baseline files (see WriteBaseline) can be compared to
+// fresh ones with Compare to detect performance regressions.
+type Sample struct {
+	Name string
+	Runs []Run
+}
+
+// Collect performs nrun optimization runs of fn using sfn to set up a
+// solver for each, stopping each run once it reaches fn's tolerance.
+// optim.Rand is seeded with BenchSeed before the runs so samples are
+// reproducible.  Solver errors are returned with the runs gathered so far.
+func Collect(fn Func, sfn func() *optim.Solver, nrun int) (*Sample, error) {
+	optim.Rand = &optim.LockedRng{Rng: rand.New(rand.NewSource(BenchSeed))}
+	smp := &Sample{Name: fn.Name()}
+	for i := 0; i < nrun; i++ {
+		s := sfn()
+		for s.Next() {
+			if s.Best().Val < fn.Tol() {
+				break
+			}
+		}
+		if err := s.Err(); err != nil {
+			return smp, fmt.Errorf("bench: %v run %v: %v", fn.Name(), i, err)
+		}
+		best := s.Best().Val
+		smp.Runs = append(smp.Runs, Run{Neval: s.Neval(), Best: best, Success: best < fn.Tol()})
+	}
+	return smp, nil
+}
+
+// SuccessRate returns the fraction of runs that reached the tolerance.
+func (s *Sample) SuccessRate() float64 {
+	n := 0
+	for _, r := range s.Runs {
+		if r.Success {
+			n++
+		}
+	}
+	return float64(n) / float64(len(s.Runs))
+}
+
+// MedianEvals returns the median number of evaluations successful runs
+// needed to reach the tolerance or NaN if no run succeeded.
+func (s *Sample) MedianEvals() float64 {
+	var evals []float64
+	for _, r := range s.Runs {
+		if r.Success {
+			evals = append(evals, float64(r.Neval))
+		}
+	}
+	return medianOf(evals)
+}
+
+// MedianBest returns the median best value found over all runs.
+func (s *Sample) MedianBest() float64 {
+	vals := make([]float64, len(s.Runs))
+	for i, r := range s.Runs {
+		vals[i] = r.Best
+	}
+	return medianOf(vals)
+}
+
+func medianOf(vals []float64) float64 {
+	if len(vals) == 0 {
+		return math.NaN()
+	}
+	sort.Float64s(vals)
+	n := len(vals)
+	if n%2 == 1 {
+		return vals[n/2]
+	}
+	return (vals[n/2-1] + vals[n/2]) / 2
+}
+
+// WriteBaseline writes samples to w as comma separated
+// "name,run,neval,best,success" records with a header line.
+func WriteBaseline(w io.Writer, samples ...*Sample) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"name", "run", "neval", "best", "success"})
+	for _, s := range samples {
+		for i, r := range s.Runs {
+			cw.Write([]string{
+				s.Name,
+				strconv.Itoa(i),
+				strconv.Itoa(r.Neval),
+				strconv.FormatFloat(r.Best, 'g', -1, 64),
+				strconv.FormatBool(r.Success),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadBaseline reads samples written by WriteBaseline from r.  Samples are
+// returned in order of first appearance.
+func ReadBaseline(r io.Reader) ([]*Sample, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 5
+
+	var samples []*Sample
+	index := map[string]*Sample{}
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		} else if line == 1 && rec[0] == "name" {
+			continue
+		}
+		neval, err1 := strconv.Atoi(rec[2])
+		best, err2 := strconv.ParseFloat(rec[3], 64)
+		success, err3 := strconv.ParseBool(rec[4])
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("bench: baseline line %v: invalid record %v", line, rec)
+		}
+		s, ok := index[rec[0]]
+		if !ok {
+			s = &Sample{Name: rec[0]}
+			index[rec[0]] = s
+			samples = append(samples, s)
+		}
+		s.Runs = append(s.Runs, Run{Neval: neval, Best: best, Success: success})
+	}
+	return samples, nil
+}
+
+// Thresholds determine how much worse a sample must be than its baseline
+// to count as a regression.
+type Thresholds struct {
+	// Alpha is the significance level of the one-sided rank-sum test that
+	// the current runs perform worse than the baseline runs.
+	Alpha float64
+	// MaxSuccessDrop is the largest tolerated drop in success rate.
+	MaxSuccessDrop float64
+	// MaxSlowdown is the largest tolerated relative increase in the median
+	// evaluations successful runs need (e.g. 0.1 for 10%).
+	MaxSlowdown float64
+}
+
+// DefaultThresholds are reasonable regression thresholds for samples of a
+// few dozen runs.
+var DefaultThresholds = Thresholds{Alpha: .01, MaxSuccessDrop: .1, MaxSlowdown: .1}
+
+// Regression describes a sample that performed significantly worse than
+// its baseline.
+type Regression struct {
+	Name string
+	// P is the rank-sum test p-value.
+	P                       float64
+	BaseSuccess, CurSuccess float64
+	BaseEvals, CurEvals     float64
+	BaseBest, CurBest       float64
+}
+
+func (r *Regression) Error() string {
+	return fmt.Sprintf("bench: %v regressed (p=%.3g): success rate %.2f -> %.2f, median evals %v -> %v, median best %.4g -> %.4g",
+		r.Name, r.P, r.BaseSuccess, r.CurSuccess, r.BaseEvals, r.CurEvals, r.BaseBest, r.CurBest)
+}
+
+// Compare tests whether cur performed worse than the baseline base and
+// returns a *Regression describing it if so (or nil otherwise).  Runs are
+// ranked with successful runs ordered by evaluations ahead of failed runs
+// ordered by best value, and a one-sided Mann-Whitney rank-sum test checks
+// whether cur's runs rank worse than base's.  A significant result only
+// counts as a regression if the success rate dropped by more than
+// th.MaxSuccessDrop, the median evaluations grew by more than
+// th.MaxSlowdown, or - when neither sample had successful runs - the
+// median best value got worse.
+func Compare(base, cur *Sample, th Thresholds) *Regression {
+	r := &Regression{
+		Name:        cur.Name,
+		P:           rankSumWorse(base.Runs, cur.Runs),
+		BaseSuccess: base.SuccessRate(),
+		CurSuccess:  cur.SuccessRate(),
+		BaseEvals:   base.MedianEvals(),
+		CurEvals:    cur.MedianEvals(),
+		BaseBest:    base.MedianBest(),
+		CurBest:     cur.MedianBest(),
+	}
+	if r.P >= th.Alpha {
+		return nil
+	}
+
+	switch {
+	case r.BaseSuccess-r.CurSuccess > th.MaxSuccessDrop:
+		return r
+	case r.CurEvals > r.BaseEvals*(1+th.MaxSlowdown):
+		return r
+	case r.BaseSuccess == 0 && r.CurSuccess == 0 && r.CurBest > r.BaseBest:
+		return r
+	}
+	return nil
+}
+
+// CompareAll compares each of samples to the baseline sample with the same
+// name and returns an error listing every regression found.  Samples with
+// no baseline are ignored.
+func CompareAll(base, samples []*Sample, th Thresholds) error {
+	index := map[string]*Sample{}
+	for _, s := range base {
+		index[s.Name] = s
+	}
+	var msgs []string
+	for _, s := range samples {
+		if b, ok := index[s.Name]; ok {
+			if r := Compare(b, s, th); r != nil {
+				msgs = append(msgs, r.Error())
+			}
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "\n"))
+	}
+	return nil
+}
+
+// UpdateBaseline makes CheckBaseline (re)write baseline files instead of
+// comparing against them.
+var UpdateBaseline = false
+
+// CheckBaseline compares samples to those stored in the baseline file at
+// path with DefaultThresholds and reports regressions as errors on t.  If
+// UpdateBaseline is true or the file doesn't exist, samples are written to
+// path as the new baseline instead.
+func CheckBaseline(t *testing.T, path string, samples ...*Sample) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) || UpdateBaseline {
+		if err == nil {
+			f.Close()
+		}
+		if err := writeBaselineFile(path, samples); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("wrote baseline %v", path)
+		return
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	base, err := ReadBaseline(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range samples {
+		t.Logf("[%v] success rate %.2f, median evals %v, median best %.4g", s.Name, s.SuccessRate(), s.MedianEvals(), s.MedianBest())
+	}
+	if err := CompareAll(base, samples, DefaultThresholds); err != nil {
+		t.Error(err)
+	}
+}
+
+func writeBaselineFile(path string, samples []*Sample) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteBaseline(f, samples...); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runLess reports whether run a performed better than run b.
+func runLess(a, b Run) bool {
+	if a.Success != b.Success {
+		return a.Success
+	} else if a.Success {
+		return a.Neval < b.Neval
+	}
+	return a.Best < b.Best
+}
+
+// rankSumWorse returns the p-value of a one-sided Mann-Whitney rank-sum
+// test (normal approximation with tie and continuity corrections) of the
+// hypothesis that cur's runs rank worse than base's.
+func rankSumWorse(base, cur []Run) float64 {
+	n1, n2 := float64(len(base)), float64(len(cur))
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type entry struct {
+		run Run
+		cur bool
+	}
+	all := make([]entry, 0, len(base)+len(cur))
+	for _, r := range base {
+		all = append(all, entry{r, false})
+	}
+	for _, r := range cur {
+		all = append(all, entry{r, true})
+	}
+	sort.SliceStable(all, func(i, j int) bool { return runLess(all[i].run, all[j].run) })
+
+	rsum, ties := 0.0, 0.0
+	for i := 0; i < len(all); {
+		j := i + 1
+		for j < len(all) && !runLess(all[i].run, all[j].run) {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // average of ranks i+1..j
+		for k := i; k < j; k++ {
+			if all[k].cur {
+				rsum += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	n := n1 + n2
+	u := rsum - n2*(n2+1)/2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (u - mean - .5) / math.Sqrt(variance)
+	return .5 * math.Erfc(z/math.Sqrt2)
+}
@@ -0,0 +1,92 @@
+package bench
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// ScaleDims are the dimensions swept by default in scalability studies.
+var ScaleDims = []int{2, 5, 10, 20, 50, 100}
+
+// ScaleResult summarizes the runs performed at a single dimension of a
+// scalability study.
+type ScaleResult struct {
+	Name string
+	NDim int
+	Runs int
+	// Successes is the number of runs that reached the function's
+	// tolerance.
+	Successes int
+	// MeanEvals is the mean number of evaluations successful runs needed
+	// to reach the tolerance or NaN if no run succeeded.
+	MeanEvals float64
+	// MeanBest is the mean best value found over all runs.
+	MeanBest float64
+}
+
+// EvalsPerDim returns MeanEvals divided by the dimension.
+func (r ScaleResult) EvalsPerDim() float64 { return r.MeanEvals / float64(r.NDim) }
+
+// Scaling performs a scalability study: for each dimension in dims it
+// performs nrun runs of the function returned by fn using solvers from sfn,
+// stopping each run once it reaches the function's tolerance.  optim.Rand
+// is seeded with BenchSeed before the runs at each dimension so results
+// don't depend on which dimensions are swept.  Solver errors are returned
+// with the results gathered so far.
+func Scaling(fn func(ndim int) Func, dims []int, sfn func(fn Func) *optim.Solver, nrun int) ([]ScaleResult, error) {
+	var results []ScaleResult
+	for _, ndim := range dims {
+		optim.Rand = &optim.LockedRng{Rng: rand.New(rand.NewSource(BenchSeed))}
+		f := fn(ndim)
+		r := ScaleResult{Name: f.Name(), NDim: ndim, Runs: nrun}
+		evals := 0
+		for i := 0; i < nrun; i++ {
+			s := sfn(f)
+			for s.Next() {
+				if s.Best().Val < f.Tol() {
+					break
+				}
+			}
+			if err := s.Err(); err != nil {
+				return results, err
+			}
+			r.MeanBest += s.Best().Val / float64(nrun)
+			if s.Best().Val < f.Tol() {
+				r.Successes++
+				evals += s.Neval()
+			}
+		}
+		r.MeanEvals = math.NaN()
+		if r.Successes > 0 {
+			r.MeanEvals = float64(evals) / float64(r.Successes)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// WriteScaling writes scalability study results to w as comma separated
+// "name,ndim,runs,successes,meanevals,evalsperdim,meanbest" records with a
+// header line - suitable for plotting evaluations against dimension.
+func WriteScaling(w io.Writer, results []ScaleResult) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"name", "ndim", "runs", "successes", "meanevals", "evalsperdim", "meanbest"})
+	for _, r := range results {
+		cw.Write([]string{
+			r.Name,
+			strconv.Itoa(r.NDim),
+			strconv.Itoa(r.Runs),
+			strconv.Itoa(r.Successes),
+			strconv.FormatFloat(r.MeanEvals, 'g', -1, 64),
+			strconv.FormatFloat(r.EvalsPerDim(), 'g', -1, 64),
+			strconv.FormatFloat(r.MeanBest, 'g', -1, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,154 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Simulator is a stochastic benchmark problem.  Eval returns the expected
+// (noise-free) simulation output, which is used to judge the quality of
+// solutions, while Sample draws the output of a single random replication
+// using rng.
+type Simulator interface {
+	Func
+	Sample(v []float64, rng *rand.Rand) float64
+}
+
+// Simulators is a set of stochastic benchmark problems.
+var Simulators = []Simulator{
+	Noisy{Func: Rosenbrock{NDim: 2}, Sigma: 10},
+	Noisy{Func: Rastrigin{NDim: 5}, Sigma: 5},
+	Newsvendor{NDim: 5},
+}
+
+// MonteCarlo wraps a simulator in an explicit Monte Carlo estimator of its
+// expected output: each evaluation returns the mean of Reps replications
+// (default 1).  Besides optim.Objectiver, it implements
+// optim.SeededObjectiver so it can be used with common random numbers and
+// optim.MultiFidelityObjectiver with Levels holding the replication count of
+// each fidelity level, so resampling and multi-fidelity evaluators can be
+// tested under realistic noise vs cost tradeoffs.  Samples reports the
+// total number of replications performed.
+type MonteCarlo struct {
+	Sim    Simulator
+	Reps   int
+	Levels []int
+	// nsample is accessed atomically.
+	nsample int64
+}
+
+func (mc *MonteCarlo) Objective(v []float64) (float64, error) {
+	return mc.estimate(v, int64(optim.Rand.Intn(math.MaxInt32)), mc.Reps), nil
+}
+
+func (mc *MonteCarlo) SeededObjective(v []float64, seed int64) (float64, error) {
+	return mc.estimate(v, seed, mc.Reps), nil
+}
+
+// Fidelities returns the number of fidelity levels.  Without Levels there is
+// a single level using Reps replications.
+func (mc *MonteCarlo) Fidelities() int {
+	if len(mc.Levels) == 0 {
+		return 1
+	}
+	return len(mc.Levels)
+}
+
+func (mc *MonteCarlo) FidelityObjective(v []float64, level int) (float64, error) {
+	if level < 0 || level >= mc.Fidelities() {
+		return math.Inf(1), fmt.Errorf("bench: invalid fidelity level %v", level)
+	} else if len(mc.Levels) == 0 {
+		return mc.Objective(v)
+	}
+	return mc.estimate(v, int64(optim.Rand.Intn(math.MaxInt32)), mc.Levels[level]), nil
+}
+
+// Samples returns the total number of replications simulated so far.
+func (mc *MonteCarlo) Samples() int { return int(atomic.LoadInt64(&mc.nsample)) }
+
+func (mc *MonteCarlo) estimate(v []float64, seed int64, reps int) float64 {
+	if reps < 1 {
+		reps = 1
+	}
+	rng := rand.New(rand.NewSource(seed))
+	tot := 0.0
+	for i := 0; i < reps; i++ {
+		tot += mc.Sim.Sample(v, rng)
+	}
+	atomic.AddInt64(&mc.nsample, int64(reps))
+	return tot / float64(reps)
+}
+
+// Noisy turns a deterministic benchmark function into a simulator by adding
+// normally distributed noise with standard deviation Sigma to each
+// replication.
+type Noisy struct {
+	Func
+	Sigma float64
+}
+
+func (fn Noisy) Name() string { return fmt.Sprintf("Noisy%v_%v", fn.Func.Name(), fn.Sigma) }
+
+func (fn Noisy) Sample(v []float64, rng *rand.Rand) float64 {
+	return fn.Eval(v) + fn.Sigma*rng.NormFloat64()
+}
+
+// Newsvendor is a multi-product newsvendor problem: an order quantity is
+// chosen for each of NDim products with exponentially distributed demand
+// (product i has a mean demand of 10*(i+1)).  Units cost 1 and sell for 3;
+// unsold units are worthless.  The objective is the negative profit.  The
+// heavy-tailed demand makes single replications very noisy, but the
+// expected profit and optimal order quantities are known analytically.
+type Newsvendor struct {
+	NDim int
+}
+
+const (
+	newsCost  = 1.0
+	newsPrice = 3.0
+)
+
+func (fn Newsvendor) mean(i int) float64 { return 10 * float64(i+1) }
+
+func (fn Newsvendor) Name() string { return fmt.Sprintf("Newsvendor_%vD", fn.NDim) }
+
+func (fn Newsvendor) Sample(v []float64, rng *rand.Rand) float64 {
+	cost := 0.0
+	for i, x := range v {
+		demand := fn.mean(i) * rng.ExpFloat64()
+		cost += newsCost*x - newsPrice*math.Min(x, demand)
+	}
+	return cost
+}
+
+func (fn Newsvendor) Eval(v []float64) float64 {
+	cost := 0.0
+	for i, x := range v {
+		m := fn.mean(i)
+		cost += newsCost*x - newsPrice*m*(1-math.Exp(-x/m))
+	}
+	return cost
+}
+
+func (fn Newsvendor) Bounds() (low, up []float64) {
+	low, up = make([]float64, fn.NDim), make([]float64, fn.NDim)
+	for i := range up {
+		up[i] = 5 * fn.mean(i)
+	}
+	return low, up
+}
+
+func (fn Newsvendor) Optima() []*optim.Point {
+	pos := make([]float64, fn.NDim)
+	for i := range pos {
+		pos[i] = fn.mean(i) * math.Log(newsPrice/newsCost)
+	}
+	return []*optim.Point{{Pos: pos, Val: fn.Eval(pos)}}
+}
+
+// Tol returns the value within 1% of the optimal expected profit.
+func (fn Newsvendor) Tol() float64 { return 0.99 * fn.Optima()[0].Val }
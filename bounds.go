@@ -0,0 +1,121 @@
+package optim
+
+import (
+	"fmt"
+	"math"
+)
+
+// Bounds is a box constraint with lower and upper limits for each
+// dimension.
+type Bounds struct {
+	Low, Up []float64
+}
+
+// Check returns an error if the bounds have mismatched lengths or any
+// lower limit exceeds its upper limit.
+func (b Bounds) Check() error {
+	if len(b.Low) != len(b.Up) {
+		return fmt.Errorf("bounds have %v lower and %v upper limits", len(b.Low), len(b.Up))
+	}
+	for i := range b.Low {
+		if !(b.Low[i] <= b.Up[i]) {
+			return fmt.Errorf("bounds dimension %v: lower limit %v exceeds upper limit %v", i, b.Low[i], b.Up[i])
+		}
+	}
+	return nil
+}
+
+// Len returns the number of dimensions.
+func (b Bounds) Len() int { return len(b.Low) }
+
+// Widths returns the width of each dimension.
+func (b Bounds) Widths() []float64 {
+	w := make([]float64, len(b.Low))
+	for i := range w {
+		w[i] = b.Up[i] - b.Low[i]
+	}
+	return w
+}
+
+// Contains returns true if x lies inside the bounds (inclusive).
+func (b Bounds) Contains(x []float64) bool {
+	for i := range x {
+		if x[i] < b.Low[i] || x[i] > b.Up[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Clip returns a copy of x with each coordinate moved to the nearest value
+// inside the bounds.
+func (b Bounds) Clip(x []float64) []float64 { return b.ClipInto(nil, x) }
+
+// ClipInto is like Clip but writes the result into dst (which may be x)
+// reusing its storage if it has enough capacity.
+func (b Bounds) ClipInto(dst, x []float64) []float64 {
+	dst = resize(dst, len(x))
+	for i := range x {
+		dst[i] = math.Min(b.Up[i], math.Max(b.Low[i], x[i]))
+	}
+	return dst
+}
+
+// Reflect returns a copy of x with coordinates outside the bounds mirrored
+// back inside across the violated limits (repeatedly if they overshoot by
+// more than the width of the bounds).  Unlike Clip, this doesn't pile
+// points up on the boundary.
+func (b Bounds) Reflect(x []float64) []float64 {
+	r := make([]float64, len(x))
+	for i := range x {
+		w := b.Up[i] - b.Low[i]
+		if w == 0 {
+			r[i] = b.Low[i]
+			continue
+		}
+		// fold the coordinate into [0, 2w) then mirror the upper half
+		d := math.Mod(x[i]-b.Low[i], 2*w)
+		if d < 0 {
+			d += 2 * w
+		}
+		if d > w {
+			d = 2*w - d
+		}
+		r[i] = b.Low[i] + d
+	}
+	return r
+}
+
+// Sample returns a position drawn uniformly from inside the bounds using
+// RandFloat.
+func (b Bounds) Sample() []float64 { return b.SampleFrom(Rand) }
+
+// SampleFrom is like Sample but draws random numbers from r.
+func (b Bounds) SampleFrom(r Rng) []float64 {
+	x := make([]float64, len(b.Low))
+	for i := range x {
+		x[i] = b.Low[i] + r.Float64()*(b.Up[i]-b.Low[i])
+	}
+	return x
+}
+
+// ToUnit maps x from the bounds onto the unit hypercube.  Dimensions with
+// zero width map to zero.
+func (b Bounds) ToUnit(x []float64) []float64 {
+	u := make([]float64, len(x))
+	for i := range x {
+		if w := b.Up[i] - b.Low[i]; w != 0 {
+			u[i] = (x[i] - b.Low[i]) / w
+		}
+	}
+	return u
+}
+
+// FromUnit maps u from the unit hypercube onto the bounds.
+func (b Bounds) FromUnit(u []float64) []float64 {
+	x := make([]float64, len(u))
+	for i := range u {
+		x[i] = b.Low[i] + u[i]*(b.Up[i]-b.Low[i])
+	}
+	return x
+}
@@ -0,0 +1,45 @@
+package optim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBounds(t *testing.T) {
+	b := Bounds{Low: []float64{0, -1, 2}, Up: []float64{1, 1, 2}}
+	if err := b.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Bounds{Low: []float64{1}, Up: []float64{0}}).Check(); err == nil {
+		t.Errorf("want error for inverted bounds")
+	}
+
+	x := []float64{1.25, -4.5, 3}
+	checkslice(t, "clip", b.Clip(x), []float64{1, -1, 2})
+	// -4.5 mirrors across -1 to 2.5 then across 1 to -0.5
+	checkslice(t, "reflect", b.Reflect(x), []float64{0.75, -0.5, 2})
+	checkslice(t, "reflect inside", b.Reflect([]float64{0.5, 0, 2}), []float64{0.5, 0, 2})
+
+	if b.Contains(x) || !b.Contains(b.Clip(x)) {
+		t.Errorf("wrong containment")
+	}
+
+	u := b.ToUnit([]float64{0.5, 0, 2})
+	checkslice(t, "to unit", u, []float64{0.5, 0.5, 0})
+	checkslice(t, "from unit", b.FromUnit(u), []float64{0.5, 0, 2})
+
+	for i := 0; i < 100; i++ {
+		if s := b.Sample(); !b.Contains(s) {
+			t.Fatalf("sample %v outside bounds", s)
+		}
+	}
+}
+
+func checkslice(t *testing.T, name string, got, want []float64) {
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-12 {
+			t.Errorf("%v: want %v, got %v", name, want, got)
+			return
+		}
+	}
+}
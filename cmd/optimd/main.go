@@ -0,0 +1,25 @@
+// Command optimd serves the optimization job API implemented by package
+// service.  Clients create jobs and evaluate their objective remotely using
+// the ask/tell endpoints, e.g.:
+//
+//	curl -X POST -d '{"low":[-5,-5],"up":[5,5],"maxeval":1000}' localhost:7070/jobs
+//	curl 'localhost:7070/jobs/1/ask?n=4&wait=10s'
+//	curl -X POST -d '[{"id":1,"val":3.2}]' localhost:7070/jobs/1/tell
+//	curl localhost:7070/jobs/1/stream
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/rwcarlsen/optim/service"
+)
+
+var addr = flag.String("addr", ":7070", "address to listen on")
+
+func main() {
+	flag.Parse()
+	log.Printf("optimd listening on %v", *addr)
+	log.Fatal(http.ListenAndServe(*addr, service.NewServer()))
+}
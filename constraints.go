@@ -0,0 +1,106 @@
+package optim
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Constraints is a set of mixed linear and nonlinear constraints.  It
+// measures how much points violate the constraints so the same definition
+// can be shared by penalty objectives, feasibility rules, repair heuristics
+// and reporting.  Per-constraint violations are ordered with the linear
+// constraints (one per row of A) first followed by Ineq and then Eq.
+type Constraints struct {
+	// A, Low and Up define the linear constraints Low <= A*x <= Up.  Use
+	// infinite bounds for one-sided constraints.  A may be nil if there
+	// are no linear constraints.
+	A, Low, Up *mat64.Dense
+	// Ineq holds nonlinear inequality constraints g(x) <= 0.
+	Ineq []func(x []float64) float64
+	// Eq holds nonlinear equality constraints h(x) == 0.
+	Eq []func(x []float64) float64
+	// Tol is the total violation at or below which a point is considered
+	// feasible.  It is mostly useful for equality constraints.
+	Tol float64
+}
+
+// Len returns the number of individual constraints.
+func (c *Constraints) Len() int {
+	n := len(c.Ineq) + len(c.Eq)
+	if c.A != nil {
+		rows, _ := c.A.Dims()
+		n += rows
+	}
+	return n
+}
+
+// Violations returns the amount by which x violates each constraint - zero
+// for satisfied constraints.
+func (c *Constraints) Violations(x []float64) []float64 {
+	viol := make([]float64, 0, c.Len())
+	if c.A != nil {
+		rows, cols := c.A.Dims()
+		for r := 0; r < rows; r++ {
+			ax := 0.0
+			for k := 0; k < cols; k++ {
+				ax += c.A.At(r, k) * x[k]
+			}
+			viol = append(viol, math.Max(0, c.Low.At(r, 0)-ax)+math.Max(0, ax-c.Up.At(r, 0)))
+		}
+	}
+	for _, g := range c.Ineq {
+		viol = append(viol, math.Max(0, g(x)))
+	}
+	for _, h := range c.Eq {
+		viol = append(viol, math.Abs(h(x)))
+	}
+	return viol
+}
+
+// Violation returns the total amount by which x violates the constraints.
+func (c *Constraints) Violation(x []float64) float64 {
+	tot := 0.0
+	for _, v := range c.Violations(x) {
+		tot += v
+	}
+	return tot
+}
+
+// Feasible reports whether x's total violation is within Tol.
+func (c *Constraints) Feasible(x []float64) bool { return c.Violation(x) <= c.Tol }
+
+// Better compares points a and b using the feasibility rules from:
+//
+//	Deb, Kalyanmoy. "An efficient constraint handling method for genetic
+//	algorithms." Computer Methods in Applied Mechanics and Engineering
+//	186.2 (2000): 311-338.
+//
+// A feasible point is better than an infeasible one, of two feasible points
+// the one with the lower value is better, and of two infeasible points the
+// one with the lower total violation is better.
+func (c *Constraints) Better(a, b *Point) bool {
+	va, vb := c.Violation(a.Pos), c.Violation(b.Pos)
+	fa, fb := va <= c.Tol, vb <= c.Tol
+	switch {
+	case fa && fb:
+		return a.Val < b.Val
+	case fa != fb:
+		return fa
+	default:
+		return va < vb
+	}
+}
+
+// Penalized wraps an objective and adds Weight times the total constraint
+// violation of each evaluated point to its value.
+type Penalized struct {
+	Obj    Objectiver
+	Constr *Constraints
+	Weight float64
+}
+
+func (p *Penalized) Objective(v []float64) (float64, error) {
+	val, err := p.Obj.Objective(v)
+	return val + p.Weight*p.Constr.Violation(v), err
+}
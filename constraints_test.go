@@ -0,0 +1,84 @@
+package optim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func testConstraints() *Constraints {
+	// 0 <= x0 + x1 <= 2, x0^2 - 4 <= 0 (|x0| <= 2) and x1 == 1
+	return &Constraints{
+		A:    mat64.NewDense(1, 2, []float64{1, 1}),
+		Low:  mat64.NewDense(1, 1, []float64{0}),
+		Up:   mat64.NewDense(1, 1, []float64{2}),
+		Ineq: []func(x []float64) float64{func(x []float64) float64 { return x[0]*x[0] - 4 }},
+		Eq:   []func(x []float64) float64{func(x []float64) float64 { return x[1] - 1 }},
+		Tol:  1e-9,
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	c := testConstraints()
+	if c.Len() != 3 {
+		t.Errorf("want 3 constraints, got %v", c.Len())
+	}
+
+	cases := []struct {
+		x        []float64
+		viol     []float64
+		feasible bool
+	}{
+		{[]float64{0.5, 1}, []float64{0, 0, 0}, true},
+		{[]float64{3, 1}, []float64{2, 5, 0}, false},
+		{[]float64{-3, 0}, []float64{3, 5, 1}, false},
+	}
+	for _, test := range cases {
+		viol := c.Violations(test.x)
+		tot := 0.0
+		for i := range test.viol {
+			tot += test.viol[i]
+			if math.Abs(viol[i]-test.viol[i]) > 1e-12 {
+				t.Errorf("x=%v: want violations %v, got %v", test.x, test.viol, viol)
+				break
+			}
+		}
+		if got := c.Violation(test.x); math.Abs(got-tot) > 1e-12 {
+			t.Errorf("x=%v: want total violation %v, got %v", test.x, tot, got)
+		}
+		if got := c.Feasible(test.x); got != test.feasible {
+			t.Errorf("x=%v: want feasible=%v, got %v", test.x, test.feasible, got)
+		}
+	}
+}
+
+func TestConstraintsBetter(t *testing.T) {
+	c := testConstraints()
+	feas := &Point{Pos: []float64{0.5, 1}, Val: 10}
+	feas2 := &Point{Pos: []float64{0, 1}, Val: 5}
+	slight := &Point{Pos: []float64{0.5, 1.1}, Val: 0}
+	bad := &Point{Pos: []float64{3, 1}, Val: -10}
+
+	cases := []struct {
+		a, b *Point
+		want bool
+	}{
+		{feas2, feas, true},
+		{feas, feas2, false},
+		{feas, slight, true},
+		{slight, feas, false},
+		{slight, bad, true},
+		{bad, slight, false},
+	}
+	for i, test := range cases {
+		if got := c.Better(test.a, test.b); got != test.want {
+			t.Errorf("case %v: Better(%v, %v) = %v, want %v", i, test.a, test.b, got, test.want)
+		}
+	}
+
+	pen := &Penalized{Obj: Func(func(x []float64) float64 { return 1 }), Constr: c, Weight: 10}
+	if val, _ := pen.Objective([]float64{3, 1}); val != 71 {
+		t.Errorf("penalized value: want 71, got %v", val)
+	}
+}
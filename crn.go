@@ -0,0 +1,56 @@
+package optim
+
+import (
+	"math"
+	"sync"
+)
+
+// SeededObjectiver is implemented by stochastic objectives that can be
+// evaluated using a caller-provided random seed.  Evaluations with equal
+// seeds should use identical random number streams.
+type SeededObjectiver interface {
+	SeededObjective(v []float64, seed int64) (float64, error)
+}
+
+// CRNObjectiver adapts a stochastic objective for optimization using common
+// random numbers.  Every point is evaluated once for each scenario seed in
+// Seeds and the mean value is returned.  Because all points see the same
+// scenarios, differences in objective values between points reflect the
+// points themselves rather than sampling noise.  If Seeds is empty, a single
+// scenario with seed zero is used.
+type CRNObjectiver struct {
+	Obj   SeededObjectiver
+	Seeds []int64
+	mu    sync.RWMutex
+}
+
+// Reseed replaces the scenario set with n new seeds drawn from Rand.
+// Values computed before and after reseeding are generally not comparable.
+func (o *CRNObjectiver) Reseed(n int) {
+	seeds := make([]int64, n)
+	for i := range seeds {
+		seeds[i] = int64(Rand.Intn(math.MaxInt32))
+	}
+	o.mu.Lock()
+	o.Seeds = seeds
+	o.mu.Unlock()
+}
+
+func (o *CRNObjectiver) Objective(v []float64) (float64, error) {
+	o.mu.RLock()
+	seeds := o.Seeds
+	o.mu.RUnlock()
+	if len(seeds) == 0 {
+		seeds = []int64{0}
+	}
+
+	tot := 0.0
+	for _, seed := range seeds {
+		val, err := o.Obj.SeededObjective(v, seed)
+		if err != nil {
+			return math.Inf(1), err
+		}
+		tot += val
+	}
+	return tot / float64(len(seeds)), nil
+}
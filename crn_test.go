@@ -0,0 +1,44 @@
+package optim
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// noisySphere is the sphere function plus gaussian noise.
+type noisySphere struct{}
+
+func (_ noisySphere) SeededObjective(v []float64, seed int64) (float64, error) {
+	r := rand.New(rand.NewSource(seed))
+	tot := 0.0
+	for _, x := range v {
+		tot += x * x
+	}
+	return tot + r.NormFloat64(), nil
+}
+
+func TestCRNObjectiver(t *testing.T) {
+	o := &CRNObjectiver{Obj: noisySphere{}}
+	o.Reseed(5)
+	if len(o.Seeds) != 5 {
+		t.Fatalf("wrong number of seeds: want 5, got %v", len(o.Seeds))
+	}
+
+	// with common random numbers the noise cancels exactly in comparisons
+	v1, _ := o.Objective([]float64{1, 1})
+	v2, _ := o.Objective([]float64{1, 2})
+	if diff := v2 - v1; math.Abs(diff-3) > 1e-9 {
+		t.Errorf("noise not common between points: want diff 3, got %v", diff)
+	}
+
+	again, _ := o.Objective([]float64{1, 1})
+	if again != v1 {
+		t.Errorf("repeated evaluation differs: %v != %v", again, v1)
+	}
+
+	o.Reseed(5)
+	if reseeded, _ := o.Objective([]float64{1, 1}); reseeded == v1 {
+		t.Errorf("reseeding did not change scenarios")
+	}
+}
@@ -0,0 +1,203 @@
+// Package crossover provides recombination operators for evolutionary
+// methods that create two children from two parent positions:
+//
+//   - SBX: simulated binary crossover.  Deb, Kalyanmoy, and Ram Bhushan
+//     Agrawal. "Simulated binary crossover for continuous search space."
+//     Complex Systems 9.2 (1995): 115-148.
+//   - BLX: blend crossover (BLX-alpha).  Eshelman, Larry J., and J. David
+//     Schaffer. "Real-coded genetic algorithms and interval-schemata."
+//     Foundations of Genetic Algorithms 2 (1993): 187-202.
+//   - Arithmetic: children are weighted averages of the parents.
+//   - Uniform: each coordinate is swapped between the children with a
+//     fixed probability.
+//
+// SBX and BLX can create children outside the search bounds.  Wrap an
+// operator with Bounded to repair such children with one of the Repair
+// strategies.  See e.g. es.Crossover for use with evolutionary methods.
+package crossover
+
+import (
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Crossover creates two children from parents a and b.  Children are new
+// slices - a and b are not modified.  github.com/rwcarlsen/optim.Rand is
+// used for random numbers.
+type Crossover interface {
+	Cross(a, b []float64) (c1, c2 []float64)
+}
+
+// SBX is simulated binary crossover.  Eta is the distribution index: large
+// values create children near their parents and small values spread them
+// out (2 to 20 is typical).  Each coordinate is crossed with probability
+// Prob (1 if zero) and otherwise copied from the parents.
+type SBX struct {
+	Eta  float64
+	Prob float64
+}
+
+func (x SBX) Cross(a, b []float64) (c1, c2 []float64) {
+	c1, c2 = append([]float64{}, a...), append([]float64{}, b...)
+	prob := x.Prob
+	if prob == 0 {
+		prob = 1
+	}
+	for i := range a {
+		if optim.RandFloat() >= prob {
+			continue
+		}
+		u := optim.RandFloat()
+		var beta float64
+		if u <= .5 {
+			beta = math.Pow(2*u, 1/(x.Eta+1))
+		} else {
+			beta = math.Pow(1/(2*(1-u)), 1/(x.Eta+1))
+		}
+		c1[i] = .5 * ((1+beta)*a[i] + (1-beta)*b[i])
+		c2[i] = .5 * ((1-beta)*a[i] + (1+beta)*b[i])
+	}
+	return c1, c2
+}
+
+// BLX is blend crossover.  Each child coordinate is drawn uniformly from
+// the parents' interval extended by Alpha times its width on both sides
+// (0.5 is typical).
+type BLX struct {
+	Alpha float64
+}
+
+func (x BLX) Cross(a, b []float64) (c1, c2 []float64) {
+	c1, c2 = make([]float64, len(a)), make([]float64, len(a))
+	for i := range a {
+		lo, hi := math.Min(a[i], b[i]), math.Max(a[i], b[i])
+		d := x.Alpha * (hi - lo)
+		c1[i] = lo - d + optim.RandFloat()*(hi-lo+2*d)
+		c2[i] = lo - d + optim.RandFloat()*(hi-lo+2*d)
+	}
+	return c1, c2
+}
+
+// Arithmetic creates children w*a + (1-w)*b and (1-w)*a + w*b.  If Weight
+// is zero, w is drawn uniformly from [0, 1] for each pair of children.
+// Children always lie between their parents.
+type Arithmetic struct {
+	Weight float64
+}
+
+func (x Arithmetic) Cross(a, b []float64) (c1, c2 []float64) {
+	w := x.Weight
+	if w == 0 {
+		w = optim.RandFloat()
+	}
+	c1, c2 = make([]float64, len(a)), make([]float64, len(a))
+	for i := range a {
+		c1[i] = w*a[i] + (1-w)*b[i]
+		c2[i] = (1-w)*a[i] + w*b[i]
+	}
+	return c1, c2
+}
+
+// Uniform swaps each coordinate between the children with probability
+// Prob (0.5 if zero).
+type Uniform struct {
+	Prob float64
+}
+
+func (x Uniform) Cross(a, b []float64) (c1, c2 []float64) {
+	prob := x.Prob
+	if prob == 0 {
+		prob = .5
+	}
+	c1, c2 = append([]float64{}, a...), append([]float64{}, b...)
+	for i := range a {
+		if optim.RandFloat() < prob {
+			c1[i], c2[i] = c2[i], c1[i]
+		}
+	}
+	return c1, c2
+}
+
+// Repair is a strategy for moving out of bounds children back inside the
+// bounds.
+type Repair int
+
+const (
+	// Clip moves violating coordinates to the nearest bound.
+	Clip Repair = iota
+	// Reflect mirrors violating coordinates back inside the bounds.
+	Reflect
+	// Resample redraws violating coordinates uniformly inside the bounds.
+	Resample
+	// Midpoint moves violating coordinates halfway between the violated
+	// bound and the nearer parent's coordinate - keeping children near
+	// their parents while avoiding pile-up on the bounds.
+	Midpoint
+)
+
+// Bounded wraps a crossover operator and repairs children that violate
+// Bounds using Repair.
+type Bounded struct {
+	Crossover
+	Bounds optim.Bounds
+	Repair Repair
+}
+
+func (x Bounded) Cross(a, b []float64) (c1, c2 []float64) {
+	c1, c2 = x.Crossover.Cross(a, b)
+	return x.repair(c1, a, b), x.repair(c2, a, b)
+}
+
+func (x Bounded) repair(c, a, b []float64) []float64 {
+	if x.Bounds.Contains(c) {
+		return c
+	}
+	switch x.Repair {
+	case Clip:
+		return x.Bounds.Clip(c)
+	case Reflect:
+		return x.Bounds.Reflect(c)
+	case Resample:
+		fresh := x.Bounds.Sample()
+		for i, bound := range x.Bounds.Clip(c) {
+			if c[i] != bound {
+				c[i] = fresh[i]
+			}
+		}
+	case Midpoint:
+		pa, pb := x.Bounds.Clip(a), x.Bounds.Clip(b)
+		for i, bound := range x.Bounds.Clip(c) {
+			if c[i] == bound {
+				continue
+			}
+			parent := pa[i]
+			if math.Abs(b[i]-bound) < math.Abs(a[i]-bound) {
+				parent = pb[i]
+			}
+			c[i] = (bound + parent) / 2
+		}
+	}
+	return c
+}
+
+// ViolationRate returns the fraction of children created by crossing n
+// random pairs of pts with x that lie outside bounds.  It is useful for
+// judging whether an operator needs bound repair.
+func ViolationRate(x Crossover, bounds optim.Bounds, pts [][]float64, n int) float64 {
+	if n == 0 || len(pts) == 0 {
+		return 0
+	}
+	nviol := 0
+	for k := 0; k < n; k++ {
+		a, b := pts[optim.Rand.Intn(len(pts))], pts[optim.Rand.Intn(len(pts))]
+		c1, c2 := x.Cross(a, b)
+		if !bounds.Contains(c1) {
+			nviol++
+		}
+		if !bounds.Contains(c2) {
+			nviol++
+		}
+	}
+	return float64(nviol) / float64(2*n)
+}
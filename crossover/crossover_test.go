@@ -0,0 +1,67 @@
+package crossover
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+)
+
+func TestViolationRates(t *testing.T) {
+	optim.Rand = rand.New(rand.NewSource(1))
+	ops := []Crossover{SBX{Eta: 2}, BLX{Alpha: .5}, Arithmetic{}, Uniform{}}
+	for _, fn := range bench.Basic {
+		low, up := fn.Bounds()
+		b := optim.Bounds{Low: low, Up: up}
+		var pts [][]float64
+		for _, p := range optim.RandPop(50, low, up) {
+			pts = append(pts, p.Pos)
+		}
+		// parents at the bounds make violations likely
+		pts = append(pts, low, up)
+
+		for _, x := range ops {
+			rate := ViolationRate(x, b, pts, 500)
+			t.Logf("[%v] %T violation rate %.3f", fn.Name(), x, rate)
+			switch x.(type) {
+			case Arithmetic, Uniform:
+				if rate != 0 {
+					t.Errorf("[%v] %T: children outside bounds of their parents (rate %v)", fn.Name(), x, rate)
+				}
+			case BLX:
+				if rate == 0 {
+					t.Errorf("[%v] %T: expected some violations", fn.Name(), x)
+				}
+			}
+			for _, r := range []Repair{Clip, Reflect, Resample, Midpoint} {
+				if rate := ViolationRate(Bounded{Crossover: x, Bounds: b, Repair: r}, b, pts, 500); rate != 0 {
+					t.Errorf("[%v] %T with repair %v: violation rate %v, want 0", fn.Name(), x, r, rate)
+				}
+			}
+		}
+	}
+}
+
+func TestSBXSpread(t *testing.T) {
+	optim.Rand = rand.New(rand.NewSource(1))
+	a, b := []float64{0}, []float64{1}
+	// children are symmetric about the parents' mean
+	for i := 0; i < 100; i++ {
+		c1, c2 := SBX{Eta: 10}.Cross(a, b)
+		if d := c1[0] + c2[0] - 1; d > 1e-12 || d < -1e-12 {
+			t.Fatalf("children %v, %v not centered on the parents", c1, c2)
+		}
+	}
+	if a[0] != 0 || b[0] != 1 {
+		t.Errorf("parents modified: %v, %v", a, b)
+	}
+}
+
+func TestMidpointRepair(t *testing.T) {
+	x := Bounded{Crossover: Uniform{Prob: 1}, Bounds: optim.Bounds{Low: []float64{0}, Up: []float64{1}}, Repair: Midpoint}
+	c := x.repair([]float64{1.5}, []float64{.5}, []float64{.2})
+	if c[0] != .75 {
+		t.Errorf("want child repaired to 0.75, got %v", c[0])
+	}
+}
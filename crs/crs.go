@@ -0,0 +1,160 @@
+// Package crs provides the controlled random search method with local
+// mutation (CRS2-LM) as described in:
+//
+//	Kaelo, P., and M. M. Ali. "Some variants of the controlled random search
+//	algorithm for global optimization." Journal of Optimization Theory and
+//	Applications 130.2 (2006): 253-264.
+//
+// Each iteration reflects a random point through the centroid of a random
+// simplex containing the best point.  If the reflection doesn't improve on
+// the worst population member, a local mutation around the best point is
+// tried instead.  Improving trial points replace the worst population
+// member.
+package crs
+
+import (
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+type Option func(*Method)
+
+func Evaler(e optim.Evaler) Option { return func(m *Method) { m.Evaler = e } }
+
+type Method struct {
+	Pop []*optim.Point
+	optim.Evaler
+	init bool
+}
+
+// New creates a CRS2-LM method using the given initial population.  The
+// population should be larger than the problem dimension - 10*(ndim+1) is
+// recommended.
+func New(pop []*optim.Point, opts ...Option) *Method {
+	m := &Method{Pop: pop, Evaler: optim.SerialEvaler{}}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// NewRand creates a CRS2-LM method with the recommended population size of
+// 10*(ndim+1) randomly distributed within the given bounds.
+func NewRand(low, up []float64, opts ...Option) *Method {
+	return New(optim.RandPop(10*(len(low)+1), low, up), opts...)
+}
+
+// AddPoint replaces the worst population member with p if p is better.
+func (m *Method) AddPoint(p *optim.Point) {
+	if worst := m.worst(); p.Val < m.Pop[worst].Val {
+		m.Pop[worst] = p
+	}
+}
+
+// Inject replaces the worst population members with points that are no
+// worse.
+func (m *Method) Inject(points ...*optim.Point) { optim.ReplaceWorst(m.Pop, points...) }
+
+func (m *Method) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Point, n int, err error) {
+	if !m.init {
+		m.init = true
+		var results []*optim.Point
+		results, n, err = optim.DedupEvaler{Evaler: m.Evaler}.Eval(obj, m.Pop...)
+		evaled := make(map[*optim.Point]bool, len(results))
+		for _, p := range results {
+			evaled[p] = true
+		}
+		for _, p := range m.Pop {
+			if !evaled[p] {
+				// keep unevaluated members so the population stays full
+				p.Val = math.Inf(1)
+			}
+		}
+		if err != nil {
+			return m.Pop[m.best()], n, err
+		}
+	}
+
+	b := m.Pop[m.best()]
+	trial := m.reflect(b)
+	if mesh != nil {
+		trial.Pos = mesh.Nearest(trial.Pos)
+	}
+	pts, nn, err := m.Evaler.Eval(obj, trial)
+	n += nn
+	if err != nil {
+		return m.Pop[m.best()], n, err
+	}
+
+	w := m.worst()
+	if len(pts) > 0 && pts[0].Val < m.Pop[w].Val {
+		m.Pop[w] = pts[0]
+		return m.Pop[m.best()], n, nil
+	}
+
+	trial = m.mutate(b, trial)
+	if mesh != nil {
+		trial.Pos = mesh.Nearest(trial.Pos)
+	}
+	pts, nn, err = m.Evaler.Eval(obj, trial)
+	n += nn
+	if err == nil && len(pts) > 0 && pts[0].Val < m.Pop[w].Val {
+		m.Pop[w] = pts[0]
+	}
+	return m.Pop[m.best()], n, err
+}
+
+// reflect chooses ndim random population members other than the best b and
+// reflects the last of them through the centroid of b and the others.
+func (m *Method) reflect(b *optim.Point) *optim.Point {
+	ndim := b.Len()
+	perm := optim.Rand.Perm(len(m.Pop))
+	simplex := make([]*optim.Point, 0, ndim)
+	for _, i := range perm {
+		if len(simplex) == ndim {
+			break
+		} else if m.Pop[i] != b {
+			simplex = append(simplex, m.Pop[i])
+		}
+	}
+
+	last := simplex[len(simplex)-1]
+	centroid := optim.PopMean(append(simplex[:len(simplex)-1:len(simplex)-1], b))
+	pos := make([]float64, ndim)
+	for i := range pos {
+		pos[i] = 2*centroid[i] - last.Pos[i]
+	}
+	return &optim.Point{Pos: pos, Val: math.Inf(1)}
+}
+
+// mutate generates a local mutation trial point between the best point b
+// and the failed trial point.
+func (m *Method) mutate(b, trial *optim.Point) *optim.Point {
+	pos := make([]float64, b.Len())
+	for i := range pos {
+		w := optim.RandFloat()
+		pos[i] = (1+w)*b.Pos[i] - w*trial.Pos[i]
+	}
+	return &optim.Point{Pos: pos, Val: math.Inf(1)}
+}
+
+func (m *Method) best() int {
+	best := 0
+	for i, p := range m.Pop {
+		if p.Val < m.Pop[best].Val {
+			best = i
+		}
+	}
+	return best
+}
+
+func (m *Method) worst() int {
+	worst := 0
+	for i, p := range m.Pop {
+		if p.Val > m.Pop[worst].Val {
+			worst = i
+		}
+	}
+	return worst
+}
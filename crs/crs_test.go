@@ -0,0 +1,70 @@
+package crs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+)
+
+func TestAckley(t *testing.T) {
+	fn := bench.Ackley{}
+	bench.Benchmark(t, fn, solver(fn), 1, 600)
+}
+
+func TestRosenbrock(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 5}
+	bench.Benchmark(t, fn, solver(fn), 1, 1700)
+}
+
+// solver returns a factory for CRS2-LM solvers minimizing fn.
+func solver(fn bench.Func) func() *optim.Solver {
+	return func() *optim.Solver {
+		low, up := fn.Bounds()
+		return &optim.Solver{
+			Method:  NewRand(low, up),
+			Obj:     optim.Func(fn.Eval),
+			Mesh:    &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up},
+			MaxEval: 20000,
+		}
+	}
+}
+
+func TestAddPoint(t *testing.T) {
+	m := New([]*optim.Point{{Pos: []float64{0}, Val: 1}, {Pos: []float64{1}, Val: 5}})
+	m.AddPoint(&optim.Point{Pos: []float64{2}, Val: 3})
+	if m.Pop[1].Val != 3 {
+		t.Errorf("worst point not replaced: got %v", m.Pop)
+	}
+	m.AddPoint(&optim.Point{Pos: []float64{3}, Val: 10})
+	if m.Pop[0].Val != 1 || m.Pop[1].Val != 3 {
+		t.Errorf("population changed by worse point: got %v", m.Pop)
+	}
+}
+
+// failFirst is a sphere objective whose first evaluation fails.
+type failFirst struct{ n int }
+
+func (o *failFirst) Objective(x []float64) (float64, error) {
+	if o.n++; o.n == 1 {
+		return 0, errors.New("first evaluation failed")
+	}
+	return x[0] * x[0], nil
+}
+
+func TestInitError(t *testing.T) {
+	obj := &failFirst{}
+	m := NewRand([]float64{-1}, []float64{1})
+	if _, _, err := m.Iterate(obj, nil); err == nil {
+		t.Fatal("want error from the first objective call")
+	}
+	if len(m.Pop) != 20 {
+		t.Fatalf("population shrank to %v members", len(m.Pop))
+	}
+	for i := 0; i < 10; i++ {
+		if _, _, err := m.Iterate(obj, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
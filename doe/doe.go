@@ -0,0 +1,302 @@
+// Package doe generates and evaluates classical design-of-experiments point
+// sets (full/fractional factorial, central composite, and latin hypercube
+// designs) over boxed bounds.  Generated points have their values
+// initialized to +infinity.
+package doe
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// FullFactorial returns a full factorial design with levels[i] evenly
+// spaced levels (including the bounds) for each dimension i.  A dimension
+// with a single level is held at the center of its bounds.
+func FullFactorial(low, up []float64, levels []int) []*optim.Point {
+	checkbounds(low, up)
+	if len(levels) != len(low) {
+		panic("doe: levels and bounds are not same length")
+	}
+
+	n := 1
+	for _, l := range levels {
+		n *= l
+	}
+
+	design := make([]*optim.Point, 0, n)
+	idx := make([]int, len(levels))
+	for k := 0; k < n; k++ {
+		pos := make([]float64, len(levels))
+		for i, l := range levels {
+			frac := 0.5
+			if l > 1 {
+				frac = float64(idx[i]) / float64(l-1)
+			}
+			pos[i] = low[i] + frac*(up[i]-low[i])
+		}
+		design = append(design, &optim.Point{Pos: pos, Val: math.Inf(1)})
+
+		// increment the multi-dimensional level index
+		for i := range idx {
+			idx[i]++
+			if idx[i] < levels[i] {
+				break
+			}
+			idx[i] = 0
+		}
+	}
+	return design
+}
+
+// FracFactorial returns a two-level fractional factorial design.  The first
+// nbase dimensions form a full two-level factorial and each of the remaining
+// len(low)-nbase dimensions is generated by gens - the level of dimension
+// nbase+j is the product of the coded (+/-1) levels of the base dimensions
+// listed in gens[j].  For example a 2^(4-1) design with generator D = ABC
+// uses nbase=3 and gens=[][]int{{0, 1, 2}}.
+func FracFactorial(low, up []float64, nbase int, gens [][]int) []*optim.Point {
+	checkbounds(low, up)
+	if nbase+len(gens) != len(low) {
+		panic("doe: nbase plus number of generators must equal the number of dimensions")
+	}
+
+	ndim := len(low)
+	n := 1 << uint(nbase)
+	design := make([]*optim.Point, 0, n)
+	for k := 0; k < n; k++ {
+		coded := make([]float64, ndim)
+		for i := 0; i < nbase; i++ {
+			coded[i] = -1
+			if k&(1<<uint(i)) != 0 {
+				coded[i] = 1
+			}
+		}
+		for j, gen := range gens {
+			coded[nbase+j] = 1
+			for _, i := range gen {
+				if i < 0 || i >= nbase {
+					panic("doe: generator refers to a non-base dimension")
+				}
+				coded[nbase+j] *= coded[i]
+			}
+		}
+		design = append(design, decode(coded, low, up, 1))
+	}
+	return design
+}
+
+// CentralComposite returns a central composite design consisting of a
+// two-level full factorial, 2*ndim axial points at a coded distance alpha
+// from the center, and a single center point.  The design is scaled
+// (inscribed) so that all points lie within bounds - alpha == 1 yields a
+// face-centered design.
+func CentralComposite(low, up []float64, alpha float64) []*optim.Point {
+	checkbounds(low, up)
+	ndim := len(low)
+	scale := math.Max(alpha, 1)
+
+	design := []*optim.Point{}
+	for k := 0; k < 1<<uint(ndim); k++ {
+		coded := make([]float64, ndim)
+		for i := range coded {
+			coded[i] = -1
+			if k&(1<<uint(i)) != 0 {
+				coded[i] = 1
+			}
+		}
+		design = append(design, decode(coded, low, up, scale))
+	}
+
+	for i := 0; i < ndim; i++ {
+		for _, sign := range []float64{-1, 1} {
+			coded := make([]float64, ndim)
+			coded[i] = sign * alpha
+			design = append(design, decode(coded, low, up, scale))
+		}
+	}
+	return append(design, decode(make([]float64, ndim), low, up, scale))
+}
+
+// LHS returns an n point latin hypercube design - each dimension's range is
+// divided into n equal strata and every stratum is sampled exactly once.
+// github.com/rwcarlsen/optim.Rand is used for random numbers.
+func LHS(n int, low, up []float64) []*optim.Point { return LHSFrom(optim.Rand, n, low, up) }
+
+// LHSFrom is like LHS but draws random numbers from r.
+func LHSFrom(r optim.Rng, n int, low, up []float64) []*optim.Point {
+	checkbounds(low, up)
+	design := make([]*optim.Point, n)
+	for j := range design {
+		design[j] = &optim.Point{Pos: make([]float64, len(low)), Val: math.Inf(1)}
+	}
+	for i := range low {
+		perm := r.Perm(n)
+		width := (up[i] - low[i]) / float64(n)
+		for j, p := range design {
+			p.Pos[i] = low[i] + (float64(perm[j])+r.Float64())*width
+		}
+	}
+	return design
+}
+
+// Augment returns an n point design made of copies of the user-specified
+// points (clipped to the bounds) followed by space filling points that
+// avoid them.  Each dimension's range is divided into n equal strata and
+// the filling points sample the strata not already occupied by user
+// points, so if the user points lie in distinct strata the whole design is
+// a latin hypercube.  Of ntry such candidate fillings, the one maximizing
+// the minimum distance (scaled by the bounds' widths) between any two
+// design points is returned.  If there are n or more user points, the
+// first n are returned.  github.com/rwcarlsen/optim.Rand is used for
+// random numbers.
+func Augment(user []*optim.Point, n int, low, up []float64, ntry int) []*optim.Point {
+	return AugmentFrom(optim.Rand, user, n, low, up, ntry)
+}
+
+// AugmentFrom is like Augment but draws random numbers from r.
+func AugmentFrom(r optim.Rng, user []*optim.Point, n int, low, up []float64, ntry int) []*optim.Point {
+	checkbounds(low, up)
+	b := optim.Bounds{Low: low, Up: up}
+	var design []*optim.Point
+	for _, p := range user {
+		if len(design) == n {
+			return design
+		}
+		design = append(design, &optim.Point{Pos: b.Clip(p.Pos), Val: p.Val})
+	}
+	nfill := n - len(design)
+	if nfill == 0 {
+		return design
+	}
+
+	// free[i] holds the strata of dimension i no user point occupies -
+	// there are at least nfill of them.
+	free := make([][]int, len(low))
+	for i := range low {
+		width := (up[i] - low[i]) / float64(n)
+		taken := make([]bool, n)
+		for _, p := range design {
+			k := n - 1
+			if width > 0 {
+				k = int(math.Min(float64(n-1), (p.Pos[i]-low[i])/width))
+			}
+			taken[k] = true
+		}
+		for k, t := range taken {
+			if !t {
+				free[i] = append(free[i], k)
+			}
+		}
+	}
+
+	if ntry < 1 {
+		ntry = 1
+	}
+	var best []*optim.Point
+	bestdist := -1.0
+	for t := 0; t < ntry; t++ {
+		fill := make([]*optim.Point, nfill)
+		for j := range fill {
+			fill[j] = &optim.Point{Pos: make([]float64, len(low)), Val: math.Inf(1)}
+		}
+		for i := range low {
+			perm := r.Perm(len(free[i]))
+			width := (up[i] - low[i]) / float64(n)
+			for j, p := range fill {
+				p.Pos[i] = low[i] + (float64(free[i][perm[j]])+r.Float64())*width
+			}
+		}
+		if d := minDist(append(append([]*optim.Point{}, design...), fill...), b.Widths()); d > bestdist {
+			best, bestdist = fill, d
+		}
+	}
+	return append(design, best...)
+}
+
+// minDist returns the smallest distance between any two of pts with each
+// dimension scaled by widths.
+func minDist(pts []*optim.Point, widths []float64) float64 {
+	min := math.Inf(1)
+	for i, p := range pts {
+		for _, q := range pts[:i] {
+			d := 0.0
+			for k := range p.Pos {
+				x := p.Pos[k] - q.Pos[k]
+				if widths[k] > 0 {
+					x /= widths[k]
+				}
+				d += x * x
+			}
+			min = math.Min(min, d)
+		}
+	}
+	return math.Sqrt(min)
+}
+
+// Results holds the outcome of evaluating a design.
+type Results struct {
+	// Points holds the evaluated design points in design order.
+	Points []*optim.Point
+	// Best is the design point with the lowest objective value.
+	Best *optim.Point
+	// Neval is the number of objective evaluations performed.
+	Neval int
+}
+
+// Sorted returns the evaluated design points ordered from best to worst.
+func (r *Results) Sorted() []*optim.Point {
+	pts := append([]*optim.Point{}, r.Points...)
+	sort.Sort(byval(pts))
+	return pts
+}
+
+// Eval projects each design point onto m (if non-nil) and evaluates it
+// using ev and obj.  Duplicate design points (e.g. from mesh projection) are
+// only evaluated once.
+func Eval(obj optim.Objectiver, ev optim.Evaler, m optim.Mesh, design []*optim.Point) (*Results, error) {
+	if m != nil {
+		for _, p := range design {
+			p.Pos = m.Nearest(p.Pos)
+		}
+	}
+
+	results, n, err := optim.DedupEvaler{Evaler: ev}.Eval(obj, design...)
+	r := &Results{Points: design, Neval: n}
+	if err != nil {
+		return r, err
+	} else if len(results) < len(design) {
+		return r, errors.New("doe: evaler failed to evaluate all design points")
+	}
+
+	for _, p := range design {
+		if r.Best == nil || p.Val < r.Best.Val {
+			r.Best = p
+		}
+	}
+	return r, nil
+}
+
+// decode maps coded levels in [-scale, scale] onto the bounds.
+func decode(coded, low, up []float64, scale float64) *optim.Point {
+	pos := make([]float64, len(coded))
+	for i, c := range coded {
+		mid := (low[i] + up[i]) / 2
+		pos[i] = mid + c/scale*(up[i]-low[i])/2
+	}
+	return &optim.Point{Pos: pos, Val: math.Inf(1)}
+}
+
+func checkbounds(low, up []float64) {
+	if len(low) != len(up) {
+		panic("doe: low and up vectors are not same length")
+	}
+}
+
+type byval []*optim.Point
+
+func (b byval) Less(i, j int) bool { return b[i].Val < b[j].Val }
+func (b byval) Len() int           { return len(b) }
+func (b byval) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
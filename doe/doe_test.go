@@ -0,0 +1,147 @@
+package doe
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+var low, up = []float64{-1, 0, 10}, []float64{1, 4, 20}
+
+func TestFullFactorial(t *testing.T) {
+	design := FullFactorial(low, up, []int{2, 3, 1})
+	if len(design) != 6 {
+		t.Fatalf("wrong design size: want 6, got %v", len(design))
+	}
+	seen := map[[2]float64]bool{}
+	for _, p := range design {
+		seen[[2]float64{p.Pos[0], p.Pos[1]}] = true
+		if p.Pos[2] != 15 {
+			t.Errorf("single level dimension not centered: got %v", p.Pos[2])
+		}
+	}
+	if len(seen) != 6 {
+		t.Errorf("design has duplicate points: %v", design)
+	}
+}
+
+func TestFracFactorial(t *testing.T) {
+	// 2^(3-1) design with generator C = AB
+	design := FracFactorial(low, up, 2, [][]int{{0, 1}})
+	if len(design) != 4 {
+		t.Fatalf("wrong design size: want 4, got %v", len(design))
+	}
+	for _, p := range design {
+		a := p.Pos[0]
+		b := (p.Pos[1] - 2) / 2
+		c := (p.Pos[2] - 15) / 5
+		if a*b != c {
+			t.Errorf("point %v violates generator C = AB", p.Pos)
+		}
+	}
+}
+
+func TestCentralComposite(t *testing.T) {
+	design := CentralComposite(low, up, math.Sqrt(2))
+	if want := 8 + 6 + 1; len(design) != want {
+		t.Fatalf("wrong design size: want %v, got %v", want, len(design))
+	}
+	for _, p := range design {
+		for i := range p.Pos {
+			if p.Pos[i] < low[i]-1e-12 || p.Pos[i] > up[i]+1e-12 {
+				t.Errorf("point %v outside bounds", p.Pos)
+			}
+		}
+	}
+}
+
+func TestCentralCompositeCorners(t *testing.T) {
+	// bounds that aren't exact in binary
+	low, up := []float64{-0.3, -0.3}, []float64{0.1, 0.1}
+	design := CentralComposite(low, up, 1)
+	seen := map[[2]float64]bool{}
+	for _, p := range design[:4] {
+		seen[[2]float64{p.Pos[0], p.Pos[1]}] = true
+	}
+	if len(seen) != 4 {
+		t.Errorf("want 4 distinct corner points, got %v", design[:4])
+	}
+}
+
+func TestLHS(t *testing.T) {
+	n := 10
+	design := LHS(n, low, up)
+	for i := range low {
+		strata := make([]bool, n)
+		for _, p := range design {
+			k := int((p.Pos[i] - low[i]) / (up[i] - low[i]) * float64(n))
+			strata[k] = true
+		}
+		for k, hit := range strata {
+			if !hit {
+				t.Errorf("dimension %v stratum %v not sampled", i, k)
+			}
+		}
+	}
+}
+
+func TestAugment(t *testing.T) {
+	n := 10
+	user := []*optim.Point{
+		{Pos: []float64{low[0], up[1], low[2]}, Val: 3},
+		{Pos: []float64{(low[0] + up[0]) / 2, (low[1] + up[1]) / 2, 11.5}, Val: math.Inf(1)},
+	}
+	design := Augment(user, n, low, up, 20)
+	if len(design) != n {
+		t.Fatalf("got %v points, want %v", len(design), n)
+	}
+	for i, p := range user {
+		if design[i].Pos[0] != p.Pos[0] || design[i].Pos[2] != p.Pos[2] || design[i].Val != p.Val {
+			t.Errorf("user point %v not kept: got %v", p, design[i])
+		}
+	}
+	// the user points are in distinct strata so the design is a latin
+	// hypercube
+	for i := range low {
+		strata := make([]bool, n)
+		for _, p := range design {
+			k := int(math.Min(float64(n-1), (p.Pos[i]-low[i])/(up[i]-low[i])*float64(n)))
+			strata[k] = true
+		}
+		for k, hit := range strata {
+			if !hit {
+				t.Errorf("dimension %v stratum %v not sampled", i, k)
+			}
+		}
+	}
+
+	if got := Augment(user, 1, low, up, 1); len(got) != 1 {
+		t.Errorf("got %v points, want 1", len(got))
+	}
+}
+
+func TestEval(t *testing.T) {
+	design := FullFactorial(low, up, []int{3, 3, 3})
+	obj := optim.Func(func(v []float64) float64 { return v[0]*v[0] + v[1] + v[2] })
+	mesh := &optim.InfMesh{StepSize: 2}
+
+	r, err := Eval(obj, optim.SerialEvaler{}, mesh, design)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Neval >= len(design) {
+		t.Errorf("duplicate projected points were not skipped: %v evals", r.Neval)
+	}
+	for _, p := range r.Points {
+		if p.Val == math.Inf(1) {
+			t.Errorf("point %v not evaluated", p.Pos)
+		}
+	}
+	if want := 10.0; r.Best.Val != want {
+		t.Errorf("wrong best: want %v, got %v", want, r.Best)
+	}
+	if sorted := r.Sorted(); sorted[0].Val != r.Best.Val {
+		t.Errorf("sorted results don't start with best point")
+	}
+}
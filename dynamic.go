@@ -0,0 +1,202 @@
+package optim
+
+import (
+	"math"
+	"sync"
+)
+
+// Epoch is a period during which a time-varying objective didn't change.
+type Epoch struct {
+	// Iter and Neval are the iteration and evaluation counts at which the
+	// epoch started.
+	Iter, Neval int
+	// Best is the best point evaluated during the epoch or nil if none
+	// was.
+	Best *Point
+}
+
+// Dynamic wraps a method for tracking the optimum of a time-varying
+// objective (e.g. online tuning of a live system).  Before each iteration
+// it re-evaluates a set of sentinel points and, if any of their values
+// changed by more than Tol (relative to the previous value, or absolute
+// for values smaller than one), starts a new epoch: NRandom random points
+// within [Low, Up] are evaluated and injected along with the re-evaluated
+// sentinels into the method (or a fresh method from Restart) so the search
+// can move away from a stale optimum.  The best point of each epoch is
+// recorded and returned by Iterate, so a Solver's own best (which never
+// gets worse) should not be used to track the current optimum - use
+// Current or Epochs instead.  Stop criteria based on improvement (e.g.
+// Solver.MaxNoImprove) are unreliable for dynamic objectives.
+type Dynamic struct {
+	Method
+	// Low and Up are the bounds sentinel and random points are drawn
+	// within.
+	Low, Up []float64
+	// Sentinels is the number of sentinel points (3 if zero).
+	Sentinels int
+	// Tol is the sentinel value change that signals an objective change
+	// (1e-8 if zero).
+	Tol float64
+	// NRandom is the number of random points injected after a change.
+	NRandom int
+	// Restart, if non-nil, creates the method that replaces the wrapped
+	// method after a change.  Use it for methods whose memory of stale
+	// objective values would keep them from following the optimum.
+	Restart func() Method
+	// Evaler evaluates sentinel and random points (SerialEvaler if nil).
+	Evaler Evaler
+	// OnChange, if non-nil, is called with the finished epoch when a change
+	// is detected.
+	OnChange func(e Epoch)
+
+	sentinels   []*Point
+	epochs      []Epoch
+	iter, neval int
+	mu          sync.Mutex // guards the current epoch's best
+}
+
+func (d *Dynamic) evaler() Evaler {
+	if d.Evaler == nil {
+		return SerialEvaler{}
+	}
+	return d.Evaler
+}
+
+func (d *Dynamic) Iterate(obj Objectiver, mesh Mesh) (best *Point, n int, err error) {
+	defer func() {
+		d.iter++
+		d.neval += n
+	}()
+	if d.epochs == nil {
+		d.epochs = []Epoch{{}}
+	}
+
+	eobj := &epochObj{Obj: obj, d: d}
+	if d.sentinels == nil {
+		nsent := d.Sentinels
+		if nsent == 0 {
+			nsent = 3
+		}
+		d.sentinels = RandPop(nsent, d.Low, d.Up)
+		if _, n, err = d.evaler().Eval(eobj, d.sentinels...); err != nil {
+			return d.Current(), n, err
+		}
+	} else {
+		changed, nn, err := d.detect(obj)
+		n += nn
+		if err != nil {
+			return d.Current(), n, err
+		} else if changed {
+			nn, err = d.change(eobj)
+			n += nn
+			if err != nil {
+				return d.Current(), n, err
+			}
+		}
+	}
+
+	best, nn, err := d.Method.Iterate(eobj, mesh)
+	n += nn
+	if cur := d.Current(); cur != nil {
+		best = cur
+	}
+	return best, n, err
+}
+
+// detect re-evaluates the sentinels and reports whether any of their values
+// changed.
+func (d *Dynamic) detect(obj Objectiver) (changed bool, n int, err error) {
+	pts := make([]*Point, len(d.sentinels))
+	for i, p := range d.sentinels {
+		pts[i] = &Point{Pos: p.Pos, Val: math.Inf(1)}
+	}
+	// evalers set values in place but may reorder or drop results
+	if _, n, err = d.evaler().Eval(obj, pts...); err != nil {
+		return false, n, err
+	}
+	tol := d.Tol
+	if tol == 0 {
+		tol = 1e-8
+	}
+	for i, p := range pts {
+		old := d.sentinels[i].Val
+		if math.IsInf(p.Val, 1) {
+			continue // not evaluated
+		} else if math.Abs(p.Val-old) > tol*math.Max(1, math.Abs(old)) {
+			changed = true
+		}
+		d.sentinels[i] = p
+	}
+	return changed, n, nil
+}
+
+// change starts a new epoch and re-randomizes the method.
+func (d *Dynamic) change(obj Objectiver) (n int, err error) {
+	d.mu.Lock()
+	finished := d.epochs[len(d.epochs)-1]
+	d.epochs = append(d.epochs, Epoch{Iter: d.iter, Neval: d.neval})
+	d.mu.Unlock()
+	if d.OnChange != nil {
+		d.OnChange(finished)
+	}
+
+	if d.Restart != nil {
+		d.Method = d.Restart()
+	}
+	var pts []*Point
+	if d.NRandom > 0 {
+		pts, n, err = d.evaler().Eval(obj, RandPop(d.NRandom, d.Low, d.Up)...)
+		if err != nil {
+			return n, err
+		}
+	}
+	// sentinels were re-evaluated before the epoch started
+	for _, p := range d.sentinels {
+		d.record(p)
+	}
+	Inject(d.Method, append(pts, d.sentinels...)...)
+	return n, nil
+}
+
+func (d *Dynamic) record(p *Point) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e := &d.epochs[len(d.epochs)-1]
+	if e.Best == nil || p.Val < e.Best.Val {
+		e.Best = p.Clone()
+	}
+}
+
+// Current returns the best point of the current epoch or nil if there is
+// none.
+func (d *Dynamic) Current() *Point {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.epochs) == 0 {
+		return nil
+	}
+	return d.epochs[len(d.epochs)-1].Best
+}
+
+// Epochs returns the epochs detected so far including the current one.
+func (d *Dynamic) Epochs() []Epoch {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Epoch{}, d.epochs...)
+}
+
+func (d *Dynamic) Inject(points ...*Point) { Inject(d.Method, points...) }
+
+// epochObj records the best point evaluated in the current epoch.
+type epochObj struct {
+	Obj Objectiver
+	d   *Dynamic
+}
+
+func (o *epochObj) Objective(v []float64) (float64, error) {
+	val, err := o.Obj.Objective(v)
+	if err == nil {
+		o.d.record(&Point{Pos: append([]float64{}, v...), Val: val})
+	}
+	return val, err
+}
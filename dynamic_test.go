@@ -0,0 +1,67 @@
+package optim
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randMethod evaluates random points in [0,10] each iteration.
+type randMethod struct{ best *Point }
+
+func (m *randMethod) AddPoint(p *Point) {
+	if m.best == nil || p.Val < m.best.Val {
+		m.best = p
+	}
+}
+
+func (m *randMethod) Iterate(obj Objectiver, mesh Mesh) (best *Point, n int, err error) {
+	pts, n, err := SerialEvaler{}.Eval(obj, RandPop(5, []float64{0}, []float64{10})...)
+	for _, p := range pts {
+		m.AddPoint(p)
+	}
+	return m.best, n, err
+}
+
+func TestDynamic(t *testing.T) {
+	Rand = &LockedRng{Rng: rand.New(rand.NewSource(1))}
+	center := 2.0
+	obj := Func(func(v []float64) float64 { return (v[0] - center) * (v[0] - center) })
+
+	var changes int
+	d := &Dynamic{
+		Method:   &randMethod{},
+		Low:      []float64{0},
+		Up:       []float64{10},
+		NRandom:  5,
+		OnChange: func(e Epoch) { changes++ },
+	}
+	neval := 0
+	for i := 0; i < 40; i++ {
+		if i == 20 {
+			center = 8
+		}
+		best, n, err := d.Iterate(obj, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		neval += n
+		if i == 19 && math.Abs(best.Pos[0]-2) > .5 {
+			t.Errorf("first epoch best %v, want near 2", best)
+		}
+	}
+
+	epochs := d.Epochs()
+	if changes != 1 || len(epochs) != 2 {
+		t.Fatalf("got %v changes and %v epochs, want 1 and 2", changes, len(epochs))
+	}
+	if epochs[1].Iter != 20 || epochs[1].Neval <= 0 || epochs[1].Neval >= neval {
+		t.Errorf("wrong second epoch start: iter %v, neval %v (of %v)", epochs[1].Iter, epochs[1].Neval, neval)
+	}
+	if math.Abs(epochs[0].Best.Pos[0]-2) > .5 {
+		t.Errorf("first epoch best %v, want near 2", epochs[0].Best)
+	}
+	if cur := d.Current(); math.Abs(cur.Pos[0]-8) > .5 {
+		t.Errorf("current best %v, want near 8", cur)
+	}
+}
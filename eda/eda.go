@@ -0,0 +1,187 @@
+// Package eda provides estimation of distribution algorithms that fit a
+// gaussian model to the best members of each generation and sample the next
+// generation from it.  Two model variants are supported:
+//
+//   - UMDA-c, the continuous univariate marginal distribution algorithm
+//     which fits an independent gaussian for each dimension.
+//   - EMNA, the estimation of multivariate normal algorithm which fits a
+//     full-covariance gaussian.
+//
+// See:
+//
+//	Larrañaga, Pedro, and Jose A. Lozano, eds. Estimation of distribution
+//	algorithms: A new tool for evolutionary computation. Vol. 2. Springer
+//	Science & Business Media, 2001.
+package eda
+
+import (
+	"math"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/selection"
+)
+
+type Option func(*Method)
+
+// Full sets the method to fit a full-covariance gaussian (EMNA) instead of
+// independent per-dimension gaussians (UMDA-c).
+func Full(m *Method) { m.Full = true }
+
+// Elite sets the number of best population members used to fit the model
+// each generation.
+func Elite(n int) Option { return func(m *Method) { m.NElite = n } }
+
+// MinVar sets a lower limit on the per-dimension model variance which
+// prevents premature collapse of the distribution.
+func MinVar(v float64) Option { return func(m *Method) { m.MinVar = v } }
+
+// Select sets the operator that picks the NElite points the model is fitted
+// to each generation.  The default is selection.Truncation - the NElite
+// best members.
+func Select(s selection.Selector) Option { return func(m *Method) { m.Selector = s } }
+
+func Evaler(e optim.Evaler) Option { return func(m *Method) { m.Evaler = e } }
+
+type Method struct {
+	Pop []*optim.Point
+	// NElite is the number of best population members selected to fit the
+	// model.  It defaults to half the population size and is at least one.
+	NElite int
+	// Selector picks the points the model is fitted to.
+	Selector selection.Selector
+	Full     bool
+	MinVar   float64
+	optim.Evaler
+	best *optim.Point
+	// Mean and Cov are the model fitted in the most recent iteration.  For
+	// UMDA-c, Cov is diagonal.
+	Mean []float64
+	Cov  [][]float64
+	chol [][]float64
+}
+
+// New creates an EDA that starts from the given population.  The
+// population size stays fixed at len(pop).
+func New(pop []*optim.Point, opts ...Option) *Method {
+	m := &Method{
+		Pop:      pop,
+		NElite:   len(pop) / 2,
+		Selector: selection.Truncation{},
+		MinVar:   1e-20,
+		Evaler:   optim.SerialEvaler{},
+		best:     &optim.Point{Val: math.Inf(1)},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.NElite < 1 {
+		// the model needs at least one point to fit
+		m.NElite = 1
+	}
+	return m
+}
+
+func (m *Method) AddPoint(p *optim.Point) {
+	if p.Val < m.best.Val {
+		m.best = p
+	}
+}
+
+func (m *Method) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Point, n int, err error) {
+	if m.Mean == nil {
+		// evaluate the initial population so the first selection is
+		// meaningful
+		m.Pop, n, err = m.eval(obj, mesh, m.Pop)
+		if err != nil {
+			return m.best, n, err
+		}
+	}
+
+	m.fit(m.Selector.Select(m.Pop, m.NElite))
+
+	pop := make([]*optim.Point, len(m.Pop))
+	for i := range pop {
+		pop[i] = &optim.Point{Pos: m.sample(), Val: math.Inf(1)}
+	}
+
+	pop, nn, err := m.eval(obj, mesh, pop)
+	n += nn
+	if len(pop) > 0 {
+		m.Pop = pop
+	}
+	return m.best, n, err
+}
+
+func (m *Method) eval(obj optim.Objectiver, mesh optim.Mesh, pop []*optim.Point) ([]*optim.Point, int, error) {
+	if mesh != nil {
+		for _, p := range pop {
+			p.Pos = mesh.Nearest(p.Pos)
+		}
+	}
+	results, n, err := optim.DedupEvaler{Evaler: m.Evaler}.Eval(obj, pop...)
+	for _, p := range results {
+		if p.Val < m.best.Val {
+			m.best = p.Clone()
+		}
+	}
+	return results, n, err
+}
+
+// fit sets the model mean and covariance from the selected points.
+func (m *Method) fit(elite []*optim.Point) {
+	m.Mean = optim.PopMean(elite)
+	m.Cov = optim.PopCov(elite, m.Mean)
+	for i := range m.Cov {
+		if !m.Full {
+			for j := range m.Cov[i] {
+				if i != j {
+					m.Cov[i][j] = 0
+				}
+			}
+		}
+		m.Cov[i][i] = math.Max(m.Cov[i][i], m.MinVar)
+	}
+	m.chol = cholesky(m.Cov)
+}
+
+// sample draws a position from the fitted model.
+func (m *Method) sample() []float64 {
+	z := make([]float64, len(m.Mean))
+	for i := range z {
+		z[i] = optim.RandNorm()
+	}
+
+	pos := make([]float64, len(m.Mean))
+	for i := range pos {
+		pos[i] = m.Mean[i]
+		for j := 0; j <= i; j++ {
+			pos[i] += m.chol[i][j] * z[j]
+		}
+	}
+	return pos
+}
+
+// cholesky returns the lower triangular factor l where a = l*l^T.  Non
+// positive pivots (from a numerically singular covariance) are clamped to
+// zero so sampling degrades gracefully instead of failing.
+func cholesky(a [][]float64) [][]float64 {
+	n := len(a)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			sum := a[i][j]
+			for k := 0; k < j; k++ {
+				sum -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				l[i][i] = math.Sqrt(math.Max(sum, 0))
+			} else if l[j][j] > 0 {
+				l[i][j] = sum / l[j][j]
+			}
+		}
+	}
+	return l
+}
@@ -0,0 +1,86 @@
+package eda
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+	"github.com/rwcarlsen/optim/selection"
+)
+
+func TestUMDA(t *testing.T) {
+	fn := bench.Ackley{}
+	bench.Benchmark(t, fn, solver(fn), 1, 800)
+}
+
+func TestEMNA(t *testing.T) {
+	fn := bench.Ackley{}
+	bench.Benchmark(t, fn, solver(fn, Full), 1, 800)
+}
+
+func TestRankSelect(t *testing.T) {
+	fn := bench.Ackley{}
+	bench.Benchmark(t, fn, solver(fn, Select(selection.Rank{Pressure: 2})), 1, 1100)
+}
+
+// solver returns a factory for EDA solvers with a population of 50
+// minimizing fn.
+func solver(fn bench.Func, opts ...Option) func() *optim.Solver {
+	return func() *optim.Solver {
+		low, up := fn.Bounds()
+		return &optim.Solver{
+			Method:  New(optim.RandPop(50, low, up), opts...),
+			Obj:     optim.Func(fn.Eval),
+			Mesh:    &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up},
+			MaxEval: 20000,
+		}
+	}
+}
+
+func TestSinglePoint(t *testing.T) {
+	m := New([]*optim.Point{{Pos: []float64{1, 2}, Val: math.Inf(1)}})
+	obj := optim.Func(func(x []float64) float64 { return x[0]*x[0] + x[1]*x[1] })
+	for i := 0; i < 3; i++ {
+		if _, _, err := m.Iterate(obj, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCholesky(t *testing.T) {
+	a := [][]float64{{4, 2, 0}, {2, 5, 3}, {0, 3, 10}}
+	l := cholesky(a)
+	for i := range a {
+		for j := range a {
+			sum := 0.0
+			for k := range a {
+				sum += l[i][k] * l[j][k]
+			}
+			if math.Abs(sum-a[i][j]) > 1e-12 {
+				t.Errorf("(l*l^T)[%v][%v]: want %v, got %v", i, j, a[i][j], sum)
+			}
+		}
+	}
+}
+
+func TestFitUMDA(t *testing.T) {
+	pts := []*optim.Point{
+		{Pos: []float64{0, 0}},
+		{Pos: []float64{2, 2}},
+	}
+	m := New(pts)
+	m.fit(pts)
+	if m.Cov[0][1] != 0 || m.Cov[1][0] != 0 {
+		t.Errorf("UMDA model has off-diagonal covariance: %v", m.Cov)
+	}
+	if m.Cov[0][0] != 1 || m.Cov[1][1] != 1 {
+		t.Errorf("bad variances: want 1, got %v", m.Cov)
+	}
+
+	m = New(pts, Full)
+	m.fit(pts)
+	if m.Cov[0][1] != 1 {
+		t.Errorf("EMNA covariance: want 1, got %v", m.Cov[0][1])
+	}
+}
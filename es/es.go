@@ -0,0 +1,215 @@
+// Package es provides a classic self-adaptive evolution strategy with
+// per-dimension step sizes and both (mu+lambda) and (mu,lambda) selection
+// as described in:
+//
+//	Beyer, Hans-Georg, and Hans-Paul Schwefel. "Evolution strategies - A
+//	comprehensive introduction." Natural Computing 1.1 (2002): 3-52.
+//
+// Each individual carries its own mutation step sizes which are mutated
+// log-normally before being used to mutate the individual's position, so
+// step sizes adapt along with the population.
+package es
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/crossover"
+	"github.com/rwcarlsen/optim/selection"
+)
+
+// Individual is a population member with its own per-dimension mutation
+// step sizes.
+type Individual struct {
+	*optim.Point
+	Sigma []float64
+}
+
+type Option func(*Method)
+
+// Lambda sets the number of offspring generated each iteration.
+func Lambda(n int) Option { return func(m *Method) { m.Lambda = n } }
+
+// Plus sets the method to select the next parents from both the current
+// parents and offspring - i.e. (mu+lambda) selection.  This makes the
+// method elitist.
+func Plus(m *Method) { m.Plus = true }
+
+// Comma sets the method to select the next parents from only the offspring
+// - i.e. (mu,lambda) selection.  This is the default and helps escape local
+// optima and mis-adapted step sizes.
+func Comma(m *Method) { m.Plus = false }
+
+// MinSigma sets the lower limit for mutation step sizes.
+func MinSigma(s float64) Option { return func(m *Method) { m.MinSigma = s } }
+
+// Parents sets the operator that picks the two parents of each offspring
+// (e.g. selection.Tournament).  By default parents are picked uniformly at
+// random.
+func Parents(s selection.Selector) Option { return func(m *Method) { m.Parents = s } }
+
+// Crossover sets the operator used to recombine parent positions in place
+// of discrete recombination - e.g. a crossover.Bounded SBX.  The first of
+// the two children is used.
+func Crossover(x crossover.Crossover) Option { return func(m *Method) { m.Crossover = x } }
+
+func Evaler(e optim.Evaler) Option { return func(m *Method) { m.Evaler = e } }
+
+type Method struct {
+	// Pop holds the current mu parents.
+	Pop []*Individual
+	// Lambda is the number of offspring generated each iteration.  For
+	// comma selection, Lambda must be at least len(Pop).
+	Lambda int
+	Plus   bool
+	// Tau and TauPrime are the per-dimension and global learning rates for
+	// step size mutation.
+	Tau, TauPrime float64
+	MinSigma      float64
+	// Parents, if non-nil, picks the parents of each offspring.
+	Parents selection.Selector
+	// Crossover, if non-nil, recombines parent positions.
+	Crossover crossover.Crossover
+	optim.Evaler
+	best *optim.Point
+}
+
+// New creates an evolution strategy with parents positioned at the given
+// points each starting with step sizes sigma0.  Lambda defaults to
+// 7*len(points) which is the commonly recommended ratio.
+func New(points []*optim.Point, sigma0 []float64, opts ...Option) *Method {
+	pop := make([]*Individual, len(points))
+	for i, p := range points {
+		pop[i] = &Individual{Point: p, Sigma: append([]float64{}, sigma0...)}
+	}
+
+	n := float64(len(sigma0))
+	m := &Method{
+		Pop:      pop,
+		Lambda:   7 * len(points),
+		Tau:      1 / math.Sqrt(2*math.Sqrt(n)),
+		TauPrime: 1 / math.Sqrt(2*n),
+		Evaler:   optim.SerialEvaler{},
+		best:     &optim.Point{Val: math.Inf(1)},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// NewRand creates an evolution strategy with mu parents uniformly
+// distributed in the box-bounds described by low and up with initial step
+// sizes of a third of the bounded range in each dimension.
+func NewRand(mu int, low, up []float64, opts ...Option) *Method {
+	sigma0 := optim.Bounds{Low: low, Up: up}.Widths()
+	for i := range sigma0 {
+		sigma0[i] /= 3
+	}
+	return New(optim.RandPop(mu, low, up), sigma0, opts...)
+}
+
+func (m *Method) AddPoint(p *optim.Point) {
+	if p.Val < m.best.Val {
+		m.best = p
+	}
+}
+
+func (m *Method) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Point, n int, err error) {
+	offspring := make([]*Individual, m.Lambda)
+	points := make([]*optim.Point, m.Lambda)
+	for k := range offspring {
+		offspring[k] = m.mutate(m.recombine())
+		if mesh != nil {
+			offspring[k].Pos = mesh.Nearest(offspring[k].Pos)
+		}
+		points[k] = offspring[k].Point
+	}
+
+	results, n, err := optim.DedupEvaler{Evaler: m.Evaler}.Eval(obj, points...)
+	if len(results) < len(points) {
+		// drop offspring that were not evaluated
+		evaled := map[*optim.Point]bool{}
+		for _, p := range results {
+			evaled[p] = true
+		}
+		kept := offspring[:0]
+		for _, ind := range offspring {
+			if evaled[ind.Point] {
+				kept = append(kept, ind)
+			}
+		}
+		offspring = kept
+	}
+
+	pool := offspring
+	if m.Plus || len(offspring) < len(m.Pop) {
+		pool = append(pool, m.Pop...)
+	}
+	sort.Sort(byval(pool))
+	m.Pop = append([]*Individual{}, pool[:len(m.Pop)]...)
+
+	if m.Pop[0].Val < m.best.Val {
+		m.best = m.Pop[0].Point.Clone()
+	}
+	return m.best, n, err
+}
+
+// parents picks two parents using m.Parents or uniformly at random.
+func (m *Method) parents() (p1, p2 *Individual) {
+	if m.Parents == nil {
+		return m.Pop[optim.Rand.Intn(len(m.Pop))], m.Pop[optim.Rand.Intn(len(m.Pop))]
+	}
+	inds := make(map[*optim.Point]*Individual, len(m.Pop))
+	pts := make([]*optim.Point, len(m.Pop))
+	for i, ind := range m.Pop {
+		pts[i] = ind.Point
+		inds[ind.Point] = ind
+	}
+	picks := m.Parents.Select(pts, 2)
+	return inds[picks[0]], inds[picks[1]]
+}
+
+// recombine creates a new individual from two random parents using
+// discrete recombination of positions and intermediate recombination of
+// step sizes.
+func (m *Method) recombine() *Individual {
+	p1, p2 := m.parents()
+	child := &Individual{
+		Point: &optim.Point{Pos: make([]float64, p1.Len()), Val: math.Inf(1)},
+		Sigma: make([]float64, len(p1.Sigma)),
+	}
+	if m.Crossover != nil {
+		child.Pos, _ = m.Crossover.Cross(p1.Pos, p2.Pos)
+	} else {
+		for i := range child.Pos {
+			child.Pos[i] = p1.Pos[i]
+			if optim.Rand.Intn(2) == 0 {
+				child.Pos[i] = p2.Pos[i]
+			}
+		}
+	}
+	for i := range child.Sigma {
+		child.Sigma[i] = (p1.Sigma[i] + p2.Sigma[i]) / 2
+	}
+	return child
+}
+
+// mutate log-normally mutates ind's step sizes and then uses them to
+// mutate its position.
+func (m *Method) mutate(ind *Individual) *Individual {
+	global := m.TauPrime * optim.RandNorm()
+	for i := range ind.Sigma {
+		ind.Sigma[i] *= math.Exp(global + m.Tau*optim.RandNorm())
+		ind.Sigma[i] = math.Max(ind.Sigma[i], m.MinSigma)
+		ind.Pos[i] += ind.Sigma[i] * optim.RandNorm()
+	}
+	return ind
+}
+
+type byval []*Individual
+
+func (b byval) Less(i, j int) bool { return b[i].Val < b[j].Val }
+func (b byval) Len() int           { return len(b) }
+func (b byval) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
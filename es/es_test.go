@@ -0,0 +1,67 @@
+package es
+
+import (
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+	"github.com/rwcarlsen/optim/crossover"
+	"github.com/rwcarlsen/optim/selection"
+)
+
+func TestComma(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 5}
+	bench.Benchmark(t, fn, solver(fn, Comma), 1, 1500)
+}
+
+func TestPlus(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 5}
+	bench.Benchmark(t, fn, solver(fn, Plus), 1, 1600)
+}
+
+func TestTournamentParents(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 5}
+	bench.Benchmark(t, fn, solver(fn, Comma, Parents(selection.Tournament{Size: 2})), 1, 1200)
+}
+
+func TestSBXCrossover(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 5}
+	x := crossover.Bounded{
+		Crossover: crossover.SBX{Eta: 15},
+		Bounds:    optim.Bounds{Low: esLow, Up: esUp},
+		Repair:    crossover.Reflect,
+	}
+	bench.Benchmark(t, fn, solver(fn, Plus, Crossover(x)), 1, 1500)
+}
+
+// esLow and esUp bound the benchmark problems more tightly than their usual
+// bounds.
+var esLow, esUp = []float64{-5, -5, -5, -5, -5}, []float64{5, 5, 5, 5, 5}
+
+// solver returns a factory for evolution strategy solvers with 10 parents
+// minimizing fn within esLow and esUp.
+func solver(fn bench.Func, opts ...Option) func() *optim.Solver {
+	return func() *optim.Solver {
+		return &optim.Solver{
+			Method:  NewRand(10, esLow, esUp, opts...),
+			Obj:     optim.Func(fn.Eval),
+			Mesh:    &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: esLow, Upper: esUp},
+			MaxEval: 50000,
+		}
+	}
+}
+
+func TestPopSize(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 5}
+	for _, opt := range []Option{Comma, Plus} {
+		m := NewRand(10, esLow, esUp, opt)
+		for i := 0; i < 10; i++ {
+			if _, _, err := m.Iterate(optim.Func(fn.Eval), nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(m.Pop) != 10 {
+			t.Errorf("population size changed: want 10, got %v", len(m.Pop))
+		}
+	}
+}
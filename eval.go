@@ -0,0 +1,326 @@
+package optim
+
+import (
+	"crypto/sha1"
+	"database/sql"
+	"math"
+	"sync"
+	"time"
+)
+
+// DedupEvaler wraps an Evaler and evaluates points within a single Eval
+// call that are identical (hash-equal) only once, copying the result to all
+// duplicates.  Unlike the other evalers, the results include every passed in
+// point whose position was evaluated - duplicates included.  This is useful
+// when points are projected onto a coarse mesh and many land on the same
+// grid point.
+type DedupEvaler struct {
+	Evaler
+}
+
+func (ev DedupEvaler) Eval(obj Objectiver, points ...*Point) (results []*Point, n int, err error) {
+	obj = RunPreHooks(obj, points)
+	uniq := uniqof(points)
+	dups := make(map[*Point][]*Point, len(uniq))
+	byhash := make(map[[sha1.Size]byte]*Point, len(uniq))
+	for _, p := range uniq {
+		byhash[p.Hash()] = p
+	}
+	for _, p := range points {
+		rep := byhash[p.Hash()]
+		dups[rep] = append(dups[rep], p)
+	}
+
+	evaled, n, err := ev.Evaler.Eval(obj, uniq...)
+	results = make([]*Point, 0, len(points))
+	for _, rep := range evaled {
+		for _, p := range dups[rep] {
+			p.Val = rep.Val
+			results = append(results, p)
+		}
+	}
+	return results, n, err
+}
+
+// NearEvaler wraps an Evaler and skips evaluating points within Eps of a
+// previously evaluated point (or of another point in the same batch),
+// assigning them the nearby point's value instead.  Distances are scaled
+// per dimension by History.Scale if set - e.g. to the widths of the
+// variable bounds.  This stops methods from spending evaluations on
+// near-identical positions late in convergence.  Like DedupEvaler, the
+// results include skipped points.  Points whose evaluation fails (i.e.
+// with a value of positive infinity) are not remembered.
+type NearEvaler struct {
+	Evaler
+	Eps float64
+	// History holds previously evaluated points.  It is created on first
+	// use if nil and may be pre-populated to warm-start.
+	History *History
+	// Skipped counts the points that were not evaluated.
+	Skipped int
+	// mu guards History and Skipped across concurrent Eval calls.
+	mu sync.Mutex
+}
+
+func (ev *NearEvaler) Eval(obj Objectiver, points ...*Point) (results []*Point, n int, err error) {
+	obj = RunPreHooks(obj, points)
+	ev.mu.Lock()
+	if ev.History == nil {
+		ev.History = &History{}
+	}
+	hist := ev.History
+
+	batch := &History{Scale: hist.Scale, Coords: hist.Coords}
+	followers := map[*Point][]*Point{}
+	var neweval []*Point
+	for _, p := range uniqof(points) {
+		if nn := hist.Nearest(p.Pos, 1); len(nn) > 0 && hist.Dist(p.Pos, nn[0].Pos) <= ev.Eps {
+			p.Val = nn[0].Val
+			results = append(results, p)
+			continue
+		}
+		if nn := batch.Nearest(p.Pos, 1); len(nn) > 0 && batch.Dist(p.Pos, nn[0].Pos) <= ev.Eps {
+			followers[nn[0]] = append(followers[nn[0]], p)
+			continue
+		}
+		batch.Add(p)
+		neweval = append(neweval, p)
+	}
+	ev.mu.Unlock()
+
+	evaled, n, err := ev.Evaler.Eval(obj, neweval...)
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	for _, p := range evaled {
+		if p.Val != math.Inf(1) {
+			hist.Add(p.Clone())
+		}
+		results = append(results, p)
+		for _, f := range followers[p] {
+			f.Val = p.Val
+			results = append(results, f)
+		}
+	}
+	ev.Skipped += len(results) - len(evaled)
+	return results, n, err
+}
+
+// RetryEvaler wraps an Evaler and retries each failed objective evaluation
+// up to Retries additional times before giving up.  An evaluation is
+// considered failed if it returns an error along with a value of positive
+// infinity - errors returned with a valid value are passed through without
+// retrying.  The reported evaluation count includes all retries.
+type RetryEvaler struct {
+	Evaler
+	Retries int
+	// Backoff is the time to wait before the first retry of an evaluation.
+	Backoff time.Duration
+	// BackoffMult, if greater than zero, multiplies the wait time after each
+	// successive retry of an evaluation.
+	BackoffMult float64
+}
+
+func (ev RetryEvaler) Eval(obj Objectiver, points ...*Point) (results []*Point, n int, err error) {
+	robj := &retryObj{Objectiver: RunPreHooks(obj, points), ev: ev}
+	results, _, err = ev.Evaler.Eval(robj, points...)
+	return results, robj.ncalls, err
+}
+
+type retryObj struct {
+	Objectiver
+	ev     RetryEvaler
+	mu     sync.Mutex
+	ncalls int
+}
+
+func (o *retryObj) Objective(v []float64) (val float64, err error) {
+	wait := o.ev.Backoff
+	for i := 0; ; i++ {
+		val, err = o.Objectiver.Objective(v)
+		o.mu.Lock()
+		o.ncalls++
+		o.mu.Unlock()
+
+		if err == nil || !math.IsInf(val, 1) || i >= o.ev.Retries {
+			return val, err
+		}
+
+		time.Sleep(wait)
+		if o.ev.BackoffMult > 0 {
+			wait = time.Duration(float64(wait) * o.ev.BackoffMult)
+		}
+	}
+}
+
+// RateEvaler wraps an Evaler and limits objective evaluations to at most
+// Rate per second using a token bucket that holds up to Burst tokens
+// (minimum 1).  This is useful for objectives that call metered external
+// services.  The limit is shared across all Eval calls on the same
+// RateEvaler.  A Rate of zero or less means evaluations are unlimited.
+type RateEvaler struct {
+	Evaler
+	Rate   float64
+	Burst  int
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func (ev *RateEvaler) Eval(obj Objectiver, points ...*Point) (results []*Point, n int, err error) {
+	return ev.Evaler.Eval(&rateObj{Objectiver: RunPreHooks(obj, points), ev: ev}, points...)
+}
+
+// wait blocks until an evaluation is permitted by the rate limit.
+func (ev *RateEvaler) wait() {
+	if ev.Rate <= 0 {
+		return
+	}
+	burst := math.Max(1, float64(ev.Burst))
+
+	ev.mu.Lock()
+	now := time.Now()
+	if ev.last.IsZero() {
+		ev.tokens = burst
+	} else {
+		ev.tokens = math.Min(burst, ev.tokens+now.Sub(ev.last).Seconds()*ev.Rate)
+	}
+	ev.last = now
+
+	// reserve a token - a negative balance is the wait for other callers
+	// that have already reserved
+	ev.tokens--
+	var d time.Duration
+	if ev.tokens < 0 {
+		d = time.Duration(-ev.tokens / ev.Rate * float64(time.Second))
+	}
+	ev.mu.Unlock()
+
+	time.Sleep(d)
+}
+
+type rateObj struct {
+	Objectiver
+	ev *RateEvaler
+}
+
+func (o *rateObj) Objective(v []float64) (float64, error) {
+	o.ev.wait()
+	return o.Objectiver.Objective(v)
+}
+
+// TblEvals is the name of the sql database table that contains evaluation
+// provenance records written by RecordEvals.
+const TblEvals = "evals"
+
+// EvalRecord holds provenance information for a single objective
+// evaluation.
+type EvalRecord struct {
+	Pos []float64
+	Val float64
+	Err error
+	// Start is the wall time at which the evaluation started.
+	Start time.Time
+	// Elapsed is the wall time the evaluation took.
+	Elapsed time.Duration
+	// Worker identifies the concurrent evaluation slot used - i.e. the
+	// lowest index not in use by another in-flight evaluation.  Serial
+	// evaluations always use worker 0.
+	Worker int
+	// Attempt is the number of times the position has been evaluated by
+	// the evaler including this evaluation.  Retried evaluations have
+	// attempts greater than one.
+	Attempt int
+}
+
+// ProvenanceEvaler wraps an Evaler and records timing and provenance for
+// every objective evaluation in Records.  This is useful for diagnosing
+// load imbalance between parallel workers and slow regions of parameter
+// space.  Wrapping a RetryEvaler records every retry attempt.
+type ProvenanceEvaler struct {
+	Evaler
+	Records  []EvalRecord
+	mu       sync.Mutex
+	busy     []bool
+	attempts map[[sha1.Size]byte]int
+}
+
+func (ev *ProvenanceEvaler) Eval(obj Objectiver, points ...*Point) (results []*Point, n int, err error) {
+	return ev.Evaler.Eval(&provObj{Objectiver: RunPreHooks(obj, points), ev: ev}, points...)
+}
+
+// acquire reserves the lowest free worker slot and counts an attempt for
+// pos.
+func (ev *ProvenanceEvaler) acquire(pos []float64) (worker, attempt int) {
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	if ev.attempts == nil {
+		ev.attempts = map[[sha1.Size]byte]int{}
+	}
+	h := (&Point{Pos: pos}).Hash()
+	ev.attempts[h]++
+
+	for worker = range ev.busy {
+		if !ev.busy[worker] {
+			ev.busy[worker] = true
+			return worker, ev.attempts[h]
+		}
+	}
+	ev.busy = append(ev.busy, true)
+	return len(ev.busy) - 1, ev.attempts[h]
+}
+
+func (ev *ProvenanceEvaler) release(rec EvalRecord) {
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	ev.busy[rec.Worker] = false
+	ev.Records = append(ev.Records, rec)
+}
+
+type provObj struct {
+	Objectiver
+	ev *ProvenanceEvaler
+}
+
+func (o *provObj) Objective(v []float64) (float64, error) {
+	worker, attempt := o.ev.acquire(v)
+	start := time.Now()
+	val, err := o.Objectiver.Objective(v)
+	o.ev.release(EvalRecord{
+		Pos:     append([]float64{}, v...),
+		Val:     val,
+		Err:     err,
+		Start:   start,
+		Elapsed: time.Since(start),
+		Worker:  worker,
+		Attempt: attempt,
+	})
+	return val, err
+}
+
+// RecordEvals writes evaluation provenance records to the TblEvals table
+// with positions recorded via RecordPointPos.
+func RecordEvals(tx *sql.Tx, recs ...EvalRecord) error {
+	s := "CREATE TABLE IF NOT EXISTS " + TblEvals + " (posid BLOB,val REAL,start INTEGER,elapsed REAL,worker INTEGER,attempt INTEGER,err TEXT);"
+	if _, err := tx.Exec(s); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO " + TblEvals + " (posid,val,start,elapsed,worker,attempt,err) VALUES (?,?,?,?,?,?,?);")
+	if err != nil {
+		return err
+	}
+
+	pts := make([]*Point, len(recs))
+	for i, rec := range recs {
+		pts[i] = &Point{Pos: rec.Pos, Val: rec.Val}
+		errmsg := ""
+		if rec.Err != nil {
+			errmsg = rec.Err.Error()
+		}
+		_, err := stmt.Exec(pts[i].HashSlice(), rec.Val, rec.Start.UnixNano(), rec.Elapsed.Seconds(), rec.Worker, rec.Attempt, errmsg)
+		if err != nil {
+			return err
+		}
+	}
+	return RecordPointPos(tx, pts...)
+}
@@ -0,0 +1,285 @@
+package optim
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDedupEvaler(t *testing.T) {
+	tpoints := testpoints()
+	obj := &ObjTest{max: 100000}
+	ev := DedupEvaler{Evaler: SerialEvaler{}}
+
+	r, n, err := ev.Eval(obj, tpoints...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len(tpoints) - 1; n != want {
+		t.Errorf("duplicate was evaluated: want %v evals, got %v", want, n)
+	}
+	if len(r) != len(tpoints) {
+		t.Errorf("wrong number of results: want %v, got %v", len(tpoints), len(r))
+	}
+	for i, p := range tpoints {
+		want := 0.0
+		for _, v := range p.Pos {
+			want += v
+		}
+		if p.Val != want {
+			t.Errorf("point %v (%v): want val %v, got %v", i, p.Pos, want, p.Val)
+		}
+	}
+}
+
+func TestDedupEvalerErr(t *testing.T) {
+	tpoints := testpoints()
+	obj := &ObjTest{max: 2}
+	ev := DedupEvaler{Evaler: SerialEvaler{}}
+
+	// the first point and its duplicate succeed, the next point fails
+	r, n, err := ev.Eval(obj, tpoints...)
+	if err == nil {
+		t.Errorf("did not propagate error through return")
+	}
+	if n != 2 {
+		t.Errorf("wrong evaluation count: want 2, got %v", n)
+	}
+	if len(r) != 3 {
+		t.Errorf("wrong number of results: want 3, got %v", len(r))
+	}
+}
+
+// flakyObj fails the first Nfail evaluations of every point.
+type flakyObj struct {
+	Nfail int
+	tries map[float64]int
+	sync.Mutex
+}
+
+func (o *flakyObj) Objective(x []float64) (float64, error) {
+	o.Lock()
+	defer o.Unlock()
+	if o.tries == nil {
+		o.tries = map[float64]int{}
+	}
+	o.tries[x[0]]++
+	if o.tries[x[0]] <= o.Nfail {
+		return math.Inf(1), errors.New("transient failure")
+	}
+	return x[0], nil
+}
+
+func TestRetryEvaler(t *testing.T) {
+	pts := []*Point{{Pos: []float64{1}}, {Pos: []float64{2}}}
+	obj := &flakyObj{Nfail: 2}
+	ev := RetryEvaler{Evaler: ParallelEvaler{}, Retries: 2, Backoff: time.Millisecond, BackoffMult: 2}
+
+	_, n, err := ev.Eval(obj, pts...)
+	if err != nil {
+		t.Errorf("unexpected error after retries: %v", err)
+	}
+	if want := 6; n != want {
+		t.Errorf("wrong evaluation count: want %v, got %v", want, n)
+	}
+	for _, p := range pts {
+		if p.Val != p.Pos[0] {
+			t.Errorf("point %v: want val %v, got %v", p.Pos, p.Pos[0], p.Val)
+		}
+	}
+
+	obj = &flakyObj{Nfail: 3}
+	_, n, err = ev.Eval(obj, pts[0])
+	if err == nil {
+		t.Errorf("error not returned after exhausting retries")
+	}
+	if n != 3 {
+		t.Errorf("wrong evaluation count: want 3, got %v", n)
+	}
+}
+
+func TestRetryEvalerPassthrough(t *testing.T) {
+	// errors accompanying valid values must not be retried
+	obj := &ObjTest{max: 1}
+	ev := RetryEvaler{Evaler: SerialEvaler{}, Retries: 5}
+	_, n, _ := ev.Eval(obj, &Point{Pos: []float64{1}})
+	if n != 6 {
+		t.Errorf("failed evaluation not retried: want 6 evals, got %v", n)
+	}
+
+	stop := Func(func(v []float64) float64 { return 1 })
+	_, n, _ = ev.Eval(errObj{stop}, &Point{Pos: []float64{1}})
+	if n != 1 {
+		t.Errorf("successful evaluation with error was retried: want 1 eval, got %v", n)
+	}
+}
+
+// errObj returns an error along with every valid objective value.
+type errObj struct{ Objectiver }
+
+func (o errObj) Objective(v []float64) (float64, error) {
+	val, _ := o.Objectiver.Objective(v)
+	return val, errors.New("found something")
+}
+
+func TestRateEvaler(t *testing.T) {
+	obj := &ObjTest{max: 100000}
+	ev := &RateEvaler{Evaler: ParallelEvaler{}, Rate: 200, Burst: 2}
+
+	start := time.Now()
+	_, n, err := ev.Eval(obj, testpoints()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, n2, err := ev.Eval(obj, &Point{Pos: []float64{42}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+
+	// the first Burst evaluations are free and the rest wait 1/Rate each
+	want := time.Duration(float64(n+n2-ev.Burst) / ev.Rate * float64(time.Second))
+	if elapsed < want*9/10 {
+		t.Errorf("rate limit not enforced: %v evals took %v, want >= %v", n+n2, elapsed, want)
+	}
+}
+
+func TestRateEvalerUnlimited(t *testing.T) {
+	obj := &ObjTest{max: 100000}
+	for _, rate := range []float64{0, -1} {
+		ev := &RateEvaler{Evaler: SerialEvaler{}, Rate: rate}
+		start := time.Now()
+		_, n, err := ev.Eval(obj, testpoints()...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("rate %v should be unlimited but %v evals took %v", rate, n, elapsed)
+		}
+	}
+}
+
+// slowObj sleeps for a duration proportional to the first coordinate.
+type slowObj struct{}
+
+func (_ slowObj) Objective(x []float64) (float64, error) {
+	time.Sleep(time.Duration(x[0]) * time.Millisecond)
+	return x[0], nil
+}
+
+func TestProvenanceEvaler(t *testing.T) {
+	pts := []*Point{{Pos: []float64{5}}, {Pos: []float64{10}}, {Pos: []float64{15}}}
+	ev := &ProvenanceEvaler{Evaler: ParallelEvaler{NConcurrent: 2}}
+	if _, _, err := ev.Eval(slowObj{}, pts...); err != nil {
+		t.Fatal(err)
+	}
+	if len(ev.Records) != len(pts) {
+		t.Fatalf("wrong number of records: want %v, got %v", len(pts), len(ev.Records))
+	}
+
+	workers := map[int]bool{}
+	for _, rec := range ev.Records {
+		workers[rec.Worker] = true
+		if want := time.Duration(rec.Pos[0]) * time.Millisecond; rec.Elapsed < want {
+			t.Errorf("point %v: elapsed %v shorter than sleep %v", rec.Pos, rec.Elapsed, want)
+		}
+		if rec.Attempt != 1 {
+			t.Errorf("point %v: want attempt 1, got %v", rec.Pos, rec.Attempt)
+		}
+	}
+	if len(workers) != 2 {
+		t.Errorf("wrong number of workers: want 2, got %v", len(workers))
+	}
+
+	// retries of a failing point are recorded as separate attempts
+	ev = &ProvenanceEvaler{Evaler: RetryEvaler{Evaler: SerialEvaler{}, Retries: 2}}
+	ev.Eval(&flakyObj{Nfail: 2}, &Point{Pos: []float64{1}})
+	if len(ev.Records) != 3 || ev.Records[2].Attempt != 3 || ev.Records[0].Err == nil {
+		t.Errorf("retry attempts not recorded correctly: %+v", ev.Records)
+	}
+}
+
+func TestRecordEvals(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ev := &ProvenanceEvaler{Evaler: SerialEvaler{}}
+	ev.Eval(&ObjTest{max: 3}, testpoints()...)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordEvals(tx, ev.Records...); err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+
+	var count, nerr int
+	err = db.QueryRow("SELECT COUNT(*),SUM(err != '') FROM "+TblEvals).Scan(&count, &nerr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != len(ev.Records) || nerr != 1 {
+		t.Errorf("wrong record counts: want %v rows and 1 error, got %v and %v", len(ev.Records), count, nerr)
+	}
+}
+
+func TestNearEvaler(t *testing.T) {
+	obj := Func(func(v []float64) float64 { return v[0] + v[1] })
+	ev := &NearEvaler{Evaler: SerialEvaler{}, Eps: 0.1}
+	ev.History = &History{Scale: []float64{1, 10}}
+
+	pts := []*Point{
+		{Pos: []float64{0, 0}},
+		{Pos: []float64{0.05, 0}}, // near first point in batch
+		{Pos: []float64{1, 0}},
+		{Pos: []float64{0, 0.5}}, // only 0.05 away after scaling
+	}
+	results, n, err := ev.Eval(obj, pts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(results) != 4 || ev.Skipped != 2 {
+		t.Errorf("want 2 evals, 4 results, and 2 skipped, got %v, %v, and %v", n, len(results), ev.Skipped)
+	}
+	for i, want := range []float64{0, 0, 1, 0} {
+		if pts[i].Val != want {
+			t.Errorf("point %v: want val %v, got %v", pts[i].Pos, want, pts[i].Val)
+		}
+	}
+
+	// later batches reuse remembered points
+	p := &Point{Pos: []float64{1.01, 0}}
+	if _, n, _ := ev.Eval(obj, p); n != 0 || p.Val != 1 {
+		t.Errorf("want remembered value 1 with no evals, got %v after %v evals", p.Val, n)
+	}
+	if ev.History.Len() != 2 {
+		t.Errorf("want 2 remembered points, got %v", ev.History.Len())
+	}
+}
+
+func TestNearEvalerConcurrent(t *testing.T) {
+	obj := Func(func(v []float64) float64 { return v[0] })
+	ev := &NearEvaler{Evaler: SerialEvaler{}, Eps: 1e-3}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				ev.Eval(obj, &Point{Pos: []float64{float64(i*50 + j)}})
+			}
+		}(i)
+	}
+	wg.Wait()
+	if ev.History.Len() != 400 {
+		t.Errorf("want 400 remembered points, got %v", ev.History.Len())
+	}
+}
@@ -0,0 +1,103 @@
+package optim
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrExchangeTimeout is returned by FileExchange for points whose results
+// were not written before the timeout.
+var ErrExchangeTimeout = errors.New("optim: timed out waiting for result file")
+
+// FileExchange is an Objectiver that couples to external worker processes
+// through files in a shared directory - a common arrangement on HPC
+// clusters where workers are scheduler jobs that cannot be reached directly.
+// Use it with a ParallelEvaler so all points of an iteration are pending at
+// once.
+//
+// For each evaluation, a file named <id>.in containing the point's
+// coordinates separated by spaces on a single line is written to Dir.
+// Workers should claim a point by renaming its .in file (e.g. to
+// <id>.claimed) and report the objective value by writing it as text to
+// <id>.out or report failure by writing an error message to <id>.err.
+// Result files should be written under a temporary name and renamed into
+// place so they are never read partially written.  FileExchange removes
+// the point's files once its result is collected.  Values may be written
+// as "inf" or "+Inf" for infeasible points.
+type FileExchange struct {
+	Dir string
+	// Prefix is prepended to point ids.  Ids also include the process id
+	// so several optimizers can share a directory.
+	Prefix string
+	// Poll is the interval between checks for result files.  It defaults
+	// to one second.
+	Poll time.Duration
+	// Timeout, if non-zero, is the longest to wait for a point's result.
+	// Timed out points that are still unclaimed are withdrawn.
+	Timeout time.Duration
+	count   int64
+}
+
+// Objective writes v to the exchange directory and waits for its result.
+func (fx *FileExchange) Objective(v []float64) (float64, error) {
+	id := fmt.Sprintf("%v%d-%d", fx.Prefix, os.Getpid(), atomic.AddInt64(&fx.count, 1))
+	base := filepath.Join(fx.Dir, id)
+
+	fields := make([]string, len(v))
+	for i, x := range v {
+		fields[i] = strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	if err := writeAtomic(base+".in", strings.Join(fields, " ")+"\n"); err != nil {
+		return math.Inf(1), err
+	}
+
+	poll := fx.Poll
+	if poll == 0 {
+		poll = time.Second
+	}
+	var deadline time.Time
+	if fx.Timeout > 0 {
+		deadline = time.Now().Add(fx.Timeout)
+	}
+
+	for {
+		if data, err := ioutil.ReadFile(base + ".out"); err == nil {
+			os.Remove(base + ".out")
+			os.Remove(base + ".claimed")
+			val, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+			if err != nil {
+				return math.Inf(1), fmt.Errorf("optim: invalid result for point %v: %v", id, err)
+			}
+			return val, nil
+		}
+		if data, err := ioutil.ReadFile(base + ".err"); err == nil {
+			os.Remove(base + ".err")
+			os.Remove(base + ".claimed")
+			return math.Inf(1), fmt.Errorf("optim: point %v failed: %v", id, strings.TrimSpace(string(data)))
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			// only an unclaimed point is withdrawn - a worker still
+			// processing a claimed one keeps its files
+			os.Remove(base + ".in")
+			return math.Inf(1), ErrExchangeTimeout
+		}
+		time.Sleep(poll)
+	}
+}
+
+// writeAtomic writes data to a temporary file and renames it to path.
+func writeAtomic(path, data string) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(data), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
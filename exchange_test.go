@@ -0,0 +1,132 @@
+package optim
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exchangeWorker claims and evaluates points in dir until done is closed.
+func exchangeWorker(dir string, done chan bool) {
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		ins, _ := filepath.Glob(filepath.Join(dir, "*.in"))
+		for _, in := range ins {
+			base := strings.TrimSuffix(in, ".in")
+			if os.Rename(in, base+".claimed") != nil {
+				continue // claimed by another worker
+			}
+			data, _ := ioutil.ReadFile(base + ".claimed")
+			tot := 0.0
+			for _, f := range strings.Fields(string(data)) {
+				x, _ := strconv.ParseFloat(f, 64)
+				tot += x * x
+			}
+			if tot > 100 {
+				ioutil.WriteFile(base+".err.tmp", []byte("too big"), 0644)
+				os.Rename(base+".err.tmp", base+".err")
+				continue
+			}
+			ioutil.WriteFile(base+".out.tmp", []byte(fmt.Sprint(tot)), 0644)
+			os.Rename(base+".out.tmp", base+".out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFileExchange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exchange")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	done := make(chan bool)
+	defer close(done)
+	for i := 0; i < 3; i++ {
+		go exchangeWorker(dir, done)
+	}
+
+	fx := &FileExchange{Dir: dir, Poll: time.Millisecond}
+	pts := []*Point{
+		{Pos: []float64{1, 2}},
+		{Pos: []float64{3, 0.5}},
+		{Pos: []float64{0, 0}},
+		{Pos: []float64{10, 10}},
+	}
+	results, n, err := ParallelEvaler{}.Eval(fx, pts...)
+	if err == nil || !strings.Contains(err.Error(), "too big") {
+		t.Errorf("want worker error reported, got %v", err)
+	}
+	if n != 4 || len(results) != 4 {
+		t.Errorf("want 4 evals, got %v", n)
+	}
+	want := []float64{5, 9.25, 0, math.Inf(1)}
+	for i, p := range pts {
+		if p.Val != want[i] {
+			t.Errorf("f%v: want %v, got %v", p.Pos, want[i], p.Val)
+		}
+	}
+
+	if left, _ := ioutil.ReadDir(dir); len(left) != 0 {
+		t.Errorf("exchange files not cleaned up: %v left", len(left))
+	}
+}
+
+func TestFileExchangeTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exchange")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fx := &FileExchange{Dir: dir, Poll: time.Millisecond, Timeout: 20 * time.Millisecond}
+	val, err := fx.Objective([]float64{1})
+	if err != ErrExchangeTimeout || !math.IsInf(val, 1) {
+		t.Errorf("want timeout, got %v (err=%v)", val, err)
+	}
+	if left, _ := ioutil.ReadDir(dir); len(left) != 0 {
+		t.Errorf("unclaimed point not withdrawn after timeout")
+	}
+}
+
+func TestFileExchangeTimeoutClaimed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exchange")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a slow worker claims the point but doesn't finish before the timeout
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for {
+			ins, _ := filepath.Glob(filepath.Join(dir, "*.in"))
+			if len(ins) > 0 {
+				os.Rename(ins[0], strings.TrimSuffix(ins[0], ".in")+".claimed")
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	fx := &FileExchange{Dir: dir, Poll: time.Millisecond, Timeout: 50 * time.Millisecond}
+	if _, err := fx.Objective([]float64{1}); err != ErrExchangeTimeout {
+		t.Errorf("want timeout, got %v", err)
+	}
+	<-done
+	if claimed, _ := filepath.Glob(filepath.Join(dir, "*.claimed")); len(claimed) != 1 {
+		t.Errorf("claimed point removed while its worker is still processing it")
+	}
+}
@@ -0,0 +1,26 @@
+package optim
+
+// MultiFidelityObjectiver is implemented by objectives that can be
+// evaluated at several fidelity levels - e.g. simulations with adjustable
+// mesh resolution.  Levels range from 0 (cheapest, least accurate) to
+// Fidelities()-1 (the true objective).  Use hyperband.FromLevels to allocate
+// budget among the levels with hyperband.
+type MultiFidelityObjectiver interface {
+	Fidelities() int
+	FidelityObjective(v []float64, level int) (float64, error)
+}
+
+// FidelityObjectiver evaluates a multi-fidelity objective at a fixed level.
+type FidelityObjectiver struct {
+	Obj   MultiFidelityObjectiver
+	Level int
+}
+
+func (o *FidelityObjectiver) Objective(v []float64) (float64, error) {
+	return o.Obj.FidelityObjective(v, o.Level)
+}
+
+// HighFidelity returns an objective evaluating obj at its highest level.
+func HighFidelity(obj MultiFidelityObjectiver) *FidelityObjectiver {
+	return &FidelityObjectiver{Obj: obj, Level: obj.Fidelities() - 1}
+}
@@ -0,0 +1,155 @@
+// Package gd provides first-order gradient descent methods for objectives
+// implementing optim.Gradienter.  Each iteration computes the gradient at
+// the current position, takes a single step according to the method's
+// update rule, and evaluates the new position.  Cheap differentiable
+// objectives will generally converge far faster this way than with
+// population-based methods.
+package gd
+
+import (
+	"errors"
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Rule computes a descent step from the gradient at the current position.
+type Rule interface {
+	// Step returns the change to apply to the current position given the
+	// gradient there.
+	Step(grad []float64) []float64
+}
+
+// Fixed is plain gradient descent with a fixed learning rate.
+type Fixed struct {
+	Rate float64
+}
+
+func (r Fixed) Step(grad []float64) []float64 {
+	step := make([]float64, len(grad))
+	for i, g := range grad {
+		step[i] = -r.Rate * g
+	}
+	return step
+}
+
+// Momentum is gradient descent with (heavy ball) momentum.  Beta is the
+// fraction of the previous step carried into the next.
+type Momentum struct {
+	Rate, Beta float64
+	v          []float64
+}
+
+func (r *Momentum) Step(grad []float64) []float64 {
+	if r.v == nil {
+		r.v = make([]float64, len(grad))
+	}
+	step := make([]float64, len(grad))
+	for i, g := range grad {
+		r.v[i] = r.Beta*r.v[i] - r.Rate*g
+		step[i] = r.v[i]
+	}
+	return step
+}
+
+// Adam is the adaptive moment estimation rule described in:
+//
+//	Kingma, Diederik P., and Jimmy Ba. "Adam: A method for stochastic
+//	optimization." arXiv preprint arXiv:1412.6980 (2014).
+type Adam struct {
+	Rate         float64
+	Beta1, Beta2 float64
+	Eps          float64
+	m, v         []float64
+	t            int
+}
+
+// NewAdam returns an Adam rule with the given learning rate and the
+// recommended defaults for the remaining parameters.
+func NewAdam(rate float64) *Adam {
+	return &Adam{Rate: rate, Beta1: 0.9, Beta2: 0.999, Eps: 1e-8}
+}
+
+func (r *Adam) Step(grad []float64) []float64 {
+	if r.m == nil {
+		r.m = make([]float64, len(grad))
+		r.v = make([]float64, len(grad))
+	}
+	r.t++
+	c1 := 1 - math.Pow(r.Beta1, float64(r.t))
+	c2 := 1 - math.Pow(r.Beta2, float64(r.t))
+
+	step := make([]float64, len(grad))
+	for i, g := range grad {
+		r.m[i] = r.Beta1*r.m[i] + (1-r.Beta1)*g
+		r.v[i] = r.Beta2*r.v[i] + (1-r.Beta2)*g*g
+		step[i] = -r.Rate * (r.m[i] / c1) / (math.Sqrt(r.v[i]/c2) + r.Eps)
+	}
+	return step
+}
+
+type Option func(*Method)
+
+// WithRule sets the update rule used to compute steps.
+func WithRule(r Rule) Option { return func(m *Method) { m.Rule = r } }
+
+type Method struct {
+	// Curr is the current position.
+	Curr *optim.Point
+	Rule Rule
+	best *optim.Point
+}
+
+// New creates a gradient descent method starting at x0.  The default rule
+// is Fixed with a learning rate of 0.01.
+func New(x0 []float64, opts ...Option) *Method {
+	m := &Method{
+		Curr: &optim.Point{Pos: append([]float64{}, x0...), Val: math.Inf(1)},
+		Rule: Fixed{Rate: 0.01},
+		best: &optim.Point{Val: math.Inf(1)},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// AddPoint moves the current position to p if p is better than the best
+// point found so far.
+func (m *Method) AddPoint(p *optim.Point) {
+	if p.Val < m.best.Val {
+		m.best = p
+		m.Curr = p.Clone()
+	}
+}
+
+// Iterate takes a single descent step.  obj must implement
+// optim.Gradienter.  If mesh is non-nil, the new position is projected
+// onto it which allows e.g. a BoxMesh to enforce bounds.
+func (m *Method) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Point, n int, err error) {
+	g, ok := obj.(optim.Gradienter)
+	if !ok {
+		return m.best, 0, errors.New("gd: objective does not implement optim.Gradienter")
+	}
+
+	grad, err := g.Gradient(m.Curr.Pos)
+	if err != nil {
+		return m.best, 0, err
+	}
+
+	step := m.Rule.Step(grad)
+	pos := make([]float64, len(m.Curr.Pos))
+	for i := range pos {
+		pos[i] = m.Curr.Pos[i] + step[i]
+	}
+	if mesh != nil {
+		pos = mesh.Nearest(pos)
+	}
+
+	m.Curr = &optim.Point{Pos: pos}
+	m.Curr.Val, err = obj.Objective(pos)
+	if m.Curr.Val < m.best.Val {
+		m.best = m.Curr.Clone()
+	}
+	return m.best, 1, err
+}
@@ -0,0 +1,68 @@
+package gd
+
+import (
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// quad is an ill-conditioned quadratic with its minimum at (1, -2).
+type quad struct{}
+
+func (quad) Objective(v []float64) (float64, error) {
+	return (v[0]-1)*(v[0]-1) + 10*(v[1]+2)*(v[1]+2), nil
+}
+
+func (quad) Gradient(v []float64) ([]float64, error) {
+	return []float64{2 * (v[0] - 1), 20 * (v[1] + 2)}, nil
+}
+
+func TestRules(t *testing.T) {
+	rules := map[string]Rule{
+		"fixed":    Fixed{Rate: 0.05},
+		"momentum": &Momentum{Rate: 0.02, Beta: 0.8},
+		"adam":     NewAdam(0.1),
+	}
+	for name, rule := range rules {
+		solv := &optim.Solver{
+			Method:  New([]float64{5, 5}, WithRule(rule)),
+			Obj:     quad{},
+			MaxIter: 2000,
+		}
+		for solv.Next() {
+			if solv.Best().Val < 1e-8 {
+				break
+			}
+		}
+		if err := solv.Err(); err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		t.Logf("%v: %v iters, best %v", name, solv.Niter(), solv.Best())
+		if solv.Best().Val > 1e-8 {
+			t.Errorf("%v: failed to converge: got %v", name, solv.Best())
+		}
+	}
+}
+
+func TestNotGradienter(t *testing.T) {
+	m := New([]float64{0})
+	_, _, err := m.Iterate(optim.Func(func(v []float64) float64 { return v[0] }), nil)
+	if err == nil {
+		t.Error("want error for objective without a gradient")
+	}
+}
+
+func TestBounds(t *testing.T) {
+	low, up := []float64{2, -10}, []float64{10, 10}
+	solv := &optim.Solver{
+		Method:  New([]float64{5, 5}, WithRule(Fixed{Rate: 0.04})),
+		Obj:     quad{},
+		Mesh:    &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up},
+		MaxIter: 1000,
+	}
+	for solv.Next() {
+	}
+	if x := solv.Best().Pos[0]; x < 2-1e-12 || x > 2+1e-6 {
+		t.Errorf("bounded minimum: want x=2, got %v", solv.Best())
+	}
+}
@@ -0,0 +1,105 @@
+package optim
+
+import (
+	"fmt"
+	"math"
+)
+
+// Gradienter is implemented by differentiable objectives that can compute
+// their own gradient.  Gradient returns the partial derivatives of the
+// objective with respect to each variable in v.
+type Gradienter interface {
+	Objectiver
+	Gradient(v []float64) ([]float64, error)
+}
+
+// NumGradient estimates the gradient of obj at v using central finite
+// differences.  The step in each dimension is h scaled by the magnitude of
+// the coordinate (or h if the coordinate is smaller than one).
+func NumGradient(obj Objectiver, v []float64, h float64) ([]float64, error) {
+	x := append([]float64{}, v...)
+	grad := make([]float64, len(v))
+	for i := range v {
+		step := h * math.Max(1, math.Abs(v[i]))
+		x[i] = v[i] + step
+		fp, err := obj.Objective(x)
+		if err != nil {
+			return nil, err
+		}
+		x[i] = v[i] - step
+		fm, err := obj.Objective(x)
+		if err != nil {
+			return nil, err
+		}
+		x[i] = v[i]
+		grad[i] = (fp - fm) / (2 * step)
+	}
+	return grad, nil
+}
+
+// GradientCheck compares an analytic gradient with a finite difference
+// estimate at a single position.
+type GradientCheck struct {
+	Pos      []float64
+	Analytic []float64
+	Numeric  []float64
+	// RelErr holds the per-dimension discrepancy |a-n|/max(|a|,|n|,1).
+	RelErr []float64
+}
+
+// Worst returns the dimension with the largest relative error.
+func (c *GradientCheck) Worst() (dim int, relerr float64) {
+	for i, e := range c.RelErr {
+		if e > relerr {
+			dim, relerr = i, e
+		}
+	}
+	return dim, relerr
+}
+
+func (c *GradientCheck) String() string {
+	dim, e := c.Worst()
+	return fmt.Sprintf("at %v: analytic %v, numeric %v (worst dim %v, rel err %.3g)", c.Pos, c.Analytic, c.Numeric, dim, e)
+}
+
+// CheckGradientAt compares g's gradient with central finite differences
+// (see NumGradient) at each of the given positions.  A step size h around
+// 1e-6 suits most smooth objectives.
+func CheckGradientAt(g Gradienter, h float64, positions ...[]float64) ([]*GradientCheck, error) {
+	var checks []*GradientCheck
+	for _, v := range positions {
+		analytic, err := g.Gradient(v)
+		if err != nil {
+			return checks, err
+		}
+		numeric, err := NumGradient(g, v, h)
+		if err != nil {
+			return checks, err
+		}
+		if len(analytic) != len(v) {
+			return checks, fmt.Errorf("gradient at %v has %v elements, want %v", v, len(analytic), len(v))
+		}
+
+		c := &GradientCheck{Pos: append([]float64{}, v...), Analytic: analytic, Numeric: numeric}
+		for i := range v {
+			scale := math.Max(1, math.Max(math.Abs(analytic[i]), math.Abs(numeric[i])))
+			c.RelErr = append(c.RelErr, math.Abs(analytic[i]-numeric[i])/scale)
+		}
+		checks = append(checks, c)
+	}
+	return checks, nil
+}
+
+// CheckGradient compares g's gradient with central finite differences at n
+// positions sampled uniformly within the box bounds low and up.  See
+// CheckGradientAt.
+func CheckGradient(g Gradienter, low, up []float64, n int, h float64) ([]*GradientCheck, error) {
+	positions := make([][]float64, n)
+	for i := range positions {
+		positions[i] = make([]float64, len(low))
+		for j := range low {
+			positions[i][j] = low[j] + RandFloat()*(up[j]-low[j])
+		}
+	}
+	return CheckGradientAt(g, h, positions...)
+}
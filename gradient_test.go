@@ -0,0 +1,55 @@
+package optim
+
+import (
+	"math"
+	"testing"
+)
+
+// gradObj has a correct gradient in dimension 0 and a buggy one in
+// dimension 1.
+type gradObj struct{ buggy bool }
+
+func (o gradObj) Objective(v []float64) (float64, error) {
+	return v[0]*v[0] + math.Sin(v[1]), nil
+}
+
+func (o gradObj) Gradient(v []float64) ([]float64, error) {
+	g := []float64{2 * v[0], math.Cos(v[1])}
+	if o.buggy {
+		g[1] = -g[1]
+	}
+	return g, nil
+}
+
+func TestNumGradient(t *testing.T) {
+	grad, err := NumGradient(gradObj{}, []float64{3, 0}, 1e-6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{6, 1}
+	for i := range want {
+		if math.Abs(grad[i]-want[i]) > 1e-6 {
+			t.Errorf("dim %v: want %v, got %v", i, want[i], grad[i])
+		}
+	}
+}
+
+func TestCheckGradient(t *testing.T) {
+	low, up := []float64{-5, -1}, []float64{5, 1}
+	checks, err := CheckGradient(gradObj{}, low, up, 10, 1e-6)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(checks) != 10 {
+		t.Fatalf("want 10 checks, got %v", len(checks))
+	}
+	for _, c := range checks {
+		if _, e := c.Worst(); e > 1e-6 {
+			t.Errorf("correct gradient flagged: %v", c)
+		}
+	}
+
+	checks, _ = CheckGradientAt(gradObj{buggy: true}, 1e-6, []float64{1, 0.5})
+	if dim, e := checks[0].Worst(); dim != 1 || e < 1 {
+		t.Errorf("buggy dimension not found: %v", checks[0])
+	}
+}
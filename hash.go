@@ -0,0 +1,49 @@
+package optim
+
+import (
+	"crypto/sha1"
+	"strconv"
+)
+
+// PointHasher computes an identifying hash for a point's position.  Points
+// with equal hashes are treated as identical by caches.
+type PointHasher interface {
+	Hash(p *Point) [sha1.Size]byte
+}
+
+// ExactHasher hashes points using their full-precision positions (i.e.
+// Point.Hash).
+type ExactHasher struct{}
+
+func (_ ExactHasher) Hash(p *Point) [sha1.Size]byte { return p.Hash() }
+
+// PrecisionHasher hashes points after rounding each coordinate to Digits
+// significant decimal digits.  This causes positions differing only by
+// floating point noise to hash identically.
+type PrecisionHasher struct {
+	Digits int
+}
+
+func (h PrecisionHasher) Hash(p *Point) [sha1.Size]byte {
+	pos := make([]float64, p.Len())
+	for i, x := range p.Pos {
+		s := strconv.FormatFloat(x, 'g', h.Digits, 64)
+		pos[i], _ = strconv.ParseFloat(s, 64)
+	}
+	return (&Point{Pos: pos}).Hash()
+}
+
+// MeshHasher hashes points after projecting them onto Mesh so that all
+// positions nearest the same grid point hash identically.
+type MeshHasher struct {
+	Mesh Mesh
+}
+
+func (h MeshHasher) Hash(p *Point) [sha1.Size]byte {
+	return (&Point{Pos: h.Mesh.Nearest(p.Pos)}).Hash()
+}
+
+// HasherFunc adapts an ordinary function into a PointHasher.
+type HasherFunc func(p *Point) [sha1.Size]byte
+
+func (fn HasherFunc) Hash(p *Point) [sha1.Size]byte { return fn(p) }
@@ -0,0 +1,56 @@
+package optim
+
+import (
+	"crypto/sha1"
+	"math"
+	"testing"
+)
+
+func TestPointHash(t *testing.T) {
+	pos := &Point{Pos: []float64{0, 1}}
+	neg := &Point{Pos: []float64{math.Copysign(0, -1), 1}}
+	if pos.Hash() != neg.Hash() {
+		t.Errorf("-0 and +0 hash differently")
+	}
+
+	nan1 := &Point{Pos: []float64{math.NaN()}}
+	nan2 := &Point{Pos: []float64{math.Float64frombits(0x7ff8000000000001)}}
+	if nan1.Hash() != nan2.Hash() {
+		t.Errorf("different NaN values hash differently")
+	}
+
+	short := &Point{Pos: []float64{1}}
+	long := &Point{Pos: []float64{1, 0}}
+	if short.Hash() == long.Hash() {
+		t.Errorf("points of different dimension hash identically")
+	}
+}
+
+func TestPrecisionHasher(t *testing.T) {
+	h := PrecisionHasher{Digits: 6}
+	p1 := &Point{Pos: []float64{0.1 + 0.2, 1e6}}
+	p2 := &Point{Pos: []float64{0.3, 1e6 + 1e-4}}
+	p3 := &Point{Pos: []float64{0.3001, 1e6}}
+	if h.Hash(p1) != h.Hash(p2) {
+		t.Errorf("points differing by rounding noise hash differently")
+	}
+	if h.Hash(p1) == h.Hash(p3) {
+		t.Errorf("distinct points hash identically")
+	}
+}
+
+func TestCacheEvalerHasher(t *testing.T) {
+	obj := &ObjTest{max: 100000}
+	ev := NewCacheEvaler(SerialEvaler{})
+	ev.Hasher = HasherFunc(func(p *Point) [sha1.Size]byte {
+		// only the first coordinate identifies a point
+		return (&Point{Pos: p.Pos[:1]}).Hash()
+	})
+
+	pts := testpoints()
+	ev.Eval(obj, pts[0])
+	_, n, _ := ev.Eval(obj, pts[1:]...)
+	if n != 0 {
+		t.Errorf("custom hasher not used: want 0 evals, got %v", n)
+	}
+}
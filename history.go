@@ -0,0 +1,215 @@
+package optim
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// History stores evaluated points in a k-d tree supporting fast nearest
+// neighbor and radius queries - e.g. for fitting local surrogates,
+// nearest-better clustering, and filtering duplicate evaluations.  Points
+// are stored by reference and must not be modified after being added.  It
+// is safe for concurrent use.
+type History struct {
+	// Scale, if non-nil, holds a per-dimension length scale.  Coordinate
+	// differences are divided by it before computing distances so that
+	// dimensions with very different ranges are weighted comparably.
+	Scale []float64
+	// Coords, if non-nil, maps positions to the coordinates distances are
+	// measured in (before scaling) - e.g. MeshCoords for distances in a
+	// mesh's basis.  It must be linear, or at least preserve axis ordering
+	// well enough for k-d tree pruning, and must not change once points
+	// have been added.
+	Coords func(x []float64) []float64
+	mu     sync.RWMutex
+	root   *kdnode
+	n      int
+}
+
+type kdnode struct {
+	p *Point
+	// x is p's position in the history's coordinates.
+	x           []float64
+	seq         int
+	axis        int
+	left, right *kdnode
+}
+
+// Add inserts points into the history.
+func (h *History) Add(pts ...*Point) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, p := range pts {
+		h.insert(p)
+	}
+}
+
+func (h *History) insert(p *Point) {
+	n := &kdnode{p: p, x: h.coords(p.Pos), seq: h.n}
+	h.n++
+	if h.root == nil {
+		h.root = n
+		return
+	}
+	curr := h.root
+	for {
+		next := &curr.right
+		if n.x[curr.axis] < curr.x[curr.axis] {
+			next = &curr.left
+		}
+		if *next == nil {
+			n.axis = (curr.axis + 1) % len(n.x)
+			*next = n
+			return
+		}
+		curr = *next
+	}
+}
+
+// Len returns the number of points in the history.
+func (h *History) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.n
+}
+
+// Points returns all points in the history in the order they were added.
+func (h *History) Points() []*Point {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	pts := make([]*Point, h.n)
+	var walk func(n *kdnode)
+	walk = func(n *kdnode) {
+		if n == nil {
+			return
+		}
+		pts[n.seq] = n.p
+		walk(n.left)
+		walk(n.right)
+	}
+	walk(h.root)
+	return pts
+}
+
+func (h *History) coords(x []float64) []float64 {
+	if h.Coords == nil {
+		return x
+	}
+	return h.Coords(x)
+}
+
+// Dist returns the (scaled) distance between positions a and b.
+func (h *History) Dist(a, b []float64) float64 {
+	return h.dist(h.coords(a), h.coords(b))
+}
+
+// dist returns the scaled distance between a and b in history coordinates.
+func (h *History) dist(a, b []float64) float64 {
+	tot := 0.0
+	for i := range a {
+		d := h.axisDist(i, a[i]-b[i])
+		tot += d * d
+	}
+	return math.Sqrt(tot)
+}
+
+func (h *History) axisDist(axis int, diff float64) float64 {
+	if h.Scale != nil {
+		diff /= h.Scale[axis]
+	}
+	return math.Abs(diff)
+}
+
+type neighbor struct {
+	n *kdnode
+	d float64
+}
+
+// before orders neighbors by distance with ties going to the point added
+// first.
+func (a neighbor) before(b neighbor) bool {
+	return a.d < b.d || (a.d == b.d && a.n.seq < b.n.seq)
+}
+
+// Nearest returns the k points nearest to pos ordered from nearest to
+// farthest.  Points equally distant from pos are ordered by when they were
+// added.
+func (h *History) Nearest(pos []float64, k int) []*Point {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if k <= 0 {
+		return nil
+	}
+
+	// best is kept sorted nearest first
+	q := h.coords(pos)
+	best := make([]neighbor, 0, k+1)
+	var search func(n *kdnode)
+	search = func(n *kdnode) {
+		if n == nil {
+			return
+		}
+		nb := neighbor{n, h.dist(q, n.x)}
+		if len(best) < k || nb.before(best[len(best)-1]) {
+			i := sort.Search(len(best), func(i int) bool { return nb.before(best[i]) })
+			best = append(best, neighbor{})
+			copy(best[i+1:], best[i:])
+			best[i] = nb
+			if len(best) > k {
+				best = best[:k]
+			}
+		}
+
+		diff := q[n.axis] - n.x[n.axis]
+		near, far := n.right, n.left
+		if diff < 0 {
+			near, far = n.left, n.right
+		}
+		search(near)
+		if len(best) < k || h.axisDist(n.axis, diff) <= best[len(best)-1].d {
+			search(far)
+		}
+	}
+	search(h.root)
+
+	pts := make([]*Point, len(best))
+	for i, nb := range best {
+		pts[i] = nb.n.p
+	}
+	return pts
+}
+
+// Within returns all points within distance r of pos ordered from nearest
+// to farthest.
+func (h *History) Within(pos []float64, r float64) []*Point {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	q := h.coords(pos)
+	var found []neighbor
+	var search func(n *kdnode)
+	search = func(n *kdnode) {
+		if n == nil {
+			return
+		}
+		if d := h.dist(q, n.x); d <= r {
+			found = append(found, neighbor{n, d})
+		}
+		diff := q[n.axis] - n.x[n.axis]
+		if diff < 0 || h.axisDist(n.axis, diff) <= r {
+			search(n.left)
+		}
+		if diff >= 0 || h.axisDist(n.axis, diff) <= r {
+			search(n.right)
+		}
+	}
+	search(h.root)
+
+	sort.Slice(found, func(i, j int) bool { return found[i].before(found[j]) })
+	pts := make([]*Point, len(found))
+	for i, nb := range found {
+		pts[i] = nb.n.p
+	}
+	return pts
+}
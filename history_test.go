@@ -0,0 +1,72 @@
+package optim
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func bruteNearest(h *History, pts []*Point, pos []float64) []*Point {
+	sorted := append([]*Point{}, pts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return h.Dist(pos, sorted[i].Pos) < h.Dist(pos, sorted[j].Pos)
+	})
+	return sorted
+}
+
+func TestHistoryQueries(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, scale := range [][]float64{nil, {1, 10, 0.1}} {
+		h := &History{Scale: scale}
+		var pts []*Point
+		for i := 0; i < 500; i++ {
+			p := &Point{Pos: []float64{r.Float64(), r.Float64() * 10, r.Float64() / 10}, Val: float64(i)}
+			pts = append(pts, p)
+			h.Add(p)
+		}
+		// exact duplicates resolve to the first added
+		h.Add(&Point{Pos: append([]float64{}, pts[3].Pos...), Val: -1})
+		pts = append(pts, h.Points()[500])
+
+		if h.Len() != 501 {
+			t.Fatalf("want 501 points, got %v", h.Len())
+		}
+
+		for q := 0; q < 50; q++ {
+			pos := []float64{r.Float64(), r.Float64() * 10, r.Float64() / 10}
+			if q == 0 {
+				pos = pts[3].Pos
+			}
+			want := bruteNearest(h, pts, pos)
+
+			got := h.Nearest(pos, 5)
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("scale %v query %v: nearest[%v] want %v, got %v", scale, q, i, want[i], got[i])
+				}
+			}
+
+			rad := h.Dist(pos, want[10].Pos)
+			within := h.Within(pos, rad)
+			if len(within) != 11 {
+				t.Errorf("scale %v query %v: want 11 points within %v, got %v", scale, q, rad, len(within))
+			}
+			for i := range within {
+				if i < len(want) && within[i] != want[i] {
+					t.Errorf("scale %v query %v: within[%v] want %v, got %v", scale, q, i, want[i], within[i])
+				}
+			}
+		}
+	}
+}
+
+func TestHistoryEmpty(t *testing.T) {
+	h := &History{}
+	if got := h.Nearest([]float64{1}, 3); len(got) != 0 {
+		t.Errorf("want no neighbors, got %v", got)
+	}
+	h.Add(&Point{Pos: []float64{1}})
+	if got := h.Nearest([]float64{5}, 3); len(got) != 1 {
+		t.Errorf("want 1 neighbor, got %v", got)
+	}
+}
@@ -0,0 +1,176 @@
+// Package hyperband provides budget allocation for multi-fidelity
+// objectives - objectives that can be evaluated more cheaply (and less
+// accurately) with a smaller budget such as a coarser simulation
+// resolution, fewer time steps, or fewer training epochs.  Successive
+// halving evaluates many candidates at a low budget and promotes only the
+// most promising fraction to successively higher budgets.  Hyperband runs
+// several successive halving brackets that trade off the number of
+// candidates against their starting budget.  See:
+//
+//	Li, Lisha, et al. "Hyperband: A novel bandit-based approach to
+//	hyperparameter optimization." Journal of Machine Learning Research
+//	18.1 (2017): 6765-6816.
+package hyperband
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Objectiver is an objective that can be evaluated at a given budget.
+// Larger budgets should give more accurate objective values.
+type Objectiver interface {
+	Objective(v []float64, budget float64) (float64, error)
+}
+
+type Func func(v []float64, budget float64) float64
+
+func (fn Func) Objective(v []float64, budget float64) (float64, error) { return fn(v, budget), nil }
+
+// AtBudget returns an objective that evaluates obj at a fixed budget.
+func AtBudget(obj Objectiver, budget float64) optim.Objectiver {
+	return &atBudget{obj, budget}
+}
+
+type atBudget struct {
+	obj    Objectiver
+	budget float64
+}
+
+func (o *atBudget) Objective(v []float64) (float64, error) { return o.obj.Objective(v, o.budget) }
+
+// FromLevels adapts the discrete fidelity levels of obj to budgets: budget
+// b evaluates level ceil(b)-1 clamped to obj's levels.  With MinBudget 1 and
+// MaxBudget obj.Fidelities(), the lowest budget uses the cheapest level and
+// the highest uses the true objective.
+func FromLevels(obj optim.MultiFidelityObjectiver) Objectiver { return levels{obj} }
+
+type levels struct{ obj optim.MultiFidelityObjectiver }
+
+func (o levels) Objective(v []float64, budget float64) (float64, error) {
+	level := int(math.Ceil(budget-1e-9)) - 1
+	if level < 0 {
+		level = 0
+	} else if n := o.obj.Fidelities(); level >= n {
+		level = n - 1
+	}
+	return o.obj.FidelityObjective(v, level)
+}
+
+// Hyperband allocates evaluation budget among randomly sampled candidate
+// points.
+type Hyperband struct {
+	Obj Objectiver
+	// Low and Up are the bounds candidate points are uniformly sampled
+	// from.
+	Low, Up []float64
+	// Mesh, if non-nil, is used to project candidate points.
+	Mesh optim.Mesh
+	// Ev is used for all evaluations.  If nil, a SerialEvaler is used.
+	Ev optim.Evaler
+	// MinBudget and MaxBudget are the smallest and largest budgets
+	// candidates are evaluated at.
+	MinBudget, MaxBudget float64
+	// Eta is the reduction factor - only the best 1/Eta candidates in each
+	// successive halving round are promoted to an Eta times larger budget.
+	// It defaults to 3.
+	Eta float64
+	// Cost is the total budget spent so far (i.e. the sum of the budgets of
+	// every evaluation).
+	Cost float64
+}
+
+func (h *Hyperband) eta() float64 {
+	if h.Eta == 0 {
+		return 3
+	}
+	return h.Eta
+}
+
+func (h *Hyperband) evaler() optim.Evaler {
+	if h.Ev == nil {
+		return optim.SerialEvaler{}
+	}
+	return h.Ev
+}
+
+// Run performs one full hyperband iteration consisting of successive
+// halving brackets s = smax, ..., 0 where smax = floor(log_eta(MaxBudget/MinBudget)).
+// Bracket s starts ceil((smax+1)/(s+1) * eta^s) candidates at budget
+// MaxBudget*eta^-s.  The best point evaluated at MaxBudget along with the
+// total number of objective evaluations is returned.
+func (h *Hyperband) Run() (best *optim.Point, n int, err error) {
+	eta := h.eta()
+	smax := int(math.Floor(math.Log(h.MaxBudget/h.MinBudget)/math.Log(eta) + 1e-9))
+	for s := smax; s >= 0; s-- {
+		ncand := int(math.Ceil(float64(smax+1) / float64(s+1) * math.Pow(eta, float64(s))))
+		budget := h.MaxBudget * math.Pow(eta, -float64(s))
+
+		pts := optim.RandPop(ncand, h.Low, h.Up)
+		if h.Mesh != nil {
+			for _, p := range pts {
+				p.Pos = h.Mesh.Nearest(p.Pos)
+			}
+		}
+
+		b, nn, err := h.SuccessiveHalving(pts, budget)
+		n += nn
+		if err != nil {
+			return best, n, err
+		}
+		if best == nil || b.Val < best.Val {
+			best = b
+		}
+	}
+	return best, n, nil
+}
+
+// SuccessiveHalving evaluates points at budget and repeatedly promotes the
+// best 1/Eta of them to an Eta times larger budget until MaxBudget is
+// reached.  The best point evaluated at MaxBudget is returned along with
+// the number of objective evaluations.
+func (h *Hyperband) SuccessiveHalving(points []*optim.Point, budget float64) (best *optim.Point, n int, err error) {
+	eta := h.eta()
+	start := budget
+	for k := 0; ; k++ {
+		// compute each rung's budget directly and snap it to MaxBudget so
+		// rounding error doesn't add a rung just below MaxBudget
+		budget = start * math.Pow(eta, float64(k))
+		if budget > h.MaxBudget*(1-1e-9) || len(points) == 1 {
+			budget = h.MaxBudget
+		}
+
+		rung := make([]*optim.Point, len(points))
+		for i, p := range points {
+			rung[i] = &optim.Point{Pos: p.Pos, Val: math.Inf(1)}
+		}
+		results, nn, err := optim.DedupEvaler{Evaler: h.evaler()}.Eval(AtBudget(h.Obj, budget), rung...)
+		n += nn
+		h.Cost += float64(nn) * budget
+		if err != nil {
+			return nil, n, err
+		} else if len(results) < len(rung) {
+			return nil, n, errors.New("hyperband: evaler failed to evaluate all points")
+		}
+
+		sort.Sort(byval(rung))
+		if budget >= h.MaxBudget {
+			return rung[0], n, nil
+		}
+
+		keep := int(float64(len(rung)) / eta)
+		if keep < 1 {
+			keep = 1
+		}
+		points = rung[:keep]
+	}
+}
+
+type byval []*optim.Point
+
+func (b byval) Less(i, j int) bool { return b[i].Val < b[j].Val }
+func (b byval) Len() int           { return len(b) }
+func (b byval) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
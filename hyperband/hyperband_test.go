@@ -0,0 +1,140 @@
+package hyperband
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// biased has an error that shrinks as the budget grows.
+var biased = Func(func(v []float64, budget float64) float64 {
+	return (v[0]-1)*(v[0]-1) + (v[1]-2)*(v[1]-2) + math.Sin(10*v[0])/budget
+})
+
+func TestSuccessiveHalving(t *testing.T) {
+	h := &Hyperband{Obj: biased, MinBudget: 1, MaxBudget: 27}
+	pts := optim.RandPop(27, []float64{-5, -5}, []float64{5, 5})
+	best, n, err := h.SuccessiveHalving(pts, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 27 + 9 + 3 + 1 evaluations at budgets 1, 3, 9, 27
+	if n != 40 {
+		t.Errorf("want 40 evals, got %v", n)
+	}
+	if want := 27*1 + 9*3 + 3*9 + 1*27.0; h.Cost != want {
+		t.Errorf("want cost %v, got %v", want, h.Cost)
+	}
+	if want, _ := biased.Objective(best.Pos, 27); best.Val != want {
+		t.Errorf("best not evaluated at max budget: want %v, got %v", want, best.Val)
+	}
+}
+
+func TestRungBudgets(t *testing.T) {
+	for _, eta := range []float64{2, 3, 4, 5} {
+		for max := 2.0; max <= 200; max++ {
+			var budgets []float64
+			obj := Func(func(v []float64, budget float64) float64 {
+				if len(budgets) == 0 || budgets[len(budgets)-1] != budget {
+					budgets = append(budgets, budget)
+				}
+				return v[0]
+			})
+			h := &Hyperband{Obj: obj, MaxBudget: max, Eta: eta}
+			pts := optim.RandPop(int(eta*eta), []float64{0}, []float64{1})
+			// the first rung budget as computed by Run for bracket s = 1
+			start := max * math.Pow(eta, -1)
+			if _, _, err := h.SuccessiveHalving(pts, start); err != nil {
+				t.Fatal(err)
+			}
+			if want := []float64{start, max}; len(budgets) != 2 || budgets[1] != max {
+				t.Errorf("eta %v, max budget %v: want rungs at %v, got %v", eta, max, want, budgets)
+			}
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	h := &Hyperband{
+		Obj:       biased,
+		Low:       []float64{-5, -5},
+		Up:        []float64{5, 5},
+		MinBudget: 1,
+		MaxBudget: 81,
+	}
+	var best *optim.Point
+	for i := 0; i < 5; i++ {
+		b, _, err := h.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if best == nil || b.Val < best.Val {
+			best = b
+		}
+	}
+	t.Logf("cost %v: best %v", h.Cost, best)
+	if best.Val > 0.5 {
+		t.Errorf("want < 0.5, got %v", best)
+	}
+}
+
+func TestBrackets(t *testing.T) {
+	var nmax int
+	obj := Func(func(v []float64, budget float64) float64 {
+		if budget == 81 {
+			nmax++
+		}
+		return v[0]
+	})
+	h := &Hyperband{Obj: obj, Low: []float64{0}, Up: []float64{1}, MinBudget: 1, MaxBudget: 81}
+	_, n, err := h.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bracket sizes for eta=3, R=81 from Li et al table 1
+	want := (81 + 27 + 9 + 3 + 1) + (34 + 11 + 3 + 1) + (15 + 5 + 1) + (8 + 2) + 5
+	if n != want {
+		t.Errorf("want %v evals, got %v", want, n)
+	}
+	if nmax != 1+1+1+2+5 {
+		t.Errorf("want 10 evals at max budget, got %v", nmax)
+	}
+}
+
+// threeLevel records the levels it is evaluated at.
+type threeLevel struct{ levels []int }
+
+func (o *threeLevel) Fidelities() int { return 3 }
+
+func (o *threeLevel) FidelityObjective(v []float64, level int) (float64, error) {
+	o.levels = append(o.levels, level)
+	return v[0] * v[0] / float64(level+1), nil
+}
+
+func TestFromLevels(t *testing.T) {
+	obj := &threeLevel{}
+	h := &Hyperband{Obj: FromLevels(obj), MinBudget: 1, MaxBudget: 3}
+	pts := optim.RandPop(3, []float64{-5}, []float64{5})
+	best, _, err := h.SuccessiveHalving(pts, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3 evaluations at budget 1 (level 0) and 1 at budget 3 (level 2)
+	want := []int{0, 0, 0, 2}
+	if len(obj.levels) != len(want) {
+		t.Fatalf("want levels %v, got %v", want, obj.levels)
+	}
+	for i := range want {
+		if obj.levels[i] != want[i] {
+			t.Errorf("want levels %v, got %v", want, obj.levels)
+			break
+		}
+	}
+	if v := best.Pos[0] * best.Pos[0] / 3; best.Val != v {
+		t.Errorf("best not evaluated at the top level: want %v, got %v", v, best.Val)
+	}
+}
@@ -0,0 +1,48 @@
+package optim
+
+// Injector is implemented by methods that can incorporate externally
+// discovered points - e.g. from a prior run or a human guess - into their
+// search state rather than only using them as a new best point like
+// AddPoint.  Population methods typically replace their worst members with
+// the injected points.  Points should normally be evaluated; unevaluated
+// points (with a value of positive infinity) are only useful to methods
+// that re-evaluate their population every iteration (e.g. particle swarm).
+// Inject must not be called concurrently with Iterate.
+type Injector interface {
+	Inject(points ...*Point)
+}
+
+// Inject incorporates points into m using its Inject method if it has one
+// and otherwise passes each point to m's AddPoint method.
+func Inject(m Method, points ...*Point) {
+	if inj, ok := m.(Injector); ok {
+		inj.Inject(points...)
+		return
+	}
+	for _, p := range points {
+		m.AddPoint(p)
+	}
+}
+
+// ReplaceWorst replaces the worst point in pop with each of points in turn
+// as long as the injected point is no worse.  It returns the number of
+// points replaced.
+func ReplaceWorst(pop []*Point, points ...*Point) int {
+	if len(pop) == 0 {
+		return 0
+	}
+	n := 0
+	for _, p := range points {
+		worst := 0
+		for i, q := range pop {
+			if q.Val > pop[worst].Val {
+				worst = i
+			}
+		}
+		if p.Val <= pop[worst].Val {
+			pop[worst] = p
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,37 @@
+package optim
+
+import (
+	"math"
+	"testing"
+)
+
+type addMethod struct{ added []*Point }
+
+func (m *addMethod) Iterate(obj Objectiver, mesh Mesh) (*Point, int, error) { return nil, 0, nil }
+func (m *addMethod) AddPoint(p *Point)                                      { m.added = append(m.added, p) }
+
+func TestReplaceWorst(t *testing.T) {
+	pop := []*Point{{Val: 1}, {Val: 5}, {Val: 3}}
+	a, b, c := &Point{Val: 2}, &Point{Val: 2.5}, &Point{Val: 9}
+	if n := ReplaceWorst(pop, a, b, c); n != 2 {
+		t.Errorf("want 2 points replaced, got %v", n)
+	}
+	if pop[1] != a || pop[2] != b {
+		t.Errorf("want worst points replaced in turn, got %v", pop)
+	}
+
+	unevaled := &Point{Val: math.Inf(1)}
+	pop = []*Point{{Val: math.Inf(1)}, {Val: 1}}
+	if ReplaceWorst(pop, unevaled); pop[0] != unevaled {
+		t.Errorf("unevaluated point did not replace unevaluated member: %v", pop)
+	}
+}
+
+func TestInjectFallback(t *testing.T) {
+	m := &addMethod{}
+	p1, p2 := &Point{Val: 1}, &Point{Val: 2}
+	Inject(m, p1, p2)
+	if len(m.added) != 2 || m.added[0] != p1 || m.added[1] != p2 {
+		t.Errorf("want points passed to AddPoint, got %v", m.added)
+	}
+}
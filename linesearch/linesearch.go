@@ -0,0 +1,177 @@
+// Package linesearch provides derivative-free one dimensional minimization
+// methods.  They can be used directly for 1-D problems or as building
+// blocks for methods that perform line searches along search directions
+// (e.g. coordinate descent and Powell's method) via Line.
+package linesearch
+
+import (
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Func is a one dimensional objective function.
+type Func func(x float64) float64
+
+// Line returns a Func evaluating obj along the line x0 + t*dir.  Failed
+// evaluations return positive infinity.
+func Line(obj optim.Objectiver, x0, dir []float64) Func {
+	return func(t float64) float64 {
+		x := make([]float64, len(x0))
+		for i := range x {
+			x[i] = x0[i] + t*dir[i]
+		}
+		v, err := obj.Objective(x)
+		if err != nil {
+			return math.Inf(1)
+		}
+		return v
+	}
+}
+
+// Result holds the outcome of a line search.
+type Result struct {
+	X, Val float64
+	// Neval is the number of function evaluations performed.
+	Neval int
+}
+
+var invphi = (math.Sqrt(5) - 1) / 2 // 1/golden ratio
+
+// Golden minimizes f over [a,b] with golden-section search until the
+// bracketing interval is smaller than tol.  f should be unimodal over the
+// interval.
+func Golden(f Func, a, b, tol float64) Result {
+	if a > b {
+		a, b = b, a
+	}
+	c := b - invphi*(b-a)
+	d := a + invphi*(b-a)
+	fc, fd := f(c), f(d)
+	n := 2
+	for b-a > tol {
+		if fc < fd {
+			b, d, fd = d, c, fc
+			c = b - invphi*(b-a)
+			fc = f(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + invphi*(b-a)
+			fd = f(d)
+		}
+		n++
+	}
+	if fc < fd {
+		return Result{X: c, Val: fc, Neval: n}
+	}
+	return Result{X: d, Val: fd, Neval: n}
+}
+
+// Brent minimizes f over [a,b] with Brent's method which combines
+// golden-section steps with parabolic interpolation for fast convergence on
+// smooth functions.  Iteration stops when the minimum is located to within
+// roughly tol.
+func Brent(f Func, a, b, tol float64) Result {
+	const cgold = 0.3819660112501051 // 1 - 1/golden ratio
+	const eps = 1e-12
+	if a > b {
+		a, b = b, a
+	}
+
+	x := a + cgold*(b-a)
+	w, v := x, x
+	fx := f(x)
+	fw, fv := fx, fx
+	n := 1
+	var d, e float64
+	for iter := 0; iter < 500; iter++ {
+		m := (a + b) / 2
+		tol1 := tol/2 + eps*math.Abs(x)
+		tol2 := 2 * tol1
+		if math.Abs(x-m) <= tol2-(b-a)/2 {
+			break
+		}
+
+		golden := true
+		if math.Abs(e) > tol1 {
+			// try a parabolic fit through x, w, v
+			r := (x - w) * (fx - fv)
+			q := (x - v) * (fx - fw)
+			p := (x-v)*q - (x-w)*r
+			q = 2 * (q - r)
+			if q > 0 {
+				p = -p
+			}
+			q = math.Abs(q)
+			if math.Abs(p) < math.Abs(q*e/2) && p > q*(a-x) && p < q*(b-x) {
+				e, d = d, p/q
+				u := x + d
+				if u-a < tol2 || b-u < tol2 {
+					d = math.Copysign(tol1, m-x)
+				}
+				golden = false
+			}
+		}
+		if golden {
+			if x < m {
+				e = b - x
+			} else {
+				e = a - x
+			}
+			d = cgold * e
+		}
+
+		u := x + d
+		if math.Abs(d) < tol1 {
+			u = x + math.Copysign(tol1, d)
+		}
+		fu := f(u)
+		n++
+
+		if fu <= fx {
+			if u < x {
+				b = x
+			} else {
+				a = x
+			}
+			v, fv, w, fw, x, fx = w, fw, x, fx, u, fu
+		} else {
+			if u < x {
+				a = u
+			} else {
+				b = u
+			}
+			if fu <= fw || w == x {
+				v, fv, w, fw = w, fw, u, fu
+			} else if fu <= fv || v == x || v == w {
+				v, fv = u, fu
+			}
+		}
+	}
+	return Result{X: x, Val: fx, Neval: n}
+}
+
+// Bracket searches downhill from the points a and b for an interval [lo,up]
+// containing a local minimum of f.  It returns the interval and the number
+// of function evaluations.  If no bracket is found within maxiter
+// expansion steps, the last interval searched is returned.
+func Bracket(f Func, a, b float64, maxiter int) (lo, up float64, n int) {
+	fa, fb := f(a), f(b)
+	n = 2
+	if fb > fa {
+		a, b, fa, fb = b, a, fb, fa
+	}
+	c := b + (b-a)/invphi
+	fc := f(c)
+	n++
+	for i := 0; fc < fb && i < maxiter; i++ {
+		a, b, fb = b, c, fc
+		c = b + (b-a)/invphi
+		fc = f(c)
+		n++
+	}
+	if a > c {
+		a, c = c, a
+	}
+	return a, c, n
+}
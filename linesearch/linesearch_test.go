@@ -0,0 +1,59 @@
+package linesearch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+var tests = []struct {
+	f    Func
+	a, b float64
+	want float64
+}{
+	{func(x float64) float64 { return (x - 2) * (x - 2) }, -10, 10, 2},
+	{func(x float64) float64 { return math.Abs(x + 1.5) }, -10, 10, -1.5},
+	{func(x float64) float64 { return -math.Sin(x) }, 0, 3, math.Pi / 2},
+	{func(x float64) float64 { return math.Exp(x) - 5*x }, 0, 5, math.Log(5)},
+}
+
+func TestGolden(t *testing.T) {
+	for i, test := range tests {
+		r := Golden(test.f, test.a, test.b, 1e-8)
+		if math.Abs(r.X-test.want) > 1e-6 {
+			t.Errorf("test %v: want %v, got %v", i, test.want, r.X)
+		}
+	}
+}
+
+func TestBrent(t *testing.T) {
+	for i, test := range tests {
+		r := Brent(test.f, test.a, test.b, 1e-8)
+		if math.Abs(r.X-test.want) > 1e-6 {
+			t.Errorf("test %v: want %v, got %v", i, test.want, r.X)
+		}
+		if g := Golden(test.f, test.a, test.b, 1e-8); i != 1 && r.Neval >= g.Neval {
+			t.Errorf("test %v: brent (%v evals) not faster than golden (%v evals)", i, r.Neval, g.Neval)
+		}
+	}
+}
+
+func TestBracket(t *testing.T) {
+	f := func(x float64) float64 { return (x - 40) * (x - 40) }
+	lo, up, _ := Bracket(f, 0, 1, 50)
+	if lo > 40 || up < 40 {
+		t.Errorf("[%v,%v] doesn't bracket 40", lo, up)
+	}
+}
+
+func TestLine(t *testing.T) {
+	obj := optim.Func(func(x []float64) float64 {
+		return (x[0]-1)*(x[0]-1) + (x[1]-1)*(x[1]-1)
+	})
+	f := Line(obj, []float64{0, 0}, []float64{1, 1})
+	r := Brent(f, -5, 5, 1e-8)
+	if math.Abs(r.X-1) > 1e-6 || r.Val > 1e-10 {
+		t.Errorf("want t=1, val=0, got t=%v, val=%v", r.X, r.Val)
+	}
+}
@@ -0,0 +1,100 @@
+package optim
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// Level is a logging verbosity level.  Higher levels are more verbose.
+type Level int
+
+const (
+	LevelError Level = iota
+	LevelWarn
+	LevelInfo
+	LevelDebug
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelError:
+		return "ERROR"
+	case LevelWarn:
+		return "WARN"
+	case LevelInfo:
+		return "INFO"
+	case LevelDebug:
+		return "DEBUG"
+	}
+	return fmt.Sprintf("LEVEL%d", int(l))
+}
+
+// Logger receives structured log events from solvers, methods, and
+// objective/evaler wrappers.  kv holds alternating keys and values
+// providing context for the message.
+type Logger interface {
+	Log(level Level, msg string, kv ...interface{})
+}
+
+// NopLogger discards all log events.
+type NopLogger struct{}
+
+func (_ NopLogger) Log(level Level, msg string, kv ...interface{}) {}
+
+// StdLogger adapts a standard library logger.  Events more verbose than
+// Level are dropped.  If Logger is nil, the log package's standard logger
+// is used.
+type StdLogger struct {
+	Logger *log.Logger
+	Level  Level
+}
+
+func (l StdLogger) Log(level Level, msg string, kv ...interface{}) {
+	if level > l.Level {
+		return
+	}
+	line := format(level, msg, kv)
+	if l.Logger == nil {
+		log.Print(line)
+	} else {
+		l.Logger.Print(line)
+	}
+}
+
+// WriterLogger writes one line per event to W in the format
+//
+//	LEVEL msg key1=val1 key2=val2 ...
+//
+// Events more verbose than Level are dropped.  WriterLogger is safe for
+// concurrent use.
+type WriterLogger struct {
+	W     io.Writer
+	Level Level
+	mu    sync.Mutex
+}
+
+func (l *WriterLogger) Log(level Level, msg string, kv ...interface{}) {
+	if level > l.Level {
+		return
+	}
+	line := format(level, msg, kv)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintln(l.W, line)
+}
+
+func format(level Level, msg string, kv []interface{}) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%v %v", level, msg)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 < len(kv) {
+			fmt.Fprintf(&buf, " %v=%v", kv[i], kv[i+1])
+		} else {
+			fmt.Fprintf(&buf, " %v", kv[i])
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,127 @@
+package optim
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWriterLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := &WriterLogger{W: &buf, Level: LevelInfo}
+	l.Log(LevelInfo, "improved", "iter", 3, "val", 1.5)
+	l.Log(LevelDebug, "dropped")
+	l.Log(LevelError, "odd", "key")
+
+	want := "INFO improved iter=3 val=1.5\nERROR odd key\n"
+	if got := buf.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := StdLogger{Logger: log.New(&buf, "", 0), Level: LevelWarn}
+	l.Log(LevelWarn, "careful", "x", 1)
+	l.Log(LevelInfo, "dropped")
+	if got := buf.String(); got != "WARN careful x=1\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSolverLogger(t *testing.T) {
+	var buf bytes.Buffer
+	s := &Solver{
+		Method:  &seqMethod{Vals: []float64{3, 2, 2}},
+		Obj:     Func(func(v []float64) float64 { return 0 }),
+		MaxIter: 3,
+		Logger:  &WriterLogger{W: &buf, Level: LevelInfo},
+	}
+	s.Run()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("want 2 improvements and a done event, got:\n%v", buf.String())
+	}
+	if !strings.HasPrefix(lines[2], "INFO done iter=3") {
+		t.Errorf("bad done event: %v", lines[2])
+	}
+}
+
+func TestObjectiveLoggerLogger(t *testing.T) {
+	var buf bytes.Buffer
+	obj := &ObjectiveLogger{
+		Obj:    Func(func(v []float64) float64 { return v[0] * 2 }),
+		Logger: &WriterLogger{W: &buf, Level: LevelDebug},
+	}
+	obj.Objective([]float64{2})
+	if got := buf.String(); got != "DEBUG objective pos=[2] val=4\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// negErrObj fails for points with a negative first coordinate.
+type negErrObj struct{}
+
+func (negErrObj) Objective(v []float64) (float64, error) {
+	if v[0] < 0 {
+		return math.Inf(1), errors.New("negative")
+	}
+	return v[0] + v[1], nil
+}
+
+func TestObjectiveLoggerFormats(t *testing.T) {
+	obj := negErrObj{}
+	points := [][]float64{{1, 2}, {0.5, 1e-3}, {-1, 0}}
+
+	wants := map[LogFormat]string{
+		LogPlain: "f[1 2] = 3\nf[0.5 0.001] = 0.501\nf[-1 0] = +Inf\n",
+		LogCSV:   "x0,x1,val\n1,2,3\n0.5,0.001,0.501\n-1,0,+Inf\n",
+		LogTSV:   "x0\tx1\tval\n1\t2\t3\n0.5\t0.001\t0.501\n-1\t0\t+Inf\n",
+		LogJSON: `{"eval":1,"pos":[1,2],"val":3}
+{"eval":2,"pos":[0.5,0.001],"val":0.501}
+{"eval":3,"pos":[-1,0],"val":"+Inf","err":"negative"}
+`,
+	}
+	for format, want := range wants {
+		var buf bytes.Buffer
+		l := &ObjectiveLogger{Obj: obj, W: &buf, Format: format, Header: true}
+		for _, v := range points {
+			l.Objective(v)
+		}
+		if got := buf.String(); got != want {
+			t.Errorf("format %v: want\n%v\ngot\n%v", format, want, got)
+		}
+
+		pts, err := ReadLog(&buf)
+		if err != nil {
+			t.Fatalf("format %v: %v", format, err)
+		} else if len(pts) != len(points) {
+			t.Fatalf("format %v: read %v points, want %v", format, len(pts), len(points))
+		}
+		if pts[1].Pos[1] != 1e-3 || pts[1].Val != 0.501 || !math.IsInf(pts[2].Val, 1) {
+			t.Errorf("format %v: bad round trip: %v", format, pts)
+		}
+	}
+}
+
+func TestObjectiveLoggerConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	l := &ObjectiveLogger{Obj: Func(func(v []float64) float64 { return v[0] }), W: &buf, Format: LogCSV}
+	points := RandPop(200, []float64{0, 0}, []float64{1, 1})
+	if _, _, err := (ParallelEvaler{NConcurrent: 8}).Eval(l, points...); err != nil {
+		t.Fatal(err)
+	}
+	if l.Count() != len(points) {
+		t.Errorf("want count %v, got %v", len(points), l.Count())
+	}
+	pts, err := ReadLog(&buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(pts) != len(points) {
+		t.Errorf("want %v lines, got %v", len(points), len(pts))
+	}
+}
@@ -3,6 +3,7 @@ package optim
 import (
 	"fmt"
 	"math"
+	"sync"
 
 	"github.com/gonum/matrix/mat64"
 )
@@ -19,6 +20,53 @@ type Mesh interface {
 	Origin() []float64
 }
 
+// NearestIntoer is implemented by meshes that can project points without
+// allocating.  NearestInto writes the nearest mesh location to x into dst -
+// growing it only if its capacity is too small - and returns it.  dst and x
+// may be the same slice.
+type NearestIntoer interface {
+	NearestInto(dst, x []float64) []float64
+}
+
+// NearestInto projects x onto m writing the result into dst.  It uses m's
+// NearestInto method if it has one and otherwise copies the result of
+// m.Nearest into dst.
+func NearestInto(m Mesh, dst, x []float64) []float64 {
+	if mi, ok := m.(NearestIntoer); ok {
+		return mi.NearestInto(dst, x)
+	}
+	return append(dst[:0], m.Nearest(x)...)
+}
+
+// NearestBatcher is implemented by meshes that can project many points at
+// once more efficiently than calling Nearest for each.  NearestBatch returns
+// the nearest mesh location for each of points and never modifies them.
+type NearestBatcher interface {
+	NearestBatch(points [][]float64) [][]float64
+}
+
+// NearestBatch projects each of points onto m.  It uses m's NearestBatch
+// method if it has one and otherwise calls m.Nearest for each point.
+func NearestBatch(m Mesh, points [][]float64) [][]float64 {
+	if mb, ok := m.(NearestBatcher); ok {
+		return mb.NearestBatch(points)
+	}
+	near := make([][]float64, len(points))
+	for i, p := range points {
+		near[i] = m.Nearest(p)
+	}
+	return near
+}
+
+// resize returns dst resliced to length n, allocating a new slice only if
+// dst's capacity is too small.
+func resize(dst []float64, n int) []float64 {
+	if cap(dst) < n {
+		return make([]float64, n)
+	}
+	return dst[:n]
+}
+
 type MaxStepMesh struct {
 	Mesh
 	MaxStep float64
@@ -30,6 +78,14 @@ func (m *MaxStepMesh) SetStep(step float64) {
 	}
 }
 
+func (m *MaxStepMesh) NearestInto(dst, p []float64) []float64 {
+	return NearestInto(m.Mesh, dst, p)
+}
+
+func (m *MaxStepMesh) NearestBatch(points [][]float64) [][]float64 {
+	return NearestBatch(m.Mesh, points)
+}
+
 type IntMesh struct {
 	Mesh
 }
@@ -42,6 +98,24 @@ func (m *IntMesh) Nearest(p []float64) []float64 {
 	return gridp
 }
 
+func (m *IntMesh) NearestInto(dst, p []float64) []float64 {
+	dst = NearestInto(m.Mesh, dst, p)
+	for i := range dst {
+		dst[i] = math.Floor(dst[i] + .5)
+	}
+	return dst
+}
+
+func (m *IntMesh) NearestBatch(points [][]float64) [][]float64 {
+	near := NearestBatch(m.Mesh, points)
+	for _, p := range near {
+		for i := range p {
+			p[i] = math.Floor(p[i] + .5)
+		}
+	}
+	return near
+}
+
 func (m *IntMesh) SetStep(step float64) {
 	m.Mesh.SetStep(math.Max(step, 1))
 }
@@ -50,12 +124,73 @@ func (m *IntMesh) SetOrigin(origin []float64) {
 	m.Mesh.SetOrigin(m.Nearest(origin))
 }
 
+// RepairMesh wraps a mesh with integer dimensions (typically an IntMesh) and
+// repairs projected points that violate the constraints Constr.  Rather
+// than returning an infeasible grid point, Nearest
+// greedily moves integer coordinates by +/-1 - one coordinate at a time,
+// always taking the move that most reduces the total constraint violation -
+// until the point is feasible or no move reduces the violation.  The least
+// violating point found is returned, so callers should still check
+// feasibility when no feasible point may be reachable.
+type RepairMesh struct {
+	Mesh
+	Constr *Constraints
+	// Int holds the indices of the integer dimensions that may be adjusted.
+	// If nil, all dimensions are adjusted.
+	Int []int
+	// MaxMoves limits the number of unit moves per repair.  If zero, it is
+	// 10 times the number of dimensions.
+	MaxMoves int
+}
+
+func (m *RepairMesh) Nearest(p []float64) []float64 {
+	x := m.Mesh.Nearest(p)
+	viol := m.Constr.Violation(x)
+	if viol <= m.Constr.Tol {
+		return x
+	}
+
+	dims := m.Int
+	if dims == nil {
+		dims = make([]int, len(x))
+		for i := range dims {
+			dims[i] = i
+		}
+	}
+	maxmoves := m.MaxMoves
+	if maxmoves == 0 {
+		maxmoves = 10 * len(x)
+	}
+
+	for n := 0; n < maxmoves && viol > m.Constr.Tol; n++ {
+		bestdim, bestdelta, bestviol := -1, 0.0, viol
+		for _, i := range dims {
+			for _, delta := range []float64{-1, 1} {
+				x[i] += delta
+				v := m.Constr.Violation(x)
+				x[i] -= delta
+				if v < bestviol || (v == bestviol && bestdim >= 0 && math.Abs(x[i]+delta-p[i]) < math.Abs(x[bestdim]+bestdelta-p[bestdim])) {
+					bestdim, bestdelta, bestviol = i, delta, v
+				}
+			}
+		}
+		if bestdim < 0 {
+			break // local minimum of violation
+		}
+		x[bestdim] += bestdelta
+		viol = bestviol
+	}
+	return x
+}
+
 // Inifinite is a grid-based, linear-axis mesh that extends in all dimensions
 // without bounds.  The length of Origin defines the dimensionality of the
 // mesh. If Origin == nil, the dimensionality is set by the first call to
 // Nearest.  If Basis == nil, a unit basis (the identify matrix) is used.  If
 // Step == 0, then the mesh represents continuous space and the Nearest method
-// just returns the point passed to it.
+// just returns the point passed to it.  The basis should be changed via
+// SetBasis after the first call to Nearest so the cached inverse is
+// recomputed.
 type InfMesh struct {
 	Center []float64
 	// Basis contains a set of row vectors defining the directions of each
@@ -64,6 +199,9 @@ type InfMesh struct {
 	// Step represents the discretization or grid size of the mesh.
 	StepSize float64
 	inverter *mat64.Dense
+	mu       sync.Mutex
+	// rel, rot and near are scratch column vectors reused by Nearest.
+	rel, rot, near *mat64.Dense
 }
 
 func (m *InfMesh) Step() float64              { return m.StepSize }
@@ -71,18 +209,38 @@ func (m *InfMesh) SetStep(step float64)       { m.StepSize = step }
 func (m *InfMesh) Origin() []float64          { return m.Center }
 func (m *InfMesh) SetOrigin(origin []float64) { m.Center = origin }
 
+// SetBasis replaces the mesh basis and invalidates the cached inverse, which
+// is recomputed on the next call to Nearest.  It is safe to call concurrently
+// with Nearest.
+func (m *InfMesh) SetBasis(basis *mat64.Dense) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Basis = basis
+	m.inverter = nil
+}
+
 // Nearest returns the nearest grid point to p by rounding each dimensional
 // position to the nearest grid point.  If the mesh basis is not the identity
 // matrix, then p is transformed to the mesh basis before rounding and then
 // retransformed back.
 func (m *InfMesh) Nearest(p []float64) []float64 {
+	return m.NearestInto(nil, p)
+}
+
+// NearestInto is the same as Nearest except it writes the nearest grid point
+// into dst, reusing its storage when large enough.  It does not allocate
+// besides growing dst.
+func (m *InfMesh) NearestInto(dst, p []float64) []float64 {
 	if m.StepSize == 0 {
-		return append([]float64{}, p...)
+		return append(dst[:0], p...)
 	} else if l := len(m.Center); l != 0 && l != len(p) {
 		panic(fmt.Sprintf("origin len %v incompatible with point len %v", l, len(p)))
 	}
 
-	// set up origin and inverter matrix if necessary
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// set up origin, inverter matrix and scratch buffers if necessary
 	if len(m.Center) == 0 {
 		m.Center = make([]float64, len(p))
 	}
@@ -93,37 +251,133 @@ func (m *InfMesh) Nearest(p []float64) []float64 {
 			panic("basis inversion failed: " + err.Error())
 		}
 	}
+	ndim := len(m.Center)
+	if r, _ := dims(m.rel); r != ndim {
+		m.rel = mat64.NewDense(ndim, 1, nil)
+		m.rot = mat64.NewDense(ndim, 1, nil)
+		m.near = mat64.NewDense(ndim, 1, nil)
+	}
 
 	// translate p based on origin and transform to new vector space
-	newp := make([]float64, len(p))
-	for i := range newp {
-		newp[i] = p[i] - m.Center[i]
+	for i := range m.Center {
+		m.rel.Set(i, 0, p[i]-m.Center[i])
 	}
-	v := mat64.NewDense(len(m.Center), 1, newp)
-	rotv := v
+	rotv := m.rel
 	if m.inverter != nil {
-		rotv.Mul(m.inverter, v)
+		m.rot.Mul(m.inverter, m.rel)
+		rotv = m.rot
 	}
 
 	// calculate nearest point
-	nearest := mat64.NewDense(len(p), 1, nil)
 	for i := range m.Center {
 		n, rem := math.Modf(rotv.At(i, 0) / m.StepSize)
 		if rem/m.StepSize > 0.5 {
 			n++
 		}
-		nearest.Set(i, 0, float64(n)*m.StepSize)
+		m.near.Set(i, 0, float64(n)*m.StepSize)
 	}
 
 	// transform back to standard space
+	nearest := m.near
 	if m.Basis != nil {
-		nearest.Mul(m.Basis, nearest)
+		m.rot.Mul(m.Basis, m.near)
+		nearest = m.rot
 	}
-	nv := nearest.Col(nil, 0)
-	for i := range nv {
-		nv[i] += m.Center[i]
+	dst = resize(dst, ndim)
+	for i := range dst {
+		dst[i] = nearest.At(i, 0) + m.Center[i]
 	}
-	return nv
+	return dst
+}
+
+// NearestBatch returns the nearest grid point to each of points.  Rather than
+// transforming each point separately, all points are stacked as columns of a
+// single matrix that is transformed to and from the mesh basis with one
+// matrix multiplication each - which is much faster for large populations
+// with a non-identity basis.  The returned positions share a single backing
+// array.
+func (m *InfMesh) NearestBatch(points [][]float64) [][]float64 {
+	npts := len(points)
+	near := make([][]float64, npts)
+	if npts == 0 {
+		return near
+	}
+	ndim := len(points[0])
+	data := make([]float64, npts*ndim)
+	for j := range near {
+		near[j] = data[j*ndim : (j+1)*ndim : (j+1)*ndim]
+	}
+
+	if m.StepSize == 0 {
+		for j, p := range points {
+			copy(near[j], p)
+		}
+		return near
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.Center) == 0 {
+		m.Center = make([]float64, ndim)
+	}
+	for _, p := range points {
+		if len(p) != len(m.Center) {
+			panic(fmt.Sprintf("origin len %v incompatible with point len %v", len(m.Center), len(p)))
+		}
+	}
+	if m.Basis != nil && m.inverter == nil {
+		var err error
+		m.inverter, err = mat64.Inverse(m.Basis)
+		if err != nil {
+			panic("basis inversion failed: " + err.Error())
+		}
+	}
+
+	// translate points based on origin and stack them as columns
+	x := mat64.NewDense(ndim, npts, nil)
+	for j, p := range points {
+		for i := range p {
+			x.Set(i, j, p[i]-m.Center[i])
+		}
+	}
+	if m.inverter != nil {
+		rot := mat64.NewDense(ndim, npts, nil)
+		rot.Mul(m.inverter, x)
+		x = rot
+	}
+
+	// calculate nearest points
+	for i := 0; i < ndim; i++ {
+		for j := 0; j < npts; j++ {
+			n, rem := math.Modf(x.At(i, j) / m.StepSize)
+			if rem/m.StepSize > 0.5 {
+				n++
+			}
+			x.Set(i, j, float64(n)*m.StepSize)
+		}
+	}
+
+	// transform back to standard space
+	if m.Basis != nil {
+		back := mat64.NewDense(ndim, npts, nil)
+		back.Mul(m.Basis, x)
+		x = back
+	}
+	for j := range near {
+		for i := range near[j] {
+			near[j][i] = x.At(i, j) + m.Center[i]
+		}
+	}
+	return near
+}
+
+// dims returns the dimensions of a, or zeros if a is nil.
+func dims(a *mat64.Dense) (r, c int) {
+	if a == nil {
+		return 0, 0
+	}
+	return a.Dims()
 }
 
 type BoxMesh struct {
@@ -138,11 +392,66 @@ type BoxMesh struct {
 // then p is transformed to the mesh basis before rounding and then
 // retransformed back.
 func (m *BoxMesh) Nearest(p []float64) []float64 {
-	pdup := make([]float64, len(p))
-	copy(pdup, p)
-	for i := range pdup {
-		pdup[i] = math.Max(m.Lower[i], pdup[i])
-		pdup[i] = math.Min(m.Upper[i], pdup[i])
+	return m.Mesh.Nearest(m.Bounds().Clip(p))
+}
+
+func (m *BoxMesh) NearestInto(dst, p []float64) []float64 {
+	dst = m.Bounds().ClipInto(dst, p)
+	return NearestInto(m.Mesh, dst, dst)
+}
+
+func (m *BoxMesh) NearestBatch(points [][]float64) [][]float64 {
+	b := m.Bounds()
+	clipped := make([][]float64, len(points))
+	for i, p := range points {
+		clipped[i] = b.Clip(p)
+	}
+	return NearestBatch(m.Mesh, clipped)
+}
+
+// Bounds returns the mesh's box bounds.
+func (m *BoxMesh) Bounds() Bounds { return Bounds{m.Lower, m.Upper} }
+
+// MeshCoords returns a function mapping positions to coordinates along the
+// axes of mesh's basis (unwrapping BoxMesh and the other wrappers in this
+// package to find an InfMesh).  Coordinates are not scaled by the step
+// size, so distances between them don't change as a search refines its
+// mesh.  If mesh has no basis, positions are returned unchanged.  The basis
+// is read once - call MeshCoords again after changing it.
+func MeshCoords(mesh Mesh) func(x []float64) []float64 {
+	basis, _ := meshGeometry(mesh)
+	if basis == nil {
+		return func(x []float64) []float64 { return x }
+	}
+	inv, err := mat64.Inverse(basis)
+	if err != nil {
+		panic("basis inversion failed: " + err.Error())
+	}
+	return func(x []float64) []float64 {
+		y := make([]float64, len(x))
+		for i := range y {
+			for j, v := range x {
+				y[i] += inv.At(i, j) * v
+			}
+		}
+		return y
+	}
+}
+
+// MeshDist returns a function computing the Euclidean distance between
+// positions in mesh's basis coordinates (see MeshCoords).  Use it in place
+// of raw Euclidean distance for diversity computations (niching, duplicate
+// filtering, elite selection) so skewed or stretched bases don't distort
+// them.
+func MeshDist(mesh Mesh) func(a, b []float64) float64 {
+	coords := MeshCoords(mesh)
+	return func(a, b []float64) float64 {
+		ca, cb := coords(a), coords(b)
+		tot := 0.0
+		for i := range ca {
+			d := ca[i] - cb[i]
+			tot += d * d
+		}
+		return math.Sqrt(tot)
 	}
-	return m.Mesh.Nearest(pdup)
 }
@@ -2,6 +2,7 @@ package optim
 
 import (
 	"math"
+	"sync"
 	"testing"
 
 	"github.com/gonum/matrix/mat64"
@@ -69,6 +70,52 @@ func TestSimple(t *testing.T) {
 	}
 }
 
+func TestSetBasis(t *testing.T) {
+	m := &InfMesh{StepSize: 1, Center: []float64{0, 0}}
+	p := []float64{1.0, 1.0}
+	if got := m.Nearest(p); got[0] != 1 || got[1] != 1 {
+		t.Fatalf("unit basis: got %v, expected [1 1]", got)
+	}
+
+	rot := tests[3]
+	m.SetBasis(rot.Basis)
+	got := m.Nearest(p)
+	for j := range got {
+		if diff := DiffInUlps(got[j], rot.Exp[j]); diff > 1 {
+			t.Errorf("rotated basis: got %v, expected %v", got, rot.Exp)
+			break
+		}
+	}
+
+	m.SetBasis(nil)
+	if got := m.Nearest(p); got[0] != 1 || got[1] != 1 {
+		t.Errorf("reset basis: got %v, expected [1 1]", got)
+	}
+}
+
+func TestNearestConcurrent(t *testing.T) {
+	prob := tests[3]
+	m := &InfMesh{StepSize: prob.Step, Basis: prob.Basis, Center: prob.Origin}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				got := m.Nearest(prob.Point)
+				for j := range got {
+					if diff := DiffInUlps(got[j], prob.Exp[j]); diff > 1 {
+						t.Errorf("got %v, expected %v", got, prob.Exp)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 func DiffInUlps(x, y float64) uint64 {
 	switch {
 	case math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0):
@@ -84,3 +131,122 @@ func DiffInUlps(x, y float64) uint64 {
 		return yi - xi
 	}
 }
+
+func TestNearestInto(t *testing.T) {
+	for i, prob := range tests {
+		m := &InfMesh{StepSize: prob.Step, Basis: prob.Basis, Center: prob.Origin}
+		want := m.Nearest(prob.Point)
+
+		p := append([]float64{}, prob.Point...)
+		got := m.NearestInto(p, p) // in place
+		for j := range got {
+			if got[j] != want[j] {
+				t.Errorf("prob %v: NearestInto got %v, Nearest got %v", i, got, want)
+				break
+			}
+		}
+
+		dst := make([]float64, len(prob.Point))
+		allocs := testing.AllocsPerRun(100, func() { dst = m.NearestInto(dst, prob.Point) })
+		if allocs != 0 {
+			t.Errorf("prob %v: NearestInto made %v allocations per call, want 0", i, allocs)
+		}
+	}
+
+	box := &BoxMesh{Mesh: &IntMesh{&InfMesh{StepSize: 1}}, Lower: []float64{0, 0}, Upper: []float64{3, 3}}
+	p := []float64{-1.2, 2.6}
+	want := box.Nearest(p)
+	got := NearestInto(box, nil, p)
+	if got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("box mesh: NearestInto got %v, Nearest got %v", got, want)
+	}
+}
+
+func TestNearestBatch(t *testing.T) {
+	for i, prob := range tests {
+		m := &InfMesh{StepSize: prob.Step, Basis: prob.Basis, Center: prob.Origin}
+		points := [][]float64{prob.Point, {-0.7, 2.2}, {3.1, -4.9}}
+		got := m.NearestBatch(points)
+		for j, p := range points {
+			want := m.Nearest(p)
+			for k := range want {
+				if diff := DiffInUlps(got[j][k], want[k]); diff > 1 {
+					t.Errorf("prob %v point %v: NearestBatch got %v, Nearest got %v", i, p, got[j], want)
+					break
+				}
+			}
+		}
+	}
+
+	box := &BoxMesh{Mesh: &IntMesh{&InfMesh{StepSize: 1}}, Lower: []float64{0, 0}, Upper: []float64{3, 3}}
+	points := [][]float64{{-1.2, 2.6}, {4, 1.4}}
+	got := NearestBatch(box, points)
+	for j, p := range points {
+		want := box.Nearest(p)
+		if got[j][0] != want[0] || got[j][1] != want[1] {
+			t.Errorf("box mesh point %v: NearestBatch got %v, Nearest got %v", p, got[j], want)
+		}
+	}
+}
+
+func TestRepairMesh(t *testing.T) {
+	// x0 + x1 <= 3 and x0 - x1 >= 0
+	A := mat64.NewDense(2, 2, []float64{1, 1, 1, -1})
+	low := mat64.NewDense(2, 1, []float64{math.Inf(-1), 0})
+	up := mat64.NewDense(2, 1, []float64{3, math.Inf(1)})
+	m := &RepairMesh{Mesh: &IntMesh{&InfMesh{StepSize: 1}}, Constr: &Constraints{A: A, Low: low, Up: up}}
+
+	cases := []struct {
+		p, want []float64
+	}{
+		{[]float64{1.6, 1.6}, []float64{2, 1}}, // rounds to infeasible [2 2]
+		{[]float64{0.9, 0.2}, []float64{1, 0}}, // already feasible
+		{[]float64{0.4, 3.2}, []float64{1, 1}}, // needs several moves
+	}
+	for _, test := range cases {
+		got := m.Nearest(test.p)
+		if got[0] != test.want[0] || got[1] != test.want[1] {
+			t.Errorf("Nearest(%v): want %v, got %v", test.p, test.want, got)
+		}
+	}
+
+	// only the second dimension is integer and adjustable
+	m.Int = []int{1}
+	if got := m.Nearest([]float64{1.6, 1.6}); got[0] != 2 || got[1] != 1 {
+		t.Errorf("mixed repair: want [2 1], got %v", got)
+	}
+	m.Int = []int{0}
+	if got := m.Nearest([]float64{0.4, 3.2}); m.Constr.Feasible(got) {
+		t.Errorf("mixed repair: want unrepairable point, got feasible %v", got)
+	}
+}
+
+func TestMeshDist(t *testing.T) {
+	// the first mesh axis is ten times longer than the second
+	inf := &InfMesh{StepSize: .5, Basis: mat64.NewDense(2, 2, []float64{10, 0, 0, 1})}
+	mesh := &BoxMesh{Mesh: inf, Lower: []float64{-100, -100}, Upper: []float64{100, 100}}
+	dist := MeshDist(mesh)
+	if d := dist([]float64{0, 0}, []float64{10, 0}); math.Abs(d-1) > 1e-12 {
+		t.Errorf("long axis: want distance 1, got %v", d)
+	}
+	if d := dist([]float64{3, 0}, []float64{3, 1}); math.Abs(d-1) > 1e-12 {
+		t.Errorf("short axis: want distance 1, got %v", d)
+	}
+	if d := MeshDist(nil)([]float64{0, 0}, []float64{3, 4}); d != 5 {
+		t.Errorf("no mesh: want euclidean distance 5, got %v", d)
+	}
+
+	h := &History{Coords: MeshCoords(mesh)}
+	h.Add(&Point{Pos: []float64{5, 0}}, &Point{Pos: []float64{0, 2}})
+	if nn := h.Nearest([]float64{0, 0}, 1); nn[0].Pos[0] != 5 {
+		t.Errorf("history nearest in mesh coordinates: want [5 0], got %v", nn[0])
+	}
+	if got := h.Within([]float64{0, 0}, 1); len(got) != 1 {
+		t.Errorf("history within 1 in mesh coordinates: want 1 point, got %v", got)
+	}
+
+	pts := []*Point{{Pos: []float64{0, 0}, Val: 0}, {Pos: []float64{5, 0}, Val: 1}, {Pos: []float64{0, 2}, Val: 2}}
+	if elite := EliteDist(pts, 3, 1, dist); len(elite) != 2 || elite[1].Val != 2 {
+		t.Errorf("elite in mesh coordinates: want values [0 2], got %v", elite)
+	}
+}
@@ -0,0 +1,107 @@
+package optim
+
+// PreHook processes a candidate position before it is evaluated.  It
+// modifies pos in place and must not change its length.
+type PreHook func(pos []float64)
+
+// PostHook observes an evaluated position and its result.  It must not
+// modify pos.
+type PostHook func(pos []float64, val float64, err error)
+
+// Middleware wraps a method and runs every point the method evaluates
+// through a chain of hooks, so cross-cutting concerns such as logging,
+// projection, clipping or jitter can be composed without modifying the
+// method.  Pre hooks run in order on each candidate position and modify it
+// in place - the method sees the processed positions in its evaluated
+// points.  The evalers in this package run them (see RunPreHooks) before
+// hashing, caching or deduplicating points, so e.g. two points clipped to
+// the same position are evaluated once.  Post hooks run in order after each
+// evaluation and may be called concurrently by evalers like ParallelEvaler.
+// Middleware passes Inject and Converged calls through to the wrapped
+// method.
+type Middleware struct {
+	Method
+	Pre  []PreHook
+	Post []PostHook
+}
+
+func (m *Middleware) Iterate(obj Objectiver, mesh Mesh) (best *Point, n int, err error) {
+	return m.Method.Iterate(&hookObj{Obj: obj, pre: m.Pre, post: m.Post}, mesh)
+}
+
+func (m *Middleware) Inject(points ...*Point) { Inject(m.Method, points...) }
+
+func (m *Middleware) Converged() bool {
+	c, ok := m.Method.(Converger)
+	return ok && c.Converged()
+}
+
+type hookObj struct {
+	Obj  Objectiver
+	pre  []PreHook
+	post []PostHook
+}
+
+func (o *hookObj) Objective(v []float64) (float64, error) {
+	for _, h := range o.pre {
+		h(v)
+	}
+	val, err := o.Obj.Objective(v)
+	for _, h := range o.post {
+		h(v, val, err)
+	}
+	return val, err
+}
+
+// RunPreHooks runs the pre hooks of obj, if it is an objective wrapped by a
+// Middleware, on the positions of points and returns the objective to
+// evaluate them with which no longer runs them.  Otherwise obj is returned
+// unchanged.  Evalers that hash, cache or otherwise compare positions
+// before evaluating them call this first so they see processed positions.
+func RunPreHooks(obj Objectiver, points []*Point) Objectiver {
+	h, ok := obj.(*hookObj)
+	if !ok || len(h.pre) == 0 {
+		return obj
+	}
+	for _, p := range points {
+		for _, fn := range h.pre {
+			fn(p.Pos)
+		}
+	}
+	return &hookObj{Obj: h.Obj, post: h.post}
+}
+
+// Clip returns a pre hook that clips positions to the box bounds low and
+// up.
+func Clip(low, up []float64) PreHook {
+	b := Bounds{Low: low, Up: up}
+	return func(pos []float64) { b.ClipInto(pos, pos) }
+}
+
+// Jitter returns a pre hook that adds normally distributed noise with
+// standard deviation scale[i] to each dimension i.
+func Jitter(scale []float64) PreHook {
+	return func(pos []float64) {
+		for i := range pos {
+			pos[i] += scale[i] * RandNorm()
+		}
+	}
+}
+
+// Project returns a pre hook that moves positions to the nearest location
+// on mesh.
+func Project(mesh Mesh) PreHook {
+	return func(pos []float64) { copy(pos, mesh.Nearest(pos)) }
+}
+
+// LogEvals returns a post hook that logs every evaluation to l at debug
+// level and failed evaluations at error level.
+func LogEvals(l Logger) PostHook {
+	return func(pos []float64, val float64, err error) {
+		if err != nil {
+			l.Log(LevelError, "evaluation failed", "pos", pos, "err", err)
+			return
+		}
+		l.Log(LevelDebug, "evaluated", "pos", pos, "val", val)
+	}
+}
@@ -0,0 +1,129 @@
+package optim
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+var errFail = errors.New("bad point")
+
+// failObj fails to evaluate points whose first coordinate is 2.
+type failObj struct{}
+
+func (failObj) Objective(v []float64) (float64, error) {
+	if v[0] == 2 {
+		return 0, errFail
+	}
+	return v[0], nil
+}
+
+// batchMethod is a fake Method that evaluates a fixed batch of points each
+// iteration.
+type batchMethod struct {
+	Pts    [][]float64
+	Result []*Point
+}
+
+func (m *batchMethod) AddPoint(p *Point) {}
+
+func (m *batchMethod) Iterate(obj Objectiver, mesh Mesh) (best *Point, n int, err error) {
+	pts := make([]*Point, len(m.Pts))
+	for i, pos := range m.Pts {
+		pts[i] = &Point{Pos: append([]float64{}, pos...)}
+	}
+	m.Result, n, err = SerialEvaler{ContinueOnErr: true}.Eval(obj, pts...)
+	best = m.Result[0]
+	for _, p := range m.Result {
+		if p.Val < best.Val {
+			best = p
+		}
+	}
+	return best, n, err
+}
+
+func TestMiddleware(t *testing.T) {
+	inner := &batchMethod{Pts: [][]float64{{-3, 0.5}, {0.25, 2}, {0.5, 0.5}}}
+	var mu sync.Mutex
+	var order []string
+	var observed [][]float64
+	mw := &Middleware{
+		Method: inner,
+		Pre: []PreHook{
+			Clip([]float64{0, 0}, []float64{1, 1}),
+			func(pos []float64) { mu.Lock(); order = append(order, "pre"); mu.Unlock() },
+		},
+		Post: []PostHook{func(pos []float64, val float64, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, "post")
+			observed = append(observed, append([]float64{}, pos...))
+		}},
+	}
+
+	obj := Func(func(v []float64) float64 { return v[0] + v[1] })
+	best, n, err := mw.Iterate(obj, &InfMesh{})
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 3 {
+		t.Errorf("want 3 evaluations, got %v", n)
+	}
+
+	want := [][]float64{{0, 0.5}, {0.25, 1}, {0.5, 0.5}}
+	for i, p := range inner.Result {
+		if p.Pos[0] != want[i][0] || p.Pos[1] != want[i][1] {
+			t.Errorf("point %v: method saw %v, want clipped %v", i, p.Pos, want[i])
+		}
+		if observed[i][0] != want[i][0] || observed[i][1] != want[i][1] {
+			t.Errorf("point %v: post hook saw %v, want %v", i, observed[i], want[i])
+		}
+	}
+	if best.Val != 0.5 {
+		t.Errorf("want best value 0.5 from clipped position, got %v", best.Val)
+	}
+	// pre hooks run on the whole batch before the evaler dedups it
+	for i, o := range order {
+		if (i < 3) != (o == "pre") {
+			t.Fatalf("hooks ran out of order: %v", order)
+		}
+	}
+}
+
+func TestMiddlewareDedup(t *testing.T) {
+	mw := &Middleware{
+		Method: &batchMethod{Pts: [][]float64{{-1, 0.5}, {-2, 0.5}}},
+		Pre:    []PreHook{Clip([]float64{0, 0}, []float64{1, 1})},
+	}
+	obj := Func(func(v []float64) float64 { return v[0] + v[1] })
+	if _, n, err := mw.Iterate(obj, &InfMesh{}); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("points clipped to the same position: want 1 evaluation, got %v", n)
+	}
+}
+
+func TestMiddlewareErrors(t *testing.T) {
+	var failed int
+	mw := &Middleware{
+		Method: &batchMethod{Pts: [][]float64{{1}, {2}}},
+		Post: []PostHook{func(pos []float64, val float64, err error) {
+			if err != nil {
+				failed++
+			}
+		}},
+	}
+	if _, _, err := mw.Iterate(failObj{}, &InfMesh{}); err != errFail {
+		t.Errorf("want error %v, got %v", errFail, err)
+	}
+	if failed != 1 {
+		t.Errorf("post hook saw %v failures, want 1", failed)
+	}
+}
+
+func TestProject(t *testing.T) {
+	pos := []float64{0.1, 0.9}
+	Project(&InfMesh{StepSize: 0.5})(pos)
+	if pos[0] != 0 || pos[1] != 1 {
+		t.Errorf("want position projected to [0 1], got %v", pos)
+	}
+}
@@ -0,0 +1,179 @@
+package multi
+
+import (
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// EpsConstraint approximates a Pareto front using the epsilon-constraint
+// method: objective Primary is minimized subject to every other objective j
+// being at most eps[j] for a grid of epsilon levels.  Each constrained
+// objective gets NLevels levels spanning the range between its individual
+// minimum and its worst value at the other objectives' individual minima
+// (i.e. from the payoff table).  Each subproblem is an ordinary
+// single-objective problem solved by a solver from NewSolver with the
+// constraints enforced by a penalty.  Every point evaluated along the way is
+// added to Archive, so the front includes all nondominated points found and
+// not just each subproblem's optimum.
+type EpsConstraint struct {
+	Obj Objectiver
+	// NObj is the number of objectives.
+	NObj int
+	// Primary is the index of the objective to minimize.
+	Primary int
+	// NLevels is the number of epsilon levels per constrained objective.
+	// If zero, 10 levels are used.
+	NLevels int
+	// Weight is the penalty added per unit of constraint violation relative
+	// to the constrained objective's range.  If zero, 1e3 is used.
+	Weight float64
+	// NewSolver returns a solver minimizing obj - e.g. a closure around
+	// pswarm.NewSolver.  It is called once per subproblem.
+	NewSolver func(obj optim.Objectiver) *optim.Solver
+	// Archive collects every evaluated point.  It is created if nil.
+	Archive *Archive
+}
+
+// Run solves the payoff table and epsilon subproblems and returns the
+// nondominated solutions found.
+func (e *EpsConstraint) Run() ([]Solution, error) {
+	if e.NObj < 2 {
+		return nil, errors.New("multi: epsilon-constraint needs at least 2 objectives")
+	}
+	if e.Archive == nil {
+		e.Archive = &Archive{}
+	}
+	nlevels := e.NLevels
+	if nlevels == 0 {
+		nlevels = 10
+	}
+
+	// payoff table: minimize each objective individually
+	ideal, nadir := make([]float64, e.NObj), make([]float64, e.NObj)
+	for j := range nadir {
+		nadir[j] = math.Inf(-1)
+	}
+	for j := 0; j < e.NObj; j++ {
+		_, vals, err := e.solve(j, nil, nil)
+		if err != nil {
+			return e.Archive.Solutions(), err
+		} else if vals == nil {
+			return e.Archive.Solutions(), errors.New("multi: no successful evaluations")
+		}
+		ideal[j] = vals[j]
+		for k, v := range vals {
+			nadir[k] = math.Max(nadir[k], v)
+		}
+	}
+
+	var constrained []int
+	for j := 0; j < e.NObj; j++ {
+		if j != e.Primary {
+			constrained = append(constrained, j)
+		}
+	}
+	ranges := make([]float64, e.NObj)
+	for j := range ranges {
+		ranges[j] = nadir[j] - ideal[j]
+		if ranges[j] <= 0 {
+			ranges[j] = 1
+		}
+	}
+
+	// sweep every combination of epsilon levels
+	level := make([]int, len(constrained))
+	eps := make([]float64, e.NObj)
+	for {
+		for i, j := range constrained {
+			eps[j] = ideal[j] + (nadir[j]-ideal[j])*float64(level[i])/math.Max(1, float64(nlevels-1))
+		}
+		if _, _, err := e.solve(e.Primary, eps, ranges); err != nil {
+			return e.Archive.Solutions(), err
+		}
+
+		i := 0
+		for ; i < len(level); i++ {
+			if level[i]++; level[i] < nlevels {
+				break
+			}
+			level[i] = 0
+		}
+		if i == len(level) {
+			break
+		}
+	}
+	return e.Archive.Solutions(), nil
+}
+
+func (e *EpsConstraint) solve(primary int, eps, ranges []float64) (pos, vals []float64, err error) {
+	weight := e.Weight
+	if weight == 0 {
+		weight = 1e3
+	}
+	return solveConstrained(e.NewSolver, e.Obj, e.Archive, primary, eps, ranges, weight)
+}
+
+// solveConstrained minimizes objective primary of obj with a solver from
+// newSolver subject to each other objective j being at most eps[j] and
+// returns the position and objective values of the best point found.
+// Violations are divided by ranges[j] (or 1 if ranges is nil) and
+// multiplied by weight to form a penalty.  If eps is nil, no constraints
+// are applied.  Every evaluated point is added to archive.
+func solveConstrained(newSolver func(optim.Objectiver) *optim.Solver, obj Objectiver, archive *Archive, primary int, eps, ranges []float64, weight float64) (pos, vals []float64, err error) {
+	o := &constrObjective{
+		obj:     obj,
+		archive: archive,
+		primary: primary,
+		eps:     eps,
+		ranges:  ranges,
+		weight:  weight,
+		best:    math.Inf(1),
+	}
+	s := newSolver(o)
+	for s.Next() {
+	}
+	return o.bestpos, o.bestvals, s.Err()
+}
+
+type constrObjective struct {
+	obj         Objectiver
+	archive     *Archive
+	primary     int
+	eps, ranges []float64
+	weight      float64
+	mu          sync.Mutex
+	best        float64
+	bestpos     []float64
+	bestvals    []float64
+}
+
+func (o *constrObjective) Objective(v []float64) (float64, error) {
+	vals, err := o.obj.Objectives(v)
+	if err != nil {
+		return math.Inf(1), err
+	}
+	o.archive.Add(Solution{Pos: v, Vals: vals})
+
+	val := vals[o.primary]
+	for j, eps := range o.eps {
+		if j != o.primary && vals[j] > eps {
+			scale := 1.0
+			if o.ranges != nil {
+				scale = o.ranges[j]
+			}
+			val += o.weight * (vals[j] - eps) / scale
+		}
+	}
+
+	o.mu.Lock()
+	if val < o.best {
+		o.best = val
+		o.bestpos = append([]float64{}, v...)
+		o.bestvals = append([]float64{}, vals...)
+	}
+	o.mu.Unlock()
+	return val, nil
+}
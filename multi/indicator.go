@@ -0,0 +1,143 @@
+package multi
+
+import (
+	"math"
+	"sort"
+)
+
+// Hypervolume returns the volume of objective space dominated by front and
+// bounded by the reference point ref (all objectives minimized).  Points
+// that don't strictly dominate ref contribute nothing.  It uses the WFG
+// algorithm from:
+//
+//	While, Lyndon, Lucas Bradstreet, and Luigi Barone. "A fast way of
+//	calculating exact hypervolumes." IEEE Transactions on Evolutionary
+//	Computation 16.1 (2012): 86-95.
+//
+// which is practical for fronts of a few hundred points with up to about six
+// objectives.
+func Hypervolume(front [][]float64, ref []float64) float64 {
+	pts := make([][]float64, 0, len(front))
+	for _, p := range front {
+		if beyond(p, ref) {
+			pts = append(pts, p)
+		}
+	}
+	return wfg(nondominated(pts), ref)
+}
+
+// wfg returns the hypervolume of the mutually nondominated points pts.
+func wfg(pts [][]float64, ref []float64) float64 {
+	if len(pts) == 0 {
+		return 0
+	} else if len(ref) == 2 {
+		return hv2(pts, ref)
+	}
+
+	// processing points in decreasing order of the last objective keeps
+	// the limit sets small.
+	last := len(ref) - 1
+	sort.Slice(pts, func(i, j int) bool { return pts[i][last] > pts[j][last] })
+
+	vol := 0.0
+	for k, p := range pts {
+		vol += inclusive(p, ref) - wfg(limitset(pts[k+1:], p), ref)
+	}
+	return vol
+}
+
+// limitset returns the nondominated set of pts each limited to be no better
+// than p in every objective.
+func limitset(pts [][]float64, p []float64) [][]float64 {
+	lim := make([][]float64, len(pts))
+	for i, q := range pts {
+		lim[i] = make([]float64, len(q))
+		for j := range q {
+			lim[i][j] = math.Max(p[j], q[j])
+		}
+	}
+	return nondominated(lim)
+}
+
+// hv2 returns the hypervolume of nondominated 2-objective points with a
+// single sweep.
+func hv2(pts [][]float64, ref []float64) float64 {
+	sort.Slice(pts, func(i, j int) bool { return pts[i][0] < pts[j][0] })
+	vol := 0.0
+	prev := ref[1]
+	for _, p := range pts {
+		vol += (ref[0] - p[0]) * (prev - p[1])
+		prev = p[1]
+	}
+	return vol
+}
+
+// inclusive returns the volume dominated by p alone.
+func inclusive(p, ref []float64) float64 {
+	vol := 1.0
+	for i := range p {
+		vol *= ref[i] - p[i]
+	}
+	return vol
+}
+
+// beyond reports whether p is strictly better than ref in every objective.
+func beyond(p, ref []float64) bool {
+	for i := range p {
+		if p[i] >= ref[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// nondominated returns the points in pts not dominated by or equal to an
+// earlier point.
+func nondominated(pts [][]float64) [][]float64 {
+	nd := make([][]float64, 0, len(pts))
+	for _, p := range pts {
+		dominated := false
+		for _, q := range nd {
+			if Dominates(q, p) || equal(q, p) {
+				dominated = true
+				break
+			}
+		}
+		if dominated {
+			continue
+		}
+		kept := nd[:0]
+		for _, q := range nd {
+			if !Dominates(p, q) {
+				kept = append(kept, q)
+			}
+		}
+		nd = append(kept, p)
+	}
+	return nd
+}
+
+// IGD returns the inverted generational distance of front from the
+// reference points sampling the true Pareto front - the mean Euclidean
+// distance from each reference point to its nearest point in front.  Lower
+// is better; it penalizes both fronts that are far from the true front and
+// fronts that leave parts of it uncovered.  IGD returns positive infinity if
+// front is empty.
+func IGD(front, reference [][]float64) float64 {
+	if len(front) == 0 {
+		return math.Inf(1)
+	}
+	sum := 0.0
+	for _, r := range reference {
+		min := math.Inf(1)
+		for _, p := range front {
+			d := 0.0
+			for i := range r {
+				d += (p[i] - r[i]) * (p[i] - r[i])
+			}
+			min = math.Min(min, d)
+		}
+		sum += math.Sqrt(min)
+	}
+	return sum / float64(len(reference))
+}
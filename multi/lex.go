@@ -0,0 +1,74 @@
+package multi
+
+import (
+	"errors"
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Lexicographic minimizes objectives in strict priority order: objective 0
+// is minimized first, then objective 1 subject to objective 0 staying
+// within Tol[0] of its optimum, then objective 2 subject to both earlier
+// objectives staying within tolerance, and so on.  Each stage is an ordinary
+// single-objective problem solved by a solver from NewSolver with the
+// tolerance constraints enforced by a penalty.
+type Lexicographic struct {
+	Obj Objectiver
+	// NObj is the number of objectives in priority order.
+	NObj int
+	// Tol holds the absolute amount each objective may degrade from its
+	// optimum while lower priority objectives are minimized.  Missing
+	// entries are zero.
+	Tol []float64
+	// Weight is the penalty added per unit an earlier objective exceeds
+	// its tolerance.  If zero, 1e6 is used.
+	Weight float64
+	// NewSolver returns a solver minimizing obj.  It is called once per
+	// stage.
+	NewSolver func(obj optim.Objectiver) *optim.Solver
+	// Archive collects every evaluated point.  It is created if nil.
+	Archive *Archive
+	// Optima holds the optimum found for each objective at its stage.
+	Optima []float64
+}
+
+// Run performs every stage and returns the best solution of the last.
+func (l *Lexicographic) Run() (Solution, error) {
+	if l.NObj < 1 {
+		return Solution{}, errors.New("multi: lexicographic optimization needs at least 1 objective")
+	}
+	if l.Archive == nil {
+		l.Archive = &Archive{}
+	}
+	weight := l.Weight
+	if weight == 0 {
+		weight = 1e6
+	}
+
+	var best Solution
+	eps := make([]float64, l.NObj)
+	for j := range eps {
+		eps[j] = math.Inf(1)
+	}
+	l.Optima = l.Optima[:0]
+	for k := 0; k < l.NObj; k++ {
+		pos, vals, err := solveConstrained(l.NewSolver, l.Obj, l.Archive, k, eps, nil, weight)
+		if vals != nil {
+			best = Solution{Pos: pos, Vals: vals}
+		}
+		if err != nil {
+			return best, err
+		} else if vals == nil {
+			return best, errors.New("multi: no successful evaluations")
+		}
+
+		tol := 0.0
+		if k < len(l.Tol) {
+			tol = l.Tol[k]
+		}
+		l.Optima = append(l.Optima, vals[k])
+		eps[k] = vals[k] + tol
+	}
+	return best, nil
+}
@@ -0,0 +1,181 @@
+// Package multi provides multi-objective optimization support built on the
+// single-objective methods of package optim.  Problems are reduced to
+// series of single-objective problems (e.g. by EpsConstraint) that any
+// optim.Solver can solve, while every evaluated point is collected into a
+// nondominated Archive approximating the Pareto front.
+package multi
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Objectiver is a vector-valued objective function.  All objectives must be
+// framed so that lower values are better.  If the evaluation fails,
+// positive infinity should be returned for every objective along with an
+// error.
+type Objectiver interface {
+	Objectives(v []float64) ([]float64, error)
+}
+
+// Func adapts an ordinary function into an Objectiver.
+type Func func(v []float64) []float64
+
+func (fn Func) Objectives(v []float64) ([]float64, error) { return fn(v), nil }
+
+// Solution is an evaluated point and its objective values.
+type Solution struct {
+	Pos  []float64
+	Vals []float64
+}
+
+// Dominates reports whether objective values a Pareto-dominate b - i.e. a is
+// no worse than b in every objective and better in at least one.
+func Dominates(a, b []float64) bool {
+	better := false
+	for i := range a {
+		if a[i] > b[i] {
+			return false
+		} else if a[i] < b[i] {
+			better = true
+		}
+	}
+	return better
+}
+
+// Archive is a set of mutually nondominated solutions.  It is safe for
+// concurrent use.
+type Archive struct {
+	mu   sync.Mutex
+	sols []Solution
+}
+
+// Add adds s to the archive if no archived solution dominates or equals it,
+// removing any archived solutions s dominates.  Solutions with non-finite
+// objective values are ignored.  Add reports whether s was added.  s's
+// slices are copied.
+func (a *Archive) Add(s Solution) bool {
+	for _, v := range s.Vals {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return false
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	kept := a.sols[:0]
+	for _, old := range a.sols {
+		if Dominates(old.Vals, s.Vals) || equal(old.Vals, s.Vals) {
+			return false
+		}
+		if !Dominates(s.Vals, old.Vals) {
+			kept = append(kept, old)
+		}
+	}
+	a.sols = append(kept, Solution{
+		Pos:  append([]float64{}, s.Pos...),
+		Vals: append([]float64{}, s.Vals...),
+	})
+	return true
+}
+
+// Len returns the number of archived solutions.
+func (a *Archive) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.sols)
+}
+
+// Solutions returns the archived solutions ordered by their objective
+// values (first objective first).
+func (a *Archive) Solutions() []Solution {
+	a.mu.Lock()
+	sols := append([]Solution{}, a.sols...)
+	a.mu.Unlock()
+	sort.Slice(sols, func(i, j int) bool { return lessVals(sols[i].Vals, sols[j].Vals) })
+	return sols
+}
+
+// Front returns the objective values of the archived solutions ordered as
+// by Solutions.
+func (a *Archive) Front() [][]float64 {
+	sols := a.Solutions()
+	front := make([][]float64, len(sols))
+	for i, s := range sols {
+		front[i] = s.Vals
+	}
+	return front
+}
+
+// Truncate removes the most crowded solutions (see CrowdingDistance) one at
+// a time until at most n remain.
+func (a *Archive) Truncate(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for len(a.sols) > n {
+		front := make([][]float64, len(a.sols))
+		for i, s := range a.sols {
+			front[i] = s.Vals
+		}
+		crowd := CrowdingDistance(front)
+		worst := 0
+		for i, d := range crowd {
+			if d < crowd[worst] {
+				worst = i
+			}
+		}
+		a.sols = append(a.sols[:worst], a.sols[worst+1:]...)
+	}
+}
+
+// CrowdingDistance returns the crowding distance of each point in front
+// from:
+//
+//	Deb, Kalyanmoy, et al. "A fast and elitist multiobjective genetic
+//	algorithm: NSGA-II." IEEE Transactions on Evolutionary Computation 6.2
+//	(2002): 182-197.
+//
+// It is the sum over objectives of the normalized distance between each
+// point's neighbors and measures how isolated the point is.  Extreme points
+// in any objective have infinite distance.
+func CrowdingDistance(front [][]float64) []float64 {
+	dist := make([]float64, len(front))
+	if len(front) == 0 {
+		return dist
+	}
+	idx := make([]int, len(front))
+	for m := range front[0] {
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(i, j int) bool { return front[idx[i]][m] < front[idx[j]][m] })
+		lo, hi := front[idx[0]][m], front[idx[len(idx)-1]][m]
+		dist[idx[0]], dist[idx[len(idx)-1]] = math.Inf(1), math.Inf(1)
+		if hi == lo {
+			continue
+		}
+		for i := 1; i < len(idx)-1; i++ {
+			dist[idx[i]] += (front[idx[i+1]][m] - front[idx[i-1]][m]) / (hi - lo)
+		}
+	}
+	return dist
+}
+
+func equal(a, b []float64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func lessVals(a, b []float64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package multi
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/pswarm"
+)
+
+// twoCircles has the Pareto set y=0, 0 <= x <= 2 and front
+// f2 = (2 - sqrt(f1))^2.
+var twoCircles = Func(func(v []float64) []float64 {
+	return []float64{v[0]*v[0] + v[1]*v[1], (v[0]-2)*(v[0]-2) + v[1]*v[1]}
+})
+
+func newSolver(obj optim.Objectiver) *optim.Solver {
+	s, _ := pswarm.NewSolver(obj, []float64{-3, -3}, []float64{3, 3}, pswarm.MaxEval(1500), pswarm.WithEvaler(optim.SerialEvaler{}))
+	return s
+}
+
+func TestArchive(t *testing.T) {
+	a := &Archive{}
+	adds := []struct {
+		vals []float64
+		want bool
+	}{
+		{[]float64{2, 2}, true},
+		{[]float64{3, 3}, false}, // dominated
+		{[]float64{1, 3}, true},
+		{[]float64{2, 2}, false}, // duplicate
+		{[]float64{1, 1}, true},  // dominates both
+		{[]float64{math.Inf(1), 0}, false},
+	}
+	for _, add := range adds {
+		if got := a.Add(Solution{Vals: add.vals}); got != add.want {
+			t.Errorf("Add(%v): want %v, got %v", add.vals, add.want, got)
+		}
+	}
+	if front := a.Front(); len(front) != 1 || front[0][0] != 1 || front[0][1] != 1 {
+		t.Errorf("want front [[1 1]], got %v", front)
+	}
+}
+
+func TestEpsConstraint(t *testing.T) {
+	e := &EpsConstraint{Obj: twoCircles, NObj: 2, NLevels: 6, NewSolver: newSolver}
+	sols, err := e.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sols) < 6 {
+		t.Fatalf("want at least 6 front points, got %v", len(sols))
+	}
+
+	maxerr, meanerr := 0.0, 0.0
+	for _, s := range sols {
+		want := (2 - math.Sqrt(s.Vals[0])) * (2 - math.Sqrt(s.Vals[0]))
+		maxerr = math.Max(maxerr, s.Vals[1]-want)
+		meanerr += (s.Vals[1] - want) / float64(len(sols))
+	}
+	t.Logf("%v front points: mean error %v, max error %v", len(sols), meanerr, maxerr)
+	if meanerr > 0.01 || maxerr > 0.1 {
+		t.Errorf("front is far from the true front: mean error %v, max error %v", meanerr, maxerr)
+	}
+
+	first, last := sols[0].Vals, sols[len(sols)-1].Vals
+	if first[0] > 0.01 || last[1] > 0.01 {
+		t.Errorf("front does not span the extremes: %v to %v", first, last)
+	}
+}
+
+func TestLexicographic(t *testing.T) {
+	// with f1 allowed to rise to 1, f2 is minimized at (1, 0) where both
+	// objectives are 1.
+	l := &Lexicographic{Obj: twoCircles, NObj: 2, Tol: []float64{1}, NewSolver: newSolver}
+	best, err := l.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("optima=%v best=%v", l.Optima, best)
+	if math.Abs(l.Optima[0]) > 1e-2 {
+		t.Errorf("f1 optimum: want 0, got %v", l.Optima[0])
+	}
+	if best.Vals[0] > 1+1e-3 {
+		t.Errorf("f1 tolerance violated: got %v", best.Vals[0])
+	}
+	if math.Abs(best.Vals[1]-1) > 2e-2 {
+		t.Errorf("f2: want 1, got %v", best.Vals[1])
+	}
+}
+
+func TestHypervolume(t *testing.T) {
+	tests := []struct {
+		front [][]float64
+		ref   []float64
+		want  float64
+	}{
+		{[][]float64{{1, 1}}, []float64{2, 2}, 1},
+		{[][]float64{{0, 2}, {1, 1}, {2, 0}}, []float64{3, 3}, 6},
+		// dominated, duplicate, and out of bounds points don't count
+		{[][]float64{{1, 1}, {1.5, 1.5}, {1, 1}, {4, 0}}, []float64{2, 2}, 1},
+		{[][]float64{{0, 0, 0}}, []float64{1, 2, 3}, 6},
+		// union of two overlapping boxes: 8 + 6 - 4
+		{[][]float64{{0, 0, 1}, {1, 0, 0}}, []float64{2, 2, 3}, 10},
+	}
+	for i, test := range tests {
+		if got := Hypervolume(test.front, test.ref); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("case %v: want %v, got %v", i, test.want, got)
+		}
+	}
+
+	// compare a random 4-objective front against Monte Carlo sampling
+	rng := rand.New(rand.NewSource(1))
+	ref := []float64{1, 1, 1, 1}
+	var front [][]float64
+	for i := 0; i < 30; i++ {
+		p := make([]float64, 4)
+		for j := range p {
+			p[j] = rng.Float64()
+		}
+		front = append(front, p)
+	}
+	n, hits := 200000, 0
+	for i := 0; i < n; i++ {
+		x := make([]float64, 4)
+		for j := range x {
+			x[j] = rng.Float64()
+		}
+		for _, p := range front {
+			if Dominates(p, x) {
+				hits++
+				break
+			}
+		}
+	}
+	want := float64(hits) / float64(n)
+	if got := Hypervolume(front, ref); math.Abs(got-want) > 0.01 {
+		t.Errorf("random front: want ~%v, got %v", want, got)
+	}
+}
+
+func TestIGD(t *testing.T) {
+	reference := [][]float64{{0, 1}, {1, 0}}
+	if got := IGD(reference, reference); got != 0 {
+		t.Errorf("identical fronts: want 0, got %v", got)
+	}
+	if got, want := IGD([][]float64{{0, 1}}, reference), math.Sqrt(2)/2; math.Abs(got-want) > 1e-12 {
+		t.Errorf("half covered: want %v, got %v", want, got)
+	}
+	if got := IGD(nil, reference); !math.IsInf(got, 1) {
+		t.Errorf("empty front: want +Inf, got %v", got)
+	}
+}
+
+func TestCrowdingDistance(t *testing.T) {
+	front := [][]float64{{0, 4}, {1, 2}, {3, 1}, {4, 0}}
+	got := CrowdingDistance(front)
+	want := []float64{math.Inf(1), 3.0/4 + 3.0/4, 3.0/4 + 2.0/4, math.Inf(1)}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("point %v: want %v, got %v", i, want[i], got[i])
+		}
+	}
+
+	a := &Archive{}
+	for _, v := range front {
+		a.Add(Solution{Vals: v})
+	}
+	a.Truncate(3)
+	if f := a.Front(); len(f) != 3 || f[1][0] != 1 {
+		t.Errorf("truncate removed the wrong point: %v", f)
+	}
+}
+
+func TestSMPSO(t *testing.T) {
+	optim.Rand = &optim.LockedRng{Rng: rand.New(rand.NewSource(1))}
+	s := &SMPSO{Obj: twoCircles, Low: []float64{-3, -3}, Up: []float64{3, 3}, MaxEval: 5000}
+	sols, err := s.Run()
+	if err != nil {
+		t.Fatal(err)
+	} else if s.Neval != 5000 {
+		t.Errorf("want 5000 evaluations, got %v", s.Neval)
+	}
+
+	var front, truefront [][]float64
+	for _, sol := range sols {
+		front = append(front, sol.Vals)
+	}
+	for i := 0; i <= 100; i++ {
+		f1 := 4 * float64(i) / 100
+		truefront = append(truefront, []float64{f1, (2 - math.Sqrt(f1)) * (2 - math.Sqrt(f1))})
+	}
+	igd := IGD(front, truefront)
+	t.Logf("%v solutions, IGD %v", len(sols), igd)
+	if igd > 0.02 {
+		t.Errorf("want IGD <= 0.02, got %v", igd)
+	}
+}
+
+func TestSMPSOInfeasible(t *testing.T) {
+	inf := Func(func(v []float64) []float64 { return []float64{math.Inf(1), math.Inf(1)} })
+	s := &SMPSO{Obj: inf, Low: []float64{-3, -3}, Up: []float64{3, 3}, MaxEval: 500}
+	if _, err := s.Run(); err != nil {
+		t.Fatal(err)
+	} else if s.Neval != 500 {
+		t.Errorf("want 500 evaluations, got %v", s.Neval)
+	}
+}
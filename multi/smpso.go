@@ -0,0 +1,198 @@
+package multi
+
+import (
+	"errors"
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// SMPSO is the speed-constrained multi-objective particle swarm from:
+//
+//	Nebro, Antonio J., et al. "SMPSO: A new PSO-based metaheuristic for
+//	multi-objective optimization." IEEE Symposium on Computational
+//	Intelligence in Multi-Criteria Decision-Making (2009): 66-73.
+//
+// It extends OMOPSO - particles follow leaders chosen by crowding distance
+// from a bounded archive of nondominated solutions, and a sixth of the
+// particles undergo polynomial mutation each iteration - with velocity
+// constriction that keeps particles from flying out of the bounds.  Random
+// numbers are drawn from optim.Rand.
+type SMPSO struct {
+	Obj     Objectiver
+	Low, Up []float64
+	// Population is the number of particles.  If zero, 100 is used.
+	Population int
+	// Leaders is the maximum number of leaders kept.  If zero, Population
+	// is used.
+	Leaders int
+	// MaxEval is the objective evaluation budget.
+	MaxEval int
+	// MutationEta is the polynomial mutation distribution index.  If zero,
+	// 20 is used.
+	MutationEta float64
+	// Archive collects every evaluated point.  It is created if nil.
+	Archive *Archive
+	// Neval is the number of objective evaluations performed by Run.
+	Neval int
+}
+
+type mparticle struct {
+	Solution
+	vel  []float64
+	best Solution
+}
+
+// Run performs iterations until the MaxEval budget is used and returns the
+// nondominated solutions found.
+func (s *SMPSO) Run() ([]Solution, error) {
+	if s.MaxEval <= 0 {
+		return nil, errors.New("multi: SMPSO needs a positive MaxEval")
+	}
+	if s.Archive == nil {
+		s.Archive = &Archive{}
+	}
+	npop := s.Population
+	if npop == 0 {
+		npop = 100
+	}
+	nlead := s.Leaders
+	if nlead == 0 {
+		nlead = npop
+	}
+	eta := s.MutationEta
+	if eta == 0 {
+		eta = 20
+	}
+	ndim := len(s.Low)
+	delta := make([]float64, ndim)
+	for i := range delta {
+		delta[i] = (s.Up[i] - s.Low[i]) / 2
+	}
+
+	s.Neval = 0
+	leaders := &Archive{}
+	pop := make([]*mparticle, npop)
+	for i := range pop {
+		p := &mparticle{vel: make([]float64, ndim)}
+		p.Pos = make([]float64, ndim)
+		for j := range p.Pos {
+			p.Pos[j] = s.Low[j] + optim.RandFloat()*(s.Up[j]-s.Low[j])
+		}
+		pop[i] = p
+	}
+
+	for iter := 0; ; iter++ {
+		for _, p := range pop {
+			if s.Neval >= s.MaxEval {
+				return s.Archive.Solutions(), nil
+			}
+			vals, err := s.Obj.Objectives(p.Pos)
+			s.Neval++
+			if err != nil {
+				return s.Archive.Solutions(), err
+			}
+			p.Vals = vals
+			s.Archive.Add(p.Solution)
+			if leaders.Add(p.Solution) {
+				leaders.Truncate(nlead)
+			}
+
+			switch {
+			case iter == 0 || Dominates(p.Vals, p.best.Vals):
+				p.best = clonesol(p.Solution)
+			case Dominates(p.best.Vals, p.Vals):
+			case optim.RandFloat() < .5:
+				p.best = clonesol(p.Solution)
+			}
+		}
+
+		lead := leaders.Solutions()
+		crowd := CrowdingDistance(leaders.Front())
+		for i, p := range pop {
+			// without finite archived solutions, particles follow their
+			// own best
+			leader := p.best.Pos
+			if len(lead) > 0 {
+				leader = lead[tournament(crowd)].Pos
+			}
+			s.move(p, leader, delta)
+			if i%6 == 0 {
+				s.mutate(p.Pos, eta)
+			}
+		}
+	}
+}
+
+// move updates p's velocity and position toward leader using the SMPSO
+// constricted velocity update.
+func (s *SMPSO) move(p *mparticle, leader, delta []float64) {
+	r1, r2 := optim.RandFloat(), optim.RandFloat()
+	c1, c2 := 1.5+optim.RandFloat(), 1.5+optim.RandFloat()
+	const w = 0.1
+	chi := constriction(c1 + c2)
+	for j := range p.Pos {
+		v := chi * (w*p.vel[j] + c1*r1*(p.best.Pos[j]-p.Pos[j]) + c2*r2*(leader[j]-p.Pos[j]))
+		p.vel[j] = math.Max(-delta[j], math.Min(delta[j], v))
+
+		p.Pos[j] += p.vel[j]
+	}
+
+	// bounce off the bounds
+	clipped := optim.Bounds{Low: s.Low, Up: s.Up}.Clip(p.Pos)
+	for j := range clipped {
+		if clipped[j] != p.Pos[j] {
+			p.vel[j] = -p.vel[j]
+		}
+	}
+	p.Pos = clipped
+}
+
+// mutate applies polynomial mutation with distribution index eta to each
+// dimension of pos with probability 1/len(pos).
+func (s *SMPSO) mutate(pos []float64, eta float64) {
+	for j, y := range pos {
+		if optim.RandFloat() >= 1/float64(len(pos)) {
+			continue
+		}
+		lo, up := s.Low[j], s.Up[j]
+		if up <= lo {
+			continue
+		}
+		d1, d2 := (y-lo)/(up-lo), (up-y)/(up-lo)
+		rnd := optim.RandFloat()
+		pow := 1 / (eta + 1)
+		var dq float64
+		if rnd <= .5 {
+			val := 2*rnd + (1-2*rnd)*math.Pow(1-d1, eta+1)
+			dq = math.Pow(val, pow) - 1
+		} else {
+			val := 2*(1-rnd) + 2*(rnd-.5)*math.Pow(1-d2, eta+1)
+			dq = 1 - math.Pow(val, pow)
+		}
+		pos[j] = math.Max(lo, math.Min(up, y+dq*(up-lo)))
+	}
+}
+
+// constriction returns the constriction coefficient for phi = c1 + c2 or 1
+// if phi <= 4.
+func constriction(phi float64) float64 {
+	if phi <= 4 {
+		return 1
+	}
+	return 2 / math.Abs(2-phi-math.Sqrt(phi*phi-4*phi))
+}
+
+// tournament returns the index of the less crowded of two randomly chosen
+// solutions.
+func tournament(crowd []float64) int {
+	a, b := optim.Rand.Intn(len(crowd)), optim.Rand.Intn(len(crowd))
+	if crowd[b] > crowd[a] {
+		return b
+	}
+	return a
+}
+
+func clonesol(s Solution) Solution {
+	return Solution{Pos: append([]float64{}, s.Pos...), Vals: append([]float64{}, s.Vals...)}
+}
@@ -0,0 +1,217 @@
+package optim
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Mutator perturbs positions for population methods.  Perturbations are
+// drawn along the axes of the mesh passed to Mutate - following a rotated
+// InfMesh basis rather than assuming axis-aligned coordinates - and are
+// scaled in units of the mesh step (or in absolute units if mesh is nil or
+// continuous).  Mutated positions outside a BoxMesh's bounds are reflected
+// back inside them.  Mutate must not modify x.
+type Mutator interface {
+	Mutate(x []float64, mesh Mesh) []float64
+}
+
+// Gaussian mutates every mesh axis by normally distributed noise with
+// standard deviation Scale.
+type Gaussian struct {
+	Scale float64
+	// Rng is the source of random numbers.  If nil, Rand is used.
+	Rng Rng
+}
+
+func (g Gaussian) Mutate(x []float64, mesh Mesh) []float64 {
+	z := make([]float64, len(x))
+	for i := range z {
+		z[i] = g.Scale * RandNormFrom(rngOr(g.Rng))
+	}
+	return perturb(x, z, mesh)
+}
+
+// Cauchy mutates every mesh axis by Cauchy distributed noise with scale
+// parameter Scale.  Its heavy tails occasionally produce long jumps that
+// help escape local optima.
+type Cauchy struct {
+	Scale float64
+	// Rng is the source of random numbers.  If nil, Rand is used.
+	Rng Rng
+}
+
+func (c Cauchy) Mutate(x []float64, mesh Mesh) []float64 {
+	rng := rngOr(c.Rng)
+	z := make([]float64, len(x))
+	for i := range z {
+		z[i] = c.Scale * math.Tan(math.Pi*(rng.Float64()-.5))
+	}
+	return perturb(x, z, mesh)
+}
+
+// LatticeHop moves a position to a nearby mesh point: each mesh axis is
+// moved with probability Prob (at least one axis always moves) by a
+// random whole number of steps between 1 and MaxHops in either direction.
+// Positions on the mesh stay on the mesh.
+type LatticeHop struct {
+	Prob    float64
+	MaxHops int
+	// Rng is the source of random numbers.  If nil, Rand is used.
+	Rng Rng
+}
+
+func (h LatticeHop) Mutate(x []float64, mesh Mesh) []float64 {
+	maxhops := h.MaxHops
+	if maxhops < 1 {
+		maxhops = 1
+	}
+	rng := rngOr(h.Rng)
+	z := make([]float64, len(x))
+	hop := func(i int) {
+		z[i] = float64(1 + rng.Intn(maxhops))
+		if rng.Float64() < .5 {
+			z[i] = -z[i]
+		}
+	}
+	for i := range z {
+		if rng.Float64() < h.Prob {
+			hop(i)
+		}
+	}
+	if moved := false; len(z) > 0 {
+		for _, v := range z {
+			moved = moved || v != 0
+		}
+		if !moved {
+			hop(rng.Intn(len(z)))
+		}
+	}
+	return perturb(x, z, mesh)
+}
+
+// Adaptive mutates each mesh axis by normally distributed noise with its own
+// standard deviation Sigma[i] and adapts the deviations to the search's
+// success: call Feedback after evaluating each mutated position to widen
+// the mutated axes after improvements and narrow them after failures (a
+// per-axis variant of the 1/5th success rule).  Adaptive is not safe for
+// concurrent use.
+type Adaptive struct {
+	// Sigma holds each axis' standard deviation.  If nil, it is initialized
+	// to ones on the first call to Mutate.
+	Sigma []float64
+	// Grow and Shrink are the factors Sigma is multiplied by on success
+	// and failure.  If zero, 1.5 and 1.5^(-1/4) are used so Sigma is
+	// stable at a success rate of 1/5.
+	Grow, Shrink float64
+	// MinSigma and MaxSigma limit Sigma if non-zero.
+	MinSigma, MaxSigma float64
+	// Rng is the source of random numbers.  If nil, Rand is used.
+	Rng Rng
+}
+
+func (a *Adaptive) Mutate(x []float64, mesh Mesh) []float64 {
+	if a.Sigma == nil {
+		a.Sigma = make([]float64, len(x))
+		for i := range a.Sigma {
+			a.Sigma[i] = 1
+		}
+	}
+	z := make([]float64, len(x))
+	for i := range z {
+		z[i] = a.Sigma[i] * RandNormFrom(rngOr(a.Rng))
+	}
+	return perturb(x, z, mesh)
+}
+
+// Feedback adapts Sigma given whether the last mutated position improved
+// on its parent.
+func (a *Adaptive) Feedback(improved bool) {
+	grow, shrink := a.Grow, a.Shrink
+	if grow == 0 {
+		grow = 1.5
+	}
+	if shrink == 0 {
+		shrink = math.Pow(1.5, -.25)
+	}
+	f := shrink
+	if improved {
+		f = grow
+	}
+	for i := range a.Sigma {
+		a.Sigma[i] *= f
+		if a.MinSigma > 0 {
+			a.Sigma[i] = math.Max(a.Sigma[i], a.MinSigma)
+		}
+		if a.MaxSigma > 0 {
+			a.Sigma[i] = math.Min(a.Sigma[i], a.MaxSigma)
+		}
+	}
+}
+
+// rngOr returns r or Rand if r is nil.
+func rngOr(r Rng) Rng {
+	if r == nil {
+		return Rand
+	}
+	return r
+}
+
+// perturb returns x moved by z mesh steps along the mesh axes and reflected
+// into the mesh bounds.
+func perturb(x, z []float64, mesh Mesh) []float64 {
+	step := 1.0
+	if mesh != nil && mesh.Step() > 0 {
+		step = mesh.Step()
+	}
+	basis, bounds := meshGeometry(mesh)
+
+	delta := make([]float64, len(z))
+	if r, c := dims(basis); r == len(z) && c == len(z) {
+		for i := range delta {
+			for j := range z {
+				delta[i] += basis.At(i, j) * z[j]
+			}
+		}
+	} else {
+		copy(delta, z)
+	}
+
+	y := make([]float64, len(x))
+	for i := range y {
+		y[i] = x[i] + step*delta[i]
+	}
+	if bounds != nil {
+		y = bounds.Reflect(y)
+	}
+	return y
+}
+
+// meshGeometry returns the basis and box bounds of mesh by unwrapping this
+// package's mesh wrappers.  Either may be nil.
+func meshGeometry(mesh Mesh) (basis *mat64.Dense, bounds *Bounds) {
+	for mesh != nil {
+		switch m := mesh.(type) {
+		case *InfMesh:
+			m.mu.Lock()
+			basis = m.Basis
+			m.mu.Unlock()
+			return basis, bounds
+		case *BoxMesh:
+			if bounds == nil {
+				b := m.Bounds()
+				bounds = &b
+			}
+			mesh = m.Mesh
+		case *MaxStepMesh:
+			mesh = m.Mesh
+		case *IntMesh:
+			mesh = m.Mesh
+		case *RepairMesh:
+			mesh = m.Mesh
+		default:
+			return basis, bounds
+		}
+	}
+	return basis, bounds
+}
@@ -0,0 +1,69 @@
+package optim
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestMutators(t *testing.T) {
+	Rand = &LockedRng{Rng: rand.New(rand.NewSource(1))}
+	x := []float64{0, 0}
+	box := &BoxMesh{Mesh: &InfMesh{StepSize: .5}, Lower: []float64{-1, -1}, Upper: []float64{1, 1}}
+	muts := []Mutator{Gaussian{Scale: 3}, Cauchy{Scale: 3}, LatticeHop{Prob: 1, MaxHops: 4}, &Adaptive{Sigma: []float64{3, 3}}}
+	for _, mut := range muts {
+		moved := false
+		for i := 0; i < 100; i++ {
+			y := mut.Mutate(x, box)
+			if !box.Bounds().Contains(y) {
+				t.Fatalf("%T: mutated position %v outside the mesh bounds", mut, y)
+			}
+			moved = moved || y[0] != 0 || y[1] != 0
+		}
+		if !moved {
+			t.Errorf("%T: never moved", mut)
+		}
+	}
+	if x[0] != 0 || x[1] != 0 {
+		t.Errorf("mutators modified their input: %v", x)
+	}
+}
+
+func TestLatticeHopBasis(t *testing.T) {
+	Rand = &LockedRng{Rng: rand.New(rand.NewSource(1))}
+	// mesh axes along the diagonals
+	s := math.Sqrt(.5)
+	mesh := &InfMesh{StepSize: 2, Basis: mat64.NewDense(2, 2, []float64{s, -s, s, s})}
+	x := mesh.Nearest([]float64{0, 0})
+	for i := 0; i < 50; i++ {
+		y := LatticeHop{Prob: .5, MaxHops: 3}.Mutate(x, mesh)
+		// mesh coordinates (the basis is orthonormal) must be whole steps
+		for j := 0; j < 2; j++ {
+			c := (mesh.Basis.At(0, j)*y[0] + mesh.Basis.At(1, j)*y[1]) / mesh.StepSize
+			if math.Abs(c-math.Floor(c+.5)) > 1e-9 {
+				t.Fatalf("hop from %v left the mesh: got %v (axis %v coordinate %v)", x, y, j, c)
+			}
+		}
+		if y[0] == x[0] && y[1] == x[1] {
+			t.Errorf("hop didn't move")
+		}
+		x = y
+	}
+}
+
+func TestAdaptiveFeedback(t *testing.T) {
+	a := &Adaptive{}
+	a.Mutate([]float64{0, 0, 0}, nil)
+	a.Feedback(true)
+	if a.Sigma[0] != 1.5 {
+		t.Errorf("sigma not widened on success: %v", a.Sigma)
+	}
+	for i := 0; i < 4; i++ {
+		a.Feedback(false)
+	}
+	if math.Abs(a.Sigma[2]-1) > 1e-12 {
+		t.Errorf("four failures should undo one success: got sigma %v", a.Sigma)
+	}
+}
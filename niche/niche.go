@@ -0,0 +1,113 @@
+// Package niche provides speciation schemes that divide a population into
+// species so population methods can converge to several optima
+// concurrently rather than all following a single global best.  Schemes
+// operate on plain point slices and so can be used by any population method
+// - see swarm.Niching for use with particle swarms.
+package niche
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/analysis"
+)
+
+// Speciator divides a population into species.  Species returns for each of
+// pts the index (into pts) of its species' seed - the best point of the
+// species.  Seeds are their own seed.  pts is not modified.
+type Speciator interface {
+	Species(pts []*optim.Point) []int
+}
+
+// Radius is the speciation scheme used by species-based PSO:
+//
+//	Li, Xiaodong. "Adaptively choosing neighbourhood bests using species in
+//	a particle swarm optimizer for multimodal function optimization."
+//	Genetic and Evolutionary Computation Conference. Springer, 2004.
+//
+// Points are processed from best to worst.  Each joins the species of the
+// first (best) seed within distance R or otherwise becomes a new seed.
+type Radius struct {
+	R float64
+	// Dist, if non-nil, measures distances between positions (e.g.
+	// optim.MeshDist).  Euclidean distance is used otherwise.
+	Dist func(a, b []float64) float64
+}
+
+func (s Radius) Species(pts []*optim.Point) []int {
+	order := byval(pts)
+	seed := make([]int, len(pts))
+	var seeds []int
+	for _, i := range order {
+		seed[i] = i
+		for _, j := range seeds {
+			if dist(s.Dist, pts[i], pts[j]) <= s.R {
+				seed[i] = j
+				break
+			}
+		}
+		if seed[i] == i {
+			seeds = append(seeds, i)
+		}
+	}
+	return seed
+}
+
+// dist returns the distance between p and q using fn or Euclidean distance
+// if fn is nil.
+func dist(fn func(a, b []float64) float64, p, q *optim.Point) float64 {
+	if fn == nil {
+		return optim.L2Dist(p, q)
+	}
+	return fn(p.Pos, q.Pos)
+}
+
+// NearestBetter forms species using nearest-better clustering (see
+// analysis.NearestBetter) which needs no problem-specific distance scale.
+// Phi is the link cut factor - 2 is typical.  Points with infinite or NaN
+// values form their own species.
+type NearestBetter struct {
+	Phi float64
+}
+
+func (s NearestBetter) Species(pts []*optim.Point) []int {
+	index := make(map[*optim.Point]int, len(pts))
+	seed := make([]int, len(pts))
+	for i, p := range pts {
+		index[p] = i
+		seed[i] = i
+	}
+	for _, b := range analysis.NearestBetter(pts, s.Phi) {
+		for _, p := range b.Points {
+			seed[index[p]] = index[b.Best]
+		}
+	}
+	return seed
+}
+
+// Seeds returns the indices of the seeds in a species assignment returned by
+// a Speciator.
+func Seeds(species []int) []int {
+	var seeds []int
+	for i, s := range species {
+		if s == i {
+			seeds = append(seeds, i)
+		}
+	}
+	return seeds
+}
+
+// byval returns the indices of pts ordered from best to worst value with
+// NaN values last.
+func byval(pts []*optim.Point) []int {
+	order := make([]int, len(pts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		va, vb := pts[order[a]].Val, pts[order[b]].Val
+		return va < vb || (!math.IsNaN(va) && math.IsNaN(vb))
+	})
+	return order
+}
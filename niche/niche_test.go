@@ -0,0 +1,63 @@
+package niche
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/rwcarlsen/optim"
+)
+
+// twoclusters returns points around two optima at x=0 (best 0) and x=10
+// (best 1).
+func twoclusters() []*optim.Point {
+	return []*optim.Point{
+		{Pos: []float64{10.5}, Val: 1.5},
+		{Pos: []float64{0.2}, Val: 0.2},
+		{Pos: []float64{10}, Val: 1},
+		{Pos: []float64{0}, Val: 0},
+		{Pos: []float64{-0.4}, Val: 0.4},
+		{Pos: []float64{9.6}, Val: 1.4},
+		{Pos: []float64{5}, Val: math.Inf(1)},
+	}
+}
+
+func TestSpecies(t *testing.T) {
+	want := []int{2, 3, 2, 3, 3, 2, 6}
+	for _, s := range []Speciator{Radius{R: 2}, NearestBetter{Phi: 2}} {
+		got := s.Species(twoclusters())
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%T: want species %v, got %v", s, want, got)
+				break
+			}
+		}
+		if seeds := Seeds(got); len(seeds) != 3 || seeds[0] != 2 || seeds[1] != 3 || seeds[2] != 6 {
+			t.Errorf("%T: want seeds [2 3 6], got %v", s, seeds)
+		}
+	}
+}
+
+func TestRadiusSmall(t *testing.T) {
+	pts := twoclusters()
+	species := Radius{R: 0.1}.Species(pts)
+	for i, s := range species {
+		if s != i {
+			t.Errorf("point %v: want own species with tiny radius, got seed %v", i, s)
+		}
+	}
+}
+
+func TestRadiusDist(t *testing.T) {
+	pts := []*optim.Point{
+		{Pos: []float64{0, 0}, Val: 0},
+		{Pos: []float64{5, 0}, Val: 1},
+		{Pos: []float64{0, 5}, Val: 2},
+	}
+	// the first axis is stretched ten times
+	mesh := &optim.InfMesh{Basis: mat64.NewDense(2, 2, []float64{10, 0, 0, 1})}
+	species := Radius{R: 2, Dist: optim.MeshDist(mesh)}.Species(pts)
+	if want := []int{0, 0, 2}; species[0] != want[0] || species[1] != want[1] || species[2] != want[2] {
+		t.Errorf("want species %v, got %v", want, species)
+	}
+}
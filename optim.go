@@ -4,16 +4,27 @@ import (
 	"crypto/sha1"
 	"database/sql"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
 	"math/rand"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gonum/matrix/mat64"
 )
 
-var Rand Rng = rand.New(rand.NewSource(1))
+// Rand is the random number source used by all methods.  It is shared by
+// every solver in the process and so must be safe for concurrent use if
+// solvers are run in parallel (e.g. in an island model) - wrap generators
+// that aren't in a LockedRng.
+var Rand Rng = &LockedRng{Rng: rand.New(rand.NewSource(1))}
 
 type Rng interface {
 	Float64() float64
@@ -21,8 +32,43 @@ type Rng interface {
 	Perm(n int) []int
 }
 
+// LockedRng wraps an Rng making it safe for concurrent use.
+type LockedRng struct {
+	Rng
+	mu sync.Mutex
+}
+
+func (r *LockedRng) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Rng.Float64()
+}
+
+func (r *LockedRng) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Rng.Intn(n)
+}
+
+func (r *LockedRng) Perm(n int) []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Rng.Perm(n)
+}
+
 func RandFloat() float64 { return Rand.Float64() }
 
+// RandNorm returns a standard normally distributed random number generated
+// from Rand using the Box-Muller transform.
+func RandNorm() float64 { return RandNormFrom(Rand) }
+
+// RandNormFrom is like RandNorm but draws from r.
+func RandNormFrom(r Rng) float64 {
+	u1 := 1 - r.Float64() // avoid log(0)
+	u2 := r.Float64()
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+}
+
 type Solver struct {
 	Method       Method
 	Obj          Objectiver
@@ -32,11 +78,34 @@ type Solver struct {
 	MaxEval      int
 	MaxNoImprove int
 	MinStep      float64
-
+	// Target, if non-nil, stops the solver once the best objective value is
+	// less than or equal to *Target.
+	Target *float64
+	// Feasible, if non-nil, stops the solver as soon as it returns true for
+	// the best point found.  This is useful for satisficing searches where
+	// the first acceptable design ends the run.
+	Feasible func(p *Point) bool
+	// MaxTime, if non-zero, stops the solver after the first iteration that
+	// completes more than MaxTime after the run started.
+	MaxTime time.Duration
+	// Checkpoint, if non-nil, is called when a run is stopped early by Stop
+	// (e.g. via StopOnSignal) or MaxTime.  It is intended for persisting
+	// state such as the best point found so far.
+	Checkpoint func(s *Solver) error
+	// Logger, if non-nil, receives per-iteration (debug), improvement and
+	// termination (info), and iteration error (error) events.
+	Logger Logger
+
+	start        time.Time
+	elapsed      time.Duration
+	term         Termination
+	stopped      int32
 	neval, niter int
 	noimprove    int
 	best         *Point
 	err          error
+	subs         []chan Point
+	submu        sync.Mutex
 }
 
 func (s *Solver) Best() *Point { return s.best }
@@ -44,6 +113,67 @@ func (s *Solver) Niter() int   { return s.niter }
 func (s *Solver) Neval() int   { return s.neval }
 func (s *Solver) Err() error   { return s.err }
 
+// Subscribe returns a channel on which a copy of every new best point found
+// by the solver is sent.  The channel has a buffer of size buf and sends
+// never block the solver - if the buffer is full when an improvement occurs,
+// that improvement is not sent.  The channel is closed when Next returns
+// false.
+func (s *Solver) Subscribe(buf int) <-chan Point {
+	s.submu.Lock()
+	defer s.submu.Unlock()
+	ch := make(chan Point, buf)
+	s.subs = append(s.subs, ch)
+	return ch
+}
+
+func (s *Solver) publish(p *Point) {
+	s.submu.Lock()
+	defer s.submu.Unlock()
+	for _, ch := range s.subs {
+		select {
+		case ch <- *p.Clone():
+		default:
+		}
+	}
+}
+
+func (s *Solver) closeSubs() {
+	s.submu.Lock()
+	defer s.submu.Unlock()
+	for _, ch := range s.subs {
+		close(ch)
+	}
+	s.subs = nil
+}
+
+// Stop causes the solver to stop after its current iteration completes -
+// i.e. in-flight evaluations are finished and their results kept.  It is
+// safe to call Stop from other goroutines.
+func (s *Solver) Stop() { atomic.StoreInt32(&s.stopped, 1) }
+
+// StopOnSignal calls Stop when any of the given signals is received (or
+// os.Interrupt if none are given).  The returned function must be called to
+// stop listening for the signals.
+func (s *Solver) StopOnSignal(sigs ...os.Signal) (cancel func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		select {
+		case <-ch:
+			s.Stop()
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
 func (s *Solver) Run() error {
 	for s.Next() {
 	}
@@ -51,11 +181,19 @@ func (s *Solver) Run() error {
 }
 
 func (s *Solver) Next() (more bool) {
+	defer func() {
+		if !more {
+			s.closeSubs()
+		}
+	}()
+
 	if s.Mesh == nil {
 		s.Mesh = &InfMesh{}
 	}
 	if s.niter == 0 {
 		s.best = &Point{Val: math.Inf(1)}
+		s.start = time.Now()
+		s.term = Running
 	}
 
 	var n int
@@ -63,25 +201,99 @@ func (s *Solver) Next() (more bool) {
 	best, n, s.err = s.Method.Iterate(s.Obj, s.Mesh)
 	s.neval += n
 	s.niter++
+	s.logger().Log(LevelDebug, "iteration", "iter", s.niter, "neval", s.neval, "step", s.Mesh.Step(), "best", best.Val)
+	if s.err != nil {
+		s.logger().Log(LevelError, "iteration failed", "iter", s.niter, "err", s.err)
+	}
 
 	if best.Val < s.best.Val {
 		s.best = best
 		s.noimprove = 0
+		s.publish(best)
+		s.logger().Log(LevelInfo, "improved", "iter", s.niter, "neval", s.neval, "best", best)
 	} else {
 		s.noimprove++
 	}
 
 	if s.err != nil && s.StopOnErr {
+		s.term = TermError
+		s.elapsed = time.Since(s.start)
 		return false
 	}
 
-	more = true && (s.MaxNoImprove == 0 || s.noimprove < s.MaxNoImprove)
-	more = more && (s.MaxIter == 0 || s.niter < s.MaxIter)
-	more = more && (s.MaxEval == 0 || s.neval < s.MaxEval)
-	more = more && (s.MinStep == 0 || s.Mesh.Step() > s.MinStep)
+	switch c, ok := s.Method.(Converger); {
+	case s.MaxNoImprove != 0 && s.noimprove >= s.MaxNoImprove:
+		s.term = TermStalled
+	case s.MaxIter != 0 && s.niter >= s.MaxIter:
+		s.term = TermMaxIter
+	case s.MaxEval != 0 && s.neval >= s.MaxEval:
+		s.term = TermMaxEval
+	case s.MinStep != 0 && s.Mesh.Step() <= s.MinStep:
+		s.term = TermMeshMinimum
+	case s.Target != nil && s.best.Val <= *s.Target:
+		s.term = TermTargetReached
+	case s.Feasible != nil && s.best.Pos != nil && s.Feasible(s.best):
+		s.term = TermFeasible
+	case ok && c.Converged():
+		s.term = TermConverged
+	case atomic.LoadInt32(&s.stopped) == 1:
+		s.term = TermUserCancelled
+	case s.MaxTime != 0 && time.Since(s.start) > s.MaxTime:
+		s.term = TermMaxTime
+	}
+	more = s.term == Running
+
+	if s.term == TermUserCancelled || s.term == TermMaxTime {
+		if s.Checkpoint != nil {
+			if err := s.Checkpoint(s); err != nil && s.err == nil {
+				s.err = err
+			}
+		}
+	}
+	if !more {
+		s.elapsed = time.Since(s.start)
+		s.logger().Log(LevelInfo, "done", "iter", s.niter, "neval", s.neval, "best", s.best, "reason", s.term)
+	}
 	return more
 }
 
+func (s *Solver) logger() Logger {
+	if s.Logger == nil {
+		return NopLogger{}
+	}
+	return s.Logger
+}
+
+// TblCheckpoint is the name of the sql database table that contains
+// checkpoints written by CheckpointDB.
+const TblCheckpoint = "checkpoint"
+
+// CheckpointDB returns a function for use as a Solver's Checkpoint that
+// records the solver's iteration count, evaluation count, and best point in
+// db.
+func CheckpointDB(db *sql.DB) func(s *Solver) error {
+	return func(s *Solver) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		q := "CREATE TABLE IF NOT EXISTS " + TblCheckpoint + " (iter INTEGER,neval INTEGER,val REAL,posid BLOB);"
+		if _, err := tx.Exec(q); err != nil {
+			return err
+		}
+		q = "INSERT INTO " + TblCheckpoint + " (iter,neval,val,posid) VALUES (?,?,?,?);"
+		if _, err := tx.Exec(q, s.Niter(), s.Neval(), s.Best().Val, s.Best().HashSlice()); err != nil {
+			return err
+		}
+		if err := RecordPointPos(tx, s.Best()); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+}
+
 type Point struct {
 	Pos []float64
 	Val float64
@@ -97,10 +309,36 @@ func (p *Point) Clone() *Point {
 	return &Point{Pos: pos, Val: p.Val}
 }
 
+// CloneInto copies p's position and value into dst, reusing dst's position
+// storage when large enough, and returns dst.  If dst is nil, a new point is
+// allocated.
+func (p *Point) CloneInto(dst *Point) *Point {
+	if dst == nil {
+		return p.Clone()
+	}
+	dst.Pos = append(dst.Pos[:0], p.Pos...)
+	dst.Val = p.Val
+	return dst
+}
+
+// Hash returns a sha1 hash of p's position.  Negative and positive zero
+// hash identically as do all NaN values.
 func (p *Point) Hash() [sha1.Size]byte {
-	data := make([]byte, p.Len()*8)
-	for i := 0; i < p.Len(); i++ {
-		binary.BigEndian.PutUint64(data[i*8:], math.Float64bits(p.Pos[i]))
+	// hash small points from a stack buffer to avoid allocating
+	var buf [16 * 8]byte
+	var data []byte
+	if n := p.Len() * 8; n <= len(buf) {
+		data = buf[:n]
+	} else {
+		data = make([]byte, n)
+	}
+	for i, x := range p.Pos {
+		if x == 0 {
+			x = 0 // collapse -0 to +0
+		} else if math.IsNaN(x) {
+			x = math.NaN()
+		}
+		binary.BigEndian.PutUint64(data[i*8:], math.Float64bits(x))
 	}
 	return sha1.Sum(data)
 }
@@ -141,12 +379,21 @@ type Objectiver interface {
 	Objective(v []float64) (float64, error)
 }
 
+// CacheEvaler is safe for concurrent use - e.g. by several solvers sharing
+// one cache - as long as its fields aren't modified while evaluating.
 type CacheEvaler struct {
 	ev    Evaler
 	cache map[[sha1.Size]byte]float64
+	mu    sync.Mutex
 	// UseCount reports the number of times a cached objective evaluation was
-	// successfully used to avoid recalculation.
+	// successfully used to avoid recalculation.  It should only be read
+	// while no evaluations are in progress.
 	UseCount int
+	// Hasher is used to compute cache keys for points.  If nil, points are
+	// hashed using their exact positions.  Using a MeshHasher causes points
+	// with slightly different floating-point positions that lie nearest the
+	// same grid point to share a single cache entry.
+	Hasher PointHasher
 }
 
 func NewCacheEvaler(ev Evaler) *CacheEvaler {
@@ -156,12 +403,27 @@ func NewCacheEvaler(ev Evaler) *CacheEvaler {
 	}
 }
 
+// Add stores the values of already evaluated points in the cache - e.g. to
+// warm-start from points loaded with LoadPoints or ReadLog.  Points with
+// infinite values are ignored.
+func (ev *CacheEvaler) Add(points ...*Point) {
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	for _, p := range points {
+		if p.Val != math.Inf(1) {
+			ev.cache[ev.hash(p)] = p.Val
+		}
+	}
+}
+
 func (ev *CacheEvaler) Eval(obj Objectiver, points ...*Point) (results []*Point, n int, err error) {
+	obj = RunPreHooks(obj, points)
 	results = make([]*Point, 0, len(points))
 	newp := make([]*Point, 0, len(points))
 	uniq := uniqof(points)
+	ev.mu.Lock()
 	for _, p := range uniq {
-		h := p.Hash()
+		h := ev.hash(p)
 		if val, ok := ev.cache[h]; ok {
 			p.Val = val
 			results = append(results, p)
@@ -171,21 +433,27 @@ func (ev *CacheEvaler) Eval(obj Objectiver, points ...*Point) (results []*Point,
 			newp = append(newp, p)
 		}
 	}
+	ev.mu.Unlock()
 
 	newresults, n, err := ev.ev.Eval(obj, newp...)
-	for _, p := range newresults {
-		if p.Val != math.Inf(1) {
-			ev.cache[p.Hash()] = p.Val
-		}
-	}
+	ev.Add(newresults...)
 	return append(newresults, results...), n, err
 }
 
+func (ev *CacheEvaler) hash(p *Point) [sha1.Size]byte {
+	if ev.Hasher == nil {
+		return p.Hash()
+	}
+	return ev.Hasher.Hash(p)
+}
+
+// SerialEvaler evaluates points one at a time in the calling goroutine.
 type SerialEvaler struct {
 	ContinueOnErr bool
 }
 
 func (ev SerialEvaler) Eval(obj Objectiver, points ...*Point) (results []*Point, n int, err error) {
+	obj = RunPreHooks(obj, points)
 	var err2 error
 	uniq := uniqof(points)
 	for i, p := range uniq {
@@ -195,20 +463,34 @@ func (ev SerialEvaler) Eval(obj Objectiver, points ...*Point) (results []*Point,
 		if err2 != nil {
 			err = err2
 			if !ev.ContinueOnErr {
-				return uniq[:i+1], n, err
+				return copyof(uniq[:i+1]), n, err
 			}
 		}
 	}
-	return uniq, n, err
+	return copyof(uniq), n, err
 }
 
+// copyof returns a copy of ps so results never share storage with the
+// points passed to an evaler.
+func copyof(ps []*Point) []*Point { return append(make([]*Point, 0, len(ps)), ps...) }
+
 type errpoint struct {
 	*Point
 	Err error
 }
 
-// uniqof returns only unique points in ps.
+// smallBatch is the largest number of points uniqof checks for duplicates by
+// direct pairwise comparison rather than by hashing.
+const smallBatch = 32
+
+// uniqof returns only unique points in ps.  If ps has no duplicates, ps itself
+// is returned with its capacity limited to its length so appending to the
+// result never clobbers the caller's storage.  Callers must not assign to
+// elements of the result or return it without copying.
 func uniqof(ps []*Point) []*Point {
+	if len(ps) <= smallBatch && !hasdups(ps) {
+		return ps[:len(ps):len(ps)]
+	}
 	alreadyhave := map[[sha1.Size]byte]struct{}{}
 	uniq := []*Point{}
 	for _, p := range ps {
@@ -221,11 +503,42 @@ func uniqof(ps []*Point) []*Point {
 	return uniq
 }
 
+// hasdups reports whether any two points in ps have identical positions.
+// Positions are compared the same way Point.Hash treats them.
+func hasdups(ps []*Point) bool {
+	for i, p := range ps {
+		for _, q := range ps[:i] {
+			if samepos(p.Pos, q.Pos) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func samepos(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] && !(math.IsNaN(a[i]) && math.IsNaN(b[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParallelEvaler evaluates points concurrently.  Results are returned in the
+// same order as the passed in (unique) points regardless of the order in
+// which evaluations complete, so runs are reproducible given the same seed.
+// If several evaluations fail, the error for the earliest point is
+// returned.
 type ParallelEvaler struct {
 	NConcurrent int
 }
 
 func (ev ParallelEvaler) Eval(obj Objectiver, points ...*Point) (results []*Point, n int, err error) {
+	obj = RunPreHooks(obj, points)
 	nbuf := ev.NConcurrent
 	if nbuf == 0 {
 		nbuf = 100000
@@ -235,9 +548,9 @@ func (ev ParallelEvaler) Eval(obj Objectiver, points ...*Point) (results []*Poin
 		limiter <- true
 	}
 
-	ch := make(chan errpoint, len(points))
 	wg := sync.WaitGroup{}
 	uniq := uniqof(points)
+	evaled := make([]errpoint, len(uniq))
 	for i, p := range uniq {
 		wg.Add(1)
 		go func(i int, p *Point) {
@@ -246,20 +559,16 @@ func (ev ParallelEvaler) Eval(obj Objectiver, points ...*Point) (results []*Poin
 			defer func() { limiter <- true }()
 			perr := errpoint{Point: p}
 			perr.Val, perr.Err = obj.Objective(p.Pos)
-			ch <- perr
+			evaled[i] = perr
 		}(i, p)
 	}
-
-	go func() {
-		wg.Wait()
-		close(ch)
-	}()
+	wg.Wait()
 
 	results = make([]*Point, 0, len(points))
-	for p := range ch {
+	for _, p := range evaled {
 		n++
 		results = append(results, p.Point)
-		if p.Err != nil {
+		if p.Err != nil && err == nil {
 			err = p.Err
 		}
 	}
@@ -271,18 +580,125 @@ type Func func([]float64) float64
 
 func (so Func) Objective(v []float64) (float64, error) { return so(v), nil }
 
+// LogFormat selects the output format of an ObjectiveLogger.
+type LogFormat int
+
+const (
+	// LogPlain writes lines of the form "f[x1 x2 ...] = val".
+	LogPlain LogFormat = iota
+	// LogCSV writes comma separated positions followed by the value.
+	LogCSV
+	// LogTSV writes tab separated positions followed by the value.
+	LogTSV
+	// LogJSON writes one JSON object per line with "eval", "pos", "val",
+	// and (for failed evaluations) "err" fields.  Non-finite values are
+	// written as the strings "+Inf", "-Inf", and "NaN".
+	LogJSON
+)
+
+// ObjectiveLogger wraps an objective and logs every evaluation.  If W is
+// non-nil, each evaluation is written to it in the given Format - all
+// formats can be read back by ReadLog.  If Logger is non-nil, each
+// evaluation is also sent to it as a debug event (or an error event for
+// failed evaluations).  ObjectiveLogger is safe for concurrent use (e.g.
+// with a ParallelEvaler) - lines are never interleaved.
 type ObjectiveLogger struct {
-	Obj Objectiver
-	W   io.Writer
+	Obj    Objectiver
+	W      io.Writer
+	Format LogFormat
+	// Header causes a column header line to be written before the first
+	// CSV or TSV entry.
+	Header bool
+	Logger Logger
+	mu     sync.Mutex
+	n      int
+}
+
+// Count returns the number of evaluations logged so far.
+func (l *ObjectiveLogger) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.n
 }
 
 func (l *ObjectiveLogger) Objective(v []float64) (float64, error) {
 	val, err := l.Obj.Objective(v)
 
-	fmt.Fprintf(l.W, "f%v = %v\n", v, val)
+	l.mu.Lock()
+	l.n++
+	if l.W != nil {
+		l.write(l.n, v, val, err)
+	}
+	l.mu.Unlock()
+
+	if l.Logger != nil && err != nil {
+		l.Logger.Log(LevelError, "objective failed", "pos", v, "val", val, "err", err)
+	} else if l.Logger != nil {
+		l.Logger.Log(LevelDebug, "objective", "pos", v, "val", val)
+	}
 	return val, err
 }
 
+func (l *ObjectiveLogger) write(n int, v []float64, val float64, err error) {
+	switch l.Format {
+	case LogCSV, LogTSV:
+		sep := ","
+		if l.Format == LogTSV {
+			sep = "\t"
+		}
+		fields := make([]string, len(v)+1)
+		if l.Header && n == 1 {
+			for i := range v {
+				fields[i] = fmt.Sprintf("x%v", i)
+			}
+			fields[len(v)] = "val"
+			fmt.Fprintln(l.W, strings.Join(fields, sep))
+		}
+		for i, x := range v {
+			fields[i] = strconv.FormatFloat(x, 'g', -1, 64)
+		}
+		fields[len(v)] = strconv.FormatFloat(val, 'g', -1, 64)
+		fmt.Fprintln(l.W, strings.Join(fields, sep))
+	case LogJSON:
+		entry := logEntry{Eval: n, Pos: make([]jsonFloat, len(v)), Val: jsonFloat(val)}
+		for i, x := range v {
+			entry.Pos[i] = jsonFloat(x)
+		}
+		if err != nil {
+			entry.Err = err.Error()
+		}
+		data, _ := json.Marshal(entry)
+		fmt.Fprintf(l.W, "%s\n", data)
+	default:
+		fmt.Fprintf(l.W, "f%v = %v\n", v, val)
+	}
+}
+
+type logEntry struct {
+	Eval int         `json:"eval"`
+	Pos  []jsonFloat `json:"pos"`
+	Val  jsonFloat   `json:"val"`
+	Err  string      `json:"err,omitempty"`
+}
+
+// jsonFloat is a float64 that encodes non-finite values as strings.
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	x := float64(f)
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return []byte(`"` + strconv.FormatFloat(x, 'g', -1, 64) + `"`), nil
+	}
+	return []byte(strconv.FormatFloat(x, 'g', -1, 64)), nil
+}
+
+func (f *jsonFloat) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	x, err := strconv.ParseFloat(s, 64)
+	*f = jsonFloat(x)
+	return err
+}
+
 // ObjectivePenalty wraps an objective function and adds a penalty factor for
 // any violated linear constraints. If Weight is zero the underlying
 // objective value will be returned unaltered.
@@ -1,11 +1,13 @@
 package optim
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"math"
 	"sync"
 	"testing"
+	"time"
 )
 
 func testpoints() []*Point {
@@ -83,6 +85,29 @@ func TestSerialEvaler_DupPoints(t *testing.T) {
 	}
 }
 
+func TestSerialEvalerAllocs(t *testing.T) {
+	obj := Func(func(v []float64) float64 { return v[0] })
+	points := testpoints()[2:]
+	results, _, _ := SerialEvaler{}.Eval(obj, points...)
+	if &results[0] == &points[0] {
+		t.Errorf("Eval results share storage with the points passed in")
+	}
+	// the only allocation is the results slice
+	allocs := testing.AllocsPerRun(100, func() { SerialEvaler{}.Eval(obj, points...) })
+	if allocs > 1 {
+		t.Errorf("Eval made %v allocations per call, want 1", allocs)
+	}
+
+	p := &Point{Pos: make([]float64, 0, 3)}
+	allocs = testing.AllocsPerRun(100, func() { points[0].CloneInto(p) })
+	if allocs != 0 {
+		t.Errorf("CloneInto made %v allocations per call, want 0", allocs)
+	}
+	if p.Pos[2] != points[0].Pos[2] || p.Val != points[0].Val {
+		t.Errorf("CloneInto got %v, want %v", p, points[0])
+	}
+}
+
 func TestSerialEvalerErr(t *testing.T) {
 	errcount := 3
 	exprlen := errcount
@@ -118,6 +143,30 @@ func TestSerialEvalerErr(t *testing.T) {
 	}
 }
 
+func TestParallelEvalerOrder(t *testing.T) {
+	// later points finish first
+	obj := Func(func(v []float64) float64 {
+		time.Sleep(time.Duration(10-v[0]) * time.Millisecond)
+		return v[0]
+	})
+	var points []*Point
+	for i := 0; i < 10; i++ {
+		points = append(points, &Point{Pos: []float64{float64(i)}})
+	}
+
+	results, n, err := ParallelEvaler{}.Eval(obj, points...)
+	if err != nil {
+		t.Fatal(err)
+	} else if n != len(points) {
+		t.Fatalf("want %v evals, got %v", len(points), n)
+	}
+	for i, p := range results {
+		if p != points[i] {
+			t.Errorf("result %v: want %v, got %v", i, points[i], p)
+		}
+	}
+}
+
 func TestParallelEvalerErr(t *testing.T) {
 	tpoints := testpoints()
 	errcount := 4
@@ -209,3 +258,238 @@ func TestCacheEvaler(t *testing.T) {
 		}
 	}
 }
+
+// seqMethod is a fake Method that returns a point with the next value in
+// Vals on each iteration.
+type seqMethod struct {
+	Vals []float64
+	i    int
+}
+
+func (m *seqMethod) AddPoint(p *Point) {}
+
+func (m *seqMethod) Iterate(obj Objectiver, mesh Mesh) (best *Point, n int, err error) {
+	v := m.Vals[m.i%len(m.Vals)]
+	m.i++
+	return &Point{Pos: []float64{v}, Val: v}, 1, nil
+}
+
+func TestSolverSubscribe(t *testing.T) {
+	s := &Solver{
+		Method:  &seqMethod{Vals: []float64{5, 4, 4, 6, 2, 3}},
+		Obj:     Func(func(v []float64) float64 { return v[0] }),
+		MaxIter: 6,
+	}
+	ch := s.Subscribe(10)
+	if err := s.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []float64{5, 4, 2}
+	got := []float64{}
+	for p := range ch {
+		got = append(got, p.Val)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("wrong number of improvements: want %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("improvement %v: want %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestCacheEvalerMesh(t *testing.T) {
+	obj := &ObjTest{max: 100000}
+	ev := NewCacheEvaler(SerialEvaler{})
+	ev.Hasher = MeshHasher{&InfMesh{StepSize: 1}}
+
+	_, n1, _ := ev.Eval(obj, &Point{Pos: []float64{1.1, 2}})
+	p := &Point{Pos: []float64{0.9, 2}}
+	_, n2, _ := ev.Eval(obj, p)
+	_, n3, _ := ev.Eval(obj, &Point{Pos: []float64{1.9, 2}})
+
+	if n1 != 1 || n2 != 0 || n3 != 1 {
+		t.Errorf("wrong eval counts: want [1 0 1], got [%v %v %v]", n1, n2, n3)
+	}
+	if p.Val != 3.1 {
+		t.Errorf("cached value not reused for same grid point: want 3.1, got %v", p.Val)
+	}
+	if ev.UseCount != 1 {
+		t.Errorf("wrong UseCount: want 1, got %v", ev.UseCount)
+	}
+}
+
+func TestSolverTarget(t *testing.T) {
+	target := 3.0
+	s := &Solver{
+		Method: &seqMethod{Vals: []float64{5, 4, 3, 2, 1}},
+		Obj:    Func(func(v []float64) float64 { return v[0] }),
+		Target: &target,
+	}
+	s.Run()
+	if s.Niter() != 3 || s.Best().Val != 3 {
+		t.Errorf("did not stop at target: %v iters, best %v", s.Niter(), s.Best())
+	} else if s.Termination() != TermTargetReached {
+		t.Errorf("wrong termination reason: %v", s.Termination())
+	}
+}
+
+func TestSolverFeasible(t *testing.T) {
+	s := &Solver{
+		Method:   &seqMethod{Vals: []float64{5, 4, 3, 2, 1}},
+		Obj:      Func(func(v []float64) float64 { return v[0] }),
+		Feasible: func(p *Point) bool { return p.Pos[0] < 4.5 },
+		MaxIter:  100,
+	}
+	s.Run()
+	if s.Niter() != 2 || s.Best().Val != 4 {
+		t.Errorf("did not stop at first feasible point: %v iters, best %v", s.Niter(), s.Best())
+	} else if s.Termination() != TermFeasible {
+		t.Errorf("wrong termination reason: %v", s.Termination())
+	}
+}
+
+func TestSolverStop(t *testing.T) {
+	ncheckpoint := 0
+	var s *Solver
+	s = &Solver{
+		Method: &seqMethod{Vals: []float64{5, 4, 3, 2, 1}},
+		Obj:    Func(func(v []float64) float64 { return v[0] }),
+		Checkpoint: func(s2 *Solver) error {
+			if s2 != s {
+				t.Errorf("checkpoint called with wrong solver")
+			}
+			ncheckpoint++
+			return nil
+		},
+	}
+
+	for s.Next() {
+		if s.Niter() == 2 {
+			s.Stop()
+		}
+	}
+	if s.Niter() != 3 {
+		t.Errorf("stop did not finish the current iteration: want 3 iters, got %v", s.Niter())
+	}
+	if ncheckpoint != 1 {
+		t.Errorf("wrong number of checkpoints: want 1, got %v", ncheckpoint)
+	}
+	if s.Termination() != TermUserCancelled {
+		t.Errorf("wrong termination reason: %v", s.Termination())
+	}
+}
+
+func TestSolverMaxTime(t *testing.T) {
+	s := &Solver{
+		Method:  &seqMethod{Vals: []float64{5, 4, 3, 2, 1}},
+		Obj:     Func(func(v []float64) float64 { time.Sleep(time.Millisecond); return v[0] }),
+		MaxTime: 20 * time.Millisecond,
+	}
+	start := time.Now()
+	for s.Next() {
+		time.Sleep(time.Millisecond)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("max time not enforced: ran for %v", elapsed)
+	}
+	if s.Termination() != TermMaxTime {
+		t.Errorf("wrong termination reason: %v", s.Termination())
+	}
+}
+
+func TestCheckpointDB(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	s := &Solver{
+		Method:     &seqMethod{Vals: []float64{5, 4, 3, 2, 1}},
+		Obj:        Func(func(v []float64) float64 { return v[0] }),
+		Checkpoint: CheckpointDB(db),
+	}
+	s.Next()
+	s.Next()
+	s.Stop()
+	s.Next()
+
+	pts, err := LoadPoints(db, TblCheckpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pts) != 1 || pts[0].Val != 3 {
+		t.Errorf("wrong checkpointed best: want val 3, got %v", pts)
+	}
+}
+
+func TestRandNorm(t *testing.T) {
+	n := 100000
+	sum, sumsq := 0.0, 0.0
+	for i := 0; i < n; i++ {
+		x := RandNorm()
+		sum += x
+		sumsq += x * x
+	}
+	mean := sum / float64(n)
+	variance := sumsq/float64(n) - mean*mean
+	if math.Abs(mean) > 0.02 || math.Abs(variance-1) > 0.02 {
+		t.Errorf("bad normal distribution: mean %v, variance %v", mean, variance)
+	}
+}
+
+func TestStepSchedule(t *testing.T) {
+	s := &StepSchedule{Contract: 0.5, NGrow: 2, MaxStep: 3, SufficientDecrease: 1, MinStep: 0.3}
+
+	steps := []struct {
+		success  bool
+		decrease float64
+		want     float64
+	}{
+		{true, 5, 2},   // first success
+		{true, 5, 3},   // second success expands (capped)
+		{true, 1, 1.5}, // decrease 1 < 1*3^2 is insufficient
+		{false, 0, 0.75},
+		{false, 0, 0.375},
+	}
+	step := 2.0
+	for i, test := range steps {
+		step = s.Update(step, test.success, test.decrease)
+		if step != test.want {
+			t.Errorf("update %v: want step %v, got %v", i, test.want, step)
+		}
+	}
+	if s.Converged() {
+		t.Errorf("converged early at step %v", step)
+	}
+	s.Update(step, false, 0)
+	if !s.Converged() {
+		t.Errorf("not converged at step %v", step*0.5)
+	}
+}
+
+func TestSolverTermination(t *testing.T) {
+	obj := Func(func(v []float64) float64 { return v[0] })
+	tests := []struct {
+		s    *Solver
+		want Termination
+	}{
+		{&Solver{Method: &seqMethod{Vals: []float64{5, 4, 4, 4}}, MaxNoImprove: 2, MaxIter: 10}, TermStalled},
+		{&Solver{Method: &seqMethod{Vals: []float64{5, 4, 3}}, MaxIter: 2}, TermMaxIter},
+		{&Solver{Method: &seqMethod{Vals: []float64{5, 4, 3}}, MaxEval: 2}, TermMaxEval},
+		{&Solver{Method: &seqMethod{Vals: []float64{5, 4, 3}}, Mesh: &InfMesh{StepSize: 1}, MinStep: 1}, TermMeshMinimum},
+	}
+	for i, test := range tests {
+		test.s.Obj = obj
+		if test.s.Termination() != Running {
+			t.Errorf("case %v: unrun solver has termination %v", i, test.s.Termination())
+		}
+		test.s.Run()
+		if got := test.s.Termination(); got != test.want {
+			t.Errorf("case %v: got termination %v, want %v", i, got, test.want)
+		}
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"crypto/sha1"
 	"database/sql"
 	"errors"
-	"log"
 	"math"
 	"sort"
+	"sync"
 
 	"github.com/rwcarlsen/optim"
 )
@@ -80,10 +80,22 @@ func DB(db *sql.DB) Option {
 	}
 }
 
+// Logger sets the logger that receives method events such as database
+// write failures.
+func Logger(l optim.Logger) Option { return func(m *Method) { m.Logger = l } }
+
 func SkipEps(eps float64) Option { return func(m *Method) { m.Poller.SkipEps = eps } }
 
 func Nkeep(n int) Option { return func(m *Method) { m.Poller.Nkeep = n } }
 
+// Rng sets the source of the poller's random numbers in place of the shared
+// optim.Rand (see Poller.Rng).
+func Rng(r optim.Rng) Option { return func(m *Method) { m.Poller.Rng = r } }
+
+// StepControl sets the controller used to update the mesh step size after
+// each poll, replacing the NsuccessGrow and StepMult policy.
+func StepControl(c optim.StepController) Option { return func(m *Method) { m.StepCtrl = c } }
+
 func ResetStep(threshold, tostep float64) Option {
 	return func(m *Method) { m.ResetStep = threshold; m.ResetStepSize = tostep }
 }
@@ -96,6 +108,10 @@ type Method struct {
 	NsuccessGrow   int  // number of successive successful polls before growing mesh
 	nsuccess       int  // (internal) number of successive successful polls
 	Db             *sql.DB
+	Logger         optim.Logger
+	// StepCtrl, if non-nil, updates the mesh step size after each poll in
+	// place of the NsuccessGrow and StepMult policy.
+	StepCtrl optim.StepController
 	// ResetStep is a step size threshold below which the mesh step is reset
 	// to ResetStepSize.  This can be useful for problems where
 	// the significance of a particular step size of one variable may be a
@@ -106,6 +122,8 @@ type Method struct {
 	origstep      float64
 	count         int
 	ev            optim.Evaler
+	// mu serializes Iterate with AddPoint calls from other goroutines.
+	mu sync.Mutex
 }
 
 func New(start *optim.Point, opts ...Option) *Method {
@@ -116,6 +134,7 @@ func New(start *optim.Point, opts ...Option) *Method {
 		Searcher:     NullSearcher{},
 		NsuccessGrow: -1,
 		StepMult:     1.0 / 1.7,
+		Logger:       optim.StdLogger{},
 	}
 
 	for _, opt := range opts {
@@ -125,15 +144,36 @@ func New(start *optim.Point, opts ...Option) *Method {
 	return m
 }
 
+// AddPoint is safe to call concurrently with Iterate - it blocks until any
+// in-progress iteration completes.
 func (m *Method) AddPoint(p *optim.Point) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if p.Val < m.Curr.Val {
 		m.Curr = p
 	}
 }
 
+// Inject moves the current point to the best of points if it is better and
+// also injects points into the search method if it is a WrapSearcher.
+func (m *Method) Inject(points ...*optim.Point) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range points {
+		if p.Val < m.Curr.Val {
+			m.Curr = p
+		}
+	}
+	if s, ok := m.Searcher.(*WrapSearcher); ok {
+		optim.Inject(s.Method, points...)
+	}
+}
+
 // Iterate mutates m and so for each iteration, the same, mutated m should be
 // passed in.
 func (m *Method) Iterate(o optim.Objectiver, mesh optim.Mesh) (best *optim.Point, n int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.count == 0 {
 		m.origstep = mesh.Step()
 	} else if mesh.Step() < m.ResetStep {
@@ -171,6 +211,19 @@ func (m *Method) Iterate(o optim.Objectiver, mesh optim.Mesh) (best *optim.Point
 	m.Poller.Spanner.Update(mesh.Step(), success)
 
 	n += nevalpoll
+	if m.StepCtrl != nil {
+		decrease := 0.0
+		if success {
+			decrease = m.Curr.Val - best.Val
+			m.Curr = best
+		}
+		if nextstep := m.StepCtrl.Update(mesh.Step(), success, decrease); nextstep > 0 {
+			mesh.SetStep(nextstep)
+		}
+		mesh.SetOrigin(m.Curr.Pos)
+		return m.Curr, n, collect(err, err2)
+	}
+
 	if success {
 		m.Curr = best
 		m.nsuccess++
@@ -194,6 +247,13 @@ func (m *Method) Iterate(o optim.Objectiver, mesh optim.Mesh) (best *optim.Point
 	}
 }
 
+// Converged returns true if the method's step controller reports
+// convergence.
+func (m *Method) Converged() bool {
+	c, ok := m.StepCtrl.(optim.Converger)
+	return ok && c.Converged()
+}
+
 func collect(err1, err2 error) error {
 	if err1 == nil && err2 == nil {
 		return nil
@@ -216,18 +276,18 @@ func (m *Method) initdb() {
 
 	s := "CREATE TABLE IF NOT EXISTS " + TblPolls + " (iter INTEGER,val REAL,posid BLOB);"
 	_, err := m.Db.Exec(s)
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 
 	s = "CREATE TABLE IF NOT EXISTS " + TblInfo + " (iter INTEGER,step INTEGER,nsearch INTEGER,npoll INTEGER,val REAL,posid BLOB);"
 	_, err = m.Db.Exec(s)
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 }
 
-func (m Method) updateDb(nsearch, npoll *int, step float64) {
+func (m *Method) updateDb(nsearch, npoll *int, step float64) {
 	if m.Db == nil {
 		return
 	}
@@ -241,7 +301,7 @@ func (m Method) updateDb(nsearch, npoll *int, step float64) {
 	s1 := "INSERT INTO " + TblPolls + " (iter,val,posid) VALUES (?,?,?);"
 	for _, p := range m.Poller.Points() {
 		_, err := tx.Exec(s1, m.count, p.Val, p.HashSlice())
-		if checkdberr(err) {
+		if m.checkdberr(err) {
 			return
 		}
 	}
@@ -249,14 +309,14 @@ func (m Method) updateDb(nsearch, npoll *int, step float64) {
 	glob := m.Curr
 	s2 := "INSERT INTO " + TblInfo + " (iter,step,nsearch, npoll,val,posid) VALUES (?,?,?,?,?,?);"
 	_, err = tx.Exec(s2, m.count, step, *nsearch, *npoll, glob.Val, glob.HashSlice())
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 
 	pts := m.Poller.Points()
 	pts = append(pts, glob)
 	err = optim.RecordPointPos(tx, pts...)
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 }
@@ -279,10 +339,36 @@ type Poller struct {
 	// FlipCompass is the number of iterations of consecutive failed polls
 	// after which the poller switches to CompassNp1 polling permanently.
 	FlipCompass int
+	// Rng is the source of random numbers for the poll order and for this
+	// package's spanners that don't have their own.  If nil, optim.Rand is
+	// used.
+	Rng optim.Rng
 }
 
 func (cp *Poller) Points() []*optim.Point { return cp.points }
 
+// share gives the poller's Rng to its spanner if it is one of this
+// package's spanners without its own.
+func (cp *Poller) share() {
+	if cp.Rng == nil {
+		return
+	}
+	switch s := cp.Spanner.(type) {
+	case Compass2N:
+		if s.Rng == nil {
+			cp.Spanner = Compass2N{Rng: cp.Rng}
+		}
+	case CompassNp1:
+		if s.Rng == nil {
+			cp.Spanner = CompassNp1{Rng: cp.Rng}
+		}
+	case *RandomN:
+		if s.Rng == nil {
+			s.Rng = cp.Rng
+		}
+	}
+}
+
 type direc struct {
 	dir []int
 	val float64
@@ -322,6 +408,7 @@ func (cp *Poller) Poll(obj optim.Objectiver, ev optim.Evaler, m optim.Mesh, from
 		// Use compass directions instead
 		cp.Spanner = CompassNp1{}
 	}
+	cp.share()
 	pollpoints = genPollPoints(from, cp.Spanner, m)
 	cp.prevhash = h
 	cp.prevstep = m.Step()
@@ -329,7 +416,7 @@ func (cp *Poller) Poll(obj optim.Objectiver, ev optim.Evaler, m optim.Mesh, from
 	// Add successful directions from last poll.  We want to add these points
 	// in front of the other points so we can potentially stop earlier if
 	// polling opportunistically.
-	perms := optim.Rand.Perm(len(pollpoints))
+	perms := rngOr(cp.Rng).Perm(len(pollpoints))
 
 	// this is an extra safety check to make sure we don't index out of bounds
 	// on the perms slice
@@ -338,8 +425,7 @@ func (cp *Poller) Poll(obj optim.Objectiver, ev optim.Evaler, m optim.Mesh, from
 		max = len(perms)
 	}
 
-	c2n := Compass2N{}
-	if cp.Spanner != c2n {
+	if _, ok := cp.Spanner.(Compass2N); !ok {
 		for i, dir := range cp.keepdirecs[:max] {
 			swapindex := perms[i]
 			pollpoints[swapindex] = pointFromDirec(from, dir.dir, m)
@@ -487,13 +573,16 @@ type Spanner interface {
 
 // Compass2N returns a compass positive basis set of polling directions in a
 // randomized order.
-type Compass2N struct{}
+type Compass2N struct {
+	// Rng is the source of random numbers.  If nil, optim.Rand is used.
+	Rng optim.Rng
+}
 
 func (c Compass2N) Update(step float64, prevsuccess bool) {}
 
 func (c Compass2N) Span(ndim int) [][]int {
 	dirs := make([][]int, 2*ndim)
-	perms := optim.Rand.Perm(ndim)
+	perms := rngOr(c.Rng).Perm(ndim)
 	for i := 0; i < ndim; i++ {
 		d := make([]int, ndim)
 		d[i] = 1
@@ -506,7 +595,10 @@ func (c Compass2N) Span(ndim int) [][]int {
 	return dirs
 }
 
-type CompassNp1 struct{}
+type CompassNp1 struct {
+	// Rng is the source of random numbers.  If nil, optim.Rand is used.
+	Rng optim.Rng
+}
 
 func (c CompassNp1) Update(step float64, prevsuccess bool) {}
 
@@ -516,7 +608,7 @@ func (c CompassNp1) Span(ndim int) [][]int {
 	for i := 0; i < ndim; i++ {
 		d := make([]int, ndim)
 
-		r := optim.Rand.Intn(2)
+		r := rngOr(c.Rng).Intn(2)
 		d[i] = 1
 		final[i] = -1
 		if r == 0 {
@@ -542,7 +634,9 @@ type RandomN struct {
 	N int
 	// Mask has either true or false for each dimension indicating whether or
 	// not it is allowed to be nonzero in the generated drections.
-	Mask        []bool
+	Mask []bool
+	// Rng is the source of random numbers.  If nil, optim.Rand is used.
+	Rng         optim.Rng
 	nonzeroFrac float64
 	origstep    float64
 }
@@ -581,6 +675,7 @@ func (r *RandomN) Span(ndim int) [][]int {
 		panic("pattern: ndim != len(mask)")
 	}
 
+	rng := rngOr(r.Rng)
 	dirs := make([][]int, 0, r.N)
 	for len(dirs) < r.N {
 		d1 := make([]int, ndim)
@@ -592,11 +687,11 @@ func (r *RandomN) Span(ndim int) [][]int {
 			// the +1 is to exclude vector of all zeros. And since Intn
 			// returns numbers < nactive we don't have to worry about
 			// nNonzero being greater than nactive.
-			nNonzero = optim.Rand.Intn(maxnonzero) + 1
+			nNonzero = rng.Intn(maxnonzero) + 1
 		}
-		perms := optim.Rand.Perm(nactive)
+		perms := rng.Perm(nactive)
 		for i := 0; i < nNonzero; i++ {
-			r := optim.Rand.Intn(2)
+			r := rng.Intn(2)
 			if r == 0 {
 				d1[indexmap[perms[i]]] = 1
 				d2[indexmap[perms[i]]] = -1
@@ -611,6 +706,14 @@ func (r *RandomN) Span(ndim int) [][]int {
 	return dirs
 }
 
+// rngOr returns r or optim.Rand if r is nil.
+func rngOr(r optim.Rng) optim.Rng {
+	if r == nil {
+		return optim.Rand
+	}
+	return r
+}
+
 func direcbetween(from, to *optim.Point, m optim.Mesh) []int {
 	d := make([]int, from.Len())
 	step := m.Step()
@@ -620,9 +723,13 @@ func direcbetween(from, to *optim.Point, m optim.Mesh) []int {
 	return d
 }
 
-func checkdberr(err error) bool {
+func (m *Method) checkdberr(err error) bool {
 	if err != nil {
-		log.Print("pattern: db write failed -", err)
+		var l optim.Logger = optim.StdLogger{}
+		if m.Logger != nil {
+			l = m.Logger
+		}
+		l.Log(optim.LevelError, "pattern: db write failed", "err", err)
 		return true
 	}
 	return false
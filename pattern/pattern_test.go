@@ -96,3 +96,32 @@ func patternsolver(fn bench.Func, db *sql.DB) (optim.Method, optim.Mesh) {
 	p := &optim.Point{pos, math.Inf(1)}
 	return New(p, DB(db)), m
 }
+
+func TestStepControl(t *testing.T) {
+	obj := optim.Func(func(v []float64) float64 { return v[0]*v[0] + v[1]*v[1] })
+	sched := &optim.StepSchedule{NGrow: 2, MaxStep: 4, MinStep: 1e-4}
+	s := &optim.Solver{
+		Method:  New(&optim.Point{Pos: []float64{3, -2}, Val: math.Inf(1)}, StepControl(sched)),
+		Obj:     obj,
+		Mesh:    &optim.InfMesh{StepSize: 1},
+		MaxIter: 10000,
+	}
+	maxstep := 0.0
+	for s.Next() {
+		maxstep = math.Max(maxstep, s.Status().Step)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	st := s.Status()
+	if st.Step >= 1e-4 || st.Niter >= 10000 {
+		t.Errorf("solver did not stop on schedule convergence: %+v", st)
+	}
+	if maxstep > 4 {
+		t.Errorf("step exceeded cap: %v", maxstep)
+	}
+	if st.Best.Val > 1e-6 {
+		t.Errorf("poor result: %v", st.Best)
+	}
+}
@@ -0,0 +1,280 @@
+// Package pipeline runs budgeted hybrid optimizations described by a
+// Pipeline: an ordered sequence of solver phases, each with its own
+// evaluation budget and stop criteria, along with rules for what each phase
+// inherits from earlier ones (the best points, the whole archive of
+// evaluated points, the mesh step).  A typical pipeline is a global
+// explorer followed by a local refiner:
+//
+//	p := &pipeline.Pipeline{Phases: []pipeline.Phase{
+//		{Method: "pswarm", MaxEval: 5000},
+//		{Method: "pattern", MaxEval: 1000, Transfer: pipeline.Transfer{Best: 1, Step: true}},
+//	}}
+//	res, err := p.Run(obj, low, up, nil)
+//
+// Pipelines are plain data and can be stored in JSON config files (see
+// Read and Write).  Phases refer to methods by name - the built-in methods
+// are "pswarm", "pattern", "crs" and "sce" and more can be added with
+// Register.  All phases operate on the problem's bounds mapped onto the
+// unit hypercube, so mesh steps are fractions of the bounds' widths.
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/analysis"
+	"github.com/rwcarlsen/optim/crs"
+	"github.com/rwcarlsen/optim/pattern"
+	"github.com/rwcarlsen/optim/pswarm"
+	"github.com/rwcarlsen/optim/sce"
+	"github.com/rwcarlsen/optim/transform"
+)
+
+// Pipeline is an ordered sequence of solver phases.
+type Pipeline struct {
+	Phases []Phase `json:"phases"`
+}
+
+// Phase is a single solver run within a pipeline.
+type Phase struct {
+	// Method is the name of a registered method.
+	Method string `json:"method"`
+	// MaxEval is the phase's evaluation budget.  It must be positive.
+	MaxEval int `json:"maxeval"`
+	// MaxNoImprove, MinStep and Target are optional stop criteria with the
+	// same meaning as the corresponding optim.Solver fields.
+	MaxNoImprove int      `json:"maxnoimprove,omitempty"`
+	MinStep      float64  `json:"minstep,omitempty"`
+	Target       *float64 `json:"target,omitempty"`
+	// Transfer selects what the phase inherits from earlier phases.
+	Transfer Transfer `json:"transfer,omitempty"`
+}
+
+// Transfer rules determine what a phase inherits from earlier phases.
+// Inherited points are passed to the phase's method with optim.Inject and
+// the mesh origin is moved to the best of them.
+type Transfer struct {
+	// Best passes the best Best points evaluated by the previous phase.
+	Best int `json:"best,omitempty"`
+	// Archive passes every point evaluated by all earlier phases.
+	Archive bool `json:"archive,omitempty"`
+	// Step starts the phase's mesh at the previous phase's final step size.
+	Step bool `json:"step,omitempty"`
+}
+
+// Context holds what a Builder needs to create a phase's method.
+type Context struct {
+	// Low and Up are the unit hypercube bounds the method operates in.
+	Low, Up []float64
+	// MaxEval is the phase's evaluation budget.
+	MaxEval int
+	// Evaler must be used by the method for all evaluations so the
+	// pipeline can archive them.
+	Evaler optim.Evaler
+}
+
+// Builder creates the method and mesh for a phase.
+type Builder func(c *Context) (optim.Method, optim.Mesh)
+
+var (
+	buildmu  sync.Mutex
+	builders = map[string]Builder{
+		"pswarm":  buildPSwarm,
+		"pattern": buildPattern,
+		"crs":     buildCRS,
+		"sce":     buildSCE,
+	}
+)
+
+// Register makes a method available to pipeline phases under name.  It
+// panics if name is already registered.
+func Register(name string, b Builder) {
+	buildmu.Lock()
+	defer buildmu.Unlock()
+	if _, dup := builders[name]; dup {
+		panic("pipeline: Register called twice for method " + name)
+	}
+	builders[name] = b
+}
+
+func builder(name string) (Builder, bool) {
+	buildmu.Lock()
+	defer buildmu.Unlock()
+	b, ok := builders[name]
+	return b, ok
+}
+
+// Validate checks that p has at least one phase and that every phase uses
+// a registered method with a positive budget.
+func (p *Pipeline) Validate() error {
+	if len(p.Phases) == 0 {
+		return errors.New("pipeline: no phases")
+	}
+	for i, ph := range p.Phases {
+		if _, ok := builder(ph.Method); !ok {
+			return fmt.Errorf("pipeline: phase %v: unknown method %q", i, ph.Method)
+		} else if ph.MaxEval <= 0 {
+			return fmt.Errorf("pipeline: phase %v: budget must be positive", i)
+		}
+	}
+	return nil
+}
+
+// MaxEval returns the total evaluation budget of all phases.
+func (p *Pipeline) MaxEval() int {
+	n := 0
+	for _, ph := range p.Phases {
+		n += ph.MaxEval
+	}
+	return n
+}
+
+// Write writes p to w as JSON.
+func (p *Pipeline) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// Read reads and validates a JSON pipeline written by Pipeline.Write.
+func Read(r io.Reader) (*Pipeline, error) {
+	p := &Pipeline{}
+	if err := json.NewDecoder(r).Decode(p); err != nil {
+		return nil, err
+	}
+	return p, p.Validate()
+}
+
+// Result holds the outcome of a pipeline run.  Points are in problem
+// space.
+type Result struct {
+	Best   optim.Point
+	Neval  int
+	Phases []PhaseResult
+}
+
+// PhaseResult summarizes a single phase of a pipeline run.
+type PhaseResult struct {
+	Method string
+	Neval  int
+	Niter  int
+	// Best is the best point found by the phase or nil if it evaluated
+	// none.
+	Best *optim.Point
+	// Step is the phase's final mesh step size.
+	Step float64
+	// Termination is the reason the phase ended.
+	Termination optim.Termination
+}
+
+// Run minimizes obj within the box bounds low and up by running each phase
+// of p in turn.  Every phase's evaluations go through ev (or an
+// optim.SerialEvaler if ev is nil).  If a phase fails, Run returns the
+// results gathered so far with the error.
+func (p *Pipeline) Run(obj optim.Objectiver, low, up []float64, ev optim.Evaler) (*Result, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if ev == nil {
+		ev = optim.SerialEvaler{}
+	}
+	ndim := len(low)
+	space := transform.UnitSpace(low, up)
+	uobj := &transform.Objective{Obj: obj, Space: space}
+	zeros, ones := make([]float64, ndim), make([]float64, ndim)
+	for i := range ones {
+		ones[i] = 1
+	}
+
+	res := &Result{Best: optim.Point{Val: math.Inf(1)}}
+	var archive, prev []*optim.Point // unit space
+	prevstep := 0.0
+	for _, ph := range p.Phases {
+		tr := &analysis.Tracker{Evaler: ev}
+		build, _ := builder(ph.Method)
+		method, mesh := build(&Context{Low: zeros, Up: ones, MaxEval: ph.MaxEval, Evaler: tr})
+
+		if ph.Transfer.Step && prevstep > 0 {
+			mesh.SetStep(prevstep)
+		}
+		if pts := transfer(ph.Transfer, prev, archive); len(pts) > 0 {
+			mesh.SetOrigin(append([]float64{}, pts[0].Pos...))
+			optim.Inject(method, pts...)
+		}
+
+		s := &optim.Solver{
+			Method:       method,
+			Obj:          uobj,
+			Mesh:         mesh,
+			MaxEval:      ph.MaxEval,
+			MaxNoImprove: ph.MaxNoImprove,
+			MinStep:      ph.MinStep,
+			Target:       ph.Target,
+		}
+		err := s.Run()
+
+		pr := PhaseResult{Method: ph.Method, Neval: s.Neval(), Niter: s.Niter(), Step: mesh.Step(), Termination: s.Termination()}
+		if b := s.Best(); b != nil && b.Pos != nil {
+			pr.Best = space.Point(b)
+			if pr.Best.Val < res.Best.Val {
+				res.Best = *pr.Best
+			}
+		}
+		res.Neval += s.Neval()
+		res.Phases = append(res.Phases, pr)
+		if err != nil {
+			return res, err
+		}
+
+		prev = tr.Points
+		archive = append(archive, tr.Points...)
+		prevstep = mesh.Step()
+	}
+	return res, nil
+}
+
+// transfer returns the points a phase inherits according to t sorted from
+// best to worst.
+func transfer(t Transfer, prev, archive []*optim.Point) []*optim.Point {
+	var pts []*optim.Point
+	if t.Archive {
+		pts = append(pts, archive...)
+	} else if t.Best > 0 {
+		pts = append(pts, prev...)
+	}
+	sort.SliceStable(pts, func(i, j int) bool { return pts[i].Val < pts[j].Val })
+	if !t.Archive && len(pts) > t.Best {
+		pts = pts[:t.Best]
+	}
+	return pts
+}
+
+func buildPSwarm(c *Context) (optim.Method, optim.Mesh) {
+	s, _ := pswarm.NewSolver(nil, c.Low, c.Up, pswarm.MaxEval(c.MaxEval), pswarm.WithEvaler(c.Evaler))
+	return s.Method, s.Mesh
+}
+
+func buildPattern(c *Context) (optim.Method, optim.Mesh) {
+	center := make([]float64, len(c.Low))
+	for i := range center {
+		center[i] = (c.Low[i] + c.Up[i]) / 2
+	}
+	mesh := &optim.BoxMesh{Mesh: &optim.InfMesh{StepSize: 1.0 / 9}, Lower: c.Low, Upper: c.Up}
+	mesh.SetOrigin(center)
+	m := pattern.New(&optim.Point{Pos: center, Val: math.Inf(1)}, pattern.Evaler(c.Evaler))
+	return m, mesh
+}
+
+func buildCRS(c *Context) (optim.Method, optim.Mesh) {
+	return crs.NewRand(c.Low, c.Up, crs.Evaler(c.Evaler)), &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: c.Low, Upper: c.Up}
+}
+
+func buildSCE(c *Context) (optim.Method, optim.Mesh) {
+	return sce.New(4, c.Low, c.Up, sce.Evaler(c.Evaler)), &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: c.Low, Upper: c.Up}
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+var rosen = optim.Func(func(x []float64) float64 {
+	tot := 0.0
+	for i := 0; i < len(x)-1; i++ {
+		a, b := x[i+1]-x[i]*x[i], x[i]-1
+		tot += 100*a*a + b*b
+	}
+	return tot
+})
+
+func TestRun(t *testing.T) {
+	optim.Rand = &optim.LockedRng{Rng: rand.New(rand.NewSource(1))}
+	low, up := []float64{-5, -5, -5, -5}, []float64{5, 5, 5, 5}
+	p := &Pipeline{Phases: []Phase{
+		{Method: "pswarm", MaxEval: 3000},
+		{Method: "pattern", MaxEval: 2000, MinStep: 1e-10, Transfer: Transfer{Best: 1, Step: true}},
+	}}
+	res, err := p.Run(rosen, low, up, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Neval > p.MaxEval()+100 {
+		t.Errorf("used %v evals, want at most %v", res.Neval, p.MaxEval())
+	}
+	first, second := res.Phases[0], res.Phases[1]
+	if first.Termination == optim.Running || second.Termination == optim.Running {
+		t.Errorf("phase terminations not recorded: %v, %v", first.Termination, second.Termination)
+	}
+	t.Logf("pswarm %v (%v evals), pattern %v (%v evals)", first.Best, first.Neval, second.Best, second.Neval)
+	if second.Best.Val >= first.Best.Val {
+		t.Errorf("refinement phase did not improve on the transferred best: %v >= %v", second.Best.Val, first.Best.Val)
+	}
+	if res.Best.Val != second.Best.Val {
+		t.Errorf("result best %v is not the best phase result %v", res.Best.Val, second.Best.Val)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	prev := []*optim.Point{{Pos: []float64{1}, Val: 3}, {Pos: []float64{2}, Val: 1}, {Pos: []float64{3}, Val: 2}}
+	archive := append([]*optim.Point{{Pos: []float64{4}, Val: 0}}, prev...)
+
+	if pts := transfer(Transfer{}, prev, archive); len(pts) != 0 {
+		t.Errorf("no transfer passed %v points", len(pts))
+	}
+	if pts := transfer(Transfer{Best: 2}, prev, archive); len(pts) != 2 || pts[0].Val != 1 || pts[1].Val != 2 {
+		t.Errorf("best 2 passed %v", pts)
+	}
+	if pts := transfer(Transfer{Archive: true}, prev, archive); len(pts) != 4 || pts[0].Val != 0 {
+		t.Errorf("archive passed %v", pts)
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	target := 1e-6
+	p := &Pipeline{Phases: []Phase{
+		{Method: "sce", MaxEval: 1000, MaxNoImprove: 20},
+		{Method: "crs", MaxEval: 500, Target: &target, Transfer: Transfer{Archive: true}},
+	}}
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Phases) != 2 || got.Phases[0] != (Phase{Method: "sce", MaxEval: 1000, MaxNoImprove: 20}) ||
+		*got.Phases[1].Target != target || !got.Phases[1].Transfer.Archive {
+		t.Errorf("round trip changed pipeline: %+v", got.Phases)
+	}
+
+	bad := `{"phases": [{"method": "nosuch", "maxeval": 10}]}`
+	if _, err := Read(strings.NewReader(bad)); err == nil {
+		t.Error("unknown method was accepted")
+	}
+	bad = `{"phases": [{"method": "crs"}]}`
+	if _, err := Read(strings.NewReader(bad)); err == nil {
+		t.Error("missing budget was accepted")
+	}
+}
@@ -5,20 +5,52 @@ import "math"
 // RandPop generates n randomly positioned points in the boxed bounds defined by
 // low and up.  The number of dimensions is equal to len(low).  Returned
 // points have their values initialized to +infinity.
-func RandPop(n int, low, up []float64) []*Point {
+func RandPop(n int, low, up []float64) []*Point { return RandPopFrom(Rand, n, low, up) }
+
+// RandPopFrom is like RandPop but draws random numbers from r.
+func RandPopFrom(r Rng, n int, low, up []float64) []*Point {
 	if len(low) != len(up) {
 		panic("low and up vectors are not same length")
 	}
 
-	ndims := len(low)
-
+	b := Bounds{low, up}
 	points := make([]*Point, n)
 	for i := 0; i < n; i++ {
-		pos := make([]float64, ndims)
-		for j := range pos {
-			pos[j] = low[j] + RandFloat()*(up[j]-low[j])
-		}
-		points[i] = &Point{pos, math.Inf(1)}
+		points[i] = &Point{b.SampleFrom(r), math.Inf(1)}
 	}
 	return points
 }
+
+// PopMean returns the mean position of the points.
+func PopMean(pts []*Point) []float64 {
+	mean := make([]float64, pts[0].Len())
+	for _, p := range pts {
+		for i, x := range p.Pos {
+			mean[i] += x / float64(len(pts))
+		}
+	}
+	return mean
+}
+
+// PopCov returns the (maximum likelihood) covariance matrix of the point
+// positions around mean.
+func PopCov(pts []*Point, mean []float64) [][]float64 {
+	n := len(mean)
+	cov := make([][]float64, n)
+	for i := range cov {
+		cov[i] = make([]float64, n)
+	}
+	for _, p := range pts {
+		for i := 0; i < n; i++ {
+			for j := 0; j <= i; j++ {
+				cov[i][j] += (p.Pos[i] - mean[i]) * (p.Pos[j] - mean[j]) / float64(len(pts))
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			cov[j][i] = cov[i][j]
+		}
+	}
+	return cov
+}
@@ -1 +1,28 @@
 package optim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPopMeanCov(t *testing.T) {
+	pts := []*Point{
+		&Point{Pos: []float64{0, 0}},
+		&Point{Pos: []float64{2, 4}},
+		&Point{Pos: []float64{4, 2}},
+	}
+	mean := PopMean(pts)
+	if mean[0] != 2 || mean[1] != 2 {
+		t.Errorf("bad mean: want [2 2], got %v", mean)
+	}
+
+	cov := PopCov(pts, mean)
+	want := [][]float64{{8.0 / 3, 4.0 / 3}, {4.0 / 3, 8.0 / 3}}
+	for i := range want {
+		for j := range want[i] {
+			if math.Abs(cov[i][j]-want[i][j]) > 1e-12 {
+				t.Errorf("cov[%v][%v]: want %v, got %v", i, j, want[i][j], cov[i][j])
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package pswarm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime/debug"
+	"sync"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/transform"
+)
+
+// ModulePath is the import path used to look up the package version recorded
+// in manifests.
+const ModulePath = "github.com/rwcarlsen/optim"
+
+// Manifest records everything needed to reproduce a solver run exactly: the
+// random seed, solver and mesh settings, the package version, and an
+// identifier for the objective.  It is intended to be written alongside a
+// run's results (e.g. with Write) and later passed to Replay.  Only options
+// with a serializable effect on the search trajectory are recorded - the
+// evaler, archive, and recorder don't change the trajectory while custom
+// movers, step controllers, mutators, and RNGs can't be recorded and are
+// rejected by NewManifest.
+type Manifest struct {
+	// Objective identifies the objective function.  Replay uses it to look
+	// up an objective added with Register.
+	Objective string
+	Seed      int64
+	Low, Up   []float64
+
+	MaxEval    int
+	Stall      int
+	MinStep    float64
+	Population int
+	// MeshStep is the initial mesh step size as a fraction of the bounds.
+	MeshStep float64
+	NearDist float64
+	// TrustRegion records whether WithTrustRegion was used.
+	TrustRegion bool `json:",omitempty"`
+	// Designs holds the problem-space positions given with WithDesigns.
+	Designs [][]float64 `json:",omitempty"`
+
+	// Version is the optim package version the run was performed with.
+	// Replaying with a different version may not reproduce the run.
+	Version string
+
+	// Best and Neval hold the result of the recorded run, if known.
+	Best  *optim.Point `json:",omitempty"`
+	Neval int          `json:",omitempty"`
+}
+
+// NewManifest returns a manifest for a run minimizing the objective
+// identified by objective within low and up using the given seed and
+// options.
+func NewManifest(objective string, seed int64, low, up []float64, opts ...Option) (*Manifest, error) {
+	user := &config{}
+	for _, opt := range opts {
+		opt(user)
+	}
+	switch {
+	case user.mover != nil:
+		return nil, errors.New("pswarm: manifests cannot record a custom mover")
+	case user.stepctrl != nil:
+		return nil, errors.New("pswarm: manifests cannot record a step controller")
+	case user.mutator != nil:
+		return nil, errors.New("pswarm: manifests cannot record a mutator")
+	case user.rng != nil:
+		return nil, errors.New("pswarm: manifests cannot record an RNG - use the seed instead")
+	}
+
+	c := newConfig(len(low), opts)
+	var designs [][]float64
+	for _, pos := range c.designs {
+		designs = append(designs, append([]float64{}, pos...))
+	}
+	return &Manifest{
+		Objective:   objective,
+		Seed:        seed,
+		Low:         append([]float64{}, low...),
+		Up:          append([]float64{}, up...),
+		MaxEval:     c.maxeval,
+		Stall:       c.stall,
+		MinStep:     c.minstep,
+		Population:  c.npop,
+		MeshStep:    c.meshstep,
+		NearDist:    c.neareps,
+		TrustRegion: c.trust,
+		Designs:     designs,
+		Version:     Version(),
+	}, nil
+}
+
+// Options returns the options that recreate the manifest's solver settings.
+func (m *Manifest) Options() []Option {
+	opts := []Option{
+		MaxEval(m.MaxEval),
+		Stall(m.Stall),
+		MinStep(m.MinStep),
+		Population(m.Population),
+		func(c *config) { c.meshstep = m.MeshStep },
+		WithNearDist(m.NearDist),
+	}
+	if m.TrustRegion {
+		opts = append(opts, WithTrustRegion())
+	}
+	if len(m.Designs) > 0 {
+		opts = append(opts, WithDesigns(m.Designs...))
+	}
+	return opts
+}
+
+// Solver returns a solver for minimizing obj with the manifest's settings
+// drawing its random numbers from its own source seeded with the manifest's
+// seed - optim.Rand is left untouched.  Additional options (e.g. WithEvaler
+// or WithRecorder) are applied after the recorded ones.
+func (m *Manifest) Solver(obj optim.Objectiver, opts ...Option) (*optim.Solver, transform.Space) {
+	opts = append(append(m.Options(), opts...), WithRNG(rand.New(rand.NewSource(m.Seed))))
+	return NewSolver(obj, m.Low, m.Up, opts...)
+}
+
+// Run performs the manifest's run minimizing obj and records the result in
+// m.Best and m.Neval.
+func (m *Manifest) Run(obj optim.Objectiver, opts ...Option) (optim.Point, error) {
+	s, space := m.Solver(obj, opts...)
+	for s.Next() {
+	}
+	m.Neval = s.Neval()
+	if s.Best() == nil {
+		return optim.Point{}, s.Err()
+	}
+	best := space.Point(s.Best())
+	m.Best = best.Clone()
+	return *best, s.Err()
+}
+
+// Write writes m to w as JSON.
+func (m *Manifest) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// ReadManifest reads a JSON manifest written by Manifest.Write.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var (
+	objmu      sync.Mutex
+	objectives = map[string]optim.Objectiver{}
+)
+
+// Register makes obj available to Replay under the identifier id.  It
+// panics if id is already registered.
+func Register(id string, obj optim.Objectiver) {
+	objmu.Lock()
+	defer objmu.Unlock()
+	if _, dup := objectives[id]; dup {
+		panic("pswarm: Register called twice for objective " + id)
+	}
+	objectives[id] = obj
+}
+
+// Replay reproduces the run recorded in m using the objective registered
+// under m.Objective.  The result is returned without modifying m, so it can
+// be compared with m.Best.
+func Replay(m *Manifest, opts ...Option) (optim.Point, error) {
+	objmu.Lock()
+	obj, ok := objectives[m.Objective]
+	objmu.Unlock()
+	if !ok {
+		return optim.Point{}, fmt.Errorf("pswarm: unknown objective %q", m.Objective)
+	}
+
+	replay := *m
+	return replay.Run(obj, opts...)
+}
+
+// Version returns the version of the optim module in the running binary or
+// "(devel)" if it is unknown.
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	version := ""
+	if info.Main.Path == ModulePath {
+		version = info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == ModulePath {
+			version = dep.Version
+		}
+	}
+	if version == "" {
+		return "(devel)"
+	}
+	return version
+}
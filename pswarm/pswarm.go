@@ -0,0 +1,241 @@
+// Package pswarm provides a ready-to-use hybrid of pattern search and
+// particle swarm (similar to PSwarm by Vaz and Vicente) with sensible
+// defaults.  The swarm is used as the pattern search's search step which
+// gives global exploration while the pattern poll step provides local
+// convergence.  Solve is the easiest way to get a result without
+// assembling methods, meshes, and solvers by hand:
+//
+//	best, err := pswarm.Solve(obj, low, up)
+//
+// For multi-objective problems, see the particle swarm multi.SMPSO.
+package pswarm
+
+import (
+	"database/sql"
+	"math"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/analysis"
+	"github.com/rwcarlsen/optim/doe"
+	"github.com/rwcarlsen/optim/pattern"
+	"github.com/rwcarlsen/optim/swarm"
+	"github.com/rwcarlsen/optim/transform"
+)
+
+type config struct {
+	maxeval  int
+	stall    int
+	minstep  float64
+	npop     int
+	ev       optim.Evaler
+	meshstep float64
+	mover    func(pop []*optim.Point, ev optim.Evaler) optim.Method
+	rng      optim.Rng
+	archive  *analysis.Tracker
+	db       *sql.DB
+	neareps  float64
+	stepctrl optim.StepController
+	trust    bool
+	designs  [][]float64
+	mutator  optim.Mutator
+	mutprob  float64
+}
+
+type Option func(*config)
+
+// MaxEval sets the maximum number of objective evaluations.
+func MaxEval(n int) Option { return func(c *config) { c.maxeval = n } }
+
+// Stall sets the number of iterations without improvement after which the
+// solver stops.
+func Stall(n int) Option { return func(c *config) { c.stall = n } }
+
+// MinStep sets the pattern search step size (as a fraction of the bounds)
+// at which the solver stops.
+func MinStep(step float64) Option { return func(c *config) { c.minstep = step } }
+
+// Population sets the number of swarm particles.
+func Population(n int) Option { return func(c *config) { c.npop = n } }
+
+// WithEvaler sets the evaler used for all objective evaluations.
+func WithEvaler(e optim.Evaler) Option { return func(c *config) { c.ev = e } }
+
+// Evaler sets the evaler used for all objective evaluations.
+//
+// Deprecated: use WithEvaler.
+func Evaler(e optim.Evaler) Option { return WithEvaler(e) }
+
+// WithMover replaces the particle swarm used as the pattern search's search
+// step with the method returned by fn.  fn is given the initial (latin
+// hypercube) population and the evaler the method should use.  This allows
+// e.g. a swarmx.GreyWolf to be used in place of the default swarm.
+func WithMover(fn func(pop []*optim.Point, ev optim.Evaler) optim.Method) Option {
+	return func(c *config) { c.mover = fn }
+}
+
+// WithRNG makes the solver draw its random numbers from r instead of the
+// shared optim.Rand.  r is used for the initial design, the default swarm
+// and the pattern search poll - a method set with WithMover must be given
+// its own source.  Other solvers are unaffected.
+func WithRNG(r optim.Rng) Option { return func(c *config) { c.rng = r } }
+
+// WithArchive records every evaluated point (in solver space) in a.  a's
+// Evaler is set to wrap the configured evaler.
+func WithArchive(a *analysis.Tracker) Option { return func(c *config) { c.archive = a } }
+
+// WithRecorder records the pattern search and swarm iteration history in
+// db.
+func WithRecorder(db *sql.DB) Option { return func(c *config) { c.db = db } }
+
+// WithNearDist skips evaluating points within eps (in fractions of the
+// bounds' widths) of an already evaluated point using an optim.NearEvaler.
+// This avoids spending evaluations on near-identical positions late in
+// convergence.
+func WithNearDist(eps float64) Option { return func(c *config) { c.neareps = eps } }
+
+// WithStepController sets the controller used by the pattern search to
+// update its mesh step size (in fractions of the bounds' widths).
+func WithStepController(sc optim.StepController) Option {
+	return func(c *config) { c.stepctrl = sc }
+}
+
+// WithTrustRegion restricts the swarm's search to a box around the best
+// point found that expands on improvement and contracts on stagnation (see
+// swarm.TrustRegion).  The box is coupled to the solver's mesh bounds, so
+// the pattern search poll is restricted to it too.
+func WithTrustRegion() Option { return func(c *config) { c.trust = true } }
+
+// WithDesigns starts the swarm with particles at the given problem-space
+// positions - e.g. known good designs - and fills the rest of the
+// population with latin hypercube points that avoid them (see
+// doe.Augment).
+func WithDesigns(designs ...[]float64) Option {
+	return func(c *config) { c.designs = append(c.designs, designs...) }
+}
+
+// WithMutation perturbs each swarm particle's position with mut after it
+// moves with probability prob (see swarm.Mutation).  The built-in mutators
+// draw from the solver's RNG unless they have their own source.  It has no
+// effect on a method set with WithMover.
+func WithMutation(mut optim.Mutator, prob float64) Option {
+	return func(c *config) { c.mutator, c.mutprob = mut, prob }
+}
+
+func newConfig(ndim int, opts []Option) *config {
+	c := &config{
+		maxeval:  50000,
+		stall:    500,
+		minstep:  1e-8,
+		npop:     30 + ndim,
+		ev:       optim.ParallelEvaler{},
+		meshstep: 1.0 / 9,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.npop > c.maxeval/500 && c.maxeval/500 > 0 {
+		c.npop = c.maxeval / 500
+	}
+	if c.rng == nil {
+		c.rng = optim.Rand
+	}
+	if c.archive != nil {
+		c.archive.Evaler = c.ev
+		c.ev = c.archive
+	}
+	if c.neareps > 0 {
+		c.ev = &optim.NearEvaler{Evaler: c.ev, Eps: c.neareps}
+	}
+	if c.mover == nil {
+		c.mover = func(pts []*optim.Point, ev optim.Evaler) optim.Method {
+			zeros, ones := bounds(len(pts[0].Pos))
+			pop := swarm.NewPopulationFrom(c.rng, pts, vmax(len(zeros)))
+			opts := []swarm.Option{swarm.Evaler(ev), swarm.VmaxBounds(zeros, ones), swarm.DB(c.db), swarm.Rng(c.rng)}
+			if c.trust {
+				opts = append(opts, swarm.Trust(swarm.NewTrustRegion(zeros, ones)))
+			}
+			if c.mutator != nil {
+				opts = append(opts, swarm.Mutation(c.mutator, c.mutprob))
+			}
+			return swarm.New(pop, opts...)
+		}
+	}
+	return c
+}
+
+// NewSolver returns a solver for minimizing obj within the box bounds low
+// and up.  The solver operates on the bounds mapped onto the unit hypercube
+// (so one mesh step size suits all dimensions) - use Space to map its
+// points back to problem space.  The swarm is initialized with a latin
+// hypercube design (plus any WithDesigns positions) and the pattern search
+// starts at the center of the bounds.
+func NewSolver(obj optim.Objectiver, low, up []float64, opts ...Option) (*optim.Solver, transform.Space) {
+	ndim := len(low)
+	c := newConfig(ndim, opts)
+	space := transform.UnitSpace(low, up)
+
+	zeros, ones := bounds(ndim)
+	center := make([]float64, ndim)
+	for i := range center {
+		center[i] = 0.5
+	}
+
+	pop := doe.LHSFrom(c.rng, c.npop, zeros, ones)
+	if len(c.designs) > 0 {
+		user := make([]*optim.Point, len(c.designs))
+		for i, pos := range c.designs {
+			user[i] = &optim.Point{Pos: space.Inverse(pos), Val: math.Inf(1)}
+		}
+		pop = doe.AugmentFrom(c.rng, user, c.npop, zeros, ones, 10)
+	}
+	search := c.mover(pop, c.ev)
+	m := pattern.New(&optim.Point{Pos: center, Val: math.Inf(1)},
+		pattern.SearchMethod(search, pattern.Share),
+		pattern.Evaler(c.ev),
+		pattern.DB(c.db),
+		pattern.StepControl(c.stepctrl),
+		pattern.Rng(c.rng),
+	)
+
+	mesh := &optim.BoxMesh{Mesh: &optim.InfMesh{StepSize: c.meshstep}, Lower: zeros, Upper: ones}
+	mesh.SetOrigin(center)
+
+	return &optim.Solver{
+		Method:       m,
+		Obj:          &transform.Objective{Obj: obj, Space: space},
+		Mesh:         mesh,
+		MaxEval:      c.maxeval,
+		MaxNoImprove: c.stall,
+		MinStep:      c.minstep,
+	}, space
+}
+
+// Solve minimizes obj within the box bounds low and up using default
+// settings that can be adjusted with opts.  The best point found is
+// returned in problem space.
+func Solve(obj optim.Objectiver, low, up []float64, opts ...Option) (optim.Point, error) {
+	s, space := NewSolver(obj, low, up, opts...)
+	for s.Next() {
+	}
+	if s.Best() == nil {
+		return optim.Point{}, s.Err()
+	}
+	return *space.Point(s.Best()), s.Err()
+}
+
+// bounds returns the unit hypercube bounds.
+func bounds(ndim int) (zeros, ones []float64) {
+	zeros, ones = make([]float64, ndim), make([]float64, ndim)
+	for i := range ones {
+		ones[i] = 1
+	}
+	return zeros, ones
+}
+
+func vmax(ndim int) []float64 {
+	v := make([]float64, ndim)
+	for i := range v {
+		v[i] = 0.5
+	}
+	return v
+}
@@ -0,0 +1,326 @@
+package pswarm
+
+import (
+	"bytes"
+	"database/sql"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+
+	_ "github.com/rwcarlsen/go-sqlite/sqlite3"
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/analysis"
+	"github.com/rwcarlsen/optim/bench"
+	"github.com/rwcarlsen/optim/pattern"
+	"github.com/rwcarlsen/optim/swarm"
+	"github.com/rwcarlsen/optim/swarmx"
+)
+
+func TestSolve(t *testing.T) {
+	fns := []bench.Func{bench.Ackley{}, bench.Rosenbrock{NDim: 2}, bench.Rastrigin{NDim: 4}}
+	for _, fn := range fns {
+		low, up := fn.Bounds()
+		best, err := Solve(optim.Func(fn.Eval), low, up)
+		if err != nil {
+			t.Fatalf("%v: %v", fn.Name(), err)
+		}
+		t.Logf("%v: %v", fn.Name(), best)
+		if best.Val > fn.Tol() {
+			t.Errorf("%v: want < %v, got %v", fn.Name(), fn.Tol(), best.Val)
+		}
+		if !bench.InsideBounds(best.Pos, fn) {
+			t.Errorf("%v: best point %v outside bounds", fn.Name(), best.Pos)
+		}
+	}
+}
+
+func TestUnevenBounds(t *testing.T) {
+	obj := optim.Func(func(v []float64) float64 {
+		return (v[0]-0.001)*(v[0]-0.001)*1e6 + (v[1]-500)*(v[1]-500)/1e4
+	})
+	best, err := Solve(obj, []float64{0, -1000}, []float64{0.01, 1000}, MaxEval(5000), WithEvaler(optim.SerialEvaler{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best.Val > 1e-4 {
+		t.Errorf("want < 1e-4, got %v", best)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	fn := bench.Ackley{}
+	low, up := fn.Bounds()
+	archive := &analysis.Tracker{}
+	nmover := 0
+	mover := func(pop []*optim.Point, ev optim.Evaler) optim.Method {
+		nmover++
+		return swarmx.NewGreyWolf(pop, swarmx.Evaler(ev))
+	}
+
+	best, err := Solve(optim.Func(fn.Eval), low, up,
+		WithEvaler(optim.SerialEvaler{}),
+		WithMover(mover),
+		WithRNG(rand.New(rand.NewSource(1))),
+		WithArchive(archive),
+		WithRecorder(db),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best.Val > fn.Tol() {
+		t.Errorf("want < %v, got %v", fn.Tol(), best)
+	}
+	if nmover != 1 {
+		t.Errorf("want mover created once, got %v", nmover)
+	}
+	if len(archive.Points) == 0 || archive.Best().Val != best.Val {
+		t.Errorf("archive missing best point: want %v, got %v (%v points)", best.Val, archive.Best(), len(archive.Points))
+	}
+
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + pattern.TblPolls).Scan(&n); err != nil {
+		t.Fatal(err)
+	} else if n == 0 {
+		t.Error("no iterations recorded")
+	}
+}
+
+func TestWithDesigns(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 2}
+	low, up := fn.Bounds()
+	var first []*optim.Point
+	mover := func(pop []*optim.Point, ev optim.Evaler) optim.Method {
+		first = append(first, pop[0].Clone())
+		return swarm.New(swarm.NewPopulation(pop, vmax(2)), swarm.Evaler(ev))
+	}
+	s, space := NewSolver(optim.Func(fn.Eval), low, up, WithDesigns([]float64{1, 1}), WithMover(mover), WithEvaler(optim.SerialEvaler{}))
+	if len(first) != 1 {
+		t.Fatalf("mover created %v times", len(first))
+	} else if pos := space.Forward(first[0].Pos); math.Abs(pos[0]-1) > 1e-10 || math.Abs(pos[1]-1) > 1e-10 {
+		t.Errorf("first particle at %v, want the user design [1 1]", pos)
+	}
+	s.Next()
+	if s.Best().Val > 1e-20 {
+		t.Errorf("user design (the optimum) not evaluated: best %v", s.Best())
+	}
+}
+
+func TestNearDist(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 2}
+	low, up := fn.Bounds()
+	best, err := Solve(optim.Func(fn.Eval), low, up, WithNearDist(1e-9), WithRNG(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best.Val > fn.Tol() {
+		t.Errorf("want < %v, got %v", fn.Tol(), best.Val)
+	}
+}
+
+func TestWithRNG(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 2}
+	low, up := fn.Bounds()
+	orig := optim.Rand
+	solve := func() optim.Point {
+		best, err := Solve(optim.Func(fn.Eval), low, up, MaxEval(2000), WithEvaler(optim.SerialEvaler{}), WithRNG(rand.New(rand.NewSource(7))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// draws from the shared source must not affect the run
+		optim.RandFloat()
+		return best
+	}
+
+	b1, b2 := solve(), solve()
+	if optim.Rand != orig {
+		t.Errorf("WithRNG replaced optim.Rand")
+	}
+	if b1.Val != b2.Val || b1.Pos[0] != b2.Pos[0] || b1.Pos[1] != b2.Pos[1] {
+		t.Errorf("runs with the same RNG seed differ: %v != %v", b1, b2)
+	}
+}
+
+func TestWithMutation(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 2}
+	low, up := fn.Bounds()
+	solve := func() optim.Point {
+		best, err := Solve(optim.Func(fn.Eval), low, up, MaxEval(2000), WithEvaler(optim.SerialEvaler{}),
+			WithRNG(rand.New(rand.NewSource(7))), WithMutation(optim.Cauchy{Scale: .1}, .1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the mutator must draw from the solver's RNG, not the shared source
+		optim.RandFloat()
+		return best
+	}
+
+	b1, b2 := solve(), solve()
+	if b1.Val != b2.Val || b1.Pos[0] != b2.Pos[0] || b1.Pos[1] != b2.Pos[1] {
+		t.Errorf("runs with the same RNG seed differ: %v != %v", b1, b2)
+	}
+}
+
+// TestIslands runs several solvers concurrently that share an evaluation
+// cache and migrate their best points to each other between iterations.  It
+// is intended to be run with -race.
+func TestIslands(t *testing.T) {
+	obj := optim.Func(func(v []float64) float64 { return v[0]*v[0] + v[1]*v[1] })
+	low, up := []float64{-5, -5}, []float64{5, 5}
+	cache := optim.NewCacheEvaler(optim.ParallelEvaler{})
+
+	const nisland = 4
+	solvers := make([]*optim.Solver, nisland)
+	for i := range solvers {
+		solvers[i], _ = NewSolver(obj, low, up, WithEvaler(cache), MaxEval(1000))
+	}
+
+	var wg sync.WaitGroup
+	for i, s := range solvers {
+		wg.Add(1)
+		go func(i int, s *optim.Solver) {
+			defer wg.Done()
+			for s.Next() {
+				for j, other := range solvers {
+					if j != i {
+						other.Method.AddPoint(s.Best().Clone())
+					}
+				}
+			}
+		}(i, s)
+	}
+	wg.Wait()
+
+	for i, s := range solvers {
+		if err := s.Err(); err != nil {
+			t.Errorf("island %v: %v", i, err)
+		}
+		t.Logf("island %v: %v evals, best %v", i, s.Neval(), s.Best().Val)
+		if s.Best().Val > 1e-4 {
+			t.Errorf("island %v: want < 1e-4, got %v", i, s.Best().Val)
+		}
+	}
+}
+
+var registerOnce sync.Once
+
+func TestManifestReplay(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 2}
+	low, up := fn.Bounds()
+	registerOnce.Do(func() { Register(fn.Name(), optim.Func(fn.Eval)) })
+
+	m, err := NewManifest(fn.Name(), 42, low, up, MaxEval(2000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := optim.Rand
+	want, err := m.Run(optim.Func(fn.Eval))
+	if err != nil {
+		t.Fatal(err)
+	} else if optim.Rand != orig {
+		t.Error("manifest run replaced optim.Rand")
+	}
+
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	m2, err := ReadManifest(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m2.Seed != 42 || m2.MaxEval != 2000 || m2.Neval != m.Neval {
+		t.Errorf("manifest did not round trip: got %+v, want %+v", m2, m)
+	}
+
+	got, err := Replay(m2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Val != want.Val || got.Pos[0] != want.Pos[0] || got.Pos[1] != want.Pos[1] {
+		t.Errorf("replay differs: got %v, want %v", got, want)
+	}
+
+	if _, err := Replay(&Manifest{Objective: "no-such-objective"}); err == nil {
+		t.Error("replaying unknown objective: want error, got nil")
+	}
+	if _, err := NewManifest(fn.Name(), 1, low, up, WithRNG(rand.New(rand.NewSource(1)))); err == nil {
+		t.Error("manifest with custom RNG: want error, got nil")
+	}
+	if _, err := NewManifest(fn.Name(), 1, low, up, WithMutation(optim.Gaussian{Scale: 1}, .1)); err == nil {
+		t.Error("manifest with mutator: want error, got nil")
+	}
+}
+
+func TestManifestTrustRegion(t *testing.T) {
+	low, up := []float64{-1, -1}, []float64{1, 1}
+	m, err := NewManifest("trust", 1, low, up, WithTrustRegion())
+	if err != nil {
+		t.Fatal(err)
+	} else if !m.TrustRegion {
+		t.Fatal("manifest did not record the trust region")
+	}
+	if c := newConfig(len(low), m.Options()); !c.trust {
+		t.Error("manifest options don't restore the trust region")
+	}
+}
+
+func TestManifestDesigns(t *testing.T) {
+	low, up := []float64{-1, -1}, []float64{1, 1}
+	m, err := NewManifest("designs", 1, low, up, WithDesigns([]float64{0.5, 0.5}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	m2, err := ReadManifest(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newConfig(len(low), m2.Options())
+	if len(c.designs) != 1 || c.designs[0][0] != 0.5 || c.designs[0][1] != 0.5 {
+		t.Errorf("manifest did not restore the designs: got %v", c.designs)
+	}
+}
+
+func TestSolveRestarts(t *testing.T) {
+	fn := bench.Rastrigin{NDim: 2}
+	low, up := fn.Bounds()
+	n := 0
+	obj := optim.Func(func(v []float64) float64 {
+		n++
+		return fn.Eval(v)
+	})
+
+	const budget = 4000
+	best, err := SolveRestarts(obj, low, up, 0.2, MaxEval(budget), WithEvaler(optim.SerialEvaler{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("best %v after %v evals", best, n)
+
+	single, _ := NewSolver(optim.Func(fn.Eval), low, up, MaxEval(budget), WithEvaler(optim.SerialEvaler{}))
+	for single.Next() {
+	}
+	if n <= single.Neval() {
+		t.Errorf("no restarts: used %v evals, single run used %v", n, single.Neval())
+	}
+	if n > budget+100 {
+		t.Errorf("budget exceeded: want about %v evals, got %v", budget, n)
+	}
+	if best.Val > fn.Tol() {
+		t.Errorf("want < %v, got %v", fn.Tol(), best.Val)
+	}
+	if !bench.InsideBounds(best.Pos, fn) {
+		t.Errorf("best point %v outside bounds", best.Pos)
+	}
+}
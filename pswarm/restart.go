@@ -0,0 +1,100 @@
+package pswarm
+
+import (
+	"math"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/analysis"
+	"github.com/rwcarlsen/optim/transform"
+)
+
+// RestartPhi is the nearest-better clustering cut factor used by
+// SolveRestarts to find basins among archived points.
+var RestartPhi = 2.0
+
+// SolveRestarts minimizes obj within the box bounds low and up like Solve,
+// but rather than stopping when the search converges or stalls it restarts
+// the solver until the MaxEval budget is exhausted.  Each restart searches a
+// reduced region - shrink times the bounds' widths, clipped to the bounds -
+// centered on the best point of the best basin not yet exploited.  Basins
+// are found with nearest-better clustering (see analysis.NearestBetter) over
+// every point evaluated so far.  A basin is exploited once a previous run
+// was centered on or converged to a point within its region.  If every
+// basin is exploited, the restart searches the full bounds.  SolveRestarts
+// uses its own archive for each run, overriding WithArchive.  The best
+// point found by any run is returned.
+func SolveRestarts(obj optim.Objectiver, low, up []float64, shrink float64, opts ...Option) (optim.Point, error) {
+	budget := newConfig(len(low), opts).maxeval
+	unit := transform.UnitSpace(low, up)
+
+	var archive []*optim.Point // evaluated points in unit space
+	var visited [][]float64    // run centers and results in unit space
+	best := optim.Point{Val: math.Inf(1)}
+	lowr, upr := low, up
+	for neval := 0; neval < budget; {
+		tr := &analysis.Tracker{}
+		runopts := append(append([]Option{}, opts...), MaxEval(budget-neval), WithArchive(tr))
+		s, space := NewSolver(obj, lowr, upr, runopts...)
+		for s.Next() {
+		}
+		neval += s.Neval()
+		if s.Err() != nil {
+			return best, s.Err()
+		} else if s.Neval() == 0 {
+			break
+		}
+
+		for _, p := range tr.Points {
+			archive = append(archive, &optim.Point{Pos: unit.Inverse(space.Forward(p.Pos)), Val: p.Val})
+		}
+		if b := s.Best(); b != nil && b.Val < math.Inf(1) {
+			runbest := space.Point(b)
+			visited = append(visited, unit.Inverse(runbest.Pos))
+			if runbest.Val < best.Val {
+				best = *runbest
+			}
+		}
+
+		center := unexploited(analysis.NearestBetter(archive, RestartPhi), visited, shrink/2)
+		if center == nil {
+			lowr, upr = low, up
+			continue
+		}
+		visited = append(visited, center)
+		ulow, uup := make([]float64, len(center)), make([]float64, len(center))
+		for i, c := range center {
+			ulow[i], uup[i] = c-shrink/2, c+shrink/2
+		}
+		zeros, ones := bounds(len(center))
+		cube := optim.Bounds{Low: zeros, Up: ones}
+		lowr, upr = unit.Forward(cube.Clip(ulow)), unit.Forward(cube.Clip(uup))
+	}
+	return best, nil
+}
+
+// unexploited returns the position of the best basin's best point that is
+// not within radius (in every dimension) of a visited position or nil if
+// there is none.
+func unexploited(basins []*analysis.Basin, visited [][]float64, radius float64) []float64 {
+	for _, b := range basins {
+		exploited := false
+		for _, v := range visited {
+			if chebyshev(b.Best.Pos, v) <= radius {
+				exploited = true
+				break
+			}
+		}
+		if !exploited {
+			return append([]float64{}, b.Best.Pos...)
+		}
+	}
+	return nil
+}
+
+func chebyshev(a, b []float64) float64 {
+	d := 0.0
+	for i := range a {
+		d = math.Max(d, math.Abs(a[i]-b[i]))
+	}
+	return d
+}
@@ -0,0 +1,36 @@
+"""Serve a Python objective function to github.com/rwcarlsen/optim/pybridge.
+
+Usage in an objective script run by pybridge.New:
+
+    from optim_bridge import serve
+
+    def rosenbrock(x):
+        return sum(100*(x[i+1] - x[i]**2)**2 + (1 - x[i])**2 for i in range(len(x)-1))
+
+    serve(rosenbrock)
+"""
+
+import json
+import sys
+
+
+def serve(func, stdin=None, stdout=None):
+    """Evaluate func for each request read from stdin until it is closed.
+
+    Exceptions raised by func are reported to the caller as failed
+    evaluations rather than terminating the process.
+    """
+    stdin = stdin or sys.stdin
+    stdout = stdout or sys.stdout
+    for line in stdin:
+        line = line.strip()
+        if not line:
+            continue
+        req = json.loads(line)
+        resp = {"id": req["id"]}
+        try:
+            resp["val"] = float(func(req["x"]))
+        except Exception as e:
+            resp["err"] = "%s: %s" % (type(e).__name__, e)
+        stdout.write(json.dumps(resp) + "\n")
+        stdout.flush()
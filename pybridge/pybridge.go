@@ -0,0 +1,277 @@
+// Package pybridge evaluates objectives defined in Python (or any other
+// language) by a long-lived subprocess.  The process is started once and
+// evaluates many points, avoiding per-evaluation interpreter startup cost.
+//
+// The protocol is one JSON object per line.  Requests written to the
+// process's stdin look like
+//
+//	{"id": 1, "x": [1.5, -2]}
+//
+// and the process must write one response line per request to stdout:
+//
+//	{"id": 1, "val": 3.25}
+//	{"id": 2, "err": "model failed to converge"}
+//
+// Non-finite values may be written as Infinity, -Infinity, or NaN (as
+// Python's json module does).  The optim_bridge.py module in this
+// directory implements the protocol - a Python objective script only
+// needs:
+//
+//	from optim_bridge import serve
+//	serve(lambda x: sum(xi**2 for xi in x))
+package pybridge
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is returned for evaluations that exceed an Objectiver's
+// Timeout.
+var ErrTimeout = errors.New("pybridge: evaluation timed out")
+
+// Objectiver is an optim.Objectiver that evaluates points using a pool of
+// long-lived subprocesses.  Processes are started lazily and restarted if
+// they exit or time out.  It is safe for concurrent use (e.g. with
+// optim.ParallelEvaler) - concurrent evaluations are spread over up to
+// Procs processes.
+type Objectiver struct {
+	// Path and Args specify the command to run.
+	Path string
+	Args []string
+	// Env and Dir, if set, are used as the process environment and working
+	// directory.
+	Env []string
+	Dir string
+	// Stderr receives the processes' standard error.  It defaults to
+	// os.Stderr.
+	Stderr io.Writer
+	// Procs is the maximum number of processes to run concurrently.  It
+	// defaults to 1.
+	Procs int
+	// Timeout, if non-zero, is the longest an evaluation may take.  Timed
+	// out processes are killed and replaced.
+	Timeout time.Duration
+
+	once   sync.Once
+	slots  chan *proc
+	mu     sync.Mutex
+	nextid int64
+	closed bool
+}
+
+// New returns an Objectiver that runs script with python3 (in unbuffered
+// mode).
+func New(script string, args ...string) *Objectiver {
+	return &Objectiver{Path: "python3", Args: append([]string{"-u", script}, args...)}
+}
+
+type proc struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+}
+
+type request struct {
+	ID int64     `json:"id"`
+	X  []float64 `json:"x"`
+}
+
+type response struct {
+	ID  int64           `json:"id"`
+	Val json.RawMessage `json:"val"`
+	Err string          `json:"err"`
+}
+
+func (o *Objectiver) init() {
+	n := o.Procs
+	if n < 1 {
+		n = 1
+	}
+	// nil entries are slots for processes not yet started
+	o.slots = make(chan *proc, n)
+	for i := 0; i < n; i++ {
+		o.slots <- nil
+	}
+}
+
+func (o *Objectiver) start() (*proc, error) {
+	cmd := exec.Command(o.Path, o.Args...)
+	cmd.Env, cmd.Dir = o.Env, o.Dir
+	cmd.Stderr = o.Stderr
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &proc{cmd: cmd, in: in, out: bufio.NewReader(out)}, nil
+}
+
+func (p *proc) kill() {
+	p.in.Close()
+	p.cmd.Process.Kill()
+	p.cmd.Wait()
+}
+
+// Objective sends v to an idle process and returns the value it responds
+// with.
+func (o *Objectiver) Objective(v []float64) (float64, error) {
+	o.once.Do(o.init)
+
+	p := <-o.slots
+	o.mu.Lock()
+	closed := o.closed
+	o.nextid++
+	id := o.nextid
+	o.mu.Unlock()
+	if closed {
+		o.slots <- p
+		return math.Inf(1), errors.New("pybridge: objectiver is closed")
+	}
+
+	// marshal before touching the process so unencodable positions (e.g.
+	// NaN) don't kill a healthy one
+	data, err := json.Marshal(request{ID: id, X: v})
+	if err != nil {
+		o.slots <- p
+		return math.Inf(1), fmt.Errorf("pybridge: cannot encode %v: %v", v, err)
+	}
+
+	if p == nil {
+		if p, err = o.start(); err != nil {
+			o.slots <- nil
+			return math.Inf(1), fmt.Errorf("pybridge: failed to start %v: %v", o.Path, err)
+		}
+	}
+
+	val, err := o.eval(p, id, data)
+	if err != nil && !isRemote(err) {
+		// the process is dead or out of sync with the protocol
+		p.kill()
+		p = nil
+	}
+	o.slots <- p
+	return val, err
+}
+
+type remoteErr struct{ msg string }
+
+func (e remoteErr) Error() string { return "pybridge: objective failed: " + e.msg }
+
+func isRemote(err error) bool {
+	_, ok := err.(remoteErr)
+	return ok
+}
+
+func (o *Objectiver) eval(p *proc, id int64, data []byte) (float64, error) {
+	if _, err := p.in.Write(append(data, '\n')); err != nil {
+		return math.Inf(1), fmt.Errorf("pybridge: write failed: %v", err)
+	}
+
+	type result struct {
+		line []byte
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		line, err := p.out.ReadBytes('\n')
+		ch <- result{line, err}
+	}()
+
+	var timeout <-chan time.Time
+	if o.Timeout > 0 {
+		timer := time.NewTimer(o.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var r result
+	select {
+	case r = <-ch:
+	case <-timeout:
+		return math.Inf(1), ErrTimeout
+	}
+	if r.err != nil {
+		return math.Inf(1), fmt.Errorf("pybridge: read failed: %v", r.err)
+	}
+
+	var resp response
+	if json.Unmarshal(r.line, &resp) != nil {
+		// retry with non-finite tokens quoted so the line is valid JSON
+		r.line = nonfinite.ReplaceAll(r.line, []byte(`$1"$2"`))
+	}
+	if err := json.Unmarshal(r.line, &resp); err != nil {
+		return math.Inf(1), fmt.Errorf("pybridge: invalid response %q: %v", r.line, err)
+	} else if resp.ID != id {
+		return math.Inf(1), fmt.Errorf("pybridge: response id %v does not match request id %v", resp.ID, id)
+	} else if resp.Err != "" {
+		return math.Inf(1), remoteErr{resp.Err}
+	}
+	return parseVal(resp.Val)
+}
+
+// nonfinite matches the bare non-finite number tokens emitted by Python's
+// json module.
+var nonfinite = regexp.MustCompile(`(:\s*)(-?Infinity|NaN)`)
+
+func parseVal(raw json.RawMessage) (float64, error) {
+	if len(raw) == 0 {
+		return math.Inf(1), errors.New("pybridge: response has no val")
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		switch s {
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		case "NaN":
+			return math.NaN(), nil
+		}
+		return strconv.ParseFloat(s, 64)
+	}
+	var val float64
+	err := json.Unmarshal(raw, &val)
+	return val, err
+}
+
+// Close stops all idle processes.  Evaluations in progress are allowed to
+// finish and their processes are stopped afterward.
+func (o *Objectiver) Close() error {
+	o.once.Do(o.init)
+	o.mu.Lock()
+	o.closed = true
+	o.mu.Unlock()
+
+	for i := 0; i < cap(o.slots); i++ {
+		if p := <-o.slots; p != nil {
+			p.in.Close()
+			p.cmd.Wait()
+		}
+	}
+	// return empty slots so callers blocked waiting for a process see that
+	// the objectiver is closed
+	for i := 0; i < cap(o.slots); i++ {
+		o.slots <- nil
+	}
+	return nil
+}
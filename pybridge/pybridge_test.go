@@ -0,0 +1,183 @@
+package pybridge
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// TestHelperProcess is run as the objective subprocess by other tests.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("PYBRIDGE_HELPER") != "1" {
+		return
+	}
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		var req request
+		json.Unmarshal(sc.Bytes(), &req)
+		switch x := req.X[0]; {
+		case x == 42:
+			os.Exit(1)
+		case x == 99:
+			time.Sleep(time.Hour)
+		case x == 7:
+			fmt.Printf("{\"id\": %v, \"val\": Infinity}\n", req.ID)
+		case x < 0:
+			fmt.Printf("{\"id\": %v, \"err\": \"negative input\"}\n", req.ID)
+		default:
+			fmt.Printf("{\"id\": %v, \"val\": %v, \"pid\": %v}\n", req.ID, x*x, os.Getpid())
+		}
+	}
+	os.Exit(0)
+}
+
+func helper() *Objectiver {
+	return &Objectiver{
+		Path: os.Args[0],
+		Args: []string{"-test.run=TestHelperProcess"},
+		Env:  append(os.Environ(), "PYBRIDGE_HELPER=1"),
+	}
+}
+
+func TestObjective(t *testing.T) {
+	obj := helper()
+	obj.Timeout = 2 * time.Second
+	defer obj.Close()
+
+	tests := []struct {
+		x       float64
+		want    float64
+		wanterr bool
+	}{
+		{3, 9, false},
+		{-1, math.Inf(1), true},
+		{2, 4, false},
+		{7, math.Inf(1), false},
+		{42, math.Inf(1), true}, // process crash
+		{5, 25, false},          // restarted
+	}
+	for _, test := range tests {
+		got, err := obj.Objective([]float64{test.x})
+		if (err != nil) != test.wanterr {
+			t.Errorf("f(%v): want error=%v, got %v", test.x, test.wanterr, err)
+		}
+		if got != test.want {
+			t.Errorf("f(%v): want %v, got %v", test.x, test.want, got)
+		}
+	}
+
+	obj.Timeout = 200 * time.Millisecond
+	if _, err := obj.Objective([]float64{99}); err != ErrTimeout {
+		t.Errorf("want timeout error, got %v", err)
+	}
+	if got, err := obj.Objective([]float64{1}); err != nil || got != 1 {
+		t.Errorf("after timeout: want 1, got %v (err=%v)", got, err)
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	obj := helper()
+	defer obj.Close()
+
+	pid := func() int {
+		p := <-obj.slots
+		defer func() { obj.slots <- p }()
+		return p.cmd.Process.Pid
+	}
+	if _, err := obj.Objective([]float64{2}); err != nil {
+		t.Fatal(err)
+	}
+	before := pid()
+	if _, err := obj.Objective([]float64{math.NaN()}); err == nil {
+		t.Error("want error encoding NaN")
+	}
+	if got, err := obj.Objective([]float64{3}); err != nil || got != 9 {
+		t.Errorf("after encode error: want 9, got %v (err=%v)", got, err)
+	}
+	if after := pid(); after != before {
+		t.Errorf("encode error restarted the process: pid %v -> %v", before, after)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	obj := helper()
+	obj.Procs = 3
+	defer obj.Close()
+
+	pts := make([]*optim.Point, 30)
+	for i := range pts {
+		pts[i] = &optim.Point{Pos: []float64{float64(i%6) + 0.5}}
+	}
+	results, n, err := optim.ParallelEvaler{}.Eval(obj, pts...)
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 6 {
+		t.Errorf("want 6 unique evals, got %v", n)
+	}
+	for _, p := range results {
+		if p.Val != p.Pos[0]*p.Pos[0] {
+			t.Errorf("f(%v): want %v, got %v", p.Pos[0], p.Pos[0]*p.Pos[0], p.Val)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(x float64) {
+			defer wg.Done()
+			if got, err := obj.Objective([]float64{x}); err != nil || got != x*x {
+				t.Errorf("f(%v): want %v, got %v (err=%v)", x, x*x, got, err)
+			}
+		}(float64(i) + 0.25)
+	}
+	wg.Wait()
+	if len(obj.slots) != 3 {
+		t.Errorf("want 3 process slots, got %v", len(obj.slots))
+	}
+}
+
+func TestPython(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not available")
+	}
+	dir, err := ioutil.TempDir("", "pybridge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	script := filepath.Join(dir, "obj.py")
+	src := "from optim_bridge import serve\n" +
+		"def f(x):\n" +
+		"    if x[0] < 0: raise ValueError('bad')\n" +
+		"    return float('inf') if x[0] == 7 else sum(xi**2 for xi in x)\n" +
+		"serve(f)\n"
+	if err := ioutil.WriteFile(script, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+
+	obj := New(script)
+	obj.Env = append(os.Environ(), "PYTHONPATH="+wd)
+	defer obj.Close()
+
+	if got, err := obj.Objective([]float64{1, 2}); err != nil || got != 5 {
+		t.Errorf("want 5, got %v (err=%v)", got, err)
+	}
+	if got, err := obj.Objective([]float64{7}); err != nil || !math.IsInf(got, 1) {
+		t.Errorf("want +Inf, got %v (err=%v)", got, err)
+	}
+	if _, err := obj.Objective([]float64{-1}); err == nil {
+		t.Errorf("want error from python exception")
+	}
+}
@@ -0,0 +1,241 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+)
+
+// Alpha is the significance level used to flag pairwise differences as
+// significant in reports.
+var Alpha = 0.05
+
+// NCheckpoints is the number of evaluation counts at which median
+// convergence is tabulated in markdown reports and sampled for HTML
+// convergence curves.
+var NCheckpoints = 10
+
+// Targets are the target values for which fixed-target statistics are
+// tabulated in markdown reports.  If empty, the table is omitted.
+var Targets []float64
+
+// Comparison is the result of a pairwise significance test between the
+// final values of two studies.  Runs are paired by index.
+type Comparison struct {
+	A, B *Study
+	W, P float64
+	// Better is the study with the lower median final value or nil if they
+	// are equal.
+	Better *Study
+}
+
+// Significant returns true if the comparison's p-value is below Alpha.
+func (c Comparison) Significant() bool { return c.P < Alpha }
+
+// Compare runs Wilcoxon signed-rank tests between every pair of studies.
+// Studies with differing numbers of runs are compared over their first
+// common runs.
+func Compare(studies ...*Study) ([]Comparison, error) {
+	var comps []Comparison
+	for i, a := range studies {
+		for _, b := range studies[i+1:] {
+			x, y := a.Finals(), b.Finals()
+			n := len(x)
+			if len(y) < n {
+				n = len(y)
+			}
+			w, p, err := Wilcoxon(x[:n], y[:n])
+			if err != nil {
+				return nil, err
+			}
+			c := Comparison{A: a, B: b, W: w, P: p}
+			if ma, mb := median(x[:n]), median(y[:n]); ma < mb {
+				c.Better = a
+			} else if mb < ma {
+				c.Better = b
+			}
+			comps = append(comps, c)
+		}
+	}
+	return comps, nil
+}
+
+// checkpoints returns log-spaced evaluation counts spanning the runs of
+// all studies.
+func checkpoints(studies []*Study) []int {
+	max := 1
+	for _, s := range studies {
+		if m := s.maxEvals(); m > max {
+			max = m
+		}
+	}
+	var cps []int
+	for i := 1; i <= NCheckpoints; i++ {
+		n := int(math.Round(math.Pow(float64(max), float64(i)/float64(NCheckpoints))))
+		if len(cps) == 0 || n > cps[len(cps)-1] {
+			cps = append(cps, n)
+		}
+	}
+	return cps
+}
+
+// Markdown writes a comparison report of studies to w in markdown format.
+func Markdown(w io.Writer, studies ...*Study) error {
+	comps, err := Compare(studies...)
+	if err != nil {
+		return err
+	}
+	ew := &errWriter{w: w}
+
+	ew.printf("# Run Comparison\n\n## Final Values\n\n")
+	ew.printf("| study | runs | mean | std | min | median | max | median evals |\n")
+	ew.printf("|---|---|---|---|---|---|---|---|\n")
+	for _, s := range studies {
+		st := Summarize(s.Finals())
+		ew.printf("| %v | %v | %.6g | %.6g | %.6g | %.6g | %.6g | %v |\n", s.Name, st.N, st.Mean, st.Std, st.Min, st.Median, st.Max, medianEvals(s))
+	}
+
+	ew.printf("\n## Convergence (median best value)\n\n| evals |")
+	for _, s := range studies {
+		ew.printf(" %v |", s.Name)
+	}
+	ew.printf("\n|---|%v\n", strings.Repeat("---|", len(studies)))
+	for _, n := range checkpoints(studies) {
+		ew.printf("| %v |", n)
+		for _, s := range studies {
+			ew.printf(" %.6g |", s.Median(n))
+		}
+		ew.printf("\n")
+	}
+
+	if len(Targets) > 0 {
+		ew.printf("\n## Fixed Target (expected running time)\n\n| target |")
+		for _, s := range studies {
+			ew.printf(" %v |", s.Name)
+		}
+		ew.printf("\n|---|%v\n", strings.Repeat("---|", len(studies)))
+		for _, target := range Targets {
+			ew.printf("| %.6g |", target)
+			for _, s := range studies {
+				ts := s.FixedTarget(target)
+				ew.printf(" %.6g (%v/%v) |", ts.ERT, ts.Successes, ts.Runs)
+			}
+			ew.printf("\n")
+		}
+	}
+
+	ew.printf("\n## Wilcoxon Signed-Rank Tests (alpha = %v)\n\n", Alpha)
+	ew.printf("| A | B | W | p | better |\n|---|---|---|---|---|\n")
+	for _, c := range comps {
+		ew.printf("| %v | %v | %v | %.4g | %v |\n", c.A.Name, c.B.Name, c.W, c.P, verdict(c))
+	}
+	return ew.err
+}
+
+// HTML writes a comparison report of studies to w as a standalone HTML
+// page with an inline SVG plot of median convergence curves.
+func HTML(w io.Writer, studies ...*Study) error {
+	comps, err := Compare(studies...)
+	if err != nil {
+		return err
+	}
+	ew := &errWriter{w: w}
+	esc := html.EscapeString
+
+	ew.printf("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>Run Comparison</title>\n")
+	ew.printf("<style>table{border-collapse:collapse}td,th{border:1px solid #aaa;padding:2px 6px}</style></head><body>\n")
+	ew.printf("<h1>Run Comparison</h1>\n<h2>Convergence</h2>\n")
+	convergenceSVG(ew, studies, 640, 400)
+
+	ew.printf("<h2>Final Values</h2>\n<table><tr><th>study</th><th>runs</th><th>mean</th><th>std</th><th>min</th><th>median</th><th>max</th><th>median evals</th></tr>\n")
+	for _, s := range studies {
+		st := Summarize(s.Finals())
+		ew.printf("<tr><td>%v</td><td>%v</td><td>%.6g</td><td>%.6g</td><td>%.6g</td><td>%.6g</td><td>%.6g</td><td>%v</td></tr>\n", esc(s.Name), st.N, st.Mean, st.Std, st.Min, st.Median, st.Max, medianEvals(s))
+	}
+	ew.printf("</table>\n")
+
+	ew.printf("<h2>Wilcoxon Signed-Rank Tests (alpha = %v)</h2>\n<table><tr><th>A</th><th>B</th><th>W</th><th>p</th><th>better</th></tr>\n", Alpha)
+	for _, c := range comps {
+		ew.printf("<tr><td>%v</td><td>%v</td><td>%v</td><td>%.4g</td><td>%v</td></tr>\n", esc(c.A.Name), esc(c.B.Name), c.W, c.P, esc(verdict(c)))
+	}
+	ew.printf("</table>\n</body></html>\n")
+	return ew.err
+}
+
+var palette = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f"}
+
+// convergenceSVG plots the median best value of each study against
+// evaluation count using log scaled axes.  Values are shifted so the
+// smallest plotted value maps to the bottom of the plot.
+func convergenceSVG(ew *errWriter, studies []*Study, width, height int) {
+	const margin = 40
+	cps := checkpoints(studies)
+	curves := make([][]float64, len(studies))
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, s := range studies {
+		for _, n := range cps {
+			v := s.Median(n)
+			curves[i] = append(curves[i], v)
+			if !math.IsInf(v, 0) {
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+		}
+	}
+	// shift values to be positive for log scaling
+	shift := 1 - lo
+	ymap := func(v float64) float64 {
+		t := math.Log10(v+shift) / math.Max(math.Log10(hi+shift), 1e-12)
+		return float64(height-margin) - t*float64(height-2*margin)
+	}
+	xmap := func(n int) float64 {
+		t := math.Log10(float64(n)) / math.Max(math.Log10(float64(cps[len(cps)-1])), 1e-12)
+		return margin + t*float64(width-2*margin)
+	}
+
+	ew.printf("<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%v\" height=\"%v\">\n", width, height)
+	ew.printf("<rect x=\"%v\" y=\"%v\" width=\"%v\" height=\"%v\" fill=\"none\" stroke=\"#888\"/>\n", margin, margin, width-2*margin, height-2*margin)
+	ew.printf("<text x=\"%v\" y=\"%v\" font-size=\"12\" text-anchor=\"middle\">evaluations (log)</text>\n", width/2, height-10)
+	ew.printf("<text x=\"%v\" y=\"%v\" font-size=\"12\">%.4g</text>\n", 2, margin-5, hi)
+	ew.printf("<text x=\"%v\" y=\"%v\" font-size=\"12\">%.4g</text>\n", 2, height-margin+15, lo)
+	for i, s := range studies {
+		color := palette[i%len(palette)]
+		var pts []string
+		for j, v := range curves[i] {
+			if math.IsInf(v, 0) {
+				continue
+			}
+			pts = append(pts, fmt.Sprintf("%.1f,%.1f", xmap(cps[j]), ymap(v)))
+		}
+		ew.printf("<polyline fill=\"none\" stroke=\"%v\" stroke-width=\"2\" points=\"%v\"/>\n", color, strings.Join(pts, " "))
+		ew.printf("<text x=\"%v\" y=\"%v\" font-size=\"12\" fill=\"%v\">%v</text>\n", width-margin-100, margin+15*(i+1), color, html.EscapeString(s.Name))
+	}
+	ew.printf("</svg>\n")
+}
+
+func medianEvals(s *Study) float64 {
+	evals := make([]float64, len(s.Runs))
+	for i, t := range s.Runs {
+		evals[i] = float64(t.Evals())
+	}
+	return median(evals)
+}
+
+func verdict(c Comparison) string {
+	if c.Better == nil || !c.Significant() {
+		return "no significant difference"
+	}
+	return c.Better.Name
+}
+
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
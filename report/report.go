@@ -0,0 +1,294 @@
+// Package report generates side-by-side comparisons of optimization runs.
+// Runs are loaded from Recorder databases (via the checkpoint table written
+// by optim.CheckpointDB) or from bench result files and grouped into
+// studies - one per method or configuration being compared.  Reports
+// include convergence curves, final value statistics, and pairwise
+// Wilcoxon signed-rank tests and can be written as markdown or HTML.
+package report
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Trace is the convergence history of a single optimization run.  Neval[i]
+// is the number of evaluations performed when the best value so far was
+// Val[i].  Entries are ordered by increasing Neval.
+type Trace struct {
+	Neval []int
+	Val   []float64
+}
+
+// Add appends a record to the trace.  Val is kept monotonically
+// non-increasing so traces always represent best-so-far values.
+func (t *Trace) Add(neval int, val float64) {
+	if n := len(t.Val); n > 0 && t.Val[n-1] < val {
+		val = t.Val[n-1]
+	}
+	t.Neval = append(t.Neval, neval)
+	t.Val = append(t.Val, val)
+}
+
+// Final returns the best value found over the entire run.
+func (t *Trace) Final() float64 {
+	if len(t.Val) == 0 {
+		return math.Inf(1)
+	}
+	return t.Val[len(t.Val)-1]
+}
+
+// Evals returns the total number of evaluations in the run.
+func (t *Trace) Evals() int {
+	if len(t.Neval) == 0 {
+		return 0
+	}
+	return t.Neval[len(t.Neval)-1]
+}
+
+// At returns the best value found within the first neval evaluations.
+func (t *Trace) At(neval int) float64 {
+	i := sort.Search(len(t.Neval), func(i int) bool { return t.Neval[i] > neval })
+	if i == 0 {
+		return math.Inf(1)
+	}
+	return t.Val[i-1]
+}
+
+// Hit returns the number of evaluations the run needed to reach a value at
+// or below target or -1 if it never did.
+func (t *Trace) Hit(target float64) int {
+	for i, v := range t.Val {
+		if v <= target {
+			return t.Neval[i]
+		}
+	}
+	return -1
+}
+
+// LoadDB reads a run's trace from the optim.TblCheckpoint table in db.  A
+// full convergence history can be recorded by calling the function
+// returned by optim.CheckpointDB after each Solver iteration.
+func LoadDB(db *sql.DB) (*Trace, error) {
+	rows, err := db.Query("SELECT neval,val FROM " + optim.TblCheckpoint + " ORDER BY iter;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	t := &Trace{}
+	for rows.Next() {
+		var neval int
+		var val float64
+		if err := rows.Scan(&neval, &val); err != nil {
+			return nil, err
+		}
+		t.Add(neval, val)
+	}
+	return t, rows.Err()
+}
+
+// ReadResults reads traces from a bench result file in r.  Each record is
+// comma separated "run,neval,val" where run identifies the trace the
+// record belongs to.  Traces are returned in order of first appearance.
+// A header line, if present, is skipped.
+func ReadResults(r io.Reader) ([]*Trace, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	cr.Comment = '#'
+
+	var traces []*Trace
+	index := map[string]*Trace{}
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		neval, err1 := strconv.Atoi(rec[1])
+		val, err2 := strconv.ParseFloat(rec[2], 64)
+		if err1 != nil || err2 != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("report: line %v: invalid record %v", line, rec)
+		}
+		t, ok := index[rec[0]]
+		if !ok {
+			t = &Trace{}
+			index[rec[0]] = t
+			traces = append(traces, t)
+		}
+		t.Add(neval, val)
+	}
+	return traces, nil
+}
+
+// WriteResults writes traces to w in the format read by ReadResults.
+func WriteResults(w io.Writer, traces ...*Trace) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"run", "neval", "val"})
+	for run, t := range traces {
+		for i := range t.Neval {
+			cw.Write([]string{strconv.Itoa(run), strconv.Itoa(t.Neval[i]), strconv.FormatFloat(t.Val[i], 'g', -1, 64)})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Study is a named collection of repeated runs of a single method or
+// configuration.
+type Study struct {
+	Name string
+	Runs []*Trace
+}
+
+// LoadStudy creates a study named name with one run loaded from each of
+// dbs.
+func LoadStudy(name string, dbs ...*sql.DB) (*Study, error) {
+	s := &Study{Name: name}
+	for i, db := range dbs {
+		t, err := LoadDB(db)
+		if err != nil {
+			return nil, fmt.Errorf("report: study %v run %v: %v", name, i, err)
+		}
+		s.Runs = append(s.Runs, t)
+	}
+	return s, nil
+}
+
+// Finals returns the final value of each run.
+func (s *Study) Finals() []float64 {
+	vals := make([]float64, len(s.Runs))
+	for i, t := range s.Runs {
+		vals[i] = t.Final()
+	}
+	return vals
+}
+
+// Median returns the median over all runs of the best value found within
+// the first neval evaluations.
+func (s *Study) Median(neval int) float64 {
+	vals := make([]float64, len(s.Runs))
+	for i, t := range s.Runs {
+		vals[i] = t.At(neval)
+	}
+	return median(vals)
+}
+
+// TargetStats summarizes a study's runs for a fixed target value.
+type TargetStats struct {
+	Target    float64
+	Runs      int
+	Successes int
+	// Evals summarizes the evaluations successful runs needed to reach the
+	// target.
+	Evals Stats
+	// ERT is the expected running time - the total evaluations spent by
+	// all runs (up to reaching the target for successful runs) divided by
+	// the number of successful runs.  It is the number of evaluations a
+	// restarted method is expected to need to reach the target and is
+	// positive infinity if no run succeeded.
+	ERT float64
+}
+
+// SuccessRate returns the fraction of runs that reached the target.
+func (ts TargetStats) SuccessRate() float64 { return float64(ts.Successes) / float64(ts.Runs) }
+
+// FixedTarget computes fixed-target statistics of s's runs as defined by
+// the COCO benchmarking platform:
+//
+//	Hansen, Nikolaus, et al. "COCO: Performance assessment." arXiv preprint
+//	arXiv:1605.03560 (2016).
+func (s *Study) FixedTarget(target float64) TargetStats {
+	ts := TargetStats{Target: target, Runs: len(s.Runs)}
+	var hits []float64
+	total := 0
+	for _, t := range s.Runs {
+		if n := t.Hit(target); n >= 0 {
+			hits = append(hits, float64(n))
+			total += n
+		} else {
+			total += t.Evals()
+		}
+	}
+	ts.Successes = len(hits)
+	ts.Evals = Summarize(hits)
+	ts.ERT = math.Inf(1)
+	if ts.Successes > 0 {
+		ts.ERT = float64(total) / float64(ts.Successes)
+	}
+	return ts
+}
+
+// FixedBudget summarizes the best values s's runs found within the first
+// neval evaluations.
+func (s *Study) FixedBudget(neval int) Stats {
+	vals := make([]float64, len(s.Runs))
+	for i, t := range s.Runs {
+		vals[i] = t.At(neval)
+	}
+	return Summarize(vals)
+}
+
+// maxEvals returns the largest evaluation count over all runs.
+func (s *Study) maxEvals() int {
+	max := 0
+	for _, t := range s.Runs {
+		if t.Evals() > max {
+			max = t.Evals()
+		}
+	}
+	return max
+}
+
+// Stats summarizes a set of values.
+type Stats struct {
+	N                int
+	Mean, Std        float64
+	Min, Median, Max float64
+}
+
+// Summarize computes summary statistics for vals.
+func Summarize(vals []float64) Stats {
+	st := Stats{N: len(vals)}
+	if len(vals) == 0 {
+		return st
+	}
+	sorted := append([]float64{}, vals...)
+	sort.Float64s(sorted)
+	st.Min, st.Max, st.Median = sorted[0], sorted[len(sorted)-1], median(sorted)
+
+	for _, v := range vals {
+		st.Mean += v
+	}
+	st.Mean /= float64(len(vals))
+	if len(vals) > 1 {
+		for _, v := range vals {
+			st.Std += (v - st.Mean) * (v - st.Mean)
+		}
+		st.Std = math.Sqrt(st.Std / float64(len(vals)-1))
+	}
+	return st
+}
+
+func median(vals []float64) float64 {
+	if len(vals) == 0 {
+		return math.NaN()
+	}
+	sorted := append([]float64{}, vals...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
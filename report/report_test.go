@@ -0,0 +1,189 @@
+package report
+
+import (
+	"bytes"
+	"database/sql"
+	"math"
+	"strings"
+	"testing"
+
+	_ "github.com/rwcarlsen/go-sqlite/sqlite3"
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+	"github.com/rwcarlsen/optim/pattern"
+)
+
+func TestWilcoxonExact(t *testing.T) {
+	x := []float64{1, 2, 3, -4, 5, 6, 7, 8, 0}
+	y := make([]float64, len(x))
+	w, p, err := Wilcoxon(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// zero difference dropped; 7 subsets of 1..8 sum to <= 4
+	if w != 32 {
+		t.Errorf("want W=32, got %v", w)
+	}
+	if want := 2 * 7.0 / 256; math.Abs(p-want) > 1e-12 {
+		t.Errorf("want p=%v, got %v", want, p)
+	}
+
+	_, p, _ = Wilcoxon(y, y)
+	if p != 1 {
+		t.Errorf("identical samples: want p=1, got %v", p)
+	}
+}
+
+func TestWilcoxonNormal(t *testing.T) {
+	x := make([]float64, 40)
+	y := make([]float64, 40)
+	for i := range x {
+		x[i] = float64(i + 1)
+		if i%3 == 0 {
+			x[i] = -x[i]
+		}
+	}
+	_, exact, _ := Wilcoxon(x, y)
+
+	defer func(n int) { ExactLimit = n }(ExactLimit)
+	ExactLimit = 0
+	_, approx, _ := Wilcoxon(x, y)
+	if math.Abs(exact-approx) > 0.005 {
+		t.Errorf("normal approximation too far from exact: exact=%v, approx=%v", exact, approx)
+	}
+}
+
+func TestReadResults(t *testing.T) {
+	a, b := &Trace{}, &Trace{}
+	a.Add(10, 5)
+	a.Add(20, 7) // not an improvement
+	a.Add(30, 1)
+	b.Add(5, 2)
+
+	var buf bytes.Buffer
+	if err := WriteResults(&buf, a, b); err != nil {
+		t.Fatal(err)
+	}
+	traces, err := ReadResults(&buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(traces) != 2 {
+		t.Fatalf("want 2 traces, got %v", len(traces))
+	}
+	if got := traces[0].At(25); got != 5 {
+		t.Errorf("best after 25 evals: want 5, got %v", got)
+	}
+	if traces[0].Final() != 1 || traces[0].Evals() != 30 || traces[1].Final() != 2 {
+		t.Errorf("wrong traces read: %+v, %+v", traces[0], traces[1])
+	}
+}
+
+func TestLoadDB(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	fn := bench.Rosenbrock{NDim: 2}
+	s := &optim.Solver{
+		Method:  pattern.New(&optim.Point{Pos: []float64{-1, 1}, Val: math.Inf(1)}),
+		Obj:     optim.Func(fn.Eval),
+		Mesh:    &optim.InfMesh{},
+		MaxIter: 20,
+	}
+	record := optim.CheckpointDB(db)
+	for s.Next() {
+		if err := record(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tr, err := LoadDB(db)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(tr.Val) == 0 {
+		t.Fatal("no checkpoints loaded")
+	}
+	if tr.Final() != s.Best().Val || tr.Evals() != s.Neval() {
+		t.Errorf("want final %v after %v evals, got %v after %v", s.Best().Val, s.Neval(), tr.Final(), tr.Evals())
+	}
+}
+
+func TestReports(t *testing.T) {
+	good := &Study{Name: "good"}
+	bad := &Study{Name: "bad"}
+	for i := 0; i < 10; i++ {
+		g, b := &Trace{}, &Trace{}
+		g.Add(1, 10)
+		g.Add(100, float64(i)*0.01)
+		b.Add(1, 10)
+		b.Add(100, 1+float64(i)*0.01)
+		good.Runs = append(good.Runs, g)
+		bad.Runs = append(bad.Runs, b)
+	}
+
+	comps, err := Compare(good, bad)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(comps) != 1 || comps[0].Better != good || !comps[0].Significant() {
+		t.Errorf("want good significantly better, got %+v", comps)
+	}
+
+	var buf bytes.Buffer
+	if err := Markdown(&buf, good, bad); err != nil {
+		t.Fatal(err)
+	}
+	if md := buf.String(); !strings.Contains(md, "| good | bad | 0 | 0.001953 | good |") {
+		t.Errorf("markdown missing comparison row:\n%v", md)
+	}
+
+	buf.Reset()
+	if err := HTML(&buf, good, bad); err != nil {
+		t.Fatal(err)
+	}
+	if h := buf.String(); strings.Count(h, "<polyline") != 2 {
+		t.Errorf("want 2 convergence curves in html:\n%v", h)
+	}
+}
+
+func TestFixedTarget(t *testing.T) {
+	s := &Study{Name: "s"}
+	for _, hit := range []int{100, 300, -1} {
+		tr := &Trace{}
+		tr.Add(1, 10)
+		if hit > 0 {
+			tr.Add(hit, 0)
+		}
+		tr.Add(1000, 5) // failed runs use their full budget
+		s.Runs = append(s.Runs, tr)
+	}
+
+	ts := s.FixedTarget(1)
+	if ts.Successes != 2 || ts.Runs != 3 {
+		t.Errorf("want 2/3 successes, got %v/%v", ts.Successes, ts.Runs)
+	}
+	if want := (100.0 + 300 + 1000) / 2; ts.ERT != want {
+		t.Errorf("ERT: want %v, got %v", want, ts.ERT)
+	}
+	if ts.Evals.Median != 200 {
+		t.Errorf("median evals to target: want 200, got %v", ts.Evals.Median)
+	}
+	if ts := s.FixedTarget(-1); !math.IsInf(ts.ERT, 1) {
+		t.Errorf("unreached target: want infinite ERT, got %v", ts.ERT)
+	}
+
+	if st := s.FixedBudget(200); st.Min != 0 || st.Max != 10 || st.Median != 10 {
+		t.Errorf("fixed budget of 200 evals: got %+v", st)
+	}
+
+	Targets = []float64{1}
+	defer func() { Targets = nil }()
+	var buf bytes.Buffer
+	if err := Markdown(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	if md := buf.String(); !strings.Contains(md, "| 1 | 700 (2/3) |") {
+		t.Errorf("markdown missing fixed target row:\n%v", md)
+	}
+}
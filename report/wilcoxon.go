@@ -0,0 +1,129 @@
+package report
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ExactLimit is the largest number of non-zero differences for which
+// Wilcoxon computes exact p-values.  A normal approximation is used for
+// larger samples.
+var ExactLimit = 50
+
+// Wilcoxon performs a two-sided Wilcoxon signed-rank test of the null
+// hypothesis that the paired differences x[i]-y[i] are symmetric about
+// zero.  Zero differences are dropped and tied absolute differences
+// receive average ranks.  w is the sum of ranks of the positive
+// differences.  For small samples the p-value is computed exactly from the
+// permutation distribution of the (possibly tied) ranks; otherwise a normal
+// approximation with tie and continuity corrections is used.
+func Wilcoxon(x, y []float64) (w, p float64, err error) {
+	if len(x) != len(y) {
+		return 0, 0, errors.New("report: wilcoxon samples must have equal length")
+	}
+
+	var diffs []float64
+	for i := range x {
+		if d := x[i] - y[i]; d != 0 && !math.IsNaN(d) {
+			diffs = append(diffs, d)
+		}
+	}
+	n := len(diffs)
+	if n == 0 {
+		return 0, 1, nil
+	}
+
+	ranks := rank(diffs)
+	for i, d := range diffs {
+		if d > 0 {
+			w += ranks[i]
+		}
+	}
+
+	if n <= ExactLimit {
+		return w, exactP(ranks, w), nil
+	}
+
+	fn := float64(n)
+	mean := fn * (fn + 1) / 4
+	variance := fn * (fn + 1) * (2*fn + 1) / 24
+	for _, t := range tieCounts(diffs) {
+		variance -= (t*t*t - t) / 48
+	}
+	z := math.Abs(w-mean) - 0.5
+	if z < 0 {
+		z = 0
+	}
+	z /= math.Sqrt(variance)
+	return w, math.Min(1, math.Erfc(z/math.Sqrt2)), nil
+}
+
+// rank returns the average ranks of the absolute values of diffs.
+func rank(diffs []float64) []float64 {
+	idx := make([]int, len(diffs))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool { return math.Abs(diffs[idx[a]]) < math.Abs(diffs[idx[b]]) })
+
+	ranks := make([]float64, len(diffs))
+	for i := 0; i < len(idx); {
+		j := i
+		for j < len(idx) && math.Abs(diffs[idx[j]]) == math.Abs(diffs[idx[i]]) {
+			j++
+		}
+		avg := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			ranks[idx[k]] = avg
+		}
+		i = j
+	}
+	return ranks
+}
+
+func tieCounts(diffs []float64) []float64 {
+	counts := map[float64]float64{}
+	for _, d := range diffs {
+		counts[math.Abs(d)]++
+	}
+	var ties []float64
+	for _, c := range counts {
+		if c > 1 {
+			ties = append(ties, c)
+		}
+	}
+	return ties
+}
+
+// exactP computes the two-sided p-value for statistic w by enumerating the
+// distribution of signed rank sums.  Ranks are doubled so average ranks
+// from ties are integral.
+func exactP(ranks []float64, w float64) float64 {
+	total := 0
+	for _, r := range ranks {
+		total += int(2 * r)
+	}
+	// dist[s] is the number of sign assignments whose doubled positive rank
+	// sum is s
+	dist := make([]float64, total+1)
+	dist[0] = 1
+	for _, r := range ranks {
+		r2 := int(2 * r)
+		for s := total; s >= r2; s-- {
+			dist[s] += dist[s-r2]
+		}
+	}
+
+	// the distribution is symmetric about total/2 so use the tail farthest
+	// from the center
+	w2 := int(2 * w)
+	if 2*w2 > total {
+		w2 = total - w2
+	}
+	tail := 0.0
+	for s := 0; s <= w2; s++ {
+		tail += dist[s]
+	}
+	return math.Min(1, 2*tail/math.Pow(2, float64(len(ranks))))
+}
@@ -0,0 +1,97 @@
+package optim
+
+import (
+	"fmt"
+	"math"
+)
+
+// Residualer is implemented by objectives with least-squares structure -
+// i.e. objectives computed from a vector of residuals.  Methods that can
+// exploit residual structure (e.g. Gauss-Newton style solvers) can check
+// for this interface; all others just use Objective.
+type Residualer interface {
+	Objectiver
+	// Residuals returns the (weighted) residuals at v.
+	Residuals(v []float64) ([]float64, error)
+}
+
+// Loss maps a residual to its contribution to a least-squares style
+// objective.
+type Loss interface {
+	Loss(r float64) float64
+}
+
+// SquaredLoss is the ordinary least-squares loss r^2.
+type SquaredLoss struct{}
+
+func (SquaredLoss) Loss(r float64) float64 { return r * r }
+
+// HuberLoss is quadratic for residuals smaller in magnitude than Delta and
+// linear beyond, which reduces the influence of outliers.  It is scaled to
+// match SquaredLoss for small residuals.
+type HuberLoss struct{ Delta float64 }
+
+func (l HuberLoss) Loss(r float64) float64 {
+	if a := math.Abs(r); a > l.Delta {
+		return 2*l.Delta*a - l.Delta*l.Delta
+	}
+	return r * r
+}
+
+// CauchyLoss grows only logarithmically for residuals much larger than
+// Scale, strongly suppressing outliers.  It matches SquaredLoss for small
+// residuals.
+type CauchyLoss struct{ Scale float64 }
+
+func (l CauchyLoss) Loss(r float64) float64 {
+	c := l.Scale
+	return c * c * math.Log1p((r/c)*(r/c))
+}
+
+// ResidualObjectiver builds a scalar objective from a vector residual
+// function (e.g. model predictions minus observations for calibration).
+// The objective is the sum over residuals of Loss(Weights[i]*r[i]).  Its
+// Residuals method can be used directly as an analysis.ResidualFunc for
+// estimating parameter confidence intervals.
+type ResidualObjectiver struct {
+	Func func(v []float64) ([]float64, error)
+	// Weights, if non-nil, multiply the residuals - e.g. inverse
+	// measurement standard deviations.
+	Weights []float64
+	// Loss defaults to SquaredLoss.
+	Loss Loss
+}
+
+// Residuals returns the weighted residuals at v.
+func (o *ResidualObjectiver) Residuals(v []float64) ([]float64, error) {
+	r, err := o.Func(v)
+	if err != nil {
+		return nil, err
+	}
+	if o.Weights == nil {
+		return r, nil
+	} else if len(o.Weights) != len(r) {
+		return nil, fmt.Errorf("got %v residuals but %v weights", len(r), len(o.Weights))
+	}
+	weighted := make([]float64, len(r))
+	for i := range r {
+		weighted[i] = o.Weights[i] * r[i]
+	}
+	return weighted, nil
+}
+
+func (o *ResidualObjectiver) Objective(v []float64) (float64, error) {
+	r, err := o.Residuals(v)
+	if err != nil {
+		return math.Inf(1), err
+	}
+	loss := o.Loss
+	if loss == nil {
+		loss = SquaredLoss{}
+	}
+	tot := 0.0
+	for _, ri := range r {
+		tot += loss.Loss(ri)
+	}
+	return tot, nil
+}
@@ -0,0 +1,52 @@
+package optim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResidualObjectiver(t *testing.T) {
+	// observations of y = 2x with one gross outlier at x=3
+	xs := []float64{0, 1, 2, 3}
+	ys := []float64{0, 2, 4, 100}
+	fn := func(v []float64) ([]float64, error) {
+		r := make([]float64, len(xs))
+		for i := range xs {
+			r[i] = v[0]*xs[i] - ys[i]
+		}
+		return r, nil
+	}
+
+	var _ Residualer = &ResidualObjectiver{}
+
+	tests := []struct {
+		obj  *ResidualObjectiver
+		want float64
+	}{
+		{&ResidualObjectiver{Func: fn}, 94 * 94},
+		{&ResidualObjectiver{Func: fn, Weights: []float64{1, 1, 1, 0.5}}, 47 * 47},
+		{&ResidualObjectiver{Func: fn, Loss: HuberLoss{Delta: 1}}, 2*94 - 1},
+		{&ResidualObjectiver{Func: fn, Loss: CauchyLoss{Scale: 1}}, math.Log1p(94 * 94)},
+	}
+	for i, test := range tests {
+		got, err := test.obj.Objective([]float64{2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("case %v: want %v, got %v", i, test.want, got)
+		}
+	}
+
+	// small residuals match ordinary least squares for all losses
+	for _, l := range []Loss{HuberLoss{Delta: 1}, CauchyLoss{Scale: 100}} {
+		if got := l.Loss(1e-3); math.Abs(got-1e-6) > 1e-10 {
+			t.Errorf("%T: want ~1e-6 for small residual, got %v", l, got)
+		}
+	}
+
+	bad := &ResidualObjectiver{Func: fn, Weights: []float64{1}}
+	if _, err := bad.Objective([]float64{2}); err == nil {
+		t.Errorf("want error for mismatched weights")
+	}
+}
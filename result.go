@@ -0,0 +1,129 @@
+package optim
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Termination is the reason a solver stopped.  Scripts can branch on it
+// (see Solver.Termination or Result) e.g. to restart stalled runs or extend
+// runs that hit their evaluation budget.
+type Termination int
+
+const (
+	// Running means the solver has not stopped.
+	Running Termination = iota
+	// TermError means an iteration failed and StopOnErr was set.
+	TermError
+	// TermStalled means MaxNoImprove iterations passed without improvement.
+	TermStalled
+	// TermMaxIter means the MaxIter limit was reached.
+	TermMaxIter
+	// TermMaxEval means the MaxEval budget was used up.
+	TermMaxEval
+	// TermMeshMinimum means the mesh step fell to MinStep.
+	TermMeshMinimum
+	// TermTargetReached means the best value reached Target.
+	TermTargetReached
+	// TermFeasible means the Feasible function accepted the best point.
+	TermFeasible
+	// TermConverged means the method reported convergence (see Converger).
+	TermConverged
+	// TermUserCancelled means Stop was called (e.g. via StopOnSignal).
+	TermUserCancelled
+	// TermMaxTime means the MaxTime limit was reached.
+	TermMaxTime
+)
+
+func (t Termination) String() string {
+	switch t {
+	case Running:
+		return "running"
+	case TermError:
+		return "iteration error"
+	case TermStalled:
+		return "stalled"
+	case TermMaxIter:
+		return "iteration limit reached"
+	case TermMaxEval:
+		return "evaluation limit reached"
+	case TermMeshMinimum:
+		return "mesh minimum reached"
+	case TermTargetReached:
+		return "target value reached"
+	case TermFeasible:
+		return "feasible point found"
+	case TermConverged:
+		return "method converged"
+	case TermUserCancelled:
+		return "cancelled by user"
+	case TermMaxTime:
+		return "time limit reached"
+	}
+	return fmt.Sprintf("Termination(%d)", int(t))
+}
+
+// Termination returns the reason the solver stopped or Running if it
+// hasn't.
+func (s *Solver) Termination() Termination { return s.term }
+
+// Result summarizes a solver run.
+type Result struct {
+	// Best is the best point found (in the solver's space) or nil if the
+	// solver hasn't run.
+	Best        *Point
+	Neval       int
+	Niter       int
+	Elapsed     time.Duration
+	Termination Termination
+	Err         error
+}
+
+// Result returns a summary of the solver's run so far.  For an unfinished
+// run, Elapsed is the time since the run started.
+func (s *Solver) Result() *Result {
+	r := &Result{Neval: s.neval, Niter: s.niter, Elapsed: s.elapsed, Termination: s.term, Err: s.err}
+	if s.best != nil && s.best.Pos != nil {
+		r.Best = s.best.Clone()
+	}
+	if s.term == Running && s.niter > 0 {
+		r.Elapsed = time.Since(s.start)
+	}
+	return r
+}
+
+func (r *Result) String() string {
+	best := "none"
+	if r.Best != nil {
+		best = r.Best.String()
+	}
+	s := fmt.Sprintf("best %v after %v evals, %v iters in %v (%v)", best, r.Neval, r.Niter, r.Elapsed, r.Termination)
+	if r.Err != nil {
+		s += ": " + r.Err.Error()
+	}
+	return s
+}
+
+// WriteTable writes r to w as an aligned table with one row per summary
+// item followed by one row per dimension of the best point.
+func (r *Result) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "termination\t%v\n", r.Termination)
+	if r.Err != nil {
+		fmt.Fprintf(tw, "error\t%v\n", r.Err)
+	}
+	fmt.Fprintf(tw, "evaluations\t%v\n", r.Neval)
+	fmt.Fprintf(tw, "iterations\t%v\n", r.Niter)
+	fmt.Fprintf(tw, "elapsed\t%v\n", r.Elapsed)
+	if r.Best == nil {
+		fmt.Fprintf(tw, "best\tnone\n")
+		return tw.Flush()
+	}
+	fmt.Fprintf(tw, "best value\t%v\n", r.Best.Val)
+	for i, x := range r.Best.Pos {
+		fmt.Fprintf(tw, "x[%v]\t%v\n", i, x)
+	}
+	return tw.Flush()
+}
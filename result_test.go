@@ -0,0 +1,60 @@
+package optim
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSolverResult(t *testing.T) {
+	s := &Solver{
+		Method:  &seqMethod{Vals: []float64{5, 4, 3, 2, 1}},
+		Obj:     Func(func(v []float64) float64 { return v[0] }),
+		MaxIter: 4,
+	}
+	if r := s.Result(); r.Best != nil || r.Termination != Running {
+		t.Errorf("unrun solver has result %v", r)
+	}
+	s.Next()
+	if r := s.Result(); r.Termination != Running || r.Niter != 1 || r.Best.Val != 5 {
+		t.Errorf("running solver has result %v", r)
+	}
+	s.Run()
+
+	r := s.Result()
+	if r.Termination != TermMaxIter || r.Niter != 4 || r.Neval != 4 || r.Best.Val != 2 {
+		t.Errorf("wrong result %v", r)
+	}
+	if str := r.String(); !strings.Contains(str, "f[2] = 2") || !strings.Contains(str, "iteration limit reached") {
+		t.Errorf("bad summary %q", str)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 || lines[0] != "termination  iteration limit reached" || lines[5] != "x[0]         2" {
+		t.Errorf("bad table:\n%v", buf.String())
+	}
+}
+
+func TestSolverStatus(t *testing.T) {
+	s := &Solver{
+		Method:  &seqMethod{Vals: []float64{5, 4, 3}},
+		Obj:     Func(func(v []float64) float64 { return v[0] }),
+		Mesh:    &InfMesh{StepSize: 0.5},
+		MaxIter: 2,
+	}
+	s.Run()
+
+	st := s.Status()
+	if st.Step != 0.5 || st.Termination != TermMaxIter || st.Niter != 2 || st.Best.Val != 4 {
+		t.Errorf("wrong status %+v", st)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if st2 := s.Status(); st2.Elapsed != st.Elapsed || st2.Elapsed != s.Result().Elapsed {
+		t.Errorf("elapsed time kept growing after termination: %v then %v", st.Elapsed, st2.Elapsed)
+	}
+}
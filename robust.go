@@ -0,0 +1,81 @@
+package optim
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// RobustObjectiver wraps an objective for optimization under implementation
+// uncertainty.  Each candidate is evaluated at its nominal position and at a
+// set of perturbed positions and the worst (or conditional value at risk)
+// objective value is returned.
+type RobustObjectiver struct {
+	Obj Objectiver
+	// Perturb holds the offsets added to a candidate's position to form the
+	// perturbed positions.  If nil, NSample offsets are drawn uniformly from
+	// the box with per dimension half-widths Radius the first time Objective
+	// is called.
+	Perturb [][]float64
+	Radius  []float64
+	NSample int
+	// CVaR, if between zero and one, causes the mean of the worst CVaR
+	// fraction of values to be returned instead of the single worst value.
+	CVaR float64
+	// Ev is used to evaluate the perturbed positions.  If nil,
+	// SerialEvaler is used.
+	Ev   Evaler
+	once sync.Once
+}
+
+func (o *RobustObjectiver) init() {
+	if o.Perturb != nil {
+		return
+	}
+	o.Perturb = make([][]float64, o.NSample)
+	for i := range o.Perturb {
+		o.Perturb[i] = make([]float64, len(o.Radius))
+		for j, r := range o.Radius {
+			o.Perturb[i][j] = r * (2*RandFloat() - 1)
+		}
+	}
+}
+
+func (o *RobustObjectiver) Objective(v []float64) (float64, error) {
+	o.once.Do(o.init)
+
+	pts := make([]*Point, 0, len(o.Perturb)+1)
+	pts = append(pts, &Point{Pos: append([]float64{}, v...), Val: math.Inf(1)})
+	for _, d := range o.Perturb {
+		pos := make([]float64, len(v))
+		for i := range pos {
+			pos[i] = v[i] + d[i]
+		}
+		pts = append(pts, &Point{Pos: pos, Val: math.Inf(1)})
+	}
+
+	ev := o.Ev
+	if ev == nil {
+		ev = SerialEvaler{}
+	}
+	results, _, err := DedupEvaler{Evaler: ev}.Eval(o.Obj, pts...)
+	if err != nil || len(results) < len(pts) {
+		return math.Inf(1), err
+	}
+
+	vals := make([]float64, len(pts))
+	for i, p := range pts {
+		vals[i] = p.Val
+	}
+	sort.Float64s(vals)
+
+	if o.CVaR <= 0 || o.CVaR >= 1 {
+		return vals[len(vals)-1], nil
+	}
+	nworst := int(math.Ceil(o.CVaR * float64(len(vals))))
+	tot := 0.0
+	for _, val := range vals[len(vals)-nworst:] {
+		tot += val
+	}
+	return tot / float64(nworst), nil
+}
@@ -0,0 +1,46 @@
+package optim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRobustObjectiver(t *testing.T) {
+	sq := Func(func(v []float64) float64 { return v[0] * v[0] })
+	o := &RobustObjectiver{Obj: sq, Perturb: [][]float64{{-1}, {1}}}
+
+	if val, _ := o.Objective([]float64{0}); val != 1 {
+		t.Errorf("worst case at 0: want 1, got %v", val)
+	}
+	if val, _ := o.Objective([]float64{2}); val != 9 {
+		t.Errorf("worst case at 2: want 9, got %v", val)
+	}
+
+	o = &RobustObjectiver{Obj: sq, Perturb: [][]float64{{-1}, {1}, {0.5}}, CVaR: 0.5}
+	if val, _ := o.Objective([]float64{2}); val != 6.25+(9-6.25)/2 {
+		// values are [1 4 6.25 9] - the worst half is [6.25 9]
+		t.Errorf("CVaR at 2: want %v, got %v", 6.25+(9-6.25)/2, val)
+	}
+}
+
+func TestRobustObjectiverSampled(t *testing.T) {
+	sq := Func(func(v []float64) float64 { return v[0]*v[0] + v[1]*v[1] })
+	o := &RobustObjectiver{Obj: sq, Radius: []float64{1, 0.5}, NSample: 50}
+
+	val, err := o.Objective([]float64{0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val <= 0 || val > 1.25 {
+		t.Errorf("worst case outside uncertainty box: got %v", val)
+	}
+	if len(o.Perturb) != 50 {
+		t.Errorf("wrong number of sampled perturbations: want 50, got %v", len(o.Perturb))
+	}
+
+	obj := &ObjTest{max: 3}
+	o = &RobustObjectiver{Obj: obj, Perturb: [][]float64{{1, 0}, {2, 0}, {3, 0}}}
+	if val, err := o.Objective([]float64{0, 0}); err == nil || !math.IsInf(val, 1) {
+		t.Errorf("failed perturbed evaluation: want +Inf and error, got %v, %v", val, err)
+	}
+}
@@ -0,0 +1,260 @@
+// Package sce provides the shuffled complex evolution method (SCE-UA) as
+// described in:
+//
+//	Duan, Qingyun, Soroosh Sorooshian, and Vijai K. Gupta. "Optimal use of
+//	the SCE-UA global optimization method for calibrating watershed
+//	models." Journal of Hydrology 158.3 (1994): 265-284.
+//
+// The population is partitioned into complexes which are each evolved
+// independently by competitive complex evolution (CCE) - a series of
+// simplex reflection/contraction steps on randomly chosen sub-complexes.
+// After each evolution round, the complexes are shuffled back together and
+// repartitioned so information is shared between them.
+package sce
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+)
+
+type Option func(*Method)
+
+// Complexes sets the number of complexes the population is partitioned
+// into.
+func Complexes(p int) Option { return func(m *Method) { m.NComplex = p } }
+
+// Steps sets the number of CCE evolution steps each complex takes between
+// shuffles.
+func Steps(n int) Option { return func(m *Method) { m.NSteps = n } }
+
+func Evaler(e optim.Evaler) Option { return func(m *Method) { m.Evaler = e } }
+
+type Method struct {
+	// Pop holds the population sorted from best to worst.
+	Pop []*optim.Point
+	// NComplex is the number of complexes (p).
+	NComplex int
+	// NSteps is the number of evolution steps per complex between shuffles
+	// (beta).
+	NSteps int
+	// Low and Up bound the region used to generate random replacement
+	// points when both reflection and contraction fail.
+	Low, Up []float64
+	optim.Evaler
+	init bool
+}
+
+// New creates an SCE-UA method with the recommended settings from Duan et
+// al: each of the nComplex complexes has 2*ndim+1 points, sub-complexes
+// have ndim+1 points, and each complex takes 2*ndim+1 evolution steps
+// between shuffles.  The initial population is randomly distributed within
+// low and up.
+func New(nComplex int, low, up []float64, opts ...Option) *Method {
+	ndim := len(low)
+	m := &Method{
+		Pop:      optim.RandPop(nComplex*(2*ndim+1), low, up),
+		NComplex: nComplex,
+		NSteps:   2*ndim + 1,
+		Low:      low,
+		Up:       up,
+		Evaler:   optim.SerialEvaler{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// AddPoint replaces the worst population member with p if p is better.
+func (m *Method) AddPoint(p *optim.Point) {
+	if last := len(m.Pop) - 1; p.Val < m.Pop[last].Val {
+		m.Pop[last] = p
+		sort.Sort(byval(m.Pop))
+	}
+}
+
+// Inject replaces the worst population members with points that are no
+// worse.
+func (m *Method) Inject(points ...*optim.Point) {
+	optim.ReplaceWorst(m.Pop, points...)
+	sort.Sort(byval(m.Pop))
+}
+
+// Iterate evolves each complex by NSteps CCE steps and then shuffles the
+// complexes together.  The complexes are evolved in lock-step so that each
+// CCE step evaluates one trial point per complex as a single batch with the
+// Evaler.
+func (m *Method) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Point, n int, err error) {
+	if !m.init {
+		m.init = true
+		m.Pop, n, err = optim.DedupEvaler{Evaler: m.Evaler}.Eval(obj, m.Pop...)
+		sort.Sort(byval(m.Pop))
+		if err != nil {
+			return m.Pop[0], n, err
+		}
+	}
+
+	// partition: point k goes to complex k%p so every complex gets a
+	// similar spread of good and bad points
+	complexes := make([][]*optim.Point, m.NComplex)
+	for k, p := range m.Pop {
+		complexes[k%m.NComplex] = append(complexes[k%m.NComplex], p)
+	}
+
+	for step := 0; step < m.NSteps; step++ {
+		nn, err := m.cce(obj, mesh, complexes)
+		n += nn
+		if err != nil {
+			return m.shuffle(complexes), n, err
+		}
+	}
+	return m.shuffle(complexes), n, nil
+}
+
+// shuffle merges the complexes back into the sorted population and returns
+// the best point.
+func (m *Method) shuffle(complexes [][]*optim.Point) *optim.Point {
+	m.Pop = m.Pop[:0]
+	for _, c := range complexes {
+		m.Pop = append(m.Pop, c...)
+	}
+	sort.Sort(byval(m.Pop))
+	return m.Pop[0]
+}
+
+// cce performs a single competitive complex evolution step on each
+// complex: a sub-complex is selected with a trapezoidal probability
+// favoring better points, and its worst point is replaced by a reflection
+// through the centroid of the others, a contraction, or a random point -
+// whichever is first to improve on it.
+func (m *Method) cce(obj optim.Objectiver, mesh optim.Mesh, complexes [][]*optim.Point) (n int, err error) {
+	subs := make([][]int, len(complexes))
+	centroids := make([][]float64, len(complexes))
+	trials := make([]*optim.Point, len(complexes))
+	for i, c := range complexes {
+		sort.Sort(byval(c))
+		subs[i] = subcomplex(len(c), c[0].Len()+1)
+		simplex := make([]*optim.Point, len(subs[i])-1)
+		for j, k := range subs[i][:len(subs[i])-1] {
+			simplex[j] = c[k]
+		}
+		centroids[i] = optim.PopMean(simplex)
+		worst := c[subs[i][len(subs[i])-1]]
+		trials[i] = m.trial(mesh, centroids[i], worst.Pos, 2)
+	}
+
+	// reflection, then contraction, then random point
+	pending := make([]int, len(complexes))
+	for i := range pending {
+		pending[i] = i
+	}
+	for attempt := 0; attempt < 3 && len(pending) > 0; attempt++ {
+		batch := make([]*optim.Point, len(pending))
+		for j, i := range pending {
+			batch[j] = trials[i]
+		}
+		results, nn, err := m.Evaler.Eval(obj, batch...)
+		n += nn
+		if err != nil {
+			return n, err
+		}
+
+		got := map[*optim.Point]bool{}
+		for _, p := range results {
+			got[p] = true
+		}
+
+		var next []int
+		for _, i := range pending {
+			c := complexes[i]
+			wi := subs[i][len(subs[i])-1]
+			if got[trials[i]] && (trials[i].Val < c[wi].Val || attempt == 2) {
+				c[wi] = trials[i]
+				continue
+			}
+			next = append(next, i)
+			if attempt == 0 {
+				trials[i] = m.trial(mesh, centroids[i], c[wi].Pos, 0.5)
+			} else {
+				trials[i] = m.random(mesh, c)
+			}
+		}
+		pending = next
+	}
+	return n, nil
+}
+
+// trial returns the point centroid + alpha*(centroid - worst).  An alpha of
+// 2 gives a reflection, 0.5 gives a contraction toward the worst point.
+func (m *Method) trial(mesh optim.Mesh, centroid, worst []float64, alpha float64) *optim.Point {
+	pos := make([]float64, len(centroid))
+	for i := range pos {
+		pos[i] = centroid[i] + (alpha-1)*(centroid[i]-worst[i])
+	}
+	if alpha > 1 && !(optim.Bounds{Low: m.Low, Up: m.Up}).Contains(pos) {
+		// reflections outside the feasible region are replaced by random
+		// points as in Duan et al
+		return m.random(mesh, nil)
+	}
+	if mesh != nil {
+		pos = mesh.Nearest(pos)
+	}
+	return &optim.Point{Pos: pos, Val: math.Inf(1)}
+}
+
+// random returns a uniformly random point in the smallest box containing
+// complex c or the full bounds if c is nil.
+func (m *Method) random(mesh optim.Mesh, c []*optim.Point) *optim.Point {
+	low, up := m.Low, m.Up
+	if c != nil {
+		low = append([]float64{}, c[0].Pos...)
+		up = append([]float64{}, c[0].Pos...)
+		for _, p := range c {
+			for i, x := range p.Pos {
+				low[i] = math.Min(low[i], x)
+				up[i] = math.Max(up[i], x)
+			}
+		}
+	}
+	p := optim.RandPop(1, low, up)[0]
+	if mesh != nil {
+		p.Pos = mesh.Nearest(p.Pos)
+	}
+	return p
+}
+
+// subcomplex selects q distinct indices from a sorted complex of size n
+// using the trapezoidal probability distribution p(k) = 2(n+1-k)/(n(n+1))
+// of Duan et al.  The returned indices are sorted so the last is the worst
+// point.
+func subcomplex(n, q int) []int {
+	if q > n {
+		q = n
+	}
+	chosen := map[int]bool{}
+	for len(chosen) < q {
+		u := optim.RandFloat()
+		k := 0
+		for cum := 0.0; k < n-1; k++ {
+			cum += 2 * float64(n-k) / float64(n*(n+1))
+			if u < cum {
+				break
+			}
+		}
+		chosen[k] = true
+	}
+	idx := make([]int, 0, q)
+	for k := range chosen {
+		idx = append(idx, k)
+	}
+	sort.Ints(idx)
+	return idx
+}
+
+type byval []*optim.Point
+
+func (b byval) Less(i, j int) bool { return b[i].Val < b[j].Val }
+func (b byval) Len() int           { return len(b) }
+func (b byval) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,66 @@
+package sce
+
+import (
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+)
+
+func TestAckley(t *testing.T) {
+	fn := bench.Ackley{}
+	bench.Benchmark(t, fn, solver(fn), 1, 500)
+}
+
+func TestRosenbrock(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 5}
+	bench.Benchmark(t, fn, solver(fn), 1, 1000)
+}
+
+// solver returns a factory for SCE-UA solvers with 4 complexes minimizing
+// fn.
+func solver(fn bench.Func) func() *optim.Solver {
+	return func() *optim.Solver {
+		low, up := fn.Bounds()
+		return &optim.Solver{
+			Method:  New(4, low, up),
+			Obj:     optim.Func(fn.Eval),
+			Mesh:    &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up},
+			MaxEval: 20000,
+		}
+	}
+}
+
+func TestPopSize(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 5}
+	low, up := fn.Bounds()
+	m := New(4, low, up)
+	for i := 0; i < 10; i++ {
+		if _, _, err := m.Iterate(optim.Func(fn.Eval), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := 4 * (2*len(low) + 1); len(m.Pop) != want {
+		t.Errorf("population size changed: want %v, got %v", want, len(m.Pop))
+	}
+}
+
+func TestSubcomplex(t *testing.T) {
+	n, q := 11, 6
+	counts := make([]int, n)
+	for i := 0; i < 2000; i++ {
+		idx := subcomplex(n, q)
+		if len(idx) != q {
+			t.Fatalf("want %v indices, got %v", q, idx)
+		}
+		for j, k := range idx {
+			if j > 0 && k <= idx[j-1] {
+				t.Fatalf("indices not sorted and distinct: %v", idx)
+			}
+			counts[k]++
+		}
+	}
+	if counts[0] <= counts[n-1] {
+		t.Errorf("better points not favored: counts %v", counts)
+	}
+}
@@ -0,0 +1,228 @@
+// Package selection provides parent selection operators for population
+// methods.  Each operator picks points from a population in proportion to
+// their quality, with different amounts of selection pressure:
+//
+//   - Truncation picks only the best points.
+//   - Tournament picks the best of small random groups.
+//   - Rank picks with probabilities that fall linearly with rank.
+//   - Boltzmann picks with probabilities proportional to exp(-val/T).
+//   - SUS (stochastic universal sampling) picks in proportion to fitness
+//     with minimal spread.
+//
+// See e.g. eda.Select and es.Parents for use with population methods.
+// Points with infinite or NaN values are only picked when no better point
+// is available.
+package selection
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Selector picks n points from pop.  Points may be picked more than once
+// (except by Truncation) and pop is not modified.
+type Selector interface {
+	Select(pop []*optim.Point, n int) []*optim.Point
+}
+
+// Truncation picks the n best points (all of them if n > len(pop)).
+type Truncation struct{}
+
+func (Truncation) Select(pop []*optim.Point, n int) []*optim.Point {
+	return optim.Elite(pop, n, 0)
+}
+
+// Tournament picks each point as the best of Size points drawn uniformly at
+// random (with replacement).  Larger tournaments increase the selection
+// pressure.  A Size less than 2 is treated as 2.
+type Tournament struct {
+	Size int
+}
+
+func (t Tournament) Select(pop []*optim.Point, n int) []*optim.Point {
+	if len(pop) == 0 {
+		return nil
+	}
+	size := t.Size
+	if size < 2 {
+		size = 2
+	}
+	picks := make([]*optim.Point, n)
+	for i := range picks {
+		best := pop[optim.Rand.Intn(len(pop))]
+		for k := 1; k < size; k++ {
+			if p := pop[optim.Rand.Intn(len(pop))]; better(p, best) {
+				best = p
+			}
+		}
+		picks[i] = best
+	}
+	return picks
+}
+
+// Rank performs linear ranking selection: the best point is picked with
+// probability Pressure/len(pop), the worst with (2-Pressure)/len(pop), and
+// the rest in between by rank.  Pressure must be in [1, 2] - 1 picks
+// uniformly and the default (if zero) is 1.5.  Only finite points are
+// ranked; the rest are picked only if no point is finite.
+type Rank struct {
+	Pressure float64
+}
+
+func (r Rank) Select(pop []*optim.Point, n int) []*optim.Point {
+	s := r.Pressure
+	if s == 0 {
+		s = 1.5
+	}
+	sorted := sorted(pop)
+	nfinite := 0
+	for _, p := range sorted {
+		if finite(p.Val) {
+			nfinite++
+		}
+	}
+	np := float64(nfinite)
+	weights := make([]float64, len(sorted))
+	for i, p := range sorted {
+		// sorted[0] is the best
+		if finite(p.Val) {
+			weights[i] = (2 - s) + 2*(s-1)*(np-1-float64(i))/math.Max(np-1, 1)
+		}
+	}
+	return roulette(sorted, weights, n)
+}
+
+// Boltzmann picks points with probabilities proportional to
+// exp(-(val-best)/T) where best is the best value in the population.  Lower
+// temperatures T increase the selection pressure.  If T is not positive,
+// 1 is used.
+type Boltzmann struct {
+	T float64
+}
+
+func (b Boltzmann) Select(pop []*optim.Point, n int) []*optim.Point {
+	temp := b.T
+	if temp <= 0 {
+		temp = 1
+	}
+	best := math.Inf(1)
+	for _, p := range pop {
+		if finite(p.Val) {
+			best = math.Min(best, p.Val)
+		}
+	}
+	weights := make([]float64, len(pop))
+	for i, p := range pop {
+		if finite(p.Val) {
+			weights[i] = math.Exp(-(p.Val - best) / temp)
+		}
+	}
+	return roulette(pop, weights, n)
+}
+
+// SUS performs stochastic universal sampling: points are laid out on a
+// wheel with sizes proportional to their fitness - the amount by which
+// their value beats the worst value in the population - and picked by n
+// equally spaced pointers from a single random offset.  The number of times
+// each point is picked differs from its expected count by less than one.
+type SUS struct{}
+
+func (SUS) Select(pop []*optim.Point, n int) []*optim.Point {
+	worst, best := math.Inf(-1), math.Inf(1)
+	for _, p := range pop {
+		if finite(p.Val) {
+			worst = math.Max(worst, p.Val)
+			best = math.Min(best, p.Val)
+		}
+	}
+	// the worst finite point gets a small share so it isn't excluded
+	eps := math.Max((worst-best)*1e-3, 1e-300)
+	weights := make([]float64, len(pop))
+	for i, p := range pop {
+		if finite(p.Val) {
+			weights[i] = worst - p.Val + eps
+		}
+	}
+	return universal(pop, weights, n)
+}
+
+// roulette picks n points independently with probabilities proportional to
+// weights.  If all weights are zero, it picks uniformly.
+func roulette(pop []*optim.Point, weights []float64, n int) []*optim.Point {
+	if len(pop) == 0 {
+		return nil
+	}
+	cum, total := cumulative(weights)
+	picks := make([]*optim.Point, n)
+	for i := range picks {
+		if total == 0 {
+			picks[i] = pop[optim.Rand.Intn(len(pop))]
+			continue
+		}
+		r := optim.RandFloat() * total
+		picks[i] = pop[search(cum, r)]
+	}
+	return picks
+}
+
+// universal picks n points with equally spaced pointers on the wheel of
+// weights.  If all weights are zero, it picks uniformly.
+func universal(pop []*optim.Point, weights []float64, n int) []*optim.Point {
+	if len(pop) == 0 || n == 0 {
+		return nil
+	}
+	cum, total := cumulative(weights)
+	if total == 0 {
+		return roulette(pop, weights, n)
+	}
+	spacing := total / float64(n)
+	start := optim.RandFloat() * spacing
+	picks := make([]*optim.Point, n)
+	for i := range picks {
+		picks[i] = pop[search(cum, start+float64(i)*spacing)]
+	}
+	return picks
+}
+
+func cumulative(weights []float64) (cum []float64, total float64) {
+	cum = make([]float64, len(weights))
+	for i, w := range weights {
+		total += w
+		cum[i] = total
+	}
+	return cum, total
+}
+
+// search returns the index of the wheel slot [cum[i-1], cum[i]) containing
+// r.  r values at or past the end of the wheel pick the last non-empty
+// slot.
+func search(cum []float64, r float64) int {
+	i := sort.Search(len(cum), func(i int) bool { return cum[i] > r })
+	if i == len(cum) {
+		i--
+		for i > 0 && cum[i] == cum[i-1] {
+			i--
+		}
+	}
+	return i
+}
+
+// sorted returns pop ordered from best to worst with non-finite values
+// last.
+func sorted(pop []*optim.Point) []*optim.Point {
+	s := append([]*optim.Point{}, pop...)
+	sort.SliceStable(s, func(i, j int) bool { return better(s[i], s[j]) })
+	return s
+}
+
+// better reports whether p is better than q, treating NaN as worst.
+func better(p, q *optim.Point) bool {
+	if math.IsNaN(q.Val) {
+		return !math.IsNaN(p.Val)
+	}
+	return p.Val < q.Val
+}
+
+func finite(v float64) bool { return !math.IsInf(v, 0) && !math.IsNaN(v) }
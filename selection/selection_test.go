@@ -0,0 +1,98 @@
+package selection
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+func population() []*optim.Point {
+	pop := make([]*optim.Point, 10)
+	for i := range pop {
+		pop[i] = &optim.Point{Pos: []float64{float64(i)}, Val: float64(i)}
+	}
+	pop[9].Val = math.Inf(1)
+	return pop
+}
+
+// meanVal returns the mean finite value of picks and fails if any pick
+// isn't from pop.
+func meanVal(t *testing.T, s Selector, pop, picks []*optim.Point) float64 {
+	member := map[*optim.Point]bool{}
+	for _, p := range pop {
+		member[p] = true
+	}
+	sum := 0.0
+	for _, p := range picks {
+		if !member[p] {
+			t.Fatalf("%T: picked point %v not in population", s, p)
+		}
+		sum += math.Min(p.Val, 9)
+	}
+	return sum / float64(len(picks))
+}
+
+func TestSelectors(t *testing.T) {
+	optim.Rand = rand.New(rand.NewSource(1))
+	pop := population()
+	uniform := 4.5
+	sels := []Selector{Truncation{}, Tournament{Size: 3}, Rank{}, Boltzmann{T: 2}, SUS{}}
+	for _, s := range sels {
+		picks := s.Select(pop, 1000)
+		if _, trunc := s.(Truncation); trunc {
+			if len(picks) != len(pop) || picks[0].Val != 0 {
+				t.Errorf("truncation: want all %v points best first, got %v", len(pop), len(picks))
+			}
+			continue
+		} else if len(picks) != 1000 {
+			t.Fatalf("%T: got %v picks, want 1000", s, len(picks))
+		}
+		if mean := meanVal(t, s, pop, picks); mean >= uniform-.5 {
+			t.Errorf("%T: no selection pressure - mean picked value %v", s, mean)
+		}
+	}
+
+	if picks := (Truncation{}).Select(pop, 3); len(picks) != 3 || picks[2].Val != 2 {
+		t.Errorf("truncation: want the 3 best, got %v", picks)
+	}
+	// the infinite point isn't ranked, so uniform picks average 4
+	if picks := (Rank{Pressure: 1}).Select(pop, 1000); math.Abs(meanVal(t, Rank{}, pop, picks)-4) > .5 {
+		t.Errorf("rank with pressure 1 should pick the finite points uniformly")
+	}
+}
+
+func TestNonFinite(t *testing.T) {
+	optim.Rand = rand.New(rand.NewSource(1))
+	pop := population()
+	pop[8].Val = math.NaN()
+	for _, s := range []Selector{Rank{}, Boltzmann{}} {
+		for _, p := range s.Select(pop, 1000) {
+			if p == nil || !finite(p.Val) {
+				t.Fatalf("%T: picked %v with finite points available", s, p)
+			}
+		}
+	}
+
+	bad := []*optim.Point{{Val: math.NaN()}, {Val: math.Inf(1)}}
+	for _, s := range []Selector{Rank{}, Boltzmann{}} {
+		if picks := s.Select(bad, 10); len(picks) != 10 || picks[0] == nil {
+			t.Errorf("%T: want 10 picks from a non-finite population, got %v", s, picks)
+		}
+	}
+}
+
+func TestSUSSpread(t *testing.T) {
+	optim.Rand = rand.New(rand.NewSource(1))
+	pop := []*optim.Point{{Val: 0}, {Val: 2}, {Val: 3}}
+	// fitness (worst - val) is about 3, 1 and 0 so expected counts of 12
+	// picks are just under 9, just over 3 and about 0.
+	counts := map[*optim.Point]int{}
+	for _, p := range (SUS{}).Select(pop, 12) {
+		counts[p]++
+	}
+	if c0, c1 := counts[pop[0]], counts[pop[1]]; c0 < 8 || c0 > 9 || c1 < 3 || c1 > 4 {
+		t.Errorf("want counts within one of 9, 3, 0 - got %v, %v, %v", counts[pop[0]], counts[pop[1]], counts[pop[2]])
+	}
+}
@@ -0,0 +1,231 @@
+// Package sensitivity provides global sensitivity analysis tools for
+// screening out unimportant variables before optimizing.  It implements
+// Morris elementary effects screening and variance-based Sobol indices
+// estimated with the Saltelli/Jansen sampling scheme:
+//
+//	Saltelli, Andrea, et al. "Variance based sensitivity analysis of model
+//	output. Design and estimator for the total sensitivity index." Computer
+//	Physics Communications 181.2 (2010): 259-270.
+package sensitivity
+
+import (
+	"errors"
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Problem describes a bounded objective to be analyzed.  All samples are
+// drawn from the box defined by Low and Up and are projected onto Mesh (if
+// non-nil) before evaluation.
+type Problem struct {
+	Obj     optim.Objectiver
+	Low, Up []float64
+	// Ev is used to perform all objective evaluations.  If nil,
+	// optim.SerialEvaler is used.
+	Ev   optim.Evaler
+	Mesh optim.Mesh
+}
+
+// Effects holds Morris elementary effect statistics for each dimension.
+// Effects are measured in units of objective change per full variable range.
+type Effects struct {
+	// Mu is the mean elementary effect.
+	Mu []float64
+	// MuStar is the mean absolute elementary effect - it is the primary
+	// measure used to rank variable importance.
+	MuStar []float64
+	// Sigma is the standard deviation of elementary effects. Large values
+	// indicate nonlinearity or interactions with other variables.
+	Sigma []float64
+}
+
+// Indices holds Sobol sensitivity indices for each dimension.
+type Indices struct {
+	// First is the first-order (main effect) index for each dimension.
+	First []float64
+	// Total is the total effect index for each dimension including all
+	// interactions with other variables.
+	Total []float64
+}
+
+// Morris performs elementary effects screening using r random
+// one-at-a-time trajectories on a grid with the given number of levels per
+// dimension.  levels must be even and at least 2 so the standard jump of
+// levels/(2*(levels-1)) moves between grid levels and every level is
+// sampled with equal probability.  It returns the effect statistics and the
+// number of objective evaluations performed.
+func (p *Problem) Morris(r, levels int) (eff *Effects, n int, err error) {
+	if levels < 2 || levels%2 != 0 {
+		return nil, 0, errors.New("sensitivity: Morris needs an even number of levels of at least 2")
+	}
+	ndim := len(p.Low)
+	delta := float64(levels) / (2 * float64(levels-1))
+	nstart := levels / 2 // number of grid levels x can start at with x+delta <= 1
+
+	trajs := make([][]*optim.Point, r)
+	orders := make([][]int, r)
+	all := make([]*optim.Point, 0, r*(ndim+1))
+	for t := 0; t < r; t++ {
+		// each dimension starts at either a random low grid level or delta
+		// above it and then steps to the other one.
+		x := make([]float64, ndim)
+		steps := make([]float64, ndim)
+		for i := range x {
+			x[i] = float64(optim.Rand.Intn(nstart)) / float64(levels-1)
+			steps[i] = delta
+			if optim.Rand.Intn(2) == 0 {
+				x[i] += delta
+				steps[i] = -delta
+			}
+		}
+		orders[t] = optim.Rand.Perm(ndim)
+
+		traj := []*optim.Point{p.point(x)}
+		for _, i := range orders[t] {
+			x[i] += steps[i]
+			traj = append(traj, p.point(x))
+		}
+		trajs[t] = traj
+		all = append(all, traj...)
+	}
+
+	n, err = p.eval(all)
+	if err != nil {
+		return nil, n, err
+	}
+
+	eff = &Effects{
+		Mu:     make([]float64, ndim),
+		MuStar: make([]float64, ndim),
+		Sigma:  make([]float64, ndim),
+	}
+	effects := make([][]float64, ndim)
+	for t, traj := range trajs {
+		for k, i := range orders[t] {
+			prev, next := traj[k], traj[k+1]
+			// use the realized step because mesh projection may alter it
+			step := (next.Pos[i] - prev.Pos[i]) / (p.Up[i] - p.Low[i])
+			if step == 0 {
+				continue
+			}
+			effects[i] = append(effects[i], (next.Val-prev.Val)/step)
+		}
+	}
+
+	for i, ee := range effects {
+		if len(ee) == 0 {
+			continue
+		}
+		for _, e := range ee {
+			eff.Mu[i] += e / float64(len(ee))
+			eff.MuStar[i] += math.Abs(e) / float64(len(ee))
+		}
+		if len(ee) < 2 {
+			continue
+		}
+		for _, e := range ee {
+			diff := e - eff.Mu[i]
+			eff.Sigma[i] += diff * diff / float64(len(ee)-1)
+		}
+		eff.Sigma[i] = math.Sqrt(eff.Sigma[i])
+	}
+	return eff, n, nil
+}
+
+// Sobol estimates first-order and total Sobol indices using nsample base
+// samples.  This requires nsample*(ndim+2) objective evaluations.  It
+// returns the indices and the number of objective evaluations performed.
+func (p *Problem) Sobol(nsample int) (ind *Indices, n int, err error) {
+	ndim := len(p.Low)
+	a := make([][]float64, nsample)
+	b := make([][]float64, nsample)
+	for j := range a {
+		a[j] = make([]float64, ndim)
+		b[j] = make([]float64, ndim)
+		for i := 0; i < ndim; i++ {
+			a[j][i] = optim.RandFloat()
+			b[j][i] = optim.RandFloat()
+		}
+	}
+
+	pa := make([]*optim.Point, nsample)
+	pb := make([]*optim.Point, nsample)
+	pab := make([][]*optim.Point, ndim)
+	all := make([]*optim.Point, 0, nsample*(ndim+2))
+	for j := range a {
+		pa[j] = p.point(a[j])
+		pb[j] = p.point(b[j])
+		all = append(all, pa[j], pb[j])
+	}
+	for i := range pab {
+		pab[i] = make([]*optim.Point, nsample)
+		for j := range a {
+			x := append([]float64{}, a[j]...)
+			x[i] = b[j][i]
+			pab[i][j] = p.point(x)
+		}
+		all = append(all, pab[i]...)
+	}
+
+	n, err = p.eval(all)
+	if err != nil {
+		return nil, n, err
+	}
+
+	mean := 0.0
+	for j := range a {
+		mean += (pa[j].Val + pb[j].Val) / float64(2*nsample)
+	}
+	variance := 0.0
+	for j := range a {
+		da, db := pa[j].Val-mean, pb[j].Val-mean
+		variance += (da*da + db*db) / float64(2*nsample-1)
+	}
+	if variance == 0 {
+		return nil, n, errors.New("sensitivity: objective has zero variance over the sampled region")
+	}
+
+	ind = &Indices{
+		First: make([]float64, ndim),
+		Total: make([]float64, ndim),
+	}
+	for i := range pab {
+		for j := range a {
+			fa, fb, fab := pa[j].Val, pb[j].Val, pab[i][j].Val
+			ind.First[i] += fb * (fab - fa) / float64(nsample)
+			ind.Total[i] += (fa - fab) * (fa - fab) / float64(2*nsample)
+		}
+		ind.First[i] /= variance
+		ind.Total[i] /= variance
+	}
+	return ind, n, nil
+}
+
+// point converts unit-cube coordinates x into a (mesh-projected) point in
+// the problem's bounds.
+func (p *Problem) point(x []float64) *optim.Point {
+	pos := make([]float64, len(x))
+	for i := range x {
+		pos[i] = p.Low[i] + x[i]*(p.Up[i]-p.Low[i])
+	}
+	if p.Mesh != nil {
+		pos = p.Mesh.Nearest(pos)
+	}
+	return &optim.Point{Pos: pos, Val: math.Inf(1)}
+}
+
+// eval evaluates all points including any duplicates.
+func (p *Problem) eval(pts []*optim.Point) (n int, err error) {
+	ev := p.Ev
+	if ev == nil {
+		ev = optim.SerialEvaler{}
+	}
+	results, n, err := optim.DedupEvaler{Evaler: ev}.Eval(p.Obj, pts...)
+	if err != nil {
+		return n, err
+	} else if len(results) < len(pts) {
+		return n, errors.New("sensitivity: evaler failed to evaluate all points")
+	}
+	return n, nil
+}
@@ -0,0 +1,75 @@
+package sensitivity
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// linear is an objective with a strong, a weak, and an inert variable.
+var linear = optim.Func(func(v []float64) float64 { return 10*v[0] + v[1] })
+
+func TestMorris(t *testing.T) {
+	p := &Problem{
+		Obj: linear,
+		Low: []float64{0, 0, 0},
+		Up:  []float64{1, 1, 1},
+	}
+
+	r := 20
+	eff, n, err := p.Morris(r, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if max := r * (len(p.Low) + 1); n > max {
+		t.Errorf("too many evaluations: want <= %v, got %v", max, n)
+	}
+
+	want := []float64{10, 1, 0}
+	for i := range want {
+		if math.Abs(eff.MuStar[i]-want[i]) > 1e-9 {
+			t.Errorf("MuStar[%v]: want %v, got %v", i, want[i], eff.MuStar[i])
+		}
+		if eff.Sigma[i] > 1e-9 {
+			t.Errorf("Sigma[%v]: want 0 for linear objective, got %v", i, eff.Sigma[i])
+		}
+	}
+}
+
+func TestMorrisLevels(t *testing.T) {
+	p := &Problem{Obj: linear, Low: []float64{0, 0, 0}, Up: []float64{1, 1, 1}}
+	for _, levels := range []int{0, 1, 3, 5} {
+		if _, n, err := p.Morris(4, levels); err == nil || n != 0 {
+			t.Errorf("%v levels: want error and no evaluations, got err=%v, n=%v", levels, err, n)
+		}
+	}
+}
+
+func TestSobol(t *testing.T) {
+	p := &Problem{
+		Obj: linear,
+		Low: []float64{-1, -1, -1},
+		Up:  []float64{1, 1, 1},
+	}
+
+	nsample := 4000
+	ind, n, err := p.Sobol(nsample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := nsample * (len(p.Low) + 2); n != want {
+		t.Errorf("wrong eval count: want %v, got %v", want, n)
+	}
+
+	want := []float64{100.0 / 101, 1.0 / 101, 0}
+	tol := 0.05
+	for i := range want {
+		if math.Abs(ind.First[i]-want[i]) > tol {
+			t.Errorf("First[%v]: want %v, got %v", i, want[i], ind.First[i])
+		}
+		if math.Abs(ind.Total[i]-want[i]) > tol {
+			t.Errorf("Total[%v]: want %v, got %v", i, want[i], ind.Total[i])
+		}
+	}
+}
@@ -0,0 +1,507 @@
+// Package service exposes optimization as a network service.  Clients
+// create jobs over a JSON/HTTP API and evaluate the objective themselves
+// using an ask/tell protocol: pending points are fetched with ask and their
+// externally computed objective values submitted with tell.  Each job runs
+// a pswarm solver whose objective blocks until the corresponding tell
+// arrives, so any number of workers may evaluate points concurrently.
+//
+// Endpoints (all bodies are JSON):
+//
+//	POST   /jobs                create a job from a JobSpec; returns its Status
+//	GET    /jobs                list the status of all jobs
+//	GET    /jobs/{id}           job Status
+//	DELETE /jobs/{id}           stop a job
+//	GET    /jobs/{id}/best      best point found so far
+//	GET    /jobs/{id}/stream    newline delimited Status after every iteration
+//	GET    /jobs/{id}/ask       pending Evals (query params n=max count, wait=duration)
+//	POST   /jobs/{id}/tell      submit a list of Results
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/pswarm"
+	"github.com/rwcarlsen/optim/transform"
+)
+
+// Job states reported in Status.
+const (
+	StateRunning = "running"
+	StateDone    = "done"
+	StateStopped = "stopped"
+	StateFailed  = "failed"
+)
+
+// ErrStopped is returned for evaluations still pending when a job is
+// stopped.
+var ErrStopped = errors.New("service: job stopped")
+
+// JobSpec describes a box bounded minimization problem to create a job
+// for.  Zero values select pswarm defaults.
+type JobSpec struct {
+	Low        []float64 `json:"low"`
+	Up         []float64 `json:"up"`
+	MaxEval    int       `json:"maxeval,omitempty"`
+	Population int       `json:"population,omitempty"`
+	Stall      int       `json:"stall,omitempty"`
+	// Lease, if non-zero, is the number of seconds a worker has to tell an
+	// asked point's result before the point is handed out to another
+	// worker.
+	Lease float64 `json:"lease,omitempty"`
+}
+
+// Eval is a point awaiting evaluation by a worker.
+type Eval struct {
+	ID  int64     `json:"id"`
+	Pos []float64 `json:"pos"`
+}
+
+// Result is an externally computed objective value for the Eval with the
+// same ID.  A non-empty Err marks the evaluation as failed and the point
+// is treated as infeasible (i.e. +Inf).
+type Result struct {
+	ID  int64   `json:"id"`
+	Val float64 `json:"val"`
+	Err string  `json:"err,omitempty"`
+}
+
+// Best is the best point found by a job.
+type Best struct {
+	Pos []float64 `json:"pos"`
+	Val float64   `json:"val"`
+}
+
+// Status reports the progress of a job.  BestVal is nil until a point
+// with a finite objective value has been found.
+type Status struct {
+	ID      string    `json:"id"`
+	State   string    `json:"state"`
+	Niter   int       `json:"niter"`
+	Neval   int       `json:"neval"`
+	Pending int       `json:"pending"`
+	BestPos []float64 `json:"bestpos,omitempty"`
+	BestVal *float64  `json:"bestval,omitempty"`
+	Err     string    `json:"err,omitempty"`
+}
+
+type pending struct {
+	eval   Eval
+	result chan float64
+	asked  time.Time
+}
+
+// Job is a running optimization whose objective is evaluated by remote
+// workers.
+type Job struct {
+	id     string
+	spec   JobSpec
+	solver *optim.Solver
+	space  transform.Space
+
+	mu       sync.Mutex
+	status   Status
+	nexteval int64
+	pending  map[int64]*pending
+	queue    []*pending
+	// queued is closed and replaced whenever points are queued
+	queued chan struct{}
+	// changed is closed and replaced whenever status is updated
+	changed chan struct{}
+	stop    chan struct{}
+	stopped bool
+}
+
+func newJob(id string, spec JobSpec) (*Job, error) {
+	if len(spec.Low) == 0 || len(spec.Low) != len(spec.Up) {
+		return nil, errors.New("service: low and up must be non-empty and of equal length")
+	}
+	for i := range spec.Low {
+		if !(spec.Low[i] < spec.Up[i]) {
+			return nil, fmt.Errorf("service: low[%v] must be less than up[%v]", i, i)
+		}
+	}
+
+	j := &Job{
+		id:      id,
+		spec:    spec,
+		pending: map[int64]*pending{},
+		queued:  make(chan struct{}),
+		changed: make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+	var opts []pswarm.Option
+	if spec.MaxEval > 0 {
+		opts = append(opts, pswarm.MaxEval(spec.MaxEval))
+	}
+	if spec.Population > 0 {
+		opts = append(opts, pswarm.Population(spec.Population))
+	}
+	if spec.Stall > 0 {
+		opts = append(opts, pswarm.Stall(spec.Stall))
+	}
+	j.solver, j.space = pswarm.NewSolver(j, spec.Low, spec.Up, opts...)
+	j.status = Status{ID: id, State: StateRunning}
+	return j, nil
+}
+
+// Objective queues v for evaluation by a worker and blocks until its
+// result is told or the job is stopped.
+func (j *Job) Objective(v []float64) (float64, error) {
+	j.mu.Lock()
+	if j.stopped {
+		j.mu.Unlock()
+		return math.Inf(1), ErrStopped
+	}
+	j.nexteval++
+	p := &pending{
+		eval:   Eval{ID: j.nexteval, Pos: append([]float64{}, v...)},
+		result: make(chan float64, 1),
+	}
+	j.pending[p.eval.ID] = p
+	j.queue = append(j.queue, p)
+	close(j.queued)
+	j.queued = make(chan struct{})
+	j.mu.Unlock()
+
+	select {
+	case val := <-p.result:
+		return val, nil
+	case <-j.stop:
+		return math.Inf(1), ErrStopped
+	}
+}
+
+func (j *Job) run() {
+	for j.solver.Next() {
+		j.update("")
+	}
+	j.update(StateDone)
+}
+
+// update refreshes the job status from the solver.  It must only be called
+// from the goroutine running the solver.
+func (j *Job) update(final string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	s := j.solver
+	j.status.Niter, j.status.Neval = s.Niter(), s.Neval()
+	if b := s.Best(); b != nil && b.Pos != nil && !math.IsInf(b.Val, 0) {
+		best := j.space.Point(b)
+		val := best.Val
+		j.status.BestPos, j.status.BestVal = best.Pos, &val
+	}
+	if final != "" {
+		switch {
+		case j.stopped:
+			j.status.State = StateStopped
+		case s.Err() != nil:
+			j.status.State, j.status.Err = StateFailed, s.Err().Error()
+		default:
+			j.status.State = final
+		}
+	}
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// Status returns the job's current status.
+func (j *Job) Status() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.statusLocked()
+}
+
+func (j *Job) statusLocked() Status {
+	st := j.status
+	st.Pending = len(j.pending)
+	return st
+}
+
+// Stop stops the job after its current iteration.  Evaluations still
+// pending fail with ErrStopped.
+func (j *Job) Stop() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.stopped {
+		return
+	}
+	j.stopped = true
+	j.solver.Stop()
+	close(j.stop)
+}
+
+// Ask returns up to n points awaiting evaluation, waiting up to wait for at
+// least one to become available.  Points whose lease has expired are
+// handed out again.
+func (j *Job) Ask(n int, wait time.Duration) []Eval {
+	timeout := time.After(wait)
+	for {
+		j.mu.Lock()
+		j.requeueExpired()
+		if len(j.queue) > 0 || j.status.State != StateRunning {
+			evals := []Eval{}
+			for len(j.queue) > 0 && len(evals) < n {
+				p := j.queue[0]
+				j.queue = j.queue[1:]
+				if _, ok := j.pending[p.eval.ID]; !ok {
+					continue // told while queued for re-lease
+				}
+				p.asked = time.Now()
+				evals = append(evals, p.eval)
+			}
+			j.mu.Unlock()
+			return evals
+		}
+		queued, changed := j.queued, j.changed
+		j.mu.Unlock()
+
+		select {
+		case <-queued:
+		case <-changed:
+		case <-timeout:
+			return []Eval{}
+		}
+	}
+}
+
+func (j *Job) requeueExpired() {
+	if j.spec.Lease <= 0 {
+		return
+	}
+	lease := time.Duration(j.spec.Lease * float64(time.Second))
+	var expired []*pending
+	for _, p := range j.pending {
+		if !p.asked.IsZero() && time.Since(p.asked) > lease {
+			p.asked = time.Time{}
+			expired = append(expired, p)
+		}
+	}
+	sort.Slice(expired, func(a, b int) bool { return expired[a].eval.ID < expired[b].eval.ID })
+	j.queue = append(j.queue, expired...)
+}
+
+// Tell submits evaluation results.  Results for unknown (or already told)
+// evaluations are reported as an error after all others are applied.
+func (j *Job) Tell(results ...Result) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var unknown []int64
+	for _, r := range results {
+		p, ok := j.pending[r.ID]
+		if !ok {
+			unknown = append(unknown, r.ID)
+			continue
+		}
+		delete(j.pending, r.ID)
+		val := r.Val
+		if r.Err != "" {
+			val = math.Inf(1)
+		}
+		p.result <- val
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("service: no pending evaluations with ids %v", unknown)
+	}
+	return nil
+}
+
+// Server is an http.Handler serving the optimization API.
+type Server struct {
+	mu     sync.Mutex
+	jobs   map[string]*Job
+	nextid int
+}
+
+// NewServer returns a server with no jobs.
+func NewServer() *Server { return &Server{jobs: map[string]*Job{}} }
+
+// Create creates and starts a job for spec.
+func (s *Server) Create(spec JobSpec) (*Job, error) {
+	s.mu.Lock()
+	s.nextid++
+	id := strconv.Itoa(s.nextid)
+	s.mu.Unlock()
+
+	j, err := newJob(id, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.jobs[id] = j
+	s.mu.Unlock()
+	go j.run()
+	return j, nil
+}
+
+// Job returns the job with the given id or nil if it doesn't exist.
+func (s *Server) Job(id string) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[id]
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "jobs" || len(parts) > 3 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case "GET":
+			s.list(w)
+		case "POST":
+			s.create(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	j := s.Job(parts[1])
+	if j == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	route := r.Method
+	if len(parts) == 3 {
+		route += " " + parts[2]
+	}
+	switch route {
+	case "GET":
+		writeJSON(w, j.Status())
+	case "DELETE":
+		j.Stop()
+		writeJSON(w, j.Status())
+	case "GET best":
+		st := j.Status()
+		if st.BestVal == nil {
+			http.Error(w, "no feasible point found yet", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, Best{Pos: st.BestPos, Val: *st.BestVal})
+	case "GET stream":
+		s.stream(w, r, j)
+	case "GET ask":
+		s.ask(w, r, j)
+	case "POST tell":
+		var results []Result
+		if err := json.NewDecoder(r.Body).Decode(&results); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := j.Tell(results...); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter) {
+	s.mu.Lock()
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+
+	sts := make([]Status, len(jobs))
+	for i, j := range jobs {
+		sts[i] = j.Status()
+	}
+	sort.Slice(sts, func(a, b int) bool {
+		ia, _ := strconv.Atoi(sts[a].ID)
+		ib, _ := strconv.Atoi(sts[b].ID)
+		return ia < ib
+	})
+	writeJSON(w, sts)
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	var spec JobSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	j, err := s.Create(spec)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+j.id)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, j.Status())
+}
+
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, j *Job) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	for {
+		j.mu.Lock()
+		st, changed := j.statusLocked(), j.changed
+		j.mu.Unlock()
+
+		if err := enc.Encode(st); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if st.State != StateRunning {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) ask(w http.ResponseWriter, r *http.Request, j *Job) {
+	n := 1
+	if v := r.URL.Query().Get("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n < 1 {
+			http.Error(w, "invalid n", http.StatusBadRequest)
+			return
+		}
+	}
+	var wait time.Duration
+	if v := r.URL.Query().Get("wait"); v != "" {
+		var err error
+		if wait, err = time.ParseDuration(v); err != nil {
+			http.Error(w, "invalid wait: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	writeJSON(w, j.Ask(n, wait))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	json.NewEncoder(w).Encode(v)
+}
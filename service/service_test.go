@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func sphere(v []float64) float64 {
+	tot := 0.0
+	for _, x := range v {
+		tot += (x - 1) * (x - 1)
+	}
+	return tot
+}
+
+// worker evaluates job points over http until the job finishes.
+func worker(t *testing.T, url string) {
+	for {
+		resp, err := http.Get(url + "/ask?n=8&wait=100ms")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var evals []Eval
+		err = json.NewDecoder(resp.Body).Decode(&evals)
+		resp.Body.Close()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(evals) == 0 {
+			var st Status
+			resp, err := http.Get(url)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			json.NewDecoder(resp.Body).Decode(&st)
+			resp.Body.Close()
+			if st.State != StateRunning {
+				return
+			}
+			continue
+		}
+
+		results := make([]Result, len(evals))
+		for i, e := range evals {
+			results[i] = Result{ID: e.ID, Val: sphere(e.Pos)}
+		}
+		data, _ := json.Marshal(results)
+		resp, err = http.Post(url+"/tell", "application/json", bytes.NewReader(data))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("tell: unexpected status %v", resp.Status)
+			return
+		}
+	}
+}
+
+func TestAskTell(t *testing.T) {
+	srv := httptest.NewServer(NewServer())
+	defer srv.Close()
+
+	spec := JobSpec{Low: []float64{-5, -5}, Up: []float64{5, 5}, MaxEval: 600}
+	data, _ := json.Marshal(spec)
+	resp, err := http.Post(srv.URL+"/jobs", "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st Status
+	json.NewDecoder(resp.Body).Decode(&st)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || st.State != StateRunning {
+		t.Fatalf("create failed: %v %+v", resp.Status, st)
+	}
+	url := srv.URL + "/jobs/" + st.ID
+
+	stream, err := http.Get(url + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Body.Close()
+
+	done := make(chan bool)
+	for i := 0; i < 3; i++ {
+		go func() { worker(t, url); done <- true }()
+	}
+
+	nupdates := 0
+	sc := bufio.NewScanner(stream.Body)
+	for sc.Scan() {
+		if err := json.Unmarshal(sc.Bytes(), &st); err != nil {
+			t.Fatal(err)
+		}
+		nupdates++
+	}
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+
+	if st.State != StateDone {
+		t.Fatalf("want final state %v, got %+v", StateDone, st)
+	} else if nupdates < 3 {
+		t.Errorf("want several streamed updates, got %v", nupdates)
+	}
+	if st.Neval == 0 || st.BestVal == nil || *st.BestVal > 1e-3 {
+		t.Errorf("poor result after %v evals: %+v", st.Neval, st)
+	}
+
+	resp, err = http.Get(url + "/best")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var best Best
+	json.NewDecoder(resp.Body).Decode(&best)
+	resp.Body.Close()
+	if best.Val != *st.BestVal || len(best.Pos) != 2 {
+		t.Errorf("best endpoint: want val %v, got %+v", *st.BestVal, best)
+	}
+}
+
+func TestStopAndLease(t *testing.T) {
+	s := NewServer()
+	j, err := s.Create(JobSpec{Low: []float64{0}, Up: []float64{1}, Lease: 0.05})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := j.Ask(1, time.Second)
+	if len(first) != 1 {
+		t.Fatalf("want 1 eval, got %v", first)
+	}
+	// never told - it should be handed out again after the lease expires
+	time.Sleep(100 * time.Millisecond)
+	var again []Eval
+	for len(again) == 0 || again[len(again)-1].ID != first[0].ID {
+		evals := j.Ask(100, time.Second)
+		if len(evals) == 0 {
+			t.Fatal("expired eval never handed out again")
+		}
+		again = append(again, evals...)
+	}
+
+	if err := j.Tell(Result{ID: -1}); err == nil {
+		t.Error("want error telling unknown eval")
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("DELETE", "/jobs/"+j.id, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete: unexpected status %v", rec.Code)
+	}
+
+	deadline := time.After(5 * time.Second)
+	for j.Status().State == StateRunning {
+		select {
+		case <-deadline:
+			t.Fatal("job never stopped")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if st := j.Status(); st.State != StateStopped {
+		t.Errorf("want state %v, got %+v", StateStopped, st)
+	}
+
+	if _, err := s.Create(JobSpec{Low: []float64{1}, Up: []float64{0}}); err == nil {
+		t.Error("want error for inverted bounds")
+	}
+}
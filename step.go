@@ -0,0 +1,95 @@
+package optim
+
+// StepController decides how a method's mesh step size changes after each
+// iteration.  Methods that support step controllers (e.g. pattern search)
+// call Update once per iteration with the current step, whether the
+// iteration found a better point, and the decrease in the best objective
+// value (zero for unsuccessful iterations).  The returned value is used as
+// the new step size unless it is not positive.
+type StepController interface {
+	Update(step float64, success bool, decrease float64) float64
+}
+
+// Converger is implemented by methods (and step controllers) that can
+// determine they have converged.  Solvers stop once their method reports
+// convergence.
+type Converger interface {
+	Converged() bool
+}
+
+// StepSchedule is a StepController implementing common mesh adaptive
+// search policies:
+//
+//   - Unsuccessful iterations contract the step by Contract.
+//   - NGrow consecutive successful iterations expand the step by Expand
+//     (capped at MaxStep if it is non-zero).
+//   - If SufficientDecrease is non-zero, successful iterations that
+//     decrease the objective by less than SufficientDecrease*step^2 (the
+//     forcing function used by Kolda, Lewis, and Torczon's generating set
+//     search) are treated as unsuccessful for step size purposes.
+//   - Once the step falls below MinStep, the schedule reports convergence.
+type StepSchedule struct {
+	// Contract defaults to 1/1.7.
+	Contract float64
+	// Expand defaults to 1/Contract.
+	Expand float64
+	// NGrow is the number of consecutive successes required before
+	// expanding.  Zero or less means never expand.
+	NGrow              int
+	MaxStep            float64
+	SufficientDecrease float64
+	MinStep            float64
+	nsuccess           int
+	last               float64
+}
+
+func (s *StepSchedule) Update(step float64, success bool, decrease float64) float64 {
+	contract := s.Contract
+	if contract == 0 {
+		contract = 1 / 1.7
+	}
+	expand := s.Expand
+	if expand == 0 {
+		expand = 1 / contract
+	}
+
+	if success && decrease < s.SufficientDecrease*step*step {
+		success = false
+	}
+
+	if !success {
+		s.nsuccess = 0
+		step *= contract
+	} else if s.nsuccess++; s.NGrow > 0 && s.nsuccess >= s.NGrow {
+		s.nsuccess = 0
+		step *= expand
+		if s.MaxStep > 0 && step > s.MaxStep {
+			step = s.MaxStep
+		}
+	}
+	s.last = step
+	return step
+}
+
+// Converged returns true if the last step returned by Update was smaller
+// than MinStep.
+func (s *StepSchedule) Converged() bool { return s.last != 0 && s.last < s.MinStep }
+
+// Status is a snapshot of a solver's progress - its Result so far plus the
+// current mesh step size.
+type Status struct {
+	Result
+	// Step is the current mesh step size.
+	Step float64
+}
+
+// Status returns a snapshot of the solver's progress.  As with Result,
+// Elapsed stops growing once the solver terminates.  It must not be called
+// concurrently with Next.
+func (s *Solver) Status() Status {
+	st := Status{Result: *s.Result()}
+	if s.Mesh != nil {
+		st.Step = s.Mesh.Step()
+	}
+	return st
+}
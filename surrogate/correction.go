@@ -0,0 +1,184 @@
+// Package surrogate provides models that approximate expensive objectives
+// from previous evaluations.
+package surrogate
+
+import (
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/rwcarlsen/optim"
+)
+
+// Correction is a linear-correction (co-kriging style) model fusing a
+// cheap low-fidelity objective with scarce high-fidelity samples:
+//
+//	fhi(x) ~= Rho*flo(x) + Shift + delta(x)
+//
+// where Rho and Shift are fitted by least squares and the remaining
+// discrepancy delta is interpolated between high-fidelity samples with
+// gaussian radial basis functions.
+type Correction struct {
+	Rho, Shift float64
+	// Scale is the RBF length scale.  If zero when fitting, the mean
+	// distance between each sample and its nearest neighbor is used.
+	Scale float64
+	// Nugget is added to the kernel diagonal to regularize the
+	// interpolation for noisy or nearly coincident samples.
+	Nugget  float64
+	centers [][]float64
+	weights []float64
+}
+
+// Fit fits the correction to pairs of low and high fidelity values at the
+// same positions (i.e. lo[i].Pos must equal hi[i].Pos).
+func (c *Correction) Fit(lo, hi []*optim.Point) error {
+	if len(lo) != len(hi) {
+		return errors.New("surrogate: mismatched low and high fidelity sample counts")
+	} else if len(lo) == 0 {
+		return errors.New("surrogate: no samples to fit")
+	}
+
+	// least squares regression of hi on lo
+	n := float64(len(lo))
+	var mlo, mhi float64
+	for i := range lo {
+		mlo += lo[i].Val / n
+		mhi += hi[i].Val / n
+	}
+	var sxy, sxx float64
+	for i := range lo {
+		sxy += (lo[i].Val - mlo) * (hi[i].Val - mhi)
+		sxx += (lo[i].Val - mlo) * (lo[i].Val - mlo)
+	}
+	c.Rho = 1
+	if sxx > 0 {
+		c.Rho = sxy / sxx
+	}
+	c.Shift = mhi - c.Rho*mlo
+
+	c.centers = make([][]float64, len(hi))
+	for i, p := range hi {
+		c.centers[i] = p.Pos
+	}
+	if c.Scale == 0 {
+		c.Scale = meanNearest(c.centers)
+	}
+
+	k := mat64.NewDense(len(hi), len(hi), nil)
+	resid := mat64.NewDense(len(hi), 1, nil)
+	for i := range hi {
+		resid.Set(i, 0, hi[i].Val-c.Rho*lo[i].Val-c.Shift)
+		for j := range hi {
+			k.Set(i, j, c.kernel(c.centers[i], c.centers[j]))
+		}
+		k.Set(i, i, k.At(i, i)+c.Nugget)
+	}
+	kinv, err := mat64.Inverse(k)
+	if err != nil {
+		return err
+	}
+	w := &mat64.Dense{}
+	w.Mul(kinv, resid)
+	c.weights = w.Col(nil, 0)
+	return nil
+}
+
+// Predict returns the corrected high-fidelity estimate at x given the
+// low-fidelity value flo at x.
+func (c *Correction) Predict(x []float64, flo float64) float64 {
+	v := c.Rho*flo + c.Shift
+	for i, center := range c.centers {
+		v += c.weights[i] * c.kernel(x, center)
+	}
+	return v
+}
+
+func (c *Correction) kernel(a, b []float64) float64 {
+	if c.Scale == 0 {
+		return 0
+	}
+	d := optim.L2Dist(&optim.Point{Pos: a}, &optim.Point{Pos: b}) / c.Scale
+	return math.Exp(-d * d / 2)
+}
+
+func meanNearest(xs [][]float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	tot := 0.0
+	for i := range xs {
+		nearest := math.Inf(1)
+		for j := range xs {
+			if i != j {
+				d := optim.L2Dist(&optim.Point{Pos: xs[i]}, &optim.Point{Pos: xs[j]})
+				nearest = math.Min(nearest, d)
+			}
+		}
+		tot += nearest
+	}
+	return tot / float64(len(xs))
+}
+
+// Fused is an objective that guides search on a multi-fidelity objective
+// using mostly low-fidelity evaluations.  Every point is evaluated at the
+// lowest fidelity and the returned value is the Correction's high-fidelity
+// estimate.  Every HiEvery'th evaluation is also evaluated at the highest
+// fidelity and the correction is refit including the new sample - for such
+// points the true high-fidelity value is returned.  Until MinHi
+// high-fidelity samples have been collected, every point is evaluated at
+// both fidelities.
+//
+// Because returned values are estimates, the best point found by a solver
+// using Fused should be confirmed with a high-fidelity evaluation.
+type Fused struct {
+	Obj     optim.MultiFidelityObjectiver
+	HiEvery int
+	MinHi   int
+	// Correction holds the current model and may be preconfigured (e.g.
+	// with a Nugget or Scale).
+	Correction Correction
+	// Lo and Hi hold the paired low and high fidelity samples.
+	Lo, Hi []*optim.Point
+	count  int
+	mu     sync.Mutex
+}
+
+func (o *Fused) Objective(v []float64) (float64, error) {
+	flo, err := o.Obj.FidelityObjective(v, 0)
+	if err != nil {
+		return math.Inf(1), err
+	}
+
+	o.mu.Lock()
+	o.count++
+	needhi := len(o.Hi) < o.MinHi || (o.HiEvery > 0 && o.count%o.HiEvery == 0)
+	if !needhi {
+		defer o.mu.Unlock()
+		if len(o.Hi) == 0 {
+			return flo, nil
+		}
+		return o.Correction.Predict(v, flo), nil
+	}
+	o.mu.Unlock()
+
+	fhi, err := o.Obj.FidelityObjective(v, o.Obj.Fidelities()-1)
+	if err != nil {
+		return math.Inf(1), err
+	}
+
+	pos := append([]float64{}, v...)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.Lo = append(o.Lo, &optim.Point{Pos: pos, Val: flo})
+	o.Hi = append(o.Hi, &optim.Point{Pos: pos, Val: fhi})
+
+	// keep the previous model if the refit fails (e.g. a singular kernel
+	// matrix from repeated points)
+	c := o.Correction
+	if err := c.Fit(o.Lo, o.Hi); err == nil {
+		o.Correction = c
+	}
+	return fhi, nil
+}
@@ -0,0 +1,81 @@
+package surrogate
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+type twoLevel struct{ nlo, nhi int }
+
+func (o *twoLevel) Fidelities() int { return 2 }
+
+func (o *twoLevel) FidelityObjective(v []float64, level int) (float64, error) {
+	hi := (v[0]-1)*(v[0]-1) + (v[1]-2)*(v[1]-2)
+	if level == 1 {
+		o.nhi++
+		return hi, nil
+	}
+	o.nlo++
+	return 0.5*hi + 3 + 0.3*math.Sin(v[0]), nil
+}
+
+func TestCorrection(t *testing.T) {
+	obj := &twoLevel{}
+	var lo, hi []*optim.Point
+	for _, p := range optim.RandPop(30, []float64{-3, -3}, []float64{5, 5}) {
+		flo, _ := obj.FidelityObjective(p.Pos, 0)
+		fhi, _ := obj.FidelityObjective(p.Pos, 1)
+		lo = append(lo, &optim.Point{Pos: p.Pos, Val: flo})
+		hi = append(hi, &optim.Point{Pos: p.Pos, Val: fhi})
+	}
+
+	c := &Correction{}
+	if err := c.Fit(lo, hi); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(c.Rho-2) > 0.2 {
+		t.Errorf("want rho near 2, got %v", c.Rho)
+	}
+	for i := range lo {
+		if got := c.Predict(lo[i].Pos, lo[i].Val); math.Abs(got-hi[i].Val) > 1e-6 {
+			t.Errorf("sample %v not interpolated: want %v, got %v", i, hi[i].Val, got)
+		}
+	}
+
+	x := []float64{1, 2}
+	flo, _ := obj.FidelityObjective(x, 0)
+	fhi, _ := obj.FidelityObjective(x, 1)
+	if got := c.Predict(x, flo); math.Abs(got-fhi) > 0.5 {
+		t.Errorf("bad prediction at %v: want %v, got %v", x, fhi, got)
+	}
+}
+
+func TestFused(t *testing.T) {
+	obj := &twoLevel{}
+	fused := &Fused{Obj: obj, HiEvery: 10, MinHi: 5}
+	for i, p := range optim.RandPop(50, []float64{-3, -3}, []float64{5, 5}) {
+		if _, err := fused.Objective(p.Pos); err != nil {
+			t.Fatalf("eval %v: %v", i, err)
+		}
+	}
+
+	// 5 initial + every 10th evaluation
+	if want := 5 + 5; obj.nhi != want || len(fused.Hi) != want {
+		t.Errorf("want %v high fidelity evals, got %v (%v samples)", want, obj.nhi, len(fused.Hi))
+	}
+	if obj.nlo != 50 {
+		t.Errorf("want 50 low fidelity evals, got %v", obj.nlo)
+	}
+}
+
+func TestHighFidelity(t *testing.T) {
+	obj := &twoLevel{}
+	v, err := optim.HighFidelity(obj).Objective([]float64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	} else if v != 0 || obj.nhi != 1 {
+		t.Errorf("want high fidelity value 0, got %v", v)
+	}
+}
@@ -0,0 +1,138 @@
+package surrogate
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Model is a surrogate that predicts objective values from evaluated
+// points.
+type Model interface {
+	// Fit (re)fits the model to pts.
+	Fit(pts []*optim.Point) error
+	// Predict returns the model's estimate of the objective at x.
+	Predict(x []float64) float64
+}
+
+// IDW is an inverse distance weighting model that predicts a weighted
+// average of the values of the K nearest evaluated points with weights
+// 1/d^Power.  Predictions never fall outside the range of the fitted
+// values.
+type IDW struct {
+	// K is the number of neighbors used.  It defaults to 8.
+	K int
+	// Power is the distance weighting exponent.  It defaults to 2.
+	Power float64
+	// Scale holds optional per-dimension length scales (see
+	// optim.History).
+	Scale []float64
+	hist  *optim.History
+}
+
+func (m *IDW) Fit(pts []*optim.Point) error {
+	if len(pts) == 0 {
+		return errors.New("surrogate: no points to fit")
+	}
+	m.hist = &optim.History{Scale: m.Scale}
+	m.hist.Add(pts...)
+	return nil
+}
+
+func (m *IDW) Predict(x []float64) float64 {
+	k, pow := m.K, m.Power
+	if k == 0 {
+		k = 8
+	}
+	if pow == 0 {
+		pow = 2
+	}
+	tot, wtot := 0.0, 0.0
+	for _, p := range m.hist.Nearest(x, k) {
+		d := m.hist.Dist(x, p.Pos)
+		if d == 0 {
+			return p.Val
+		}
+		w := 1 / math.Pow(d, pow)
+		tot += w * p.Val
+		wtot += w
+	}
+	return tot / wtot
+}
+
+// Screen is an Evaler that uses a cheap surrogate model to screen
+// candidates.  Once Warmup points have been truly evaluated, each batch of
+// candidates is ranked by the model's predictions and only the most
+// promising Frac of them are sent to the wrapped Evaler - the rest are
+// assigned their predicted values.  The model is refit to all true
+// evaluations before each screened batch.  Results include screened
+// points.  This cuts true evaluation counts for methods with large
+// populations at the cost of some search accuracy.
+//
+// Models that can predict values better than any true evaluation (e.g.
+// extrapolating regressions) may cause a screened point to become a
+// solver's best point.  The default IDW model cannot.
+type Screen struct {
+	optim.Evaler
+	// Model defaults to an IDW with default parameters.
+	Model Model
+	// Frac is the fraction of each batch truly evaluated (rounded up).  It
+	// defaults to 0.5.
+	Frac float64
+	// Warmup is the number of true evaluations made before screening
+	// begins.  It defaults to 10.
+	Warmup int
+	// Evaluated holds every truly evaluated point with a finite value.
+	Evaluated []*optim.Point
+	// Screened counts the points assigned surrogate values.
+	Screened int
+	mu       sync.Mutex
+}
+
+func (s *Screen) Eval(obj optim.Objectiver, points ...*optim.Point) (results []*optim.Point, n int, err error) {
+	obj = optim.RunPreHooks(obj, points)
+	s.mu.Lock()
+	if s.Model == nil {
+		s.Model = &IDW{}
+	}
+	frac, warmup := s.Frac, s.Warmup
+	if frac == 0 {
+		frac = 0.5
+	}
+	if warmup == 0 {
+		warmup = 10
+	}
+
+	truepts := points
+	var screened []*optim.Point
+	if len(s.Evaluated) >= warmup && s.Model.Fit(s.Evaluated) == nil {
+		pred := make(map[*optim.Point]float64, len(points))
+		for _, p := range points {
+			pred[p] = s.Model.Predict(p.Pos)
+		}
+		sorted := append([]*optim.Point{}, points...)
+		sort.SliceStable(sorted, func(i, j int) bool { return pred[sorted[i]] < pred[sorted[j]] })
+
+		ntrue := int(math.Ceil(frac * float64(len(sorted))))
+		truepts, screened = sorted[:ntrue], sorted[ntrue:]
+		for _, p := range screened {
+			p.Val = pred[p]
+		}
+		s.Screened += len(screened)
+	}
+	s.mu.Unlock()
+
+	results, n, err = s.Evaler.Eval(obj, truepts...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range results {
+		if !math.IsInf(p.Val, 0) && !math.IsNaN(p.Val) {
+			s.Evaluated = append(s.Evaluated, p.Clone())
+		}
+	}
+	return append(results, screened...), n, err
+}
@@ -0,0 +1,67 @@
+package surrogate
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+	"github.com/rwcarlsen/optim/swarm"
+)
+
+func TestIDW(t *testing.T) {
+	m := &IDW{K: 2}
+	pts := []*optim.Point{
+		{Pos: []float64{0}, Val: 0},
+		{Pos: []float64{1}, Val: 10},
+		{Pos: []float64{5}, Val: 100},
+	}
+	if err := m.Fit(pts); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Predict([]float64{1}); got != 10 {
+		t.Errorf("at sample: want 10, got %v", got)
+	}
+	// equidistant from the two nearest samples
+	if got := m.Predict([]float64{0.5}); got != 5 {
+		t.Errorf("midpoint: want 5, got %v", got)
+	}
+	if err := (&IDW{}).Fit(nil); err == nil {
+		t.Errorf("want error fitting no points")
+	}
+}
+
+func TestScreen(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 2}
+	low, up := fn.Bounds()
+	obj := optim.Func(fn.Eval)
+
+	ev := &Screen{Evaler: optim.SerialEvaler{}, Frac: 0.25}
+	m := swarm.New(swarm.NewPopulationRand(40, low, up), swarm.Evaler(ev), swarm.VmaxBounds(low, up))
+
+	neval := 0
+	for i := 0; i < 30; i++ {
+		_, n, err := m.Iterate(obj, &optim.InfMesh{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		neval += n
+	}
+
+	// first iteration is all warmup, the rest evaluate a quarter
+	if want := 40 + 29*10; neval != want {
+		t.Errorf("want %v true evals, got %v", want, neval)
+	}
+	if ev.Screened != 29*30 {
+		t.Errorf("want %v screened points, got %v", 29*30, ev.Screened)
+	}
+
+	// the swarm's best must be a truly evaluated point
+	best := m.Pop.Best().Best
+	if got := fn.Eval(best.Pos); math.Abs(got-best.Val) > 1e-12 {
+		t.Errorf("best point value %v is a surrogate estimate (true %v)", best.Val, got)
+	}
+	if best.Val > 10 {
+		t.Errorf("screened search made poor progress: best %v", best)
+	}
+}
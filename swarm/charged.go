@@ -0,0 +1,66 @@
+package swarm
+
+import "math"
+
+// Charged makes a charged swarm by giving a fraction frac (at least one)
+// of the particles charge q.  Charged particles repel each other, so they
+// keep orbiting the global best instead of collapsing onto it - preserving
+// diversity that helps track optima of dynamic or noisy objectives.
+// Neutral particles behave as usual.  Repulsion between particles i and j
+// accelerates i by
+//
+//	q^2 (x_i - x_j) / r^3         for core <= r <= perception
+//	q^2 (x_i - x_j) / (core^2 r)  for r < core
+//
+// where r = |x_i - x_j|, and is zero beyond the perception limit.  For
+// details see:
+//
+//	Blackwell, Tim M., and Peter J. Bentley. "Dynamic search with charged
+//	swarms." Proceedings of the Genetic and Evolutionary Computation
+//	Conference. 2002.
+func Charged(frac, q, core, perception float64) Option {
+	return func(m *Method) {
+		n := int(math.Max(1, math.Floor(frac*float64(len(m.Pop)))))
+		for i, p := range m.Pop {
+			if i < n {
+				p.Charge = q
+			}
+		}
+		m.Core, m.Perception = core, perception
+	}
+}
+
+// repel computes the repulsive acceleration of every charged particle
+// from its current position.
+func (pop Population) repel(core, perception float64) {
+	for _, p := range pop {
+		if p.Charge == 0 {
+			p.acc = nil
+			continue
+		}
+		p.acc = make([]float64, len(p.Pos))
+		for _, other := range pop {
+			if other == p || other.Charge == 0 {
+				continue
+			}
+			r := 0.0
+			for i := range p.Pos {
+				d := p.Pos[i] - other.Pos[i]
+				r += d * d
+			}
+			r = math.Sqrt(r)
+			var f float64
+			switch {
+			case r > perception || r == 0:
+				continue
+			case r < core:
+				f = p.Charge * other.Charge / (core * core * r)
+			default:
+				f = p.Charge * other.Charge / (r * r * r)
+			}
+			for i := range p.Pos {
+				p.acc[i] += f * (p.Pos[i] - other.Pos[i])
+			}
+		}
+	}
+}
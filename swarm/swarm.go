@@ -17,10 +17,12 @@ package swarm
 
 import (
 	"database/sql"
-	"log"
 	"math"
+	"sort"
+	"sync"
 
 	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/niche"
 )
 
 // These parameters are calculated using a constriction factor originally
@@ -79,6 +81,12 @@ type Particle struct {
 	*optim.Point
 	Vel  []float64
 	Best *optim.Point
+	// Charge is the particle's charge for charged swarms (see Charged).
+	// Neutral particles have zero charge.
+	Charge float64
+	// acc is the repulsive acceleration added to the particle's velocity
+	// by its next move.
+	acc []float64
 }
 
 func (p *Particle) L2Vel() float64 {
@@ -90,15 +98,31 @@ func (p *Particle) L2Vel() float64 {
 }
 
 func (p *Particle) Move(gbest *optim.Point, vmax []float64, inertia, social, cognition float64) {
+	p.move(gbest, vmax, inertia, social, cognition, p.draws(optim.Rand))
+}
+
+// draws returns the random numbers used by one move of p - a pair r1, r2
+// for each dimension of p's velocity - drawn from rng.
+func (p *Particle) draws(rng optim.Rng) []float64 {
+	r := make([]float64, 2*len(p.Vel))
+	for i := range r {
+		r[i] = rng.Float64()
+	}
+	return r
+}
+
+func (p *Particle) move(gbest *optim.Point, vmax []float64, inertia, social, cognition float64, r []float64) {
 	// update velocity
 	for i, currv := range p.Vel {
-		// random numbers r1 and r2 MUST go inside this loop and be generated
-		// uniquely for each dimension of p's velocity.
-		r1 := optim.RandFloat()
-		r2 := optim.RandFloat()
+		// random numbers r1 and r2 MUST be generated uniquely for each
+		// dimension of p's velocity.
+		r1, r2 := r[2*i], r[2*i+1]
 		p.Vel[i] = inertia*currv +
 			cognition*r1*(p.Best.Pos[i]-p.Pos[i]) +
 			social*r2*(gbest.Pos[i]-p.Pos[i])
+		if p.acc != nil {
+			p.Vel[i] += p.acc[i]
+		}
 		if math.Abs(p.Vel[i]) > vmax[i] {
 			p.Vel[i] = math.Copysign(vmax[i], p.Vel[i])
 		}
@@ -142,6 +166,11 @@ type Population []*Particle
 // values between minv[i] and maxv[i].  github.com/rwcarlsen/optim.Rand is
 // used for random numbers.
 func NewPopulation(points []*optim.Point, vmax []float64) Population {
+	return NewPopulationFrom(optim.Rand, points, vmax)
+}
+
+// NewPopulationFrom is like NewPopulation but draws random numbers from r.
+func NewPopulationFrom(r optim.Rng, points []*optim.Point, vmax []float64) Population {
 	pop := make(Population, len(points))
 	for i, p := range points {
 		pop[i] = &Particle{
@@ -151,7 +180,7 @@ func NewPopulation(points []*optim.Point, vmax []float64) Population {
 			Vel:   make([]float64, len(vmax)),
 		}
 		for j, v := range vmax {
-			pop[i].Vel[j] = v * (1 - 2*optim.RandFloat())
+			pop[i].Vel[j] = v * (1 - 2*r.Float64())
 		}
 	}
 	return pop
@@ -160,8 +189,14 @@ func NewPopulation(points []*optim.Point, vmax []float64) Population {
 // NewPopulationRand creates a population of randomly positioned particles
 // uniformly distributed in the box-bounds described by low and up.
 func NewPopulationRand(n int, low, up []float64) Population {
-	points := optim.RandPop(n, low, up)
-	return NewPopulation(points, vmaxfrombounds(low, up))
+	return NewPopulationRandFrom(optim.Rand, n, low, up)
+}
+
+// NewPopulationRandFrom is like NewPopulationRand but draws random numbers
+// from r.
+func NewPopulationRandFrom(r optim.Rng, n int, low, up []float64) Population {
+	points := optim.RandPopFrom(r, n, low, up)
+	return NewPopulationFrom(r, points, vmaxfrombounds(low, up))
 }
 
 func (pop Population) Best() *Particle {
@@ -216,6 +251,10 @@ func DB(db *sql.DB) Option {
 	}
 }
 
+// Logger sets the logger that receives method events such as database
+// write failures.
+func Logger(l optim.Logger) Option { return func(m *Method) { m.Logger = l } }
+
 func KillTol(xtol, vtol float64) Option {
 	return func(m *Method) {
 		m.Xtol = xtol
@@ -232,6 +271,16 @@ func LearnFactors(cognition, social float64) Option {
 
 func Evaler(e optim.Evaler) Option { return func(m *Method) { m.Evaler = e } }
 
+// Rng sets the source of the method's random numbers in place of the shared
+// optim.Rand.
+func Rng(r optim.Rng) Option { return func(m *Method) { m.Rng = r } }
+
+// Niching makes particles follow the best of their species - as assigned by
+// s using the particles' personal bests - instead of the global best.  This
+// lets the swarm converge to several optima concurrently.  Use Seeds to
+// retrieve the species' best points.
+func Niching(s niche.Speciator) Option { return func(m *Method) { m.Speciator = s } }
+
 // LinInertia sets particle inertia for velocity updates to varry linearly
 // from the start (high) to end (low) values from 0 to maxiter.  Common values
 // are start = 0.9 and end = 0.4 - for details see:
@@ -254,6 +303,13 @@ func FixedInertia(v float64) Option {
 	}
 }
 
+// Mutation perturbs each particle's position with mut after it moves with
+// probability prob.  Occasional mutation helps the swarm escape premature
+// convergence.
+func Mutation(mut optim.Mutator, prob float64) Option {
+	return func(m *Method) { m.Mutator, m.MutateProb = mut, prob }
+}
+
 func InitIter(iter int) Option {
 	return func(m *Method) { m.iter = iter }
 }
@@ -273,10 +329,32 @@ type Method struct {
 	InertiaFn func(iter int) float64
 	// Vmax is the speed limit per dimension for particles.  If nil,
 	// infinity is used.
-	Vmax []float64
-	Db   *sql.DB
-	iter int
-	best *optim.Point
+	Vmax   []float64
+	Db     *sql.DB
+	Logger optim.Logger
+	iter   int
+	best   *optim.Point
+	// Speciator, if non-nil, divides the swarm into species each following
+	// the best personal best of its own members rather than the global best.
+	Speciator niche.Speciator
+	seeds     []*optim.Point
+	// Trust, if non-nil, restricts evaluations to a box around the global
+	// best that adapts to the search's progress.
+	Trust *TrustRegion
+	// Core and Perception are the core radius and perception limit of the
+	// repulsion between charged particles (see Charged).
+	Core, Perception float64
+	// Mutator, if non-nil, perturbs each particle's position after moving
+	// with probability MutateProb.  The built-in mutators draw from Rng
+	// unless they have their own source.
+	Mutator    optim.Mutator
+	MutateProb float64
+	// Rng is the source of random numbers for particle moves and mutation.
+	// If nil, optim.Rand is used.
+	Rng optim.Rng
+	// mu guards best, which AddPoint may update concurrently with Iterate
+	// when migrating points between islands.
+	mu sync.Mutex
 }
 
 func New(pop Population, opts ...Option) *Method {
@@ -292,6 +370,7 @@ func New(pop Population, opts ...Option) *Method {
 		Social:    DefaultSocial,
 		InertiaFn: func(iter int) float64 { return DefaultInertia },
 		Vmax:      vmax,
+		Logger:    optim.StdLogger{},
 		best:      pop.Best().Point.Clone(), // TODO: write test that checks best is a Clone
 	}
 
@@ -303,6 +382,42 @@ func New(pop Population, opts ...Option) *Method {
 	return m
 }
 
+func (m *Method) rng() optim.Rng {
+	if m.Rng == nil {
+		return optim.Rand
+	}
+	return m.Rng
+}
+
+// share gives the method's Rng to built-in mutators that don't have their
+// own.
+func (m *Method) share() {
+	if m.Rng == nil {
+		return
+	}
+	switch mut := m.Mutator.(type) {
+	case optim.Gaussian:
+		if mut.Rng == nil {
+			mut.Rng = m.Rng
+			m.Mutator = mut
+		}
+	case optim.Cauchy:
+		if mut.Rng == nil {
+			mut.Rng = m.Rng
+			m.Mutator = mut
+		}
+	case optim.LatticeHop:
+		if mut.Rng == nil {
+			mut.Rng = m.Rng
+			m.Mutator = mut
+		}
+	case *optim.Adaptive:
+		if mut.Rng == nil {
+			mut.Rng = m.Rng
+		}
+	}
+}
+
 func (m *Method) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Point, neval int, err error) {
 	defer func() { m.iter++ }()
 
@@ -315,10 +430,25 @@ func (m *Method) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Poi
 		points[i] = p
 		pmap[p] = particle
 	}
+	if m.Trust != nil {
+		m.restrict(points, mesh)
+	}
 	if mesh != nil {
-		for _, p := range points {
-			p.Pos = mesh.Nearest(p.Pos)
+		pos := make([][]float64, len(points))
+		for i, p := range points {
+			pos[i] = p.Pos
 		}
+		for i, near := range optim.NearestBatch(mesh, pos) {
+			points[i].Pos = near
+		}
+	}
+
+	// draw this iteration's random numbers before evaluating so particle
+	// trajectories don't depend on evaluation completion order or on random
+	// numbers consumed while evaluating.
+	draws := make([][]float64, len(m.Pop))
+	for i, p := range m.Pop {
+		draws[i] = p.draws(m.rng())
 	}
 
 	// evaluate current positions
@@ -329,34 +459,118 @@ func (m *Method) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Poi
 
 	// TODO: write test to make sure this checks pbest.Best.Val instead of p.Val.
 	pbest := m.Pop.Best()
+	m.mu.Lock()
 	if pbest != nil && pbest.Best.Val < m.best.Val {
 		m.best = pbest.Best
 	}
+	best = m.best
+	m.mu.Unlock()
+	if m.Trust != nil {
+		m.Trust.Update(best)
+	}
 
 	m.updateDb(mesh)
 
-	// move particles and update current best
-	for _, p := range m.Pop {
-		p.Move(m.best, m.Vmax, m.InertiaFn(m.iter), m.Social, m.Cognition)
+	m.Pop.repel(m.Core, m.Perception)
+
+	// move particles toward the global best or their species' best
+	leaders := m.leaders(best)
+	for i, p := range m.Pop {
+		p.move(leaders[p], m.Vmax, m.InertiaFn(m.iter), m.Social, m.Cognition, draws[i])
+	}
+
+	if m.Mutator != nil {
+		m.share()
+		for _, p := range m.Pop {
+			if m.rng().Float64() < m.MutateProb {
+				p.Pos = m.Mutator.Mutate(p.Pos, mesh)
+			}
+		}
 	}
 
 	// Kill slow particles near global optimum.
 	// This MUST go after the updating of the iterator's best position.
 	for i, p := range m.Pop {
-		if p.Kill(m.best, m.Xtol, m.Vtol) {
+		if p.Kill(leaders[p], m.Xtol, m.Vtol) {
 			m.Pop = append(m.Pop[:i], m.Pop[i+1:]...)
 		}
 	}
 
-	return m.best, n, err
+	return best, n, err
 }
 
+// leaders returns the point each particle moves toward - the global best
+// or, if niching, its species' best.
+func (m *Method) leaders(gbest *optim.Point) map[*Particle]*optim.Point {
+	leaders := make(map[*Particle]*optim.Point, len(m.Pop))
+	if m.Speciator == nil {
+		for _, p := range m.Pop {
+			leaders[p] = gbest
+		}
+		return leaders
+	}
+
+	bests := make([]*optim.Point, len(m.Pop))
+	for i, p := range m.Pop {
+		bests[i] = p.Best
+	}
+	species := m.Speciator.Species(bests)
+	for i, p := range m.Pop {
+		leaders[p] = bests[species[i]]
+	}
+	m.seeds = m.seeds[:0]
+	for _, i := range niche.Seeds(species) {
+		m.seeds = append(m.seeds, bests[i])
+	}
+	return leaders
+}
+
+// Seeds returns the best point of each species as of the last iteration
+// ordered from best to worst.  It returns nil if niching is not enabled.
+func (m *Method) Seeds() []*optim.Point {
+	if len(m.seeds) == 0 {
+		return nil
+	}
+	seeds := append([]*optim.Point{}, m.seeds...)
+	sort.SliceStable(seeds, func(i, j int) bool { return seeds[i].Val < seeds[j].Val })
+	return seeds
+}
+
+// AddPoint is safe to call concurrently with Iterate.
 func (m *Method) AddPoint(p *optim.Point) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if p.Val < m.best.Val {
 		m.best = p
 	}
 }
 
+// Inject replaces the particle with the worst personal best with a particle
+// at each injected point in turn - keeping the replaced particle's velocity -
+// and updates the global best.  Injected points that haven't been evaluated
+// are evaluated in the next iteration.
+func (m *Method) Inject(points ...*optim.Point) {
+	for _, p := range points {
+		if len(m.Pop) > 0 {
+			worst := m.Pop[0]
+			for _, q := range m.Pop[1:] {
+				if q.Best.Val > worst.Best.Val {
+					worst = q
+				}
+			}
+			worst.Point = p.Clone()
+			worst.Best = p.Clone()
+		}
+		m.AddPoint(p)
+	}
+}
+
+func (m *Method) globalBest() *optim.Point {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.best
+}
+
 func (m *Method) initdb() {
 	if m.Db == nil {
 		return
@@ -364,25 +578,25 @@ func (m *Method) initdb() {
 
 	s := "CREATE TABLE IF NOT EXISTS " + TblParticles + " (particle INTEGER, iter INTEGER, val REAL, posid BLOB, velid BLOB, vel INTEGER);"
 	_, err := m.Db.Exec(s)
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 
 	s = "CREATE TABLE IF NOT EXISTS " + TblParticlesMeshed + " (particle INTEGER, iter INTEGER, val REAL, posid BLOB);"
 	_, err = m.Db.Exec(s)
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 
 	s = "CREATE TABLE IF NOT EXISTS " + TblParticlesBest + " (particle INTEGER, iter INTEGER, best REAL, posid BLOB);"
 	_, err = m.Db.Exec(s)
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 
 	s = "CREATE TABLE IF NOT EXISTS " + TblBest + " (iter INTEGER, val REAL, posid BLOB);"
 	_, err = m.Db.Exec(s)
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 }
@@ -399,15 +613,15 @@ func (m *Method) updateDb(mesh optim.Mesh) {
 	defer tx.Commit()
 
 	s0, err := tx.Prepare("INSERT INTO " + TblParticles + " (particle,iter,val,posid,velid,vel) VALUES (?,?,?,?,?,?);")
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 	s0b, err := tx.Prepare("INSERT INTO " + TblParticlesMeshed + " (particle,iter,val,posid) VALUES (?,?,?,?);")
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 	s1, err := tx.Prepare("INSERT INTO " + TblParticlesBest + " (particle,iter,best,posid) VALUES (?,?,?,?);")
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 
@@ -420,52 +634,51 @@ func (m *Method) updateDb(mesh optim.Mesh) {
 		pts = append(pts, vel)
 
 		_, err := s0.Exec(p.Id, m.iter, p.Val, p.HashSlice(), vel.HashSlice(), p.L2Vel())
-		if checkdberr(err) {
+		if m.checkdberr(err) {
 			return
 		}
 
 		_, err = s1.Exec(p.Id, m.iter, p.Best.Val, p.Best.HashSlice())
-		if checkdberr(err) {
+		if m.checkdberr(err) {
 			return
 		}
 
 		pp := &optim.Point{mesh.Nearest(p.Pos), p.Val}
 		_, err = s0b.Exec(p.Id, m.iter, p.Val, pp.HashSlice())
-		if checkdberr(err) {
+		if m.checkdberr(err) {
 			return
 		}
 	}
 
 	s2, err := tx.Prepare("INSERT INTO " + TblBest + " (iter,val,posid) VALUES (?,?,?);")
-	glob := m.best
+	glob := m.globalBest()
 	_, err = s2.Exec(m.iter, glob.Val, glob.HashSlice())
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 
 	pts = append(pts, glob)
 	err = optim.RecordPointPos(tx, pts...)
-	if checkdberr(err) {
+	if m.checkdberr(err) {
 		return
 	}
 }
 
-// TODO: remove all uses of this
-func checkdberr(err error) bool {
+func (m *Method) checkdberr(err error) bool {
 	if err != nil {
-		log.Print("swarm: db write failed -", err)
+		var l optim.Logger = optim.StdLogger{}
+		if m.Logger != nil {
+			l = m.Logger
+		}
+		l.Log(optim.LevelError, "swarm: db write failed", "err", err)
 		return true
 	}
 	return false
 }
 
 func vmaxfrombounds(low, up []float64) []float64 {
-	vmax := make([]float64, len(low))
-	for i := range vmax {
-		// Eberhart et al. suggest this: (up-low)/2 - removing divide by two
-		// seems to help swarm avoid premature convergence in difficult
-		// problems.
-		vmax[i] = (up[i] - low[i])
-	}
-	return vmax
+	// Eberhart et al. suggest this: (up-low)/2 - removing divide by two
+	// seems to help swarm avoid premature convergence in difficult
+	// problems.
+	return optim.Bounds{Low: low, Up: up}.Widths()
 }
@@ -3,11 +3,15 @@ package swarm
 import (
 	"database/sql"
 	"math"
+	"math/rand"
+	"reflect"
 	"testing"
+	"time"
 
 	_ "github.com/rwcarlsen/go-sqlite/sqlite3"
 	"github.com/rwcarlsen/optim"
 	"github.com/rwcarlsen/optim/bench"
+	"github.com/rwcarlsen/optim/niche"
 )
 
 type fakeRand struct {
@@ -197,6 +201,50 @@ func TestDb(t *testing.T) {
 	}
 }
 
+// TestParallelDeterministic checks that a swarm evaluated in parallel -
+// with evaluations completing in a scrambled order - follows exactly the
+// same trajectory as one evaluated serially.
+func TestParallelDeterministic(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 3}
+	obj := optim.Func(func(v []float64) float64 {
+		// vary evaluation times so completion order differs from input order
+		time.Sleep(time.Duration(math.Mod(math.Abs(v[0])*1e4, 200)) * time.Microsecond)
+		return fn.Eval(v)
+	})
+
+	run := func(ev optim.Evaler) []float64 {
+		orig := optim.Rand
+		optim.Rand = rand.New(rand.NewSource(7))
+		defer func() { optim.Rand = orig }()
+
+		low, up := fn.Bounds()
+		m := New(NewPopulationRand(20, low, up), VmaxBounds(low, up), Evaler(ev))
+		var trace []float64
+		for i := 0; i < 15; i++ {
+			best, _, err := m.Iterate(obj, &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up})
+			if err != nil {
+				t.Fatal(err)
+			}
+			trace = append(trace, best.Val)
+			for _, p := range m.Pop {
+				trace = append(trace, p.Pos...)
+			}
+		}
+		return trace
+	}
+
+	want := run(optim.SerialEvaler{})
+	got := run(optim.ParallelEvaler{})
+	if len(got) != len(want) {
+		t.Fatalf("trajectory lengths differ: serial %v, parallel %v", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("trajectories diverge at entry %v: serial %v, parallel %v", i, want[i], got[i])
+		}
+	}
+}
+
 func swarmsolver(fn bench.Func, db *sql.DB) (optim.Method, optim.Mesh) {
 	low, up := fn.Bounds()
 	n := 20 + 1*len(low)
@@ -206,3 +254,172 @@ func swarmsolver(fn bench.Func, db *sql.DB) (optim.Method, optim.Mesh) {
 		DB(db),
 	), &optim.BoxMesh{&optim.InfMesh{}, low, up}
 }
+
+func TestInject(t *testing.T) {
+	pts := []*optim.Point{
+		{Pos: []float64{0, 0}, Val: 1},
+		{Pos: []float64{1, 1}, Val: 7},
+		{Pos: []float64{2, 2}, Val: 3},
+	}
+	m := New(NewPopulation(pts, []float64{1, 1}))
+
+	guess := &optim.Point{Pos: []float64{5, 5}, Val: math.Inf(1)}
+	good := &optim.Point{Pos: []float64{4, 4}, Val: 0.5}
+	m.Inject(guess)
+	if p := m.Pop[1]; p.Pos[0] != 5 || !math.IsInf(p.Best.Val, 1) {
+		t.Errorf("guess did not replace worst particle: got %v (best %v)", p.Point, p.Best)
+	}
+	if p := m.Pop[1]; p.Point == guess || p.Best == guess {
+		t.Errorf("injected point not copied")
+	}
+
+	// the guess (best of +Inf) is now the worst and is replaced in turn
+	m.Inject(good)
+	if p := m.Pop[1]; p.Pos[0] != 4 || p.Best.Val != 0.5 {
+		t.Errorf("good point did not replace worst particle: got %v (best %v)", p.Point, p.Best)
+	}
+	if m.globalBest().Val != 0.5 {
+		t.Errorf("global best not updated: got %v", m.globalBest())
+	}
+}
+
+func TestNiching(t *testing.T) {
+	orig := optim.Rand
+	optim.Rand = rand.New(rand.NewSource(3))
+	defer func() { optim.Rand = orig }()
+
+	// two global optima at (2, 2) and (-2, -2)
+	a, b := []float64{2, 2}, []float64{-2, -2}
+	obj := optim.Func(func(v []float64) float64 {
+		da := (v[0]-a[0])*(v[0]-a[0]) + (v[1]-a[1])*(v[1]-a[1])
+		db := (v[0]-b[0])*(v[0]-b[0]) + (v[1]-b[1])*(v[1]-b[1])
+		return math.Min(da, db)
+	})
+	low, up := []float64{-5, -5}, []float64{5, 5}
+	m := New(NewPopulationRand(40, low, up), VmaxBounds(low, up), Niching(niche.Radius{R: 2}))
+	for i := 0; i < 150; i++ {
+		if _, _, err := m.Iterate(obj, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found := map[float64]bool{}
+	for _, s := range m.Seeds() {
+		if s.Val < 1e-4 {
+			found[math.Copysign(2, s.Pos[0])] = true
+		}
+	}
+	if !found[2] || !found[-2] {
+		t.Errorf("want species converged to both optima, got seeds %v", m.Seeds())
+	}
+}
+
+func TestTrustRegion(t *testing.T) {
+	tr := NewTrustRegion([]float64{0, 0}, []float64{10, 10})
+	tr.Update(&optim.Point{Val: 5})
+	if tr.Frac != .5 {
+		t.Errorf("region did not expand on improvement: frac %v", tr.Frac)
+	}
+	for i := 0; i < tr.MaxStall; i++ {
+		tr.Update(&optim.Point{Val: 5})
+	}
+	if tr.Frac != .25 {
+		t.Errorf("region did not contract on stagnation: frac %v", tr.Frac)
+	}
+	b := tr.Bounds([]float64{1, 5})
+	if b.Low[0] != 0 || b.Up[0] != 3.5 || b.Low[1] != 2.5 || b.Up[1] != 7.5 {
+		t.Errorf("wrong region bounds %v", b)
+	}
+
+	optim.Rand = rand.New(rand.NewSource(1))
+	fn := bench.Rosenbrock{NDim: 2}
+	low, up := fn.Bounds()
+	tr = NewTrustRegion(low, up)
+	m := New(NewPopulationRand(20, low, up), VmaxBounds(low, up), Trust(tr))
+	mesh := &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up}
+	var outside int
+	obj := optim.Func(func(v []float64) float64 {
+		if !mesh.Bounds().Contains(v) {
+			outside++
+		}
+		return fn.Eval(v)
+	})
+	for i := 0; i < 50; i++ {
+		m.Iterate(obj, mesh)
+	}
+	region := mesh.Bounds()
+	if outside > 0 {
+		t.Errorf("%v evaluations outside the trust region", outside)
+	}
+	if w := region.Widths(); w[0] >= up[0]-low[0] && w[1] >= up[1]-low[1] && tr.Frac < .5 {
+		t.Errorf("mesh bounds not coupled to trust region: %v", region)
+	}
+	t.Logf("best %v, region frac %v", m.globalBest(), tr.Frac)
+}
+
+func TestCharged(t *testing.T) {
+	a := &Particle{Point: &optim.Point{Pos: []float64{0, 0}}, Charge: 2}
+	b := &Particle{Point: &optim.Point{Pos: []float64{1, 0}}, Charge: 2}
+	c := &Particle{Point: &optim.Point{Pos: []float64{0, 1}}}
+	Population{a, b, c}.repel(.5, 10)
+	if a.acc[0] != -4 || a.acc[1] != 0 || b.acc[0] != 4 {
+		t.Errorf("wrong repulsion: a %v, b %v", a.acc, b.acc)
+	} else if c.acc != nil {
+		t.Errorf("neutral particle accelerated: %v", c.acc)
+	}
+
+	// charged particles should keep their distance from the optimum while
+	// the neutral swarm collapses onto it
+	fn := bench.Sphere{NDim: 2}
+	low, up := fn.Bounds()
+	spread := func(opts ...Option) float64 {
+		optim.Rand = rand.New(rand.NewSource(1))
+		m := New(NewPopulationRand(20, low, up), append(opts, VmaxBounds(low, up))...)
+		for i := 0; i < 200; i++ {
+			m.Iterate(optim.Func(fn.Eval), nil)
+		}
+		dist := 0.0
+		for _, p := range m.Pop {
+			dist += math.Hypot(p.Pos[0], p.Pos[1]) / float64(len(m.Pop))
+		}
+		return dist
+	}
+	neutral, charged := spread(), spread(Charged(.5, 1, .1, 100))
+	t.Logf("mean distance from optimum: neutral %v, charged %v", neutral, charged)
+	if charged < 10*neutral {
+		t.Errorf("charged swarm collapsed: mean distance %v, neutral %v", charged, neutral)
+	}
+}
+
+func TestMutation(t *testing.T) {
+	optim.Rand = rand.New(rand.NewSource(1))
+	fn := bench.Ackley{}
+	low, up := fn.Bounds()
+	m := New(NewPopulationRand(20, low, up), VmaxBounds(low, up), Mutation(optim.Cauchy{Scale: .1}, .1))
+	mesh := &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up}
+	var best *optim.Point
+	for i := 0; i < 200; i++ {
+		best, _, _ = m.Iterate(optim.Func(fn.Eval), mesh)
+	}
+	if best.Val > fn.Tol() {
+		t.Errorf("mutating swarm failed: want < %v, got %v", fn.Tol(), best)
+	}
+}
+
+func TestMutationRng(t *testing.T) {
+	fn := bench.Ackley{}
+	low, up := fn.Bounds()
+	run := func(seed int64) []float64 {
+		r := rand.New(rand.NewSource(5))
+		m := New(NewPopulationRandFrom(r, 10, low, up), VmaxBounds(low, up), Rng(r), Mutation(optim.Gaussian{Scale: .5}, 1))
+		mesh := &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up}
+		optim.Rand = rand.New(rand.NewSource(seed))
+		for i := 0; i < 5; i++ {
+			m.Iterate(optim.Func(fn.Eval), mesh)
+		}
+		return m.Pop[0].Pos
+	}
+	if a, b := run(1), run(2); !reflect.DeepEqual(a, b) {
+		t.Errorf("mutation ignored the swarm's rng: %v != %v", a, b)
+	}
+}
@@ -0,0 +1,98 @@
+package swarm
+
+import (
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// TrustRegion restricts a swarm's search to a box around the incumbent
+// (global best) point.  The box expands after iterations that improve the
+// incumbent and contracts after MaxStall consecutive iterations without
+// improvement, concentrating late-stage evaluations around the incumbent
+// while letting successful runs widen their search again.  Particles keep
+// moving freely - only the positions they are evaluated at are clipped to
+// the box.  When the mesh passed to Iterate is an *optim.BoxMesh, its bounds
+// are kept equal to the box so other methods sharing the mesh (e.g. a
+// pattern search poll) are restricted too.
+type TrustRegion struct {
+	// Low and Up are the full problem bounds the box is clipped to.
+	Low, Up []float64
+	// Frac is the current box half-width as a fraction of the full bounds'
+	// widths.
+	Frac float64
+	// MinFrac and MaxFrac limit Frac.
+	MinFrac, MaxFrac float64
+	// Grow and Shrink are the factors Frac is multiplied by on improvement
+	// and on stagnation respectively.
+	Grow, Shrink float64
+	// MaxStall is the number of consecutive iterations without improvement
+	// after which the box contracts.
+	MaxStall int
+	stall    int
+	best     float64
+}
+
+// NewTrustRegion returns a trust region within the bounds low and up with
+// an initial half-width of a quarter of the bounds' widths that doubles on
+// improvement and halves after 3 iterations without improvement.
+func NewTrustRegion(low, up []float64) *TrustRegion {
+	return &TrustRegion{
+		Low:      low,
+		Up:       up,
+		Frac:     .25,
+		MinFrac:  1e-3,
+		MaxFrac:  1,
+		Grow:     2,
+		Shrink:   .5,
+		MaxStall: 3,
+		best:     math.Inf(1),
+	}
+}
+
+// Update adjusts the box size given the incumbent after an iteration.
+func (tr *TrustRegion) Update(best *optim.Point) {
+	if best.Val < tr.best {
+		tr.best = best.Val
+		tr.stall = 0
+		tr.Frac = math.Min(tr.MaxFrac, tr.Frac*tr.Grow)
+		return
+	}
+	tr.stall++
+	if tr.stall >= tr.MaxStall {
+		tr.stall = 0
+		tr.Frac = math.Max(tr.MinFrac, tr.Frac*tr.Shrink)
+	}
+}
+
+// Bounds returns the box around center clipped to the full bounds.
+func (tr *TrustRegion) Bounds(center []float64) optim.Bounds {
+	full := optim.Bounds{Low: tr.Low, Up: tr.Up}
+	low, up := make([]float64, len(center)), make([]float64, len(center))
+	for i, w := range full.Widths() {
+		low[i] = center[i] - tr.Frac*w
+		up[i] = center[i] + tr.Frac*w
+	}
+	return optim.Bounds{Low: full.Clip(low), Up: full.Clip(up)}
+}
+
+// Trust restricts the swarm's search to the trust region tr.
+func Trust(tr *TrustRegion) Option { return func(m *Method) { m.Trust = tr } }
+
+// restrict clips points to the trust region around the incumbent and
+// couples a box mesh's bounds to it.
+func (m *Method) restrict(points []*optim.Point, mesh optim.Mesh) {
+	m.mu.Lock()
+	center, val := m.best.Pos, m.best.Val
+	m.mu.Unlock()
+	if center == nil || math.IsInf(val, 1) {
+		return // nothing evaluated yet
+	}
+	b := m.Trust.Bounds(center)
+	if bm, ok := mesh.(*optim.BoxMesh); ok {
+		bm.Lower, bm.Upper = b.Low, b.Up
+	}
+	for _, p := range points {
+		p.Pos = b.Clip(p.Pos)
+	}
+}
@@ -0,0 +1,322 @@
+// Package swarmx provides additional nature-inspired population methods
+// for comparison with the particle swarm in package swarm:
+//
+//   - GreyWolf: Mirjalili, Seyedali, Seyed Mohammad Mirjalili, and Andrew
+//     Lewis. "Grey wolf optimizer." Advances in Engineering Software 69
+//     (2014): 46-61.
+//   - Whale: Mirjalili, Seyedali, and Andrew Lewis. "The whale optimization
+//     algorithm." Advances in Engineering Software 95 (2016): 51-67.
+//   - Firefly: Yang, Xin-She. "Firefly algorithms for multimodal
+//     optimization." International Symposium on Stochastic Algorithms.
+//     Springer, 2009.
+//   - APSO: Yang, Xin-She, Suash Deb, and Simon Fong. "Accelerated particle
+//     swarm optimization and support vector machine for business
+//     optimization and applications." International Conference on
+//     Networked Digital Technologies. Springer, 2011.
+//
+// All methods evaluate their entire population once per iteration using
+// the configured Evaler.  GreyWolf and Whale shift from exploration to
+// exploitation over MaxIter iterations.
+package swarmx
+
+import (
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+type Option func(*base)
+
+func Evaler(e optim.Evaler) Option { return func(b *base) { b.Evaler = e } }
+
+// MaxIter sets the number of iterations over which exploration is reduced
+// to pure exploitation.  It should usually be set to the expected
+// iteration budget.
+func MaxIter(n int) Option { return func(b *base) { b.MaxIter = n } }
+
+type base struct {
+	Pop []*optim.Point
+	optim.Evaler
+	MaxIter int
+	iter    int
+	best    *optim.Point
+}
+
+func newBase(pop []*optim.Point, opts []Option) base {
+	b := base{
+		Pop:     pop,
+		Evaler:  optim.SerialEvaler{},
+		MaxIter: 1000,
+		best:    &optim.Point{Val: math.Inf(1)},
+	}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	return b
+}
+
+func (b *base) AddPoint(p *optim.Point) {
+	if p.Val < b.best.Val {
+		b.best = p
+	}
+}
+
+// Inject replaces the worst population members with points that are no
+// worse.  Unevaluated points are evaluated in the next iteration.
+func (b *base) Inject(points ...*optim.Point) {
+	for _, p := range points {
+		optim.ReplaceWorst(b.Pop, p.Clone())
+		b.AddPoint(p)
+	}
+}
+
+// progress returns the fraction of MaxIter iterations completed.
+func (b *base) progress() float64 {
+	return math.Min(float64(b.iter)/float64(b.MaxIter), 1)
+}
+
+// eval projects pts onto mesh and evaluates them.  Points the evaler didn't
+// evaluate (e.g. duplicates) are left with infinite values.
+func (b *base) eval(obj optim.Objectiver, mesh optim.Mesh, pts []*optim.Point) (n int, err error) {
+	for _, p := range pts {
+		p.Val = math.Inf(1)
+		if mesh != nil {
+			p.Pos = mesh.Nearest(p.Pos)
+		}
+	}
+	results, n, err := optim.DedupEvaler{Evaler: b.Evaler}.Eval(obj, pts...)
+	for _, p := range results {
+		if p.Val < b.best.Val {
+			b.best = p.Clone()
+		}
+	}
+	return n, err
+}
+
+// leaders returns the n best distinct points among pts and prev.
+func leaders(n int, pts, prev []*optim.Point) []*optim.Point {
+	all := append(append([]*optim.Point{}, pts...), prev...)
+	elite := optim.Elite(all, n, 1e-300)
+	for i, p := range elite {
+		elite[i] = p.Clone()
+	}
+	return elite
+}
+
+// GreyWolf moves each wolf toward positions estimated from the three best
+// wolves (alpha, beta, and delta) found so far.
+type GreyWolf struct {
+	base
+	lead []*optim.Point
+}
+
+func NewGreyWolf(pop []*optim.Point, opts ...Option) *GreyWolf {
+	return &GreyWolf{base: newBase(pop, opts)}
+}
+
+func (m *GreyWolf) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Point, n int, err error) {
+	if m.lead == nil {
+		if n, err = m.eval(obj, mesh, m.Pop); err != nil {
+			return m.best, n, err
+		}
+		m.lead = leaders(3, m.Pop, nil)
+	}
+
+	a := 2 - 2*m.progress()
+	for _, w := range m.Pop {
+		for d := range w.Pos {
+			x := 0.0
+			for _, l := range m.lead {
+				A := 2*a*optim.RandFloat() - a
+				C := 2 * optim.RandFloat()
+				x += l.Pos[d] - A*math.Abs(C*l.Pos[d]-w.Pos[d])
+			}
+			w.Pos[d] = x / float64(len(m.lead))
+		}
+	}
+
+	nn, err := m.eval(obj, mesh, m.Pop)
+	n += nn
+	m.lead = leaders(3, m.Pop, m.lead)
+	m.iter++
+	return m.best, n, err
+}
+
+// Whale moves each whale by either encircling the best whale, searching
+// relative to a random whale, or spiraling in toward the best whale.
+type Whale struct {
+	base
+	// Spiral is the logarithmic spiral shape constant.
+	Spiral float64
+	init   bool
+}
+
+func NewWhale(pop []*optim.Point, opts ...Option) *Whale {
+	return &Whale{base: newBase(pop, opts), Spiral: 1}
+}
+
+func (m *Whale) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Point, n int, err error) {
+	if !m.init {
+		m.init = true
+		if n, err = m.eval(obj, mesh, m.Pop); err != nil {
+			return m.best, n, err
+		}
+	}
+
+	a := 2 - 2*m.progress()
+	lead := m.best.Clone()
+	for _, w := range m.Pop {
+		A := 2*a*optim.RandFloat() - a
+		C := 2 * optim.RandFloat()
+		if optim.RandFloat() < 0.5 {
+			target := lead
+			if math.Abs(A) >= 1 {
+				target = m.Pop[optim.Rand.Intn(len(m.Pop))].Clone()
+			}
+			for d := range w.Pos {
+				w.Pos[d] = target.Pos[d] - A*math.Abs(C*target.Pos[d]-w.Pos[d])
+			}
+		} else {
+			l := 2*optim.RandFloat() - 1
+			spiral := math.Exp(m.Spiral*l) * math.Cos(2*math.Pi*l)
+			for d := range w.Pos {
+				w.Pos[d] = math.Abs(lead.Pos[d]-w.Pos[d])*spiral + lead.Pos[d]
+			}
+		}
+	}
+
+	nn, err := m.eval(obj, mesh, m.Pop)
+	n += nn
+	m.iter++
+	return m.best, n, err
+}
+
+// Firefly moves each firefly toward every brighter (better) firefly with
+// an attraction that decays with distance, plus a random walk that shrinks
+// each iteration.
+type Firefly struct {
+	base
+	// Beta0 is the attraction at zero distance.
+	Beta0 float64
+	// Gamma is the light absorption coefficient controlling how quickly
+	// attraction decays with distance.  It is applied to distances scaled
+	// by Scale.
+	Gamma float64
+	// Alpha is the random walk magnitude as a fraction of Scale.  It is
+	// multiplied by Damp after every iteration.
+	Alpha, Damp float64
+	// Scale holds the characteristic length of each dimension - usually
+	// the width of the search bounds.
+	Scale []float64
+	init  bool
+}
+
+// NewFirefly creates a firefly method for the search region bounded by low
+// and up.
+func NewFirefly(pop []*optim.Point, low, up []float64, opts ...Option) *Firefly {
+	scale := optim.Bounds{Low: low, Up: up}.Widths()
+	return &Firefly{
+		base:  newBase(pop, opts),
+		Beta0: 1,
+		Gamma: 1,
+		Alpha: 0.2,
+		Damp:  0.97,
+		Scale: scale,
+	}
+}
+
+func (m *Firefly) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Point, n int, err error) {
+	if !m.init {
+		m.init = true
+		if n, err = m.eval(obj, mesh, m.Pop); err != nil {
+			return m.best, n, err
+		}
+	}
+
+	// move against a snapshot so all fireflies see the same landscape
+	prev := make([]*optim.Point, len(m.Pop))
+	for i, p := range m.Pop {
+		prev[i] = p.Clone()
+	}
+	for i, f := range m.Pop {
+		for _, other := range prev {
+			if other.Val >= prev[i].Val {
+				continue
+			}
+			r2 := 0.0
+			for d := range f.Pos {
+				dx := (other.Pos[d] - f.Pos[d]) / m.Scale[d]
+				r2 += dx * dx
+			}
+			beta := m.Beta0 * math.Exp(-m.Gamma*r2)
+			for d := range f.Pos {
+				f.Pos[d] += beta * (other.Pos[d] - f.Pos[d])
+			}
+		}
+		for d := range f.Pos {
+			f.Pos[d] += m.Alpha * (optim.RandFloat() - 0.5) * m.Scale[d]
+		}
+	}
+
+	nn, err := m.eval(obj, mesh, m.Pop)
+	n += nn
+	m.Alpha *= m.Damp
+	m.iter++
+	return m.best, n, err
+}
+
+// APSO is the accelerated particle swarm.  Each particle moves directly
+// toward the global best plus a random perturbation that shrinks every
+// iteration:
+//
+//	x = (1-Beta)*x + Beta*gbest + Alpha*N(0,1)*Scale
+//
+// Particles keep no velocity or personal best, so APSO needs only a single
+// position per particle and suits very large populations.
+type APSO struct {
+	base
+	// Beta is the attraction toward the global best.
+	Beta float64
+	// Alpha is the perturbation magnitude as a fraction of Scale.  It is
+	// multiplied by Damp after every iteration.
+	Alpha, Damp float64
+	// Scale holds the characteristic length of each dimension - usually
+	// the width of the search bounds.
+	Scale []float64
+	init  bool
+}
+
+// NewAPSO creates an accelerated particle swarm for the search region
+// bounded by low and up.
+func NewAPSO(pop []*optim.Point, low, up []float64, opts ...Option) *APSO {
+	scale := optim.Bounds{Low: low, Up: up}.Widths()
+	return &APSO{
+		base:  newBase(pop, opts),
+		Beta:  0.5,
+		Alpha: 0.2,
+		Damp:  0.97,
+		Scale: scale,
+	}
+}
+
+func (m *APSO) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Point, n int, err error) {
+	if !m.init {
+		m.init = true
+		if n, err = m.eval(obj, mesh, m.Pop); err != nil {
+			return m.best, n, err
+		}
+	}
+
+	g := m.best.Pos
+	for _, p := range m.Pop {
+		for d := range p.Pos {
+			p.Pos[d] = (1-m.Beta)*p.Pos[d] + m.Beta*g[d] + m.Alpha*optim.RandNorm()*m.Scale[d]
+		}
+	}
+
+	nn, err := m.eval(obj, mesh, m.Pop)
+	n += nn
+	m.Alpha *= m.Damp
+	m.iter++
+	return m.best, n, err
+}
@@ -0,0 +1,55 @@
+package swarmx
+
+import (
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+)
+
+func TestGreyWolf(t *testing.T) {
+	fn := bench.Ackley{}
+	low, up := fn.Bounds()
+	testsolve(t, fn, NewGreyWolf(optim.RandPop(20, low, up), MaxIter(200)))
+}
+
+func TestWhale(t *testing.T) {
+	fn := bench.Ackley{}
+	low, up := fn.Bounds()
+	testsolve(t, fn, NewWhale(optim.RandPop(20, low, up), MaxIter(200)))
+}
+
+func TestFirefly(t *testing.T) {
+	fn := bench.Ackley{}
+	low, up := fn.Bounds()
+	testsolve(t, fn, NewFirefly(optim.RandPop(20, low, up), low, up))
+}
+
+func TestAPSO(t *testing.T) {
+	fn := bench.Ackley{}
+	low, up := fn.Bounds()
+	testsolve(t, fn, NewAPSO(optim.RandPop(20, low, up), low, up))
+}
+
+func testsolve(t *testing.T, fn bench.Func, m optim.Method) {
+	low, up := fn.Bounds()
+	solv := &optim.Solver{
+		Method:  m,
+		Obj:     optim.Func(fn.Eval),
+		Mesh:    &optim.BoxMesh{Mesh: &optim.InfMesh{}, Lower: low, Upper: up},
+		MaxEval: 4000,
+	}
+	for solv.Next() {
+		if solv.Best().Val < fn.Tol() {
+			break
+		}
+	}
+	if err := solv.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("%v evals: want < %v, got %v", solv.Neval(), fn.Tol(), solv.Best().Val)
+	if solv.Best().Val > fn.Tol() {
+		t.Errorf("failed to solve %v: want < %v, got %v", fn.Name(), fn.Tol(), solv.Best().Val)
+	}
+}
@@ -0,0 +1,273 @@
+// Package tpe provides a tree-structured Parzen estimator (TPE) sequential
+// model-based optimization method as described in:
+//
+//	Bergstra, James S., et al. "Algorithms for hyper-parameter
+//	optimization." Advances in Neural Information Processing Systems. 2011.
+//
+// Evaluated points are split into a good group (the best Gamma fraction)
+// and a bad group.  Each dimension's good and bad densities l(x) and g(x)
+// are modeled independently with Parzen (kernel density) estimators, and
+// new points are chosen by sampling candidates from l(x) and keeping the one
+// maximizing l(x)/g(x) which is proportional to expected improvement.
+package tpe
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/transform"
+)
+
+// Dim describes the search domain for a single dimension.
+type Dim struct {
+	Low, Up float64
+	// Log causes the dimension to be modeled on a logarithmic scale.  Low
+	// and Up must both be positive.
+	Log bool
+	// Categories, if non-zero, makes the dimension categorical taking the
+	// integer values 0, 1, ..., Categories-1 (Low and Up are ignored).
+	// Categories are modeled with smoothed frequency counts and have no
+	// notion of ordering.
+	Categories int
+}
+
+// Continuous returns Dims for continuous, linearly scaled dimensions bounded
+// by low and up.
+func Continuous(low, up []float64) []Dim {
+	dims := make([]Dim, len(low))
+	for i := range dims {
+		dims[i] = Dim{Low: low[i], Up: up[i]}
+	}
+	return dims
+}
+
+// transform returns the mapping between the unit interval the density is
+// modeled on and the dimension's domain.
+func (d Dim) transform() transform.Transform {
+	if d.Log {
+		return transform.Log{Low: d.Low, Up: d.Up}
+	}
+	return transform.Unit(d.Low, d.Up)
+}
+
+type Option func(*Method)
+
+// Gamma sets the fraction of evaluated points considered good.
+func Gamma(g float64) Option { return func(m *Method) { m.Gamma = g } }
+
+// Startup sets the number of uniformly random points evaluated before the
+// density models are used.
+func Startup(n int) Option { return func(m *Method) { m.NStartup = n } }
+
+// Candidates sets the number of candidates sampled from the good density
+// for each proposed point.
+func Candidates(n int) Option { return func(m *Method) { m.NCandidates = n } }
+
+// Batch sets the number of points proposed and evaluated per iteration.
+func Batch(n int) Option { return func(m *Method) { m.NBatch = n } }
+
+func Evaler(e optim.Evaler) Option { return func(m *Method) { m.Evaler = e } }
+
+type Method struct {
+	Dims        []Dim
+	Gamma       float64
+	NStartup    int
+	NCandidates int
+	NBatch      int
+	optim.Evaler
+	// History holds every point evaluated or added so far.
+	History []*optim.Point
+	best    *optim.Point
+}
+
+func New(dims []Dim, opts ...Option) *Method {
+	m := &Method{
+		Dims:        dims,
+		Gamma:       0.25,
+		NStartup:    10,
+		NCandidates: 24,
+		NBatch:      1,
+		Evaler:      optim.SerialEvaler{},
+		best:        &optim.Point{Val: math.Inf(1)},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// AddPoint adds p to the method's history for use in the density models.
+// This can be used to warm start the method with previous evaluations.
+func (m *Method) AddPoint(p *optim.Point) {
+	m.History = append(m.History, p)
+	if p.Val < m.best.Val {
+		m.best = p
+	}
+}
+
+func (m *Method) Iterate(obj optim.Objectiver, mesh optim.Mesh) (best *optim.Point, n int, err error) {
+	var good, bad []*optim.Point
+	if len(m.History) >= m.NStartup {
+		sorted := optim.Elite(m.History, len(m.History), 0)
+		ngood := int(math.Ceil(m.Gamma * float64(len(sorted))))
+		good, bad = sorted[:ngood], sorted[ngood:]
+	}
+
+	points := make([]*optim.Point, m.NBatch)
+	for i := range points {
+		pos := make([]float64, len(m.Dims))
+		for d, dim := range m.Dims {
+			if good == nil {
+				pos[d] = m.uniform(dim)
+			} else {
+				pos[d] = m.propose(dim, column(good, d), column(bad, d))
+			}
+		}
+		if mesh != nil {
+			pos = mesh.Nearest(pos)
+		}
+		points[i] = &optim.Point{Pos: pos, Val: math.Inf(1)}
+	}
+
+	results, n, err := optim.DedupEvaler{Evaler: m.Evaler}.Eval(obj, points...)
+	for _, p := range results {
+		m.AddPoint(p)
+	}
+	return m.best, n, err
+}
+
+func (m *Method) uniform(dim Dim) float64 {
+	if dim.Categories > 0 {
+		return float64(optim.Rand.Intn(dim.Categories))
+	}
+	return dim.transform().Forward(optim.RandFloat())
+}
+
+// propose samples NCandidates values from the good density and returns the
+// one maximizing l(x)/g(x).
+func (m *Method) propose(dim Dim, good, bad []float64) float64 {
+	var l, g density
+	if dim.Categories > 0 {
+		l, g = newCategorical(dim.Categories, good), newCategorical(dim.Categories, bad)
+	} else {
+		t := dim.transform()
+		l, g = newParzen(unit(t, good)), newParzen(unit(t, bad))
+	}
+
+	bestx, bestratio := 0.0, math.Inf(-1)
+	for i := 0; i < m.NCandidates; i++ {
+		x := l.sample()
+		if ratio := l.logpdf(x) - g.logpdf(x); ratio > bestratio {
+			bestx, bestratio = x, ratio
+		}
+	}
+	if dim.Categories > 0 {
+		return bestx
+	}
+	return dim.transform().Forward(bestx)
+}
+
+func column(pts []*optim.Point, d int) []float64 {
+	col := make([]float64, len(pts))
+	for i, p := range pts {
+		col[i] = p.Pos[d]
+	}
+	return col
+}
+
+func unit(t transform.Transform, xs []float64) []float64 {
+	us := make([]float64, len(xs))
+	for i, x := range xs {
+		us[i] = t.Inverse(x)
+	}
+	return us
+}
+
+type density interface {
+	sample() float64
+	logpdf(x float64) float64
+}
+
+// parzen is a gaussian kernel density estimator on the unit interval with
+// an additional broad prior component centered on the interval.  Each
+// kernel's bandwidth is the larger distance to its sorted neighbors, as in
+// Bergstra et al.
+type parzen struct {
+	mus, sigmas []float64
+}
+
+func newParzen(xs []float64) *parzen {
+	mus := append([]float64{}, xs...)
+	sort.Float64s(mus)
+
+	minsigma := 1 / math.Min(100, float64(len(mus)+2))
+	sigmas := make([]float64, len(mus))
+	for i := range mus {
+		left, right := mus[i], 1-mus[i]
+		if i > 0 {
+			left = mus[i] - mus[i-1]
+		}
+		if i < len(mus)-1 {
+			right = mus[i+1] - mus[i]
+		}
+		sigmas[i] = math.Min(math.Max(math.Max(left, right), minsigma), 1)
+	}
+
+	// add the prior component
+	mus = append(mus, 0.5)
+	sigmas = append(sigmas, 1)
+	return &parzen{mus: mus, sigmas: sigmas}
+}
+
+func (p *parzen) sample() float64 {
+	i := optim.Rand.Intn(len(p.mus))
+	x := p.mus[i] + p.sigmas[i]*optim.RandNorm()
+	return math.Min(math.Max(x, 0), 1)
+}
+
+func (p *parzen) logpdf(x float64) float64 {
+	sum := 0.0
+	for i, mu := range p.mus {
+		z := (x - mu) / p.sigmas[i]
+		sum += math.Exp(-z*z/2) / (p.sigmas[i] * math.Sqrt(2*math.Pi))
+	}
+	return math.Log(sum / float64(len(p.mus)))
+}
+
+// categorical is a frequency-count distribution with add-one smoothing.
+type categorical struct {
+	probs []float64
+}
+
+func newCategorical(ncat int, xs []float64) *categorical {
+	probs := make([]float64, ncat)
+	for i := range probs {
+		probs[i] = 1 / float64(len(xs)+ncat)
+	}
+	for _, x := range xs {
+		if c := int(x); c >= 0 && c < ncat {
+			probs[c] += 1 / float64(len(xs)+ncat)
+		}
+	}
+	return &categorical{probs: probs}
+}
+
+func (c *categorical) sample() float64 {
+	u := optim.RandFloat()
+	cum := 0.0
+	for i, p := range c.probs {
+		cum += p
+		if u < cum {
+			return float64(i)
+		}
+	}
+	return float64(len(c.probs) - 1)
+}
+
+func (c *categorical) logpdf(x float64) float64 {
+	if i := int(x); i >= 0 && i < len(c.probs) {
+		return math.Log(c.probs[i])
+	}
+	return math.Inf(-1)
+}
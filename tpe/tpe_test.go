@@ -0,0 +1,65 @@
+package tpe
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+func TestContinuous(t *testing.T) {
+	obj := optim.Func(func(x []float64) float64 {
+		return (x[0]-1)*(x[0]-1) + (x[1]+2)*(x[1]+2)
+	})
+	m := New(Continuous([]float64{-5, -5}, []float64{5, 5}))
+	solv := &optim.Solver{Method: m, Obj: obj, MaxEval: 300}
+	for solv.Next() {
+	}
+	if err := solv.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if solv.Best().Val > 0.1 {
+		t.Errorf("want < 0.1, got %v", solv.Best())
+	}
+}
+
+func TestLogCategorical(t *testing.T) {
+	// minimum at x[0] = 1e-3 (log scale) and category 2
+	obj := optim.Func(func(x []float64) float64 {
+		v := math.Pow(math.Log10(x[0])+3, 2)
+		if x[1] != 2 {
+			v += 1
+		}
+		return v
+	})
+	dims := []Dim{{Low: 1e-6, Up: 1, Log: true}, {Categories: 4}}
+	m := New(dims)
+	solv := &optim.Solver{Method: m, Obj: obj, MaxEval: 200}
+	for solv.Next() {
+	}
+	if err := solv.Err(); err != nil {
+		t.Fatal(err)
+	}
+	best := solv.Best()
+	if best.Pos[1] != 2 || best.Val > 0.05 {
+		t.Errorf("want category 2 and val < 0.05, got %v", best)
+	}
+	for _, p := range m.History {
+		if p.Pos[0] < 1e-6 || p.Pos[0] > 1 {
+			t.Errorf("point outside log bounds: %v", p)
+		}
+		if c := p.Pos[1]; c != math.Floor(c) || c < 0 || c > 3 {
+			t.Errorf("invalid category: %v", p)
+		}
+	}
+}
+
+func TestCategorical(t *testing.T) {
+	c := newCategorical(3, []float64{1, 1, 1, 0})
+	want := []float64{2.0 / 7, 4.0 / 7, 1.0 / 7}
+	for i, p := range c.probs {
+		if math.Abs(p-want[i]) > 1e-12 {
+			t.Errorf("category %v: want prob %v, got %v", i, want[i], p)
+		}
+	}
+}
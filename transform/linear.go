@@ -0,0 +1,169 @@
+package transform
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gonum/matrix"
+	"github.com/gonum/matrix/mat64"
+	"github.com/rwcarlsen/optim"
+)
+
+// eqTol is the tolerance below which values are treated as zero when
+// eliminating equality constraints.
+const eqTol = 1e-10
+
+// Equality reparameterizes a problem with linear equality constraints Ax = b
+// in terms of reduced coordinates z such that x = X0 + N*z satisfies the
+// constraints for every z.  N is an orthonormal basis for the null space of
+// A.  This turns an equality constrained problem into an unconstrained
+// problem in fewer variables.
+type Equality struct {
+	// X0 is the minimum-norm solution of Ax = b.
+	X0 []float64
+	// Null holds an orthonormal basis for the null space of A in its
+	// columns.
+	Null *mat64.Dense
+	// v holds the right singular vectors of A - the first rank columns
+	// span the row space of A and the rest span its null space.
+	v    *mat64.Dense
+	rank int
+}
+
+// NewEquality computes the reparameterization for Ax = b from the singular
+// value decomposition of A.  Singular values below eqTol relative to the
+// largest are treated as zero.  It returns an error if the constraints are
+// inconsistent or leave no free variables.
+func NewEquality(A *mat64.Dense, b []float64) (*Equality, error) {
+	m, n := A.Dims()
+	if len(b) != m {
+		return nil, errors.New("transform: len(b) does not match rows of A")
+	}
+
+	var svd mat64.SVD
+	if !svd.Factorize(A, matrix.SVDFull) {
+		return nil, errors.New("transform: singular value decomposition of A failed")
+	}
+	sv := svd.Values(nil)
+	u, v := &mat64.Dense{}, &mat64.Dense{}
+	u.UFromSVD(&svd)
+	v.VFromSVD(&svd)
+
+	tol := eqTol
+	if len(sv) > 0 {
+		tol *= math.Max(1, sv[0])
+	}
+	rank := 0
+	for rank < len(sv) && sv[rank] > tol {
+		rank++
+	}
+	if rank == n {
+		return nil, errors.New("transform: equality constraints leave no free variables")
+	}
+
+	// minimum-norm solution x0 = V * S^+ * U^T * b
+	x0 := make([]float64, n)
+	for k := 0; k < rank; k++ {
+		c := dot(u.Col(nil, k), b) / sv[k]
+		for i := range x0 {
+			x0[i] += c * v.At(i, k)
+		}
+	}
+
+	// x0 only satisfies inconsistent constraints in a least squares sense
+	bnorm := math.Sqrt(dot(b, b))
+	for i := 0; i < m; i++ {
+		r := -b[i]
+		for j := 0; j < n; j++ {
+			r += A.At(i, j) * x0[j]
+		}
+		if math.Abs(r) > eqTol*math.Max(1, bnorm) {
+			return nil, errors.New("transform: equality constraints are inconsistent")
+		}
+	}
+
+	N := mat64.NewDense(n, n-rank, nil)
+	for i := 0; i < n; i++ {
+		for j := rank; j < n; j++ {
+			N.Set(i, j-rank, v.At(i, j))
+		}
+	}
+	return &Equality{X0: x0, Null: N, v: v, rank: rank}, nil
+}
+
+// Dims returns the number of reduced coordinates.
+func (e *Equality) Dims() int {
+	_, k := e.Null.Dims()
+	return k
+}
+
+// Expand returns the full-space point X0 + N*z.
+func (e *Equality) Expand(z []float64) []float64 {
+	n, k := e.Null.Dims()
+	if len(z) != k {
+		panic("transform: reduced point has wrong number of dimensions")
+	}
+	x := append([]float64{}, e.X0...)
+	for i := 0; i < n; i++ {
+		for j := 0; j < k; j++ {
+			x[i] += e.Null.At(i, j) * z[j]
+		}
+	}
+	return x
+}
+
+// Reduce returns the reduced coordinates of full-space point x.  If x
+// violates the constraints, the reduced coordinates of its orthogonal
+// projection onto the constraint set are returned.
+func (e *Equality) Reduce(x []float64) []float64 {
+	n, k := e.Null.Dims()
+	z := make([]float64, k)
+	for j := 0; j < k; j++ {
+		for i := 0; i < n; i++ {
+			z[j] += e.Null.At(i, j) * (x[i] - e.X0[i])
+		}
+	}
+	return z
+}
+
+// Point returns a copy of reduced point p expanded to full dimension.
+func (e *Equality) Point(p *optim.Point) *optim.Point {
+	return &optim.Point{Pos: e.Expand(p.Pos), Val: p.Val}
+}
+
+// MeshBasis returns an orthonormal full-space basis whose first Dims()
+// columns span the null space of A with the remaining columns spanning the
+// row space of A.  Used as the Basis of an optim.InfMesh centered at X0, the
+// mesh's grid points along the null space axes all satisfy the constraints.
+func (e *Equality) MeshBasis() *mat64.Dense {
+	n, k := e.Null.Dims()
+	B := mat64.NewDense(n, n, nil)
+	for i := 0; i < n; i++ {
+		for j := 0; j < k; j++ {
+			B.Set(i, j, e.Null.At(i, j))
+		}
+		for j := 0; j < e.rank; j++ {
+			B.Set(i, k+j, e.v.At(i, j))
+		}
+	}
+	return B
+}
+
+// EqObjective evaluates reduced points by expanding them to full dimension
+// for evaluation by Obj.
+type EqObjective struct {
+	Obj optim.Objectiver
+	Eq  *Equality
+}
+
+func (o *EqObjective) Objective(z []float64) (float64, error) {
+	return o.Obj.Objective(o.Eq.Expand(z))
+}
+
+func dot(a, b []float64) float64 {
+	tot := 0.0
+	for i := range a {
+		tot += a[i] * b[i]
+	}
+	return tot
+}
@@ -0,0 +1,92 @@
+package transform
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/rwcarlsen/optim"
+)
+
+// constraints x0 + x1 + x2 + x3 = 4 and x0 - x3 = 1
+var (
+	eqA = mat64.NewDense(2, 4, []float64{1, 1, 1, 1, 1, 0, 0, -1})
+	eqb = []float64{4, 1}
+)
+
+func satisfies(t *testing.T, x []float64) {
+	for i := range eqb {
+		tot := 0.0
+		for j := range x {
+			tot += eqA.At(i, j) * x[j]
+		}
+		if math.Abs(tot-eqb[i]) > 1e-9 {
+			t.Errorf("point %v violates constraint %v: got %v, want %v", x, i, tot, eqb[i])
+		}
+	}
+}
+
+func TestEquality(t *testing.T) {
+	eq, err := NewEquality(eqA, eqb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eq.Dims() != 2 {
+		t.Fatalf("wrong reduced dims: want 2, got %v", eq.Dims())
+	}
+
+	for _, z := range [][]float64{{0, 0}, {1, -2}, {10, 3.5}} {
+		x := eq.Expand(z)
+		satisfies(t, x)
+		got := eq.Reduce(x)
+		for i := range z {
+			if math.Abs(got[i]-z[i]) > 1e-9 {
+				t.Errorf("round trip of %v gave %v", z, got)
+			}
+		}
+	}
+
+	_, err = NewEquality(mat64.NewDense(2, 2, []float64{1, 1, 2, 2}), []float64{1, 3})
+	if err == nil {
+		t.Errorf("inconsistent constraints did not return an error")
+	}
+
+	// redundant constraints don't remove free variables
+	eq, err = NewEquality(mat64.NewDense(2, 2, []float64{1, 1, 2, 2}), []float64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	} else if eq.Dims() != 1 {
+		t.Errorf("redundant constraints: want 1 reduced dim, got %v", eq.Dims())
+	}
+}
+
+func TestEqualityMeshBasis(t *testing.T) {
+	eq, err := NewEquality(eqA, eqb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mesh := &optim.InfMesh{StepSize: 0.3, Basis: eq.MeshBasis(), Center: eq.X0}
+
+	// grid points near feasible points must remain feasible
+	for _, z := range [][]float64{{0.1, 0.2}, {1.7, -2.2}} {
+		satisfies(t, mesh.Nearest(eq.Expand(z)))
+	}
+}
+
+func TestEqObjective(t *testing.T) {
+	eq, err := NewEquality(eqA, eqb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := &EqObjective{
+		Obj: optim.Func(func(x []float64) float64 { return x[0]*x[0] + x[1]*x[1] + x[2]*x[2] + x[3]*x[3] }),
+		Eq:  eq,
+	}
+
+	// the minimum-norm feasible point is X0 so z = 0 is optimal
+	v0, _ := obj.Objective([]float64{0, 0})
+	v1, _ := obj.Objective([]float64{0.1, -0.1})
+	if v0 >= v1 {
+		t.Errorf("X0 is not the minimum-norm solution: f(0) = %v, f(dz) = %v", v0, v1)
+	}
+}
@@ -0,0 +1,101 @@
+package transform
+
+import "github.com/rwcarlsen/optim"
+
+// Subspace freezes selected dimensions of a problem and presents only the
+// remaining free dimensions to solvers.  This is useful for staged
+// optimization and for checking sensitivity to a subset of variables.
+type Subspace struct {
+	// Values is a full-dimensional point holding the values frozen
+	// dimensions are fixed at.
+	Values []float64
+	// Free indicates which dimensions are presented to the solver.
+	Free []bool
+}
+
+// Fix returns a subspace of the full-dimensional point values with the
+// dimensions listed in fixed frozen.
+func Fix(values []float64, fixed ...int) *Subspace {
+	free := make([]bool, len(values))
+	for i := range free {
+		free[i] = true
+	}
+	for _, i := range fixed {
+		free[i] = false
+	}
+	return &Subspace{Values: append([]float64{}, values...), Free: free}
+}
+
+// Dims returns the number of free dimensions.
+func (s *Subspace) Dims() int {
+	n := 0
+	for _, free := range s.Free {
+		if free {
+			n++
+		}
+	}
+	return n
+}
+
+// Reduce returns the free dimensions of full.
+func (s *Subspace) Reduce(full []float64) []float64 {
+	x := make([]float64, 0, s.Dims())
+	for i, free := range s.Free {
+		if free {
+			x = append(x, full[i])
+		}
+	}
+	return x
+}
+
+// Expand returns the full-dimensional point for reduced point x.
+func (s *Subspace) Expand(x []float64) []float64 {
+	if len(x) != s.Dims() {
+		panic("transform: reduced point has wrong number of dimensions")
+	}
+	full := append([]float64{}, s.Values...)
+	j := 0
+	for i, free := range s.Free {
+		if free {
+			full[i] = x[j]
+			j++
+		}
+	}
+	return full
+}
+
+// Point returns a copy of reduced point p expanded to full dimension.
+func (s *Subspace) Point(p *optim.Point) *optim.Point {
+	return &optim.Point{Pos: s.Expand(p.Pos), Val: p.Val}
+}
+
+// SubObjective evaluates reduced points by expanding them to full dimension
+// for evaluation by Obj.
+type SubObjective struct {
+	Obj optim.Objectiver
+	Sub *Subspace
+}
+
+func (o *SubObjective) Objective(x []float64) (float64, error) {
+	return o.Obj.Objective(o.Sub.Expand(x))
+}
+
+// SubMesh presents a full-dimensional mesh to solvers operating on reduced
+// points.
+type SubMesh struct {
+	optim.Mesh
+	Sub *Subspace
+}
+
+func (m *SubMesh) Nearest(x []float64) []float64 {
+	return m.Sub.Reduce(m.Mesh.Nearest(m.Sub.Expand(x)))
+}
+
+func (m *SubMesh) Origin() []float64 {
+	if o := m.Mesh.Origin(); len(o) > 0 {
+		return m.Sub.Reduce(o)
+	}
+	return nil
+}
+
+func (m *SubMesh) SetOrigin(x []float64) { m.Mesh.SetOrigin(m.Sub.Expand(x)) }
@@ -0,0 +1,87 @@
+package transform
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+func TestSubspace(t *testing.T) {
+	sub := Fix([]float64{1, 2, 3, 4}, 0, 2)
+	if sub.Dims() != 2 {
+		t.Fatalf("wrong free dims: want 2, got %v", sub.Dims())
+	}
+
+	full := sub.Expand([]float64{7, 8})
+	want := []float64{1, 7, 3, 8}
+	for i := range want {
+		if full[i] != want[i] {
+			t.Errorf("expanded dim %v: want %v, got %v", i, want[i], full[i])
+		}
+	}
+	if x := sub.Reduce(full); x[0] != 7 || x[1] != 8 {
+		t.Errorf("reduce: want [7 8], got %v", x)
+	}
+}
+
+func TestSubspaceSolve(t *testing.T) {
+	// minimum of the full problem is at [1 2 3] - freezing x0 at 5 moves
+	// only x0
+	obj := optim.Func(func(v []float64) float64 {
+		return (v[0]-1)*(v[0]-1) + (v[1]-2)*(v[1]-2) + (v[2]-3)*(v[2]-3)
+	})
+	sub := Fix([]float64{5, 0, 0}, 0)
+	low, up := []float64{-10, -10, -10}, []float64{10, 10, 10}
+	mesh := &SubMesh{
+		Mesh: &optim.BoxMesh{Mesh: &optim.InfMesh{StepSize: 1}, Lower: low, Upper: up},
+		Sub:  sub,
+	}
+	mesh.SetOrigin([]float64{0, 0})
+
+	m := &gridSearch{low: sub.Reduce(low), up: sub.Reduce(up), n: 21}
+	solv := &optim.Solver{
+		Method:  m,
+		Obj:     &SubObjective{Obj: obj, Sub: sub},
+		Mesh:    mesh,
+		MaxIter: 1,
+	}
+	if err := solv.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	best := sub.Point(solv.Best())
+	want := []float64{5, 2, 3}
+	for i := range want {
+		if math.Abs(best.Pos[i]-want[i]) > 1e-9 {
+			t.Errorf("best dim %v: want %v, got %v", i, want[i], best.Pos[i])
+		}
+	}
+}
+
+// gridSearch is a Method that evaluates a 2D grid of n by n points.
+type gridSearch struct {
+	low, up []float64
+	n       int
+}
+
+func (g *gridSearch) AddPoint(p *optim.Point) {}
+
+func (g *gridSearch) Iterate(obj optim.Objectiver, m optim.Mesh) (*optim.Point, int, error) {
+	pts := []*optim.Point{}
+	for i := 0; i < g.n; i++ {
+		for j := 0; j < g.n; j++ {
+			x := g.low[0] + float64(i)*(g.up[0]-g.low[0])/float64(g.n-1)
+			y := g.low[1] + float64(j)*(g.up[1]-g.low[1])/float64(g.n-1)
+			pts = append(pts, &optim.Point{Pos: m.Nearest([]float64{x, y})})
+		}
+	}
+	results, n, err := optim.SerialEvaler{}.Eval(obj, pts...)
+	best := results[0]
+	for _, p := range results {
+		if p.Val < best.Val {
+			best = p
+		}
+	}
+	return best, n, err
+}
@@ -0,0 +1,139 @@
+// Package transform provides variable transformations between the space
+// solvers operate in (solver space) and the space objectives are defined in
+// (problem space).  Wrapping an objective and mesh with a Space lets solvers
+// always work on well-scaled variables - e.g. the unit hypercube - while
+// objectives receive their natural units.
+package transform
+
+import (
+	"math"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Transform maps a single variable between solver space and problem space.
+type Transform interface {
+	// Forward maps solver-space x to problem space.
+	Forward(x float64) float64
+	// Inverse maps problem-space y to solver space.
+	Inverse(y float64) float64
+}
+
+// Identity leaves a variable unchanged.
+type Identity struct{}
+
+func (_ Identity) Forward(x float64) float64 { return x }
+func (_ Identity) Inverse(y float64) float64 { return y }
+
+// Affine maps x to Scale*x + Shift.
+type Affine struct {
+	Scale, Shift float64
+}
+
+// Unit returns an Affine transform mapping [0,1] onto [low,up].
+func Unit(low, up float64) Affine { return Affine{Scale: up - low, Shift: low} }
+
+func (t Affine) Forward(x float64) float64 { return t.Scale*x + t.Shift }
+func (t Affine) Inverse(y float64) float64 { return (y - t.Shift) / t.Scale }
+
+// Log maps [0,1] logarithmically onto [Low,Up] (both must be positive) so
+// that equal solver-space steps correspond to equal problem-space ratios.
+// This is useful for variables spanning several orders of magnitude.
+type Log struct {
+	Low, Up float64
+}
+
+func (t Log) Forward(x float64) float64 {
+	return math.Exp(math.Log(t.Low) + x*(math.Log(t.Up)-math.Log(t.Low)))
+}
+
+func (t Log) Inverse(y float64) float64 {
+	return (math.Log(y) - math.Log(t.Low)) / (math.Log(t.Up) - math.Log(t.Low))
+}
+
+// Logit maps the entire real line onto the open interval (Low,Up) with a
+// logistic function.  This turns a bounded problem-space variable into an
+// unbounded solver-space variable.
+type Logit struct {
+	Low, Up float64
+}
+
+func (t Logit) Forward(x float64) float64 { return t.Low + (t.Up-t.Low)/(1+math.Exp(-x)) }
+
+func (t Logit) Inverse(y float64) float64 {
+	frac := (y - t.Low) / (t.Up - t.Low)
+	return math.Log(frac / (1 - frac))
+}
+
+// Space holds a transform for each dimension.  Nil entries are treated as
+// Identity.
+type Space []Transform
+
+// UnitSpace returns a Space mapping the unit hypercube onto the box defined
+// by low and up.
+func UnitSpace(low, up []float64) Space {
+	s := make(Space, len(low))
+	for i := range low {
+		s[i] = Unit(low[i], up[i])
+	}
+	return s
+}
+
+// Forward maps solver-space x to problem space.
+func (s Space) Forward(x []float64) []float64 {
+	y := make([]float64, len(x))
+	for i := range x {
+		y[i] = x[i]
+		if s[i] != nil {
+			y[i] = s[i].Forward(x[i])
+		}
+	}
+	return y
+}
+
+// Inverse maps problem-space y to solver space.
+func (s Space) Inverse(y []float64) []float64 {
+	x := make([]float64, len(y))
+	for i := range y {
+		x[i] = y[i]
+		if s[i] != nil {
+			x[i] = s[i].Inverse(y[i])
+		}
+	}
+	return x
+}
+
+// Point returns a copy of solver-space point p mapped to problem space.
+func (s Space) Point(p *optim.Point) *optim.Point {
+	return &optim.Point{Pos: s.Forward(p.Pos), Val: p.Val}
+}
+
+// Objective wraps a problem-space objective for use by solvers operating in
+// solver space.
+type Objective struct {
+	Obj   optim.Objectiver
+	Space Space
+}
+
+func (o *Objective) Objective(x []float64) (float64, error) {
+	return o.Obj.Objective(o.Space.Forward(x))
+}
+
+// Mesh is a solver-space mesh.  Step sizes and origins are handled by the
+// embedded solver-space mesh.  Points are first projected onto the embedded
+// mesh and then, if Problem is non-nil, mapped to problem space, projected
+// onto Problem (e.g. an integer or bounded mesh) and mapped back to solver
+// space.
+type Mesh struct {
+	optim.Mesh
+	Problem optim.Mesh
+	Space   Space
+}
+
+func (m *Mesh) Nearest(x []float64) []float64 {
+	x = m.Mesh.Nearest(x)
+	if m.Problem == nil {
+		return x
+	}
+	return m.Space.Inverse(m.Problem.Nearest(m.Space.Forward(x)))
+}
@@ -0,0 +1,78 @@
+package transform
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		T Transform
+		X []float64
+	}{
+		{Identity{}, []float64{-3, 0, 7}},
+		{Unit(-5, 15), []float64{0, 0.25, 1}},
+		{Log{Low: 1e-3, Up: 1e3}, []float64{0, 0.5, 1}},
+		{Logit{Low: -1, Up: 3}, []float64{-10, 0, 4}},
+	}
+
+	for i, test := range tests {
+		for _, x := range test.X {
+			if got := test.T.Inverse(test.T.Forward(x)); math.Abs(got-x) > 1e-9 {
+				t.Errorf("test %v (%T): round trip of %v gave %v", i, test.T, x, got)
+			}
+		}
+	}
+
+	if y := (Log{Low: 1e-3, Up: 1e3}).Forward(0.5); math.Abs(y-1) > 1e-12 {
+		t.Errorf("log transform midpoint: want 1, got %v", y)
+	}
+	if y := (Logit{Low: -1, Up: 3}).Forward(0); y != 1 {
+		t.Errorf("logit transform midpoint: want 1, got %v", y)
+	}
+}
+
+func TestObjective(t *testing.T) {
+	space := Space{Unit(10, 20), nil, Log{Low: 1, Up: 100}}
+	var got []float64
+	obj := &Objective{
+		Obj: optim.Func(func(v []float64) float64 {
+			got = v
+			return v[0] + v[1] + v[2]
+		}),
+		Space: space,
+	}
+
+	val, _ := obj.Objective([]float64{0.5, 3, 1})
+	want := []float64{15, 3, 100}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("problem-space var %v: want %v, got %v", i, want[i], got[i])
+		}
+	}
+	if math.Abs(val-118) > 1e-9 {
+		t.Errorf("wrong objective value: want 118, got %v", val)
+	}
+}
+
+func TestMesh(t *testing.T) {
+	space := UnitSpace([]float64{0, 0}, []float64{10, 10})
+	m := &Mesh{
+		Mesh:    &optim.InfMesh{StepSize: 0.01},
+		Problem: &optim.IntMesh{&optim.InfMesh{StepSize: 1}},
+		Space:   space,
+	}
+
+	got := m.Nearest([]float64{0.123, 0.871})
+	want := []float64{0.1, 0.9}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("dim %v: want %v, got %v", i, want[i], got[i])
+		}
+	}
+	if m.Step() != 0.01 {
+		t.Errorf("step not handled by solver-space mesh: got %v", m.Step())
+	}
+}
@@ -0,0 +1,272 @@
+package transform
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/rwcarlsen/optim"
+)
+
+// Round is an affine transform whose problem-space values are rounded to
+// the nearest integer within [Low, Up].  Use IntUnit to map the unit
+// interval so every integer gets an equal share of it.
+type Round struct {
+	Affine
+	Low, Up float64
+}
+
+// IntUnit returns a Round transform mapping [0,1] onto the integers in
+// [low,up].  The affine range is widened by half a unit on each side so
+// the end values are as likely as the interior ones.
+func IntUnit(low, up float64) Round {
+	return Round{Affine: Unit(low-.5, up+.5), Low: low, Up: up}
+}
+
+func (t Round) Forward(x float64) float64 {
+	return math.Max(t.Low, math.Min(t.Up, math.Floor(t.Affine.Forward(x)+.5)))
+}
+
+// Scale is the scaling of a continuous variable's range.
+type Scale int
+
+const (
+	// Linear scales a variable's range linearly onto the unit interval.
+	Linear Scale = iota
+	// Logarithmic scales a variable's range logarithmically onto the unit
+	// interval - see Log.
+	Logarithmic
+)
+
+// Var defines a single named decision variable.
+type Var struct {
+	Name string
+	// Unit is an optional unit label (e.g. "m" or "kg/s").
+	Unit    string
+	Low, Up float64
+	// Scale determines how the variable's range is mapped onto the unit
+	// interval.  It is ignored for integer and categorical variables.
+	Scale Scale
+	// Integer restricts the variable to the integers in [Low, Up].
+	Integer bool
+	// Categories, if non-empty, makes the variable categorical: it takes
+	// the values 0, 1, ..., len(Categories)-1 labeled by the category
+	// names.  Low and Up are ignored.
+	Categories []string
+}
+
+// Discrete reports whether v is integer or categorical.
+func (v Var) Discrete() bool { return v.Integer || len(v.Categories) > 0 }
+
+// Bounds returns v's problem-space bounds.
+func (v Var) Bounds() (low, up float64) {
+	if len(v.Categories) > 0 {
+		return 0, float64(len(v.Categories) - 1)
+	}
+	return v.Low, v.Up
+}
+
+// Transform returns the transform mapping the unit interval onto v's range.
+func (v Var) Transform() Transform {
+	low, up := v.Bounds()
+	switch {
+	case v.Discrete():
+		return IntUnit(low, up)
+	case v.Scale == Logarithmic:
+		return Log{Low: low, Up: up}
+	default:
+		return Unit(low, up)
+	}
+}
+
+// Label returns v's name followed by its unit in brackets if it has one.
+func (v Var) Label() string {
+	if v.Unit == "" {
+		return v.Name
+	}
+	return v.Name + " [" + v.Unit + "]"
+}
+
+// Format returns x formatted as a value of v - categorical values are
+// shown by category name.
+func (v Var) Format(x float64) string {
+	if i := int(x); len(v.Categories) > 0 && float64(i) == x && i >= 0 && i < len(v.Categories) {
+		return v.Categories[i]
+	}
+	s := strconv.FormatFloat(x, 'g', -1, 64)
+	if v.Unit != "" && len(v.Categories) == 0 {
+		s += " " + v.Unit
+	}
+	return s
+}
+
+// Vars defines the decision variables of a problem.  It provides the
+// bounds, solver spaces and meshes needed to set up solvers and labels
+// results with variable names rather than index positions.
+type Vars []Var
+
+// Bounds returns the problem-space bounds of the variables.
+func (vs Vars) Bounds() (low, up []float64) {
+	low, up = make([]float64, len(vs)), make([]float64, len(vs))
+	for i, v := range vs {
+		low[i], up[i] = v.Bounds()
+	}
+	return low, up
+}
+
+// Space returns the space mapping the unit hypercube onto the variables'
+// ranges.  Integer and categorical variables are rounded, so objectives
+// only ever see valid discrete values.
+func (vs Vars) Space() Space {
+	s := make(Space, len(vs))
+	for i, v := range vs {
+		s[i] = v.Transform()
+	}
+	return s
+}
+
+// Mesh returns a unit hypercube mesh with the given step size for solvers
+// operating in the variables' Space.
+func (vs Vars) Mesh(step float64) optim.Mesh {
+	zeros, ones := make([]float64, len(vs)), make([]float64, len(vs))
+	for i := range ones {
+		ones[i] = 1
+	}
+	return &optim.BoxMesh{Mesh: &optim.InfMesh{StepSize: step}, Lower: zeros, Upper: ones}
+}
+
+// Discrete returns the indices of the integer and categorical variables.
+func (vs Vars) Discrete() []int {
+	var idx []int
+	for i, v := range vs {
+		if v.Discrete() {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// Names returns the variable names.
+func (vs Vars) Names() []string {
+	names := make([]string, len(vs))
+	for i, v := range vs {
+		names[i] = v.Name
+	}
+	return names
+}
+
+// Labels returns the variable labels (see Var.Label).
+func (vs Vars) Labels() []string {
+	labels := make([]string, len(vs))
+	for i, v := range vs {
+		labels[i] = v.Label()
+	}
+	return labels
+}
+
+// Index returns the index of the variable with the given name or -1 if
+// there is none.
+func (vs Vars) Index(name string) int {
+	for i, v := range vs {
+		if v.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Format returns the problem-space position pos as a list of named values,
+// e.g. "length=2.5 m, material=steel".
+func (vs Vars) Format(pos []float64) string {
+	var buf bytes.Buffer
+	for i, x := range pos {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if i < len(vs) {
+			fmt.Fprintf(&buf, "%v=%v", vs[i].Name, vs[i].Format(x))
+		} else {
+			fmt.Fprintf(&buf, "x%v=%v", i, x)
+		}
+	}
+	return buf.String()
+}
+
+// FormatPoint returns problem-space point p formatted like Format followed
+// by its objective value.
+func (vs Vars) FormatPoint(p *optim.Point) string {
+	return fmt.Sprintf("%v: %v", vs.Format(p.Pos), p.Val)
+}
+
+// WriteTable writes problem-space points pts to w as a table with a header
+// row of variable labels followed by an "objective" column.  Fields are
+// separated by sep (e.g. "," or "\t") and categorical values are written
+// by name.
+func (vs Vars) WriteTable(w io.Writer, sep string, pts []*optim.Point) error {
+	header := append(vs.Labels(), "objective")
+	if _, err := fmt.Fprintln(w, strings.Join(header, sep)); err != nil {
+		return err
+	}
+	for _, p := range pts {
+		fields := make([]string, 0, len(p.Pos)+1)
+		for i, x := range p.Pos {
+			s := strconv.FormatFloat(x, 'g', -1, 64)
+			if i < len(vs) && len(vs[i].Categories) > 0 {
+				s = vs[i].Format(x)
+			}
+			fields = append(fields, s)
+		}
+		fields = append(fields, strconv.FormatFloat(p.Val, 'g', -1, 64))
+		if _, err := fmt.Fprintln(w, strings.Join(fields, sep)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Map returns the problem-space position pos as a map from variable names
+// to values.
+func (vs Vars) Map(pos []float64) map[string]float64 {
+	m := make(map[string]float64, len(vs))
+	for i, v := range vs {
+		m[v.Name] = pos[i]
+	}
+	return m
+}
+
+// Slice returns the position with the named values in m ordered by the
+// variable definitions.  It fails if m is missing a variable or has values
+// for unknown names.
+func (vs Vars) Slice(m map[string]float64) ([]float64, error) {
+	pos := make([]float64, len(vs))
+	for i, v := range vs {
+		x, ok := m[v.Name]
+		if !ok {
+			return nil, fmt.Errorf("transform: missing value for variable %q", v.Name)
+		}
+		pos[i] = x
+	}
+	if len(m) != len(vs) {
+		for name := range m {
+			if vs.Index(name) < 0 {
+				return nil, fmt.Errorf("transform: unknown variable %q", name)
+			}
+		}
+	}
+	return pos, nil
+}
+
+// NamedObjective adapts an objective written against named parameters for
+// use by solvers.  Positions are converted to maps with Vars.Map, so Obj
+// receives each variable's value under its name.
+type NamedObjective struct {
+	Vars Vars
+	Obj  func(params map[string]float64) (float64, error)
+}
+
+func (o *NamedObjective) Objective(v []float64) (float64, error) {
+	return o.Obj(o.Vars.Map(v))
+}
@@ -0,0 +1,106 @@
+package transform
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+)
+
+var testVars = Vars{
+	{Name: "length", Unit: "m", Low: 1, Up: 3},
+	{Name: "rate", Unit: "kg/s", Low: 0.01, Up: 100, Scale: Logarithmic},
+	{Name: "count", Low: -2, Up: 2, Integer: true},
+	{Name: "material", Categories: []string{"steel", "copper", "glass"}},
+}
+
+func TestVarsSpace(t *testing.T) {
+	low, up := testVars.Bounds()
+	wantlow, wantup := []float64{1, 0.01, -2, 0}, []float64{3, 100, 2, 2}
+	for i := range low {
+		if low[i] != wantlow[i] || up[i] != wantup[i] {
+			t.Errorf("var %v: bounds [%v, %v], want [%v, %v]", i, low[i], up[i], wantlow[i], wantup[i])
+		}
+	}
+	if d := testVars.Discrete(); len(d) != 2 || d[0] != 2 || d[1] != 3 {
+		t.Errorf("discrete vars %v, want [2 3]", d)
+	}
+
+	space := testVars.Space()
+	got := space.Forward([]float64{0.5, 0.5, 0, 1})
+	want := []float64{2, 1, -2, 2}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-12 {
+			t.Errorf("var %v: unit midpoint mapped to %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// every integer gets an equal share of the unit interval
+	counts := map[float64]int{}
+	for x := 0.0005; x < 1; x += 0.001 {
+		counts[space[2].Forward(x)]++
+	}
+	for k := -2.0; k <= 2; k++ {
+		if counts[k] != 200 {
+			t.Errorf("integer %v covers %v/1000 of the unit interval, want 200", k, counts[k])
+		}
+	}
+
+	mesh := testVars.Mesh(0.25)
+	p := mesh.Nearest([]float64{0.3, 1.2, -0.1, 0.6})
+	for i, x := range p {
+		if x < 0 || x > 1 || math.Mod(x, 0.25) != 0 {
+			t.Errorf("dim %v projected to %v, want a multiple of 0.25 in [0, 1]", i, x)
+		}
+	}
+}
+
+func TestVarsFormat(t *testing.T) {
+	pos := []float64{2.5, 0.1, -1, 1}
+	want := "length=2.5 m, rate=0.1 kg/s, count=-1, material=copper"
+	if got := testVars.Format(pos); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := testVars.FormatPoint(&optim.Point{Pos: pos, Val: 7}); got != want+": 7" {
+		t.Errorf("got point %q", got)
+	}
+	if i := testVars.Index("count"); i != 2 {
+		t.Errorf("index of count is %v, want 2", i)
+	}
+
+	var buf bytes.Buffer
+	if err := testVars.WriteTable(&buf, ",", []*optim.Point{{Pos: pos, Val: 7}}); err != nil {
+		t.Fatal(err)
+	}
+	want = "length [m],rate [kg/s],count,material,objective\n2.5,0.1,-1,copper,7\n"
+	if buf.String() != want {
+		t.Errorf("got table\n%v\nwant\n%v", buf.String(), want)
+	}
+}
+
+func TestNamedObjective(t *testing.T) {
+	obj := &NamedObjective{Vars: testVars, Obj: func(p map[string]float64) (float64, error) {
+		return p["length"] * p["rate"], nil
+	}}
+	if val, err := obj.Objective([]float64{2, 3, 0, 0}); err != nil || val != 6 {
+		t.Errorf("got %v, %v, want 6", val, err)
+	}
+
+	m := testVars.Map([]float64{2, 3, 1, 2})
+	pos, err := testVars.Slice(m)
+	if err != nil {
+		t.Fatal(err)
+	} else if pos[0] != 2 || pos[1] != 3 || pos[2] != 1 || pos[3] != 2 {
+		t.Errorf("round trip through map gave %v", pos)
+	}
+
+	delete(m, "rate")
+	if _, err := testVars.Slice(m); err == nil {
+		t.Error("missing variable was accepted")
+	}
+	m["rate"], m["speed"] = 3, 1
+	if _, err := testVars.Slice(m); err == nil {
+		t.Error("unknown variable was accepted")
+	}
+}
@@ -0,0 +1,227 @@
+// Package tune provides meta-optimization of solver hyperparameters.  A small
+// outer pattern search optimizes particle swarm parameters (swarm size,
+// inertia, cognition, and social factors) against a class of benchmark
+// functions, scoring each configuration by the average number of
+// evaluations needed to solve the functions in the class.
+package tune
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+	"github.com/rwcarlsen/optim/pattern"
+	"github.com/rwcarlsen/optim/swarm"
+)
+
+// Params holds a tunable particle swarm configuration.
+type Params struct {
+	Npar      int
+	Inertia   float64
+	Cognition float64
+	Social    float64
+}
+
+func (p Params) String() string {
+	return fmt.Sprintf("npar=%v inertia=%.4g cognition=%.4g social=%.4g", p.Npar, p.Inertia, p.Cognition, p.Social)
+}
+
+func (p Params) vec() []float64 {
+	return []float64{float64(p.Npar), p.Inertia, p.Cognition, p.Social}
+}
+
+func fromvec(v []float64) Params {
+	return Params{
+		Npar:      int(math.Floor(v[0] + .5)),
+		Inertia:   v[1],
+		Cognition: v[2],
+		Social:    v[3],
+	}
+}
+
+// DefaultParams is the standard constriction-based swarm configuration.
+var DefaultParams = Params{
+	Npar:      30,
+	Inertia:   swarm.DefaultInertia,
+	Cognition: swarm.DefaultCognition,
+	Social:    swarm.DefaultSocial,
+}
+
+// Class is a named group of benchmark functions that a single configuration
+// is tuned for.
+type Class struct {
+	Name  string
+	Funcs []bench.Func
+}
+
+// Result holds the best configuration found for a class.
+type Result struct {
+	Class string
+	Best  Params
+	// Score is the average number of evaluations per run to solve the
+	// class's functions with a failed run counting as twice MaxEval.
+	Score float64
+	// Neval is the total number of inner objective evaluations performed
+	// during tuning.
+	Neval int
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("%v: %v (score %.1f)", r.Class, r.Best, r.Score)
+}
+
+// Tuner tunes swarm parameters within the box defined by Low and Up.
+type Tuner struct {
+	Low, Up Params
+	// Start is the initial configuration for the outer search.
+	Start Params
+	// MaxEval is the evaluation budget for each inner swarm run.
+	MaxEval int
+	// Nrun is the number of inner runs performed per benchmark function
+	// for each configuration.
+	Nrun int
+	// MaxIter is the number of outer pattern search iterations.
+	MaxIter int
+	// Seed seeds a random number source private to each configuration's
+	// inner runs so that all configurations are compared using the same
+	// random numbers.
+	Seed  int64
+	neval int
+}
+
+// New returns a tuner with reasonable bounds and budgets.
+func New() *Tuner {
+	return &Tuner{
+		Low:     Params{Npar: 5, Inertia: 0.2, Cognition: 0.5, Social: 0.5},
+		Up:      Params{Npar: 100, Inertia: 1.0, Cognition: 3.0, Social: 3.0},
+		Start:   DefaultParams,
+		MaxEval: 20000,
+		Nrun:    5,
+		MaxIter: 30,
+		Seed:    1,
+	}
+}
+
+// Tune searches for the best swarm configuration for class c.  The outer
+// search operates on configurations scaled to the unit hypercube spanned by
+// Low and Up.
+func (t *Tuner) Tune(c Class) (*Result, error) {
+	t.neval = 0
+	start := &optim.Point{Pos: t.encode(t.Start), Val: math.Inf(1)}
+	low, up := make([]float64, len(start.Pos)), make([]float64, len(start.Pos))
+	for i := range up {
+		up[i] = 1
+	}
+
+	mesh := &optim.BoxMesh{Mesh: &optim.InfMesh{StepSize: 0.25}, Lower: low, Upper: up}
+	mesh.SetOrigin(start.Pos)
+
+	solv := &optim.Solver{
+		Method:  pattern.New(start),
+		Obj:     t.Objective(c),
+		Mesh:    mesh,
+		MaxIter: t.MaxIter,
+	}
+	err := solv.Run()
+	return &Result{
+		Class: c.Name,
+		Best:  t.decode(solv.Best().Pos),
+		Score: solv.Best().Val,
+		Neval: t.neval,
+	}, err
+}
+
+// TuneAll tunes a separate configuration for each class.
+func (t *Tuner) TuneAll(classes ...Class) ([]*Result, error) {
+	results := make([]*Result, 0, len(classes))
+	for _, c := range classes {
+		r, err := t.Tune(c)
+		results = append(results, r)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// Objective returns an objective that scores swarm configurations on class
+// c.  Configurations are encoded as [npar, inertia, cognition, social] scaled
+// to the unit hypercube spanned by Low and Up.
+func (t *Tuner) Objective(c Class) optim.Objectiver {
+	return optim.Func(func(v []float64) float64 { return t.Score(t.decode(v), c) })
+}
+
+func (t *Tuner) encode(p Params) []float64 {
+	v, low, up := p.vec(), t.Low.vec(), t.Up.vec()
+	for i := range v {
+		v[i] = (v[i] - low[i]) / (up[i] - low[i])
+	}
+	return v
+}
+
+func (t *Tuner) decode(v []float64) Params {
+	low, up := t.Low.vec(), t.Up.vec()
+	x := make([]float64, len(v))
+	for i := range v {
+		x[i] = low[i] + v[i]*(up[i]-low[i])
+	}
+	return fromvec(x)
+}
+
+// Score returns the average number of evaluations per run needed to solve
+// the functions in class c with swarm configuration p. Failed runs count as
+// twice MaxEval.  If there are no runs to score (e.g. c has no functions),
+// Score returns +inf.
+func (t *Tuner) Score(p Params, c Class) float64 {
+	if p.Npar < 1 {
+		return math.Inf(1)
+	}
+
+	// isolate the inner runs' random numbers from the outer search and from
+	// any other solvers using optim.Rand
+	rng := rand.New(rand.NewSource(t.Seed))
+
+	tot := 0.0
+	nrun := 0
+	for _, fn := range c.Funcs {
+		low, up := fn.Bounds()
+		for i := 0; i < t.Nrun; i++ {
+			s := &optim.Solver{
+				Method:  t.method(p, low, up, rng),
+				Obj:     optim.Func(fn.Eval),
+				MaxEval: t.MaxEval,
+			}
+			for s.Next() {
+				if s.Best().Val < fn.Tol() {
+					break
+				}
+			}
+			t.neval += s.Neval()
+
+			if s.Best().Val < fn.Tol() {
+				tot += float64(s.Neval())
+			} else {
+				tot += 2 * float64(t.MaxEval)
+			}
+			nrun++
+		}
+	}
+	if nrun == 0 {
+		return math.Inf(1)
+	}
+	return tot / float64(nrun)
+}
+
+// method returns a swarm with configuration p drawing its random numbers
+// from rng.
+func (t *Tuner) method(p Params, low, up []float64, rng optim.Rng) optim.Method {
+	return swarm.New(
+		swarm.NewPopulationRandFrom(rng, p.Npar, low, up),
+		swarm.VmaxBounds(low, up),
+		swarm.FixedInertia(p.Inertia),
+		swarm.LearnFactors(p.Cognition, p.Social),
+		swarm.Rng(rng),
+	)
+}
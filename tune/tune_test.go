@@ -0,0 +1,55 @@
+package tune
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+)
+
+func TestTune(t *testing.T) {
+	tn := New()
+	tn.MaxEval = 3000
+	tn.Nrun = 2
+	tn.MaxIter = 5
+
+	c := Class{Name: "smooth", Funcs: []bench.Func{bench.Rosenbrock{NDim: 2}}}
+	def := tn.Score(tn.Start, c)
+
+	r, err := tn.Tune(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("default score %.1f, tuned %v", def, r)
+
+	if r.Score > def {
+		t.Errorf("tuned config is worse than start: want <= %v, got %v", def, r.Score)
+	}
+	if r.Best.Npar < tn.Low.Npar || r.Best.Npar > tn.Up.Npar {
+		t.Errorf("tuned swarm size %v outside bounds", r.Best.Npar)
+	}
+	if r.Neval == 0 {
+		t.Errorf("no inner evaluations were counted")
+	}
+}
+
+func TestScoreRand(t *testing.T) {
+	tn := New()
+	tn.MaxEval = 500
+	tn.Nrun = 1
+	c := Class{Name: "smooth", Funcs: []bench.Func{bench.Rosenbrock{NDim: 2}}}
+
+	orig := optim.Rand
+	s1 := tn.Score(tn.Start, c)
+	if optim.Rand != orig {
+		t.Errorf("Score replaced optim.Rand")
+	}
+	if s2 := tn.Score(tn.Start, c); s2 != s1 {
+		t.Errorf("scores with the same seed differ: %v != %v", s1, s2)
+	}
+
+	if s := tn.Score(tn.Start, Class{Name: "empty"}); !math.IsInf(s, 1) {
+		t.Errorf("empty class: want +inf, got %v", s)
+	}
+}
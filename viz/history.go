@@ -0,0 +1,174 @@
+package viz
+
+import (
+	"database/sql"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+)
+
+var (
+	bgColor   = color.RGBA{255, 255, 255, 255}
+	axisColor = color.RGBA{160, 160, 160, 255}
+)
+
+// LoadHistory reads the evaluation history recorded in table tbl of db
+// (e.g. swarm.TblParticles or pattern.TblPolls) for plotting with
+// ParallelCoords or ScatterMatrix.
+func LoadHistory(db *sql.DB, tbl string) ([]*optim.Point, error) {
+	return optim.LoadPoints(db, tbl)
+}
+
+// history holds points sorted worst to best (so the best are drawn on
+// top) along with per-dimension ranges used for normalization.
+type history struct {
+	pts      []*optim.Point
+	low, up  []float64
+	ndim     int
+	colorfor map[*optim.Point]color.RGBA
+}
+
+func newHistory(pts []*optim.Point) *history {
+	h := &history{colorfor: map[*optim.Point]color.RGBA{}}
+	for _, p := range pts {
+		if !math.IsInf(p.Val, 0) && !math.IsNaN(p.Val) {
+			h.pts = append(h.pts, p)
+		}
+	}
+	if len(h.pts) == 0 {
+		return h
+	}
+	sort.Slice(h.pts, func(i, j int) bool { return h.pts[i].Val > h.pts[j].Val })
+
+	h.ndim = h.pts[0].Len()
+	h.low = append([]float64{}, h.pts[0].Pos...)
+	h.up = append([]float64{}, h.pts[0].Pos...)
+	for i, p := range h.pts {
+		for d := 0; d < h.ndim; d++ {
+			h.low[d] = math.Min(h.low[d], p.Pos[d])
+			h.up[d] = math.Max(h.up[d], p.Pos[d])
+		}
+		// color by rank so a few very bad points don't wash out the rest;
+		// best is dark.
+		t := 1.0
+		if len(h.pts) > 1 {
+			t = 1 - float64(i)/float64(len(h.pts)-1)
+		}
+		h.colorfor[p] = mapColor(t)
+	}
+	return h
+}
+
+// norm returns x's fractional position in dimension d's range.
+func (h *history) norm(d int, x float64) float64 {
+	if h.up[d] == h.low[d] {
+		return 0.5
+	}
+	return (x - h.low[d]) / (h.up[d] - h.low[d])
+}
+
+// ParallelCoords renders pts as a parallel-coordinates plot with one
+// vertical axis per dimension.  Each point is drawn as a polyline colored
+// by its rank by objective value (dark is best).  Axes are scaled to the
+// range of the plotted points.  Points with non-finite values are omitted.
+func ParallelCoords(pts []*optim.Point, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{bgColor}, image.Point{}, draw.Src)
+
+	h := newHistory(pts)
+	if h.ndim == 0 {
+		return img
+	}
+
+	margin := width / 20
+	axisx := func(d int) int {
+		if h.ndim == 1 {
+			return width / 2
+		}
+		return margin + d*(width-1-2*margin)/(h.ndim-1)
+	}
+	axisy := func(d int, x float64) int {
+		return margin + int((1-h.norm(d, x))*float64(height-1-2*margin))
+	}
+
+	for d := 0; d < h.ndim; d++ {
+		drawLine(img, axisx(d), margin, axisx(d), height-1-margin, axisColor)
+	}
+	for _, p := range h.pts {
+		c := h.colorfor[p]
+		for d := 1; d < h.ndim; d++ {
+			drawLine(img, axisx(d-1), axisy(d-1, p.Pos[d-1]), axisx(d), axisy(d, p.Pos[d]), c)
+		}
+	}
+	return img
+}
+
+// ScatterMatrix renders pts as a grid of pairwise scatter plots with each
+// cell being size by size pixels.  The cell in row i, column j plots
+// dimension j (horizontal) against dimension i (vertical) with points
+// colored by their rank by objective value (dark is best).  Diagonal cells
+// show a histogram of each dimension's values.  Points with non-finite
+// values are omitted.
+func ScatterMatrix(pts []*optim.Point, size int) *image.RGBA {
+	h := newHistory(pts)
+	const gap = 2
+	n := h.ndim
+	dim := n*size + (n-1)*gap
+	if n == 0 {
+		dim = size
+	}
+	img := image.NewRGBA(image.Rect(0, 0, dim, dim))
+	draw.Draw(img, img.Bounds(), &image.Uniform{axisColor}, image.Point{}, draw.Src)
+
+	inner := size - 4 // leave room for point markers at cell edges
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			x0, y0 := j*(size+gap), i*(size+gap)
+			cell := image.Rect(x0, y0, x0+size, y0+size)
+			draw.Draw(img, cell, &image.Uniform{bgColor}, image.Point{}, draw.Src)
+			if i == j {
+				drawHist(img, cell, h, i)
+				continue
+			}
+			sub := img.SubImage(cell).(*image.RGBA)
+			for _, p := range h.pts {
+				x := x0 + 2 + int(h.norm(j, p.Pos[j])*float64(inner-1))
+				y := y0 + 2 + int((1-h.norm(i, p.Pos[i]))*float64(inner-1))
+				drawSquare(sub, x, y, 1, h.colorfor[p])
+			}
+		}
+	}
+	return img
+}
+
+func drawHist(img *image.RGBA, cell image.Rectangle, h *history, d int) {
+	nbins := cell.Dx() / 4
+	if nbins < 1 {
+		nbins = 1
+	}
+	counts := make([]int, nbins)
+	max := 0
+	for _, p := range h.pts {
+		b := int(h.norm(d, p.Pos[d]) * float64(nbins))
+		if b == nbins {
+			b--
+		}
+		counts[b]++
+		if counts[b] > max {
+			max = counts[b]
+		}
+	}
+	if max == 0 {
+		return
+	}
+	bw := float64(cell.Dx()) / float64(nbins)
+	for b, c := range counts {
+		top := cell.Max.Y - c*cell.Dy()/max
+		bar := image.Rect(cell.Min.X+int(float64(b)*bw), top, cell.Min.X+int(float64(b+1)*bw), cell.Max.Y)
+		draw.Draw(img, bar, &image.Uniform{colormap[1]}, image.Point{}, draw.Src)
+	}
+}
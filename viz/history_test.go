@@ -0,0 +1,64 @@
+package viz
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/rwcarlsen/go-sqlite/sqlite3"
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+	"github.com/rwcarlsen/optim/swarm"
+)
+
+func TestHistoryPlots(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	fn := bench.Rosenbrock{NDim: 4}
+	low, up := fn.Bounds()
+	obj := optim.Func(fn.Eval)
+	m := swarm.New(swarm.NewPopulationRand(8, low, up), swarm.VmaxBounds(low, up), swarm.DB(db))
+	for i := 0; i < 3; i++ {
+		if _, _, err := m.Iterate(obj, &optim.InfMesh{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pts, err := LoadHistory(db, swarm.TblParticles)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(pts) == 0 {
+		t.Fatal("no history loaded")
+	}
+
+	img := ParallelCoords(pts, 200, 100)
+	if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 100 {
+		t.Errorf("wrong parallel coords size: %v", b)
+	}
+
+	img = ScatterMatrix(pts, 40)
+	if b := img.Bounds(); b.Dx() != 4*40+3*2 {
+		t.Errorf("wrong scatter matrix size: %v", b)
+	}
+}
+
+func TestParallelCoordsBestOnTop(t *testing.T) {
+	// two points crossing at the same pixel on the first axis: the better
+	// point must be drawn last.
+	pts := []*optim.Point{
+		{Pos: []float64{0, 0}, Val: 1},
+		{Pos: []float64{0, 1}, Val: 2},
+		{Pos: []float64{1, 0.5}, Val: 0},
+	}
+	img := ParallelCoords(pts, 101, 101)
+	margin := 101 / 20
+	if got := img.RGBAAt(margin, 100-margin); got != mapColor(0.5) {
+		t.Errorf("want middle ranked point on top at shared vertex, got %v", got)
+	}
+	if got := img.RGBAAt(margin, margin); got != mapColor(0) {
+		t.Errorf("want best point's color at its vertex, got %v", got)
+	}
+}
@@ -0,0 +1,390 @@
+// Package viz renders 2-D optimization runs as images.  An objective is
+// sampled on a grid to produce a filled contour background onto which
+// population positions, velocities, personal bests, and the incumbent
+// (global best) are drawn.  Frames can be written as PNG or SVG images or
+// collected into an animated GIF of an entire run.  Only the first two
+// dimensions of points are drawn.
+package viz
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/swarm"
+)
+
+// NLevels is the number of contour bands drawn.
+var NLevels = 12
+
+// Field holds objective values sampled on a regular 2-D grid.
+type Field struct {
+	Low, Up [2]float64
+	// Vals[j][i] is the objective value at the i'th x and j'th y grid
+	// position.
+	Vals [][]float64
+	// levels holds the contour band boundaries.
+	levels []float64
+}
+
+// Sample evaluates obj on an nx by ny grid spanning the box low to up
+// (only the first two dimensions are used).  If mesh is non-nil, grid
+// points are projected onto it before evaluation.  Failed evaluations are
+// recorded as +Inf.
+func Sample(obj optim.Objectiver, mesh optim.Mesh, low, up []float64, nx, ny int) *Field {
+	f := &Field{Low: [2]float64{low[0], low[1]}, Up: [2]float64{up[0], up[1]}}
+	f.Vals = make([][]float64, ny)
+	for j := range f.Vals {
+		f.Vals[j] = make([]float64, nx)
+		y := low[1] + (up[1]-low[1])*float64(j)/float64(ny-1)
+		for i := range f.Vals[j] {
+			x := low[0] + (up[0]-low[0])*float64(i)/float64(nx-1)
+			pos := []float64{x, y}
+			if mesh != nil {
+				pos = mesh.Nearest(pos)
+			}
+			v, err := obj.Objective(pos)
+			if err != nil {
+				v = math.Inf(1)
+			}
+			f.Vals[j][i] = v
+		}
+	}
+	f.computeLevels()
+	return f
+}
+
+// computeLevels sets band boundaries at quantiles of the sampled values so
+// contours remain informative for objectives spanning many orders of
+// magnitude.
+func (f *Field) computeLevels() {
+	var all []float64
+	for _, row := range f.Vals {
+		for _, v := range row {
+			if !math.IsInf(v, 0) && !math.IsNaN(v) {
+				all = append(all, v)
+			}
+		}
+	}
+	sort.Float64s(all)
+	f.levels = make([]float64, NLevels-1)
+	if len(all) == 0 {
+		return
+	}
+	for k := range f.levels {
+		f.levels[k] = all[(k+1)*(len(all)-1)/NLevels]
+	}
+}
+
+// band returns the contour band index for v.
+func (f *Field) band(v float64) int {
+	return sort.SearchFloat64s(f.levels, v)
+}
+
+// at returns the value at the grid cell nearest to fractional position
+// (u, v) in [0,1]x[0,1].
+func (f *Field) at(u, v float64) float64 {
+	ny, nx := len(f.Vals), len(f.Vals[0])
+	j := int(math.Floor(v*float64(ny-1) + 0.5))
+	i := int(math.Floor(u*float64(nx-1) + 0.5))
+	return f.Vals[j][i]
+}
+
+// Scene is a single frame to render.
+type Scene struct {
+	Field *Field
+	// Points are the current population positions.
+	Points []*optim.Point
+	// Vels, if non-nil, holds a velocity vector for each point which is
+	// drawn as a line from the point.
+	Vels [][]float64
+	// Bests, if non-nil, holds a personal best for each point.
+	Bests     []*optim.Point
+	Incumbent *optim.Point
+	// Labels, if set, are the names of the horizontal and vertical axes
+	// (e.g. from transform.Vars.Labels) drawn by SVG.
+	Labels [2]string
+}
+
+// SwarmScene creates a scene from a particle swarm population.  The
+// incumbent is the population's best personal best.
+func SwarmScene(f *Field, pop swarm.Population) *Scene {
+	s := &Scene{Field: f}
+	for _, p := range pop {
+		s.Points = append(s.Points, p.Point.Clone())
+		s.Vels = append(s.Vels, append([]float64{}, p.Vel...))
+		s.Bests = append(s.Bests, p.Best.Clone())
+	}
+	if best := pop.Best(); best != nil {
+		s.Incumbent = best.Best.Clone()
+	}
+	return s
+}
+
+var (
+	pointColor = color.RGBA{255, 255, 255, 255}
+	velColor   = color.RGBA{200, 200, 200, 255}
+	bestColor  = color.RGBA{255, 160, 0, 255}
+	incColor   = color.RGBA{255, 0, 0, 255}
+	lineColor  = color.RGBA{40, 40, 40, 255}
+)
+
+// colormap is a perceptually ordered (viridis-like) palette for contour
+// bands from best (dark) to worst (bright).
+var colormap = []color.RGBA{
+	{68, 1, 84, 255},
+	{59, 82, 139, 255},
+	{33, 145, 140, 255},
+	{94, 201, 98, 255},
+	{253, 231, 37, 255},
+}
+
+func bandColor(band int) color.RGBA {
+	return mapColor(float64(band) / float64(NLevels-1))
+}
+
+// mapColor interpolates the colormap at t in [0,1].
+func mapColor(t float64) color.RGBA {
+	x := t * float64(len(colormap)-1)
+	k := int(math.Min(x, float64(len(colormap)-2)))
+	frac := x - float64(k)
+	a, b := colormap[k], colormap[k+1]
+	mix := func(p, q uint8) uint8 { return uint8(float64(p) + frac*(float64(q)-float64(p))) }
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+// pixel maps a problem-space position to image coordinates.
+func (s *Scene) pixel(pos []float64, w, h int) (int, int) {
+	f := s.Field
+	u := (pos[0] - f.Low[0]) / (f.Up[0] - f.Low[0])
+	v := (pos[1] - f.Low[1]) / (f.Up[1] - f.Low[1])
+	return int(u * float64(w-1)), int((1 - v) * float64(h-1))
+}
+
+// Image renders the scene as a w by h raster image.
+func (s *Scene) Image(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	bands := make([][]int, h)
+	for y := range bands {
+		bands[y] = make([]int, w)
+		for x := range bands[y] {
+			bands[y][x] = s.Field.band(s.Field.at(float64(x)/float64(w-1), 1-float64(y)/float64(h-1)))
+			img.Set(x, y, bandColor(bands[y][x]))
+		}
+	}
+	// contour lines at band boundaries
+	for y := 1; y < h; y++ {
+		for x := 1; x < w; x++ {
+			if bands[y][x] != bands[y-1][x] || bands[y][x] != bands[y][x-1] {
+				img.Set(x, y, lineColor)
+			}
+		}
+	}
+
+	for i, p := range s.Points {
+		x, y := s.pixel(p.Pos, w, h)
+		if s.Vels != nil {
+			end := []float64{p.Pos[0] + s.Vels[i][0], p.Pos[1] + s.Vels[i][1]}
+			x2, y2 := s.pixel(end, w, h)
+			drawLine(img, x, y, x2, y2, velColor)
+		}
+		if s.Bests != nil {
+			bx, by := s.pixel(s.Bests[i].Pos, w, h)
+			drawSquare(img, bx, by, 2, bestColor)
+		}
+		drawSquare(img, x, y, 1, pointColor)
+	}
+	if s.Incumbent != nil {
+		x, y := s.pixel(s.Incumbent.Pos, w, h)
+		drawLine(img, x-5, y, x+5, y, incColor)
+		drawLine(img, x, y-5, x, y+5, incColor)
+	}
+	return img
+}
+
+// PNG writes the scene to w as a PNG image.
+func (s *Scene) PNG(w io.Writer, width, height int) error {
+	return png.Encode(w, s.Image(width, height))
+}
+
+// SVG writes the scene to w as an SVG image.  The contour background is
+// drawn as one rectangle per grid cell.
+func (s *Scene) SVG(w io.Writer, width, height int) error {
+	f := s.Field
+	ny, nx := len(f.Vals), len(f.Vals[0])
+	cw, ch := float64(width)/float64(nx), float64(height)/float64(ny)
+
+	ew := &errWriter{w: w}
+	ew.printf("<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%v\" height=\"%v\">\n", width, height)
+	for j := range f.Vals {
+		for i, v := range f.Vals[j] {
+			c := bandColor(f.band(v))
+			ew.printf("<rect x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" height=\"%.2f\" fill=\"%v\"/>\n",
+				float64(i)*cw, float64(ny-1-j)*ch, cw+0.5, ch+0.5, hex(c))
+		}
+	}
+	for i, p := range s.Points {
+		x, y := s.pixel(p.Pos, width, height)
+		if s.Vels != nil {
+			x2, y2 := s.pixel([]float64{p.Pos[0] + s.Vels[i][0], p.Pos[1] + s.Vels[i][1]}, width, height)
+			ew.printf("<line x1=\"%v\" y1=\"%v\" x2=\"%v\" y2=\"%v\" stroke=\"%v\"/>\n", x, y, x2, y2, hex(velColor))
+		}
+		if s.Bests != nil {
+			bx, by := s.pixel(s.Bests[i].Pos, width, height)
+			ew.printf("<rect x=\"%v\" y=\"%v\" width=\"5\" height=\"5\" fill=\"%v\"/>\n", bx-2, by-2, hex(bestColor))
+		}
+		ew.printf("<circle cx=\"%v\" cy=\"%v\" r=\"2\" fill=\"%v\"/>\n", x, y, hex(pointColor))
+	}
+	if s.Incumbent != nil {
+		x, y := s.pixel(s.Incumbent.Pos, width, height)
+		ew.printf("<path d=\"M%v %vH%vM%v %vV%v\" stroke=\"%v\" stroke-width=\"2\"/>\n", x-5, y, x+5, x, y-5, y+5, hex(incColor))
+	}
+	if s.Labels[0] != "" {
+		ew.printf("<text x=\"%v\" y=\"%v\" text-anchor=\"middle\" fill=\"%v\">%v</text>\n", width/2, height-4, hex(pointColor), html.EscapeString(s.Labels[0]))
+	}
+	if s.Labels[1] != "" {
+		ew.printf("<text x=\"12\" y=\"%v\" text-anchor=\"middle\" fill=\"%v\" transform=\"rotate(-90 12 %v)\">%v</text>\n", height/2, hex(pointColor), height/2, html.EscapeString(s.Labels[1]))
+	}
+	ew.printf("</svg>\n")
+	return ew.err
+}
+
+// GIF writes scenes to w as an animated GIF with delay hundredths of a
+// second between frames.
+func GIF(w io.Writer, scenes []*Scene, width, height, delay int) error {
+	anim := &gif.GIF{}
+	pal := palette()
+	for _, s := range scenes {
+		img := s.Image(width, height)
+		frame := image.NewPaletted(img.Bounds(), pal)
+		draw.Draw(frame, img.Bounds(), img, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// palette returns a palette containing every color used in rendering so
+// GIF frames need no dithering.
+func palette() color.Palette {
+	pal := color.Palette{pointColor, velColor, bestColor, incColor, lineColor}
+	for b := 0; b < NLevels; b++ {
+		pal = append(pal, bandColor(b))
+	}
+	return pal
+}
+
+// Recorder collects a scene for each iteration of a swarm run for later
+// animation.
+type Recorder struct {
+	Field  *Field
+	Scenes []*Scene
+}
+
+// Capture records the current state of m's population.
+func (r *Recorder) Capture(m *swarm.Method) {
+	r.Scenes = append(r.Scenes, SwarmScene(r.Field, m.Pop))
+}
+
+// drawLine draws a line segment clipped to the image bounds.
+func drawLine(img draw.Image, x0, y0, x1, y1 int, c color.Color) {
+	var ok bool
+	if x0, y0, x1, y1, ok = clip(img.Bounds(), x0, y0, x1, y1); !ok {
+		return
+	}
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// clip uses Liang-Barsky clipping to restrict a segment to r.  ok is false
+// if the segment lies entirely outside r.
+func clip(r image.Rectangle, x0, y0, x1, y1 int) (cx0, cy0, cx1, cy1 int, ok bool) {
+	fx, fy := float64(x0), float64(y0)
+	dx, dy := float64(x1-x0), float64(y1-y0)
+	t0, t1 := 0.0, 1.0
+	edges := []struct{ p, q float64 }{
+		{-dx, fx - float64(r.Min.X)},
+		{dx, float64(r.Max.X-1) - fx},
+		{-dy, fy - float64(r.Min.Y)},
+		{dy, float64(r.Max.Y-1) - fy},
+	}
+	for _, e := range edges {
+		if e.p == 0 {
+			if e.q < 0 {
+				return 0, 0, 0, 0, false
+			}
+			continue
+		}
+		t := e.q / e.p
+		if e.p < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+	}
+	if t0 > t1 {
+		return 0, 0, 0, 0, false
+	}
+	return int(fx + t0*dx), int(fy + t0*dy), int(fx + t1*dx), int(fy + t1*dy), true
+}
+
+func drawSquare(img draw.Image, x, y, r int, c color.Color) {
+	for i := -r; i <= r; i++ {
+		for j := -r; j <= r; j++ {
+			img.Set(x+i, y+j, c)
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func sign(x int) int {
+	if x < 0 {
+		return -1
+	} else if x > 0 {
+		return 1
+	}
+	return 0
+}
+
+func hex(c color.RGBA) string { return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B) }
+
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
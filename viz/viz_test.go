@@ -0,0 +1,88 @@
+package viz
+
+import (
+	"bytes"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/rwcarlsen/optim"
+	"github.com/rwcarlsen/optim/bench"
+	"github.com/rwcarlsen/optim/swarm"
+)
+
+func TestSwarmFrames(t *testing.T) {
+	fn := bench.Rosenbrock{NDim: 2}
+	low, up := fn.Bounds()
+	obj := optim.Func(fn.Eval)
+
+	m := swarm.New(swarm.NewPopulationRand(10, low, up), swarm.VmaxBounds(low, up))
+	rec := &Recorder{Field: Sample(obj, nil, low, up, 40, 40)}
+	for i := 0; i < 5; i++ {
+		if _, _, err := m.Iterate(obj, &optim.InfMesh{}); err != nil {
+			t.Fatal(err)
+		}
+		rec.Capture(m)
+	}
+	if len(rec.Scenes) != 5 {
+		t.Fatalf("want 5 scenes, got %v", len(rec.Scenes))
+	}
+
+	scene := rec.Scenes[len(rec.Scenes)-1]
+	var buf bytes.Buffer
+	if err := scene.PNG(&buf, 120, 80); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if b := img.Bounds(); b.Dx() != 120 || b.Dy() != 80 {
+		t.Errorf("wrong png size: %v", b)
+	}
+
+	buf.Reset()
+	if err := scene.SVG(&buf, 120, 80); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	if !strings.HasPrefix(svg, "<svg") || strings.Count(svg, "<circle") != 10 {
+		t.Errorf("bad svg: %v circles", strings.Count(svg, "<circle"))
+	} else if strings.Contains(svg, "<text") {
+		t.Errorf("unlabeled scene has axis labels")
+	}
+
+	buf.Reset()
+	scene.Labels = [2]string{"length [m]", "a<b"}
+	if err := scene.SVG(&buf, 120, 80); err != nil {
+		t.Fatal(err)
+	}
+	if svg := buf.String(); !strings.Contains(svg, ">length [m]</text>") || !strings.Contains(svg, ">a&lt;b</text>") {
+		t.Errorf("svg is missing escaped axis labels")
+	}
+
+	buf.Reset()
+	if err := GIF(&buf, rec.Scenes, 60, 60, 10); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(anim.Image) != 5 {
+		t.Errorf("want 5 gif frames, got %v", len(anim.Image))
+	}
+}
+
+func TestIncumbentPixel(t *testing.T) {
+	f := Sample(optim.Func(func(v []float64) float64 { return v[0] }), nil, []float64{0, 0}, []float64{10, 10}, 5, 5)
+	s := &Scene{Field: f, Incumbent: &optim.Point{Pos: []float64{10, 10}}}
+	img := s.Image(21, 21)
+	if got := img.RGBAAt(20, 0); got != incColor {
+		t.Errorf("incumbent at top right corner not drawn: got %v", got)
+	}
+
+	// lower x values are better so the left edge gets the first band
+	if got := img.RGBAAt(0, 10); got != bandColor(0) {
+		t.Errorf("left edge: want band 0 color, got %v", got)
+	}
+}
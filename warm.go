@@ -0,0 +1,182 @@
+package optim
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LoadPoints reads previously evaluated points from table tbl in db for
+// warm-starting a new run.  tbl must have val and posid columns (e.g.
+// pattern.TblPolls or swarm.TblParticlesBest) and point positions are looked
+// up in the points table written by RecordPointPos.  Entries with no
+// recorded position or an infinite value are skipped and each distinct
+// position is returned only once.
+func LoadPoints(db *sql.DB, tbl string) ([]*Point, error) {
+	rows, err := db.Query("SELECT posid,dim,val FROM points;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	positions := map[string]map[int]float64{}
+	for rows.Next() {
+		var id []byte
+		var dim int
+		var x float64
+		if err := rows.Scan(&id, &dim, &x); err != nil {
+			return nil, err
+		}
+		if positions[string(id)] == nil {
+			positions[string(id)] = map[int]float64{}
+		}
+		positions[string(id)][dim] = x
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	vals, err := db.Query("SELECT posid,val FROM " + tbl + ";")
+	if err != nil {
+		return nil, err
+	}
+	defer vals.Close()
+
+	pts := []*Point{}
+	seen := map[string]bool{}
+	for vals.Next() {
+		var id []byte
+		var val float64
+		if err := vals.Scan(&id, &val); err != nil {
+			return nil, err
+		}
+		dims, ok := positions[string(id)]
+		if !ok || seen[string(id)] || math.IsInf(val, 1) {
+			continue
+		}
+		seen[string(id)] = true
+
+		pos := make([]float64, len(dims))
+		for dim, x := range dims {
+			if dim >= len(pos) {
+				return nil, fmt.Errorf("point %x has non-contiguous dimensions", id)
+			}
+			pos[dim] = x
+		}
+		pts = append(pts, &Point{Pos: pos, Val: val})
+	}
+	return pts, vals.Err()
+}
+
+// ReadLog reads previously evaluated points from r for warm-starting a new
+// run.  Each line must be in one of the formats written by ObjectiveLogger:
+// plain (e.g. "f[1 2 3] = 4"), JSON lines, or comma or tab separated values
+// with the objective value as the last field.  Blank lines and lines
+// starting with "#" are ignored, as is a CSV/TSV column header on the first
+// data line.
+func ReadLog(r io.Reader) ([]*Point, error) {
+	pts := []*Point{}
+	scanner := bufio.NewScanner(r)
+	first := true
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		isfirst := first
+		first = false
+
+		var fields []string
+		if strings.HasPrefix(line, "{") {
+			var entry logEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				return nil, fmt.Errorf("line %v: %v", lineno, err)
+			}
+			pos := make([]float64, len(entry.Pos))
+			for i, x := range entry.Pos {
+				pos[i] = float64(x)
+			}
+			pts = append(pts, &Point{Pos: pos, Val: float64(entry.Val)})
+			continue
+		} else if strings.HasPrefix(line, "f[") {
+			end := strings.Index(line, "]")
+			eq := strings.LastIndex(line, "=")
+			if end < 0 || eq < end {
+				return nil, fmt.Errorf("line %v: malformed log entry %q", lineno, line)
+			}
+			fields = strings.Fields(line[2:end])
+			fields = append(fields, strings.TrimSpace(line[eq+1:]))
+		} else if strings.Contains(line, "\t") {
+			fields = strings.Split(line, "\t")
+		} else {
+			fields = strings.Split(line, ",")
+		}
+
+		if _, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64); err != nil && isfirst {
+			continue // column header
+		}
+
+		vals := make([]float64, len(fields))
+		for i, f := range fields {
+			v, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %v", lineno, err)
+			}
+			vals[i] = v
+		}
+		if len(vals) < 2 {
+			return nil, fmt.Errorf("line %v: missing position or value", lineno)
+		}
+		n := len(vals) - 1
+		pts = append(pts, &Point{Pos: vals[:n:n], Val: vals[n]})
+	}
+	return pts, scanner.Err()
+}
+
+// Elite returns up to n of the best points in pts such that no two returned
+// points are closer than mindist to each other.  This is useful for choosing
+// a diverse set of good points to initialize a population from a prior run.
+// pts is not modified.
+func Elite(pts []*Point, n int, mindist float64) []*Point {
+	return EliteDist(pts, n, mindist, nil)
+}
+
+// EliteDist is like Elite but measures distances with dist (e.g. MeshDist).
+// If dist is nil, Euclidean distance is used.
+func EliteDist(pts []*Point, n int, mindist float64, dist func(a, b []float64) float64) []*Point {
+	if dist == nil {
+		dist = func(a, b []float64) float64 { return L2Dist(&Point{Pos: a}, &Point{Pos: b}) }
+	}
+	sorted := append([]*Point{}, pts...)
+	sort.Sort(byval(sorted))
+
+	elite := make([]*Point, 0, n)
+	for _, p := range sorted {
+		if len(elite) == n {
+			break
+		}
+		tooclose := false
+		for _, e := range elite {
+			if dist(p.Pos, e.Pos) < mindist {
+				tooclose = true
+				break
+			}
+		}
+		if !tooclose {
+			elite = append(elite, p)
+		}
+	}
+	return elite
+}
+
+type byval []*Point
+
+func (b byval) Less(i, j int) bool { return b[i].Val < b[j].Val }
+func (b byval) Len() int           { return len(b) }
+func (b byval) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,128 @@
+package optim
+
+import (
+	"bytes"
+	"database/sql"
+	"math"
+	"strings"
+	"testing"
+
+	_ "github.com/rwcarlsen/go-sqlite/sqlite3"
+)
+
+func TestLoadPoints(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	pts := testpoints()
+	for i, p := range pts {
+		p.Val = float64(i)
+	}
+	pts[5].Val = math.Inf(1)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("CREATE TABLE evals (val REAL,posid BLOB);"); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range pts {
+		if _, err := tx.Exec("INSERT INTO evals VALUES (?,?);", p.Val, p.HashSlice()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := RecordPointPos(tx, pts...); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadPoints(db, "evals")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// one duplicate and one infinite point are excluded
+	want := []*Point{pts[0], pts[2], pts[3], pts[4]}
+	if len(got) != len(want) {
+		t.Fatalf("wrong number of points: want %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i].Hash() != want[i].Hash() || got[i].Val != want[i].Val {
+			t.Errorf("point %v: want %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestReadLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := &ObjectiveLogger{Obj: Func(func(v []float64) float64 { return v[0] + v[1] }), W: &buf}
+	l.Objective([]float64{1, 2})
+	l.Objective([]float64{-3.5, 1e-3})
+	buf.WriteString("# csv entries\n4,5,9\n")
+
+	pts, err := ReadLog(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Point{
+		{Pos: []float64{1, 2}, Val: 3},
+		{Pos: []float64{-3.5, 1e-3}, Val: -3.499},
+		{Pos: []float64{4, 5}, Val: 9},
+	}
+	if len(pts) != len(want) {
+		t.Fatalf("wrong number of points: want %v, got %v", want, pts)
+	}
+	for i := range want {
+		if pts[i].Hash() != want[i].Hash() || pts[i].Val != want[i].Val {
+			t.Errorf("point %v: want %v, got %v", i, want[i], pts[i])
+		}
+	}
+
+	if _, err := ReadLog(strings.NewReader("f[1 2 = 3\n")); err == nil {
+		t.Errorf("malformed log entry did not return an error")
+	}
+}
+
+func TestElite(t *testing.T) {
+	pts := []*Point{
+		{Pos: []float64{0, 0}, Val: 1},
+		{Pos: []float64{0, 0.1}, Val: 2},
+		{Pos: []float64{5, 5}, Val: 3},
+		{Pos: []float64{5, 5.5}, Val: 0},
+		{Pos: []float64{-5, 5}, Val: 4},
+	}
+	elite := Elite(pts, 3, 1)
+	want := []*Point{pts[3], pts[0], pts[4]}
+	if len(elite) != len(want) {
+		t.Fatalf("wrong number of elite points: want %v, got %v", want, elite)
+	}
+	for i := range want {
+		if elite[i] != want[i] {
+			t.Errorf("elite %v: want %v, got %v", i, want[i], elite[i])
+		}
+	}
+}
+
+func TestCacheEvalerAdd(t *testing.T) {
+	obj := &ObjTest{max: 100000}
+	ev := NewCacheEvaler(SerialEvaler{})
+	ev.Add(&Point{Pos: []float64{1, 2, 3}, Val: 42})
+
+	pts := testpoints()
+	_, n, err := ev.Eval(obj, pts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len(pts) - 2; n != want {
+		t.Errorf("warm cache not used: want %v evals, got %v", want, n)
+	}
+	if pts[0].Val != 42 {
+		t.Errorf("wrong cached value: want 42, got %v", pts[0].Val)
+	}
+}